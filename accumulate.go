@@ -0,0 +1,147 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type AccumulateNode struct {
+	node
+	a *pipeline.AccumulateNode
+
+	reset *cronexpr.Expression
+	// The fractions of the budget in increasing order.
+	fractions []float64
+}
+
+// Create a new accumulate node.
+func newAccumulateNode(et *ExecutingTask, n *pipeline.AccumulateNode, d NodeDiagnostic) (*AccumulateNode, error) {
+	reset, err := cronexpr.Parse(n.Reset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reset schedule for accumulate: %v", err)
+	}
+	fractions := make([]float64, len(n.FractionsList))
+	copy(fractions, n.FractionsList)
+	sort.Float64s(fractions)
+
+	an := &AccumulateNode{
+		node:      node{Node: n, et: et, diag: d},
+		a:         n,
+		reset:     reset,
+		fractions: fractions,
+	}
+	an.node.runF = an.runAccumulate
+	return an, nil
+}
+
+func (n *AccumulateNode) runAccumulate([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *AccumulateNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *AccumulateNode) newGroup() *accumulateGroup {
+	return &accumulateGroup{
+		n: n,
+	}
+}
+
+type accumulateGroup struct {
+	n *AccumulateNode
+
+	total float64
+	// The time of the next reset, zero before the first point.
+	next time.Time
+	// The number of fractions of the budget crossed since the last reset.
+	crossed int
+}
+
+func (g *accumulateGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *accumulateGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doAccumulate(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *accumulateGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *accumulateGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doAccumulate(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doAccumulate adds the value of p to the total, after a reset if one is due,
+// and sets the total and the use of the budget as fields on p.
+// Points without a numeric value are dropped and do not affect the total.
+func (g *accumulateGroup) doAccumulate(p edge.FieldsTagsTimeSetter) bool {
+	a := g.n.a
+	value, ok := numToFloat(p.Fields()[a.Field])
+	if !ok {
+		g.n.diag.Error("cannot accumulate",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", a.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[a.Field])),
+		)
+		return false
+	}
+
+	// Crons are sensitive to timezones.
+	// Make sure we are using local time.
+	t := p.Time().Local()
+	if g.next.IsZero() || !t.Before(g.next) {
+		g.total = 0
+		g.crossed = 0
+		g.next = g.n.reset.Next(t)
+	}
+	g.total += value
+
+	fields := p.Fields().Copy()
+	fields[a.As] = g.total
+	if a.Budget > 0 {
+		fraction := g.total / a.Budget
+		fields[a.FractionAs] = fraction
+		fields[a.CrossedAs] = 0.0
+		crossed := sort.Search(len(g.n.fractions), func(i int) bool { return g.n.fractions[i] > fraction })
+		if crossed > g.crossed {
+			fields[a.CrossedAs] = g.n.fractions[crossed-1]
+			g.crossed = crossed
+		}
+	}
+	p.SetFields(fields)
+	return true
+}
+
+func (g *accumulateGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *accumulateGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *accumulateGroup) Done() {}
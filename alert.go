@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	html "html/template"
+	"math/rand"
 	"os"
 	"sync"
 	text "text/template"
@@ -50,6 +51,9 @@ const (
 	statsWarnsTriggered  = "warns_triggered"
 	statsCritsTriggered  = "crits_triggered"
 	statsEventsDropped   = "events_dropped"
+	statsAlertsLimited   = "alerts_rate_limited"
+	statsAlertsDeduped   = "alerts_deduplicated"
+	statsAlertsDigested  = "alerts_digested"
 )
 
 // The newest state change is weighted 'weightDiff' times more than oldest state change.
@@ -77,11 +81,19 @@ type AlertNode struct {
 	warnsTriggered  *expvar.Int
 	critsTriggered  *expvar.Int
 	eventsDropped   *expvar.Int
+	alertsLimited   *expvar.Int
+	alertsDeduped   *expvar.Int
+	alertsDigested  *expvar.Int
+
+	jitter *alertJitter
 
 	bufPool sync.Pool
 
 	levelResets  []stateful.Expression
 	lrScopePools []stateful.ScopePool
+
+	priority          stateful.Expression
+	priorityScopePool stateful.ScopePool
 }
 
 // Create a new  AlertNode which caches the most recent item and exposes it over the HTTP API.
@@ -162,7 +174,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Address: tcp.Address,
 		}
 		h := alertservice.NewTCPHandler(c, an.diag)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, tcp.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, email := range n.EmailHandlers {
@@ -171,7 +185,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			ToTemplates: email.ToTemplatesList,
 		}
 		h := et.tm.SMTPService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, email.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.EmailHandlers) == 0 && (et.tm.SMTPService != nil && et.tm.SMTPService.Global()) {
 		c := smtp.HandlerConfig{}
@@ -192,7 +208,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Commander: et.tm.Commander,
 		}
 		h := alertservice.NewExecHandler(c, an.diag)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, e.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, log := range n.LogHandlers {
@@ -205,7 +223,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create log alert handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, log.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, vo := range n.VictorOpsHandlers {
@@ -213,7 +233,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RoutingKey: vo.RoutingKey,
 		}
 		h := et.tm.VictorOpsService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, vo.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.VictorOpsHandlers) == 0 && (et.tm.VictorOpsService != nil && et.tm.VictorOpsService.Global()) {
 		c := victorops.HandlerConfig{}
@@ -226,7 +248,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			ServiceKey: pd.ServiceKey,
 		}
 		h := et.tm.PagerDutyService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, pd.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.PagerDutyHandlers) == 0 && (et.tm.PagerDutyService != nil && et.tm.PagerDutyService.Global()) {
 		c := pagerduty.HandlerConfig{}
@@ -250,7 +274,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create PagerDuty2 handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, pd.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.PagerDuty2Handlers) == 0 && (et.tm.PagerDuty2Service != nil && et.tm.PagerDuty2Service.Global()) {
 		c := pagerduty2.HandlerConfig{}
@@ -272,7 +298,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create sensu alert handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, s := range n.SlackHandlers {
@@ -282,8 +310,17 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Username:  s.Username,
 			IconEmoji: s.IconEmoji,
 		}
+		if s.TokenRef != "" {
+			token, err := et.resolveSecret(s.TokenRef)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create slack handler")
+			}
+			c.Token = token
+		}
 		h := et.tm.SlackService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.SlackHandlers) == 0 && (et.tm.SlackService != nil && et.tm.SlackService.Global()) {
 		h := et.tm.SlackService.Handler(slack.HandlerConfig{}, ctx...)
@@ -304,7 +341,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			DisableNotification:   t.IsDisableNotification,
 		}
 		h := et.tm.TelegramService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, t.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, s := range n.SNMPTrapHandlers {
@@ -324,7 +363,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create SNMP handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(n.TelegramHandlers) == 0 && (et.tm.TelegramService != nil && et.tm.TelegramService.Global()) {
@@ -344,8 +385,17 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Room:  hc.Room,
 			Token: hc.Token,
 		}
+		if hc.TokenRef != "" {
+			token, err := et.resolveSecret(hc.TokenRef)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create HipChat handler")
+			}
+			c.Token = token
+		}
 		h := et.tm.HipChatService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, hc.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.HipChatHandlers) == 0 && (et.tm.HipChatService != nil && et.tm.HipChatService.Global()) {
 		c := hipchat.HandlerConfig{}
@@ -371,7 +421,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create kafka handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, k.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, a := range n.AlertaHandlers {
@@ -379,6 +431,13 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if a.Token != "" {
 			c.Token = a.Token
 		}
+		if a.TokenRef != "" {
+			token, err := et.resolveSecret(a.TokenRef)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create Alerta handler")
+			}
+			c.Token = token
+		}
 		if a.Resource != "" {
 			c.Resource = a.Resource
 		}
@@ -413,7 +472,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create Alerta handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, a.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, p := range n.PushoverHandlers {
@@ -437,7 +498,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			c.UserKey = p.UserKey
 		}
 		h := et.tm.PushoverService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, p.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, p := range n.HTTPPostHandlers {
@@ -455,7 +518,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create HTTPPostService.Handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, p.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, og := range n.OpsGenieHandlers {
@@ -464,7 +529,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RecipientsList: og.RecipientsList,
 		}
 		h := et.tm.OpsGenieService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, og.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.OpsGenieHandlers) == 0 && (et.tm.OpsGenieService != nil && et.tm.OpsGenieService.Global()) {
 		c := opsgenie.HandlerConfig{}
@@ -478,7 +545,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RecoveryAction: og.RecoveryActionString,
 		}
 		h := et.tm.OpsGenie2Service.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, og.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.OpsGenie2Handlers) == 0 && (et.tm.OpsGenie2Service != nil && et.tm.OpsGenie2Service.Global()) {
 		c := opsgenie2.HandlerConfig{}
@@ -486,9 +555,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		an.handlers = append(an.handlers, h)
 	}
 
-	for range n.TalkHandlers {
+	for _, t := range n.TalkHandlers {
 		h := et.tm.TalkService.Handler(ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, t.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, m := range n.MQTTHandlers {
@@ -502,7 +573,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create MQTT handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, m.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, s := range n.DiscordHandlers {
@@ -516,7 +589,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create Discord handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, s := range n.BigPandaHandlers {
@@ -531,7 +606,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create BigPanda handler")
 		}
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, t := range n.TeamsHandlers {
@@ -539,7 +616,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			ChannelURL: t.ChannelURL,
 		}
 		h := et.tm.TeamsService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, t.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.TeamsHandlers) == 0 && (et.tm.TeamsService != nil && et.tm.TeamsService.Global()) {
 		c := teams.HandlerConfig{}
@@ -579,7 +658,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			AdditionalInfo: s.AdditionalInfoMap,
 		}
 		h := et.tm.ServiceNowService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.ServiceNowHandlers) == 0 && (et.tm.ServiceNowService != nil && et.tm.ServiceNowService.Global()) {
 		h := et.tm.ServiceNowService.Handler(servicenow.HandlerConfig{}, ctx...)
@@ -622,7 +703,9 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			CustomFields:  s.CustomFieldsMap,
 		}
 		h := et.tm.ZenossService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		if err := an.appendHandler(h, s.AlertHandlerLevel); err != nil {
+			return nil, err
+		}
 	}
 	if len(n.ZenossHandlers) == 0 && (et.tm.ZenossService != nil && et.tm.ZenossService.Global()) {
 		h := et.tm.ZenossService.Handler(zenoss.HandlerConfig{}, ctx...)
@@ -635,6 +718,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		n.IsStateChangesOnly = true
 	}
 
+	// In test mode the handlers only capture the events.
+	if et.tm.AlertCapture != nil {
+		an.handlers = et.tm.AlertCapture.stubs(et.Task.ID, an.Name(), an.handlers)
+	}
+
 	// Parse level expressions
 	an.levels = make([]stateful.Expression, alert.Critical+1)
 	an.scopePools = make([]stateful.ScopePool, alert.Critical+1)
@@ -694,6 +782,15 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		}
 	}
 
+	if n.Priority != nil {
+		statefulExpression, expressionCompileError := stateful.NewExpression(n.Priority.Expression)
+		if expressionCompileError != nil {
+			return nil, fmt.Errorf("Failed to compile stateful expression for priority: %s", expressionCompileError)
+		}
+		an.priority = statefulExpression
+		an.priorityScopePool = stateful.NewScopePool(ast.FindReferenceVariables(n.Priority.Expression))
+	}
+
 	// Setup states
 	if n.History < 2 {
 		n.History = 2
@@ -744,6 +841,29 @@ func (n *AlertNode) runAlert([]byte) error {
 	n.eventsDropped = &expvar.Int{}
 	n.statMap.Set(statsCritsTriggered, n.critsTriggered)
 
+	n.alertsLimited = &expvar.Int{}
+	if n.et.tm.AlertRateLimiter != nil {
+		n.statMap.Set(statsAlertsLimited, n.alertsLimited)
+	}
+	n.alertsDeduped = &expvar.Int{}
+	if n.et.tm.AlertDeduplicator != nil {
+		n.statMap.Set(statsAlertsDeduped, n.alertsDeduped)
+	}
+	n.alertsDigested = &expvar.Int{}
+	if n.a.DigestFlag && n.et.tm.AlertDigester != nil {
+		n.statMap.Set(statsAlertsDigested, n.alertsDigested)
+	}
+
+	if n.a.Jitter > 0 {
+		var src rand.Source
+		if n.et.tm.AlertJitterSource != nil {
+			src = n.et.tm.AlertJitterSource()
+		} else {
+			src = rand.NewSource(time.Now().UnixNano())
+		}
+		n.jitter = newAlertJitter(n.a.Jitter, rand.New(src), n.dispatchEvent)
+	}
+
 	// Setup consumer
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
@@ -751,7 +871,12 @@ func (n *AlertNode) runAlert([]byte) error {
 	)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 
-	if err := consumer.Consume(); err != nil {
+	err := consumer.Consume()
+	// Flush the delayed events before the topics are closed.
+	if n.jitter != nil {
+		n.jitter.Close()
+	}
+	if err != nil {
 		return err
 	}
 
@@ -786,12 +911,15 @@ func (n *AlertNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.R
 
 func (n *AlertNode) restoreEventState(id string, t time.Time, tags models.Tags) *alertState {
 	state := n.newAlertState(tags)
-	currentLevel, triggered := n.restoreEvent(id)
-	if currentLevel != alert.OK {
+	restored := n.restoreEvent(id)
+	if restored.Level != alert.OK {
 		// Add initial event
-		state.addEvent(t, currentLevel)
+		state.addEvent(t, restored.Level)
 		// Record triggered time
-		state.triggered(triggered)
+		state.triggered(restored.Time)
+	}
+	if n.a.IncidentHistory > 0 {
+		state.restoreIncidents(restored)
 	}
 	return state
 }
@@ -808,15 +936,19 @@ func (n *AlertNode) newAlertState(tags models.Tags) *alertState {
 		inhibitors[i] = inhibitor
 		n.et.tm.AlertService.AddInhibitor(inhibitor)
 	}
-	return &alertState{
+	state := &alertState{
 		history:    make([]alert.Level, n.a.History),
 		n:          n,
 		buffer:     new(edge.BatchBuffer),
 		inhibitors: inhibitors,
 	}
+	if n.a.SparklineField != "" {
+		state.sparklineValues = NewCircularQueue[float64](make([]float64, 0, n.a.SparklinePoints)...)
+	}
+	return state
 }
 
-func (n *AlertNode) restoreEvent(id string) (alert.Level, time.Time) {
+func (n *AlertNode) restoreEvent(id string) alert.EventState {
 	var topicState, anonTopicState alert.EventState
 	var anonFound, topicFound bool
 	// Check for previous state on anonTopic
@@ -853,9 +985,31 @@ func (n *AlertNode) restoreEvent(id string) (alert.Level, time.Time) {
 		} // else nothing was found, nothing to do
 	}
 	if anonFound {
-		return anonTopicState.Level, anonTopicState.Time
+		return anonTopicState
 	}
-	return topicState.Level, topicState.Time
+	return topicState
+}
+
+// acknowledged reports whether the event has been acknowledged on any of the node's topics.
+func (n *AlertNode) acknowledged(id string) bool {
+	topics := make([]string, 0, 2)
+	if n.hasAnonTopic() {
+		topics = append(topics, n.anonTopic)
+	}
+	if n.hasTopic() {
+		topics = append(topics, n.topic)
+	}
+	for _, topic := range topics {
+		state, ok, err := n.et.tm.AlertService.EventState(topic, id)
+		if err != nil {
+			n.diag.Error("failed to get event state", err, keyvalue.KV("topic", topic), keyvalue.KV("event", id))
+			continue
+		}
+		if ok && state.Acknowledged {
+			return true
+		}
+	}
+	return false
 }
 
 func deleteAlertHook(anonTopic string) deleteHook {
@@ -878,6 +1032,41 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 		return
 	}
 
+	// Collapse the events of all tasks sharing a dedup key into a single incident.
+	// Alerts without recoveries are not deduplicated, as they would never recover the incident.
+	if d := n.et.tm.AlertDeduplicator; d != nil && !n.a.NoRecoveriesFlag {
+		var ok bool
+		if event, ok = d.Deduplicate(n.et.Task.ID, n.Name(), event); !ok {
+			n.alertsDeduped.Add(1)
+			return
+		}
+	}
+
+	// Non urgent events are sent in the next digest.
+	if d := n.et.tm.AlertDigester; d != nil && n.a.DigestFlag {
+		if d.Digest(n.et.Task.ID, event) {
+			n.alertsDigested.Add(1)
+			n.recordEvent(event)
+			return
+		}
+	}
+
+	dispatch := n.dispatchEvent
+	if n.jitter != nil {
+		dispatch = n.jitter.Dispatch
+	}
+
+	// Check the global rate limit, the event may be dispatched later if it is deferred.
+	if rl := n.et.tm.AlertRateLimiter; rl != nil {
+		if limited := rl.Dispatch(n.et.Task.ID, event, dispatch); limited {
+			n.alertsLimited.Add(1)
+		}
+		return
+	}
+	dispatch(event)
+}
+
+func (n *AlertNode) dispatchEvent(event alert.Event) {
 	n.alertsTriggered.Add(1)
 	switch event.State.Level {
 	case alert.OK:
@@ -904,6 +1093,16 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	// If we have a user define topic, emit event to the topic.
 	if n.hasTopic() {
 		event.Topic = n.topic
+		// In test mode the events are captured instead of being sent to the handlers of the topic.
+		if c := n.et.tm.AlertCapture; c != nil {
+			c.capture(CapturedAlert{
+				TaskID:  n.et.Task.ID,
+				Node:    n.Name(),
+				Handler: capturedTopicHandler,
+				Event:   event,
+			})
+			return
+		}
 		err := n.et.tm.AlertService.Collect(event)
 		if err != nil {
 			n.eventsDropped.Add(1)
@@ -912,6 +1111,26 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	}
 }
 
+// recordEvent updates the state of the event in the topics of the node without sending it to their handlers,
+// so that the topics know the events only notified through a digest.
+func (n *AlertNode) recordEvent(event alert.Event) {
+	if n.hasAnonTopic() {
+		event.Topic = n.anonTopic
+		if err := n.et.tm.AlertService.RecordEvent(event); err != nil {
+			n.eventsDropped.Add(1)
+			n.diag.Error("encountered error recording event", err)
+		}
+	}
+	// In test mode the topic is not used.
+	if n.hasTopic() && n.et.tm.AlertCapture == nil {
+		event.Topic = n.topic
+		if err := n.et.tm.AlertService.RecordEvent(event); err != nil {
+			n.eventsDropped.Add(1)
+			n.diag.Error("encountered error recording event", err)
+		}
+	}
+}
+
 func (n *AlertNode) determineLevel(p edge.FieldsTagsTimeGetter, currentLevel alert.Level) alert.Level {
 	if higherLevel, found := n.findFirstMatchLevel(alert.Critical, currentLevel-1, p); found {
 		return higherLevel
@@ -957,20 +1176,26 @@ func (n *AlertNode) event(
 	t time.Time,
 	d time.Duration,
 	result models.Result,
+	incidents []alert.Incident,
+	fireCount int64,
+	sparkline string,
 ) (alert.Event, error) {
-	msg, details, err := n.renderMessageAndDetails(id, name, t, group, tags, fields, level, d)
+	priority := n.evalPriority(name, tags, fields, level, t)
+	msg, details, err := n.renderMessageAndDetails(id, name, t, group, tags, fields, level, d, incidents, fireCount, priority, sparkline)
 	if err != nil {
 		return alert.Event{}, err
 	}
 	event := alert.Event{
 		Topic: n.anonTopic,
 		State: alert.EventState{
-			ID:       id,
-			Message:  msg,
-			Details:  details,
-			Time:     t,
-			Duration: d,
-			Level:    level,
+			ID:        id,
+			Message:   msg,
+			Details:   details,
+			Time:      t,
+			Duration:  d,
+			Level:     level,
+			Incidents: incidents,
+			FireCount: fireCount,
 		},
 		Data: alert.EventData{
 			Name:        name,
@@ -981,11 +1206,35 @@ func (n *AlertNode) event(
 			Fields:      fields,
 			Result:      result,
 			Recoverable: !n.a.NoRecoveriesFlag,
+			Priority:    priority,
+			Sparkline:   sparkline,
 		},
 	}
 	return event, nil
 }
 
+// evalPriority returns the priority of an event, 0 without a priority expression or if it fails to evaluate.
+func (n *AlertNode) evalPriority(name string, tags models.Tags, fields models.Fields, level alert.Level, t time.Time) int64 {
+	if n.priority == nil {
+		return 0
+	}
+	vars := n.priorityScopePool.Get()
+	defer n.priorityScopePool.Put(vars)
+	p := edge.NewPointMessage(name, "", "", models.Dimensions{}, fields, tags, t)
+	if err := fillScope(vars, n.priorityScopePool.ReferenceVariables(), p); err != nil {
+		n.diag.Error("error evaluating expression for priority", err)
+		return 0
+	}
+	// The level of the event takes precedence over a field or tag named level.
+	vars.Set("level", level.String())
+	priority, err := n.priority.EvalInt(vars)
+	if err != nil {
+		n.diag.Error("error evaluating expression for priority", err)
+		return 0
+	}
+	return priority
+}
+
 type alertState struct {
 	n *AlertNode
 
@@ -1005,6 +1254,27 @@ type alertState struct {
 	expired       bool
 
 	inhibitors []*alert.Inhibitor
+
+	// Past incidents, most recent first.
+	incidents []alert.Incident
+	fireCount int64
+	// Whether an incident is in progress, when it started and its highest level.
+	inIncident    bool
+	incidentStart time.Time
+	incidentLevel alert.Level
+
+	// Number of levels the alert is escalated by,
+	// the number of reminders since the last escalation and when the alert was last escalated.
+	escalation      int
+	reminders       int64
+	escalationStart time.Time
+
+	// The number of consecutive OK levels of a pending recovery and the time of the first.
+	recoveryCount int64
+	recoveryStart time.Time
+
+	// Recent values of the sparkline field, nil without a sparkline.
+	sparklineValues *CircularQueue[float64]
 }
 
 func (a *alertState) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -1028,6 +1298,9 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	if len(b.Points()) == 0 {
 		return nil, nil
 	}
+	for _, bp := range b.Points() {
+		a.recordSparkline(bp.Fields())
+	}
 	// Keep track of lowest level for any point
 	lowestLevel := alert.Critical
 	// Keep track of highest level and point
@@ -1057,8 +1330,12 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	if a.n.a.AllFlag || l == alert.OK {
 		t = begin.Time()
 	}
+	if a.recoveryPending(t, l) {
+		return nil, nil
+	}
 
 	a.addEvent(t, l)
+	escalated := a.escalate(id, t, l)
 
 	// Trigger alert only if:
 	//  l == OK and state.changed (aka recovery)
@@ -1067,11 +1344,16 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	if !(a.changed && l == alert.OK ||
 		(l != alert.OK &&
 			!((a.n.a.UseFlapping && a.flapping) ||
-				(a.n.a.IsStateChangesOnly && !a.changed && !a.expired)))) {
+				(a.n.a.IsStateChangesOnly && !a.changed && !a.expired && !escalated)))) {
+		return nil, nil
+	}
+	// Suppress reminders of acknowledged events.
+	if a.expired && a.n.acknowledged(id) {
 		return nil, nil
 	}
 
 	a.triggered(t)
+	a.remind(l, escalated)
 
 	// Suppress the recovery event.
 	if a.n.a.NoRecoveriesFlag && l == alert.OK {
@@ -1079,7 +1361,7 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	}
 
 	duration := a.duration()
-	event, err := a.n.event(id, begin.Name(), begin.GroupID(), begin.Tags(), highestPoint.Fields(), l, t, duration, b.ToResult())
+	event, err := a.n.event(id, begin.Name(), begin.GroupID(), begin.Tags(), highestPoint.Fields(), a.escalatedLevel(l), t, duration, b.ToResult(), a.incidents, a.fireCount, a.sparkline())
 	if err != nil {
 		return nil, err
 	}
@@ -1116,16 +1398,26 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 	if err != nil {
 		return nil, err
 	}
+	a.recordSparkline(p.Fields())
 	l := a.n.determineLevel(p, a.currentLevel())
+	if a.recoveryPending(p.Time(), l) {
+		return nil, nil
+	}
 
 	a.addEvent(p.Time(), l)
+	escalated := a.escalate(id, p.Time(), l)
 
-	if (a.n.a.UseFlapping && a.flapping) || (a.n.a.IsStateChangesOnly && !a.changed && !a.expired) {
+	if (a.n.a.UseFlapping && a.flapping) || (a.n.a.IsStateChangesOnly && !a.changed && !a.expired && !escalated) {
+		return nil, nil
+	}
+	// Suppress reminders of acknowledged events.
+	if a.expired && a.n.acknowledged(id) {
 		return nil, nil
 	}
 	// send alert if we are not OK or we are OK and state changed (i.e recovery)
 	if l != alert.OK || a.changed {
 		a.triggered(p.Time())
+		a.remind(l, escalated)
 		// Suppress the recovery event.
 		if a.n.a.NoRecoveriesFlag && l == alert.OK {
 			return nil, nil
@@ -1138,10 +1430,13 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 			p.GroupID(),
 			p.Tags(),
 			p.Fields(),
-			l,
+			a.escalatedLevel(l),
 			p.Time(),
 			duration,
 			p.ToResult(),
+			a.incidents,
+			a.fireCount,
+			a.sparkline(),
 		)
 		if err != nil {
 			return nil, err
@@ -1226,6 +1521,125 @@ func (a *alertState) triggered(t time.Time) {
 	for _, in := range a.inhibitors {
 		in.Set(inhibited)
 	}
+
+	if a.n.a.IncidentHistory > 0 {
+		a.updateIncidents(t, a.escalatedLevel(a.history[a.idx]))
+	}
+}
+
+// Escalate the alert with level l at time t, and report whether it was escalated.
+// The escalation is reset when the alert recovers or its level changes.
+func (a *alertState) escalate(id string, t time.Time, l alert.Level) bool {
+	if a.n.a.EscalateReminders == 0 && a.n.a.EscalateAfter == 0 {
+		return false
+	}
+	if l == alert.OK || a.changed || a.escalationStart.IsZero() {
+		a.escalation = 0
+		a.reminders = 0
+		a.escalationStart = t
+		return false
+	}
+	if a.escalatedLevel(l) == alert.Critical {
+		return false
+	}
+	if !(a.n.a.EscalateReminders > 0 && a.reminders >= a.n.a.EscalateReminders) &&
+		!(a.n.a.EscalateAfter > 0 && t.Sub(a.escalationStart) >= a.n.a.EscalateAfter) {
+		return false
+	}
+	// Acknowledged alerts are not escalated.
+	if a.n.acknowledged(id) {
+		return false
+	}
+	a.escalation++
+	a.reminders = 0
+	a.escalationStart = t
+	return true
+}
+
+// recoveryPending reports whether the recovery of the alert to level l at time t is deferred,
+// as the OK level has not held for long enough.
+func (a *alertState) recoveryPending(t time.Time, l alert.Level) bool {
+	if a.n.a.RecoverAfter == 0 && a.n.a.RecoverAfterCount == 0 {
+		return false
+	}
+	if l != alert.OK || a.currentLevel() == alert.OK {
+		a.recoveryCount = 0
+		return false
+	}
+	if a.recoveryCount == 0 {
+		a.recoveryStart = t
+	}
+	a.recoveryCount++
+	if t.Sub(a.recoveryStart) < a.n.a.RecoverAfter || a.recoveryCount < a.n.a.RecoverAfterCount {
+		return true
+	}
+	a.recoveryCount = 0
+	return false
+}
+
+// Record that an event with level l was sent, counting the reminders.
+func (a *alertState) remind(l alert.Level, escalated bool) {
+	if l != alert.OK && !a.changed && !escalated {
+		a.reminders++
+	}
+}
+
+// Return the level l raised by the escalation of the alert, up to critical.
+func (a *alertState) escalatedLevel(l alert.Level) alert.Level {
+	if l == alert.OK {
+		return l
+	}
+	l += alert.Level(a.escalation)
+	if l > alert.Critical {
+		l = alert.Critical
+	}
+	return l
+}
+
+// Update the incident history with an event triggered at time t.
+func (a *alertState) updateIncidents(t time.Time, level alert.Level) {
+	switch {
+	case level != alert.OK && !a.inIncident:
+		a.inIncident = true
+		a.incidentStart = t
+		a.incidentLevel = level
+		a.fireCount++
+	case level != alert.OK:
+		if level > a.incidentLevel {
+			a.incidentLevel = level
+		}
+	case a.inIncident:
+		// The event recovered, remember the incident.
+		a.inIncident = false
+		incident := alert.Incident{
+			Time:     a.incidentStart,
+			Duration: t.Sub(a.incidentStart),
+			Level:    a.incidentLevel,
+		}
+		// Always copy the history, since it is shared with the sent events.
+		l := len(a.incidents) + 1
+		if depth := int(a.n.a.IncidentHistory); l > depth {
+			l = depth
+		}
+		incidents := make([]alert.Incident, l)
+		incidents[0] = incident
+		copy(incidents[1:], a.incidents)
+		a.incidents = incidents
+	}
+}
+
+// Restore the incident history from the previous state of the event.
+func (a *alertState) restoreIncidents(state alert.EventState) {
+	a.incidents = state.Incidents
+	if depth := int(a.n.a.IncidentHistory); len(a.incidents) > depth {
+		a.incidents = a.incidents[:depth]
+	}
+	a.fireCount = state.FireCount
+	if state.Level != alert.OK {
+		a.inIncident = true
+		a.incidentStart = state.Time.Add(-state.Duration)
+		a.incidentLevel = state.Level
+	}
 }
 
 // Record an event in the alert history.
@@ -1329,6 +1743,18 @@ type messageInfo struct {
 
 	// Duration of the alert
 	Duration time.Duration
+
+	// Past incidents of the alert, most recent first.
+	Incidents []alert.Incident `json:",omitempty"`
+
+	// Number of times the alert has fired.
+	FireCount int64 `json:",omitempty"`
+
+	// Priority of the alert.
+	Priority int64 `json:",omitempty"`
+
+	// Sparkline of the recent values of the sparkline field.
+	Sparkline string `json:",omitempty"`
 }
 
 type detailsInfo struct {
@@ -1370,7 +1796,7 @@ func (n *AlertNode) renderID(name string, group models.GroupID, tags models.Tags
 	return id.String(), nil
 }
 
-func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, level alert.Level, d time.Duration) (string, string, error) {
+func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, level alert.Level, d time.Duration, incidents []alert.Incident, fireCount int64, priority int64, sparkline string) (string, string, error) {
 	g := string(group)
 	if group == models.NilGroup {
 		g = "nil"
@@ -1383,11 +1809,15 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 			Tags:       tags,
 			ServerInfo: n.serverInfo(),
 		},
-		ID:       id,
-		Fields:   fields,
-		Level:    level.String(),
-		Time:     t,
-		Duration: d,
+		ID:        id,
+		Fields:    fields,
+		Level:     level.String(),
+		Time:      t,
+		Duration:  d,
+		Incidents: incidents,
+		FireCount: fireCount,
+		Priority:  priority,
+		Sparkline: sparkline,
 	}
 
 	// Grab a buffer for the message template and the details template
@@ -80,7 +80,18 @@ func (s *Topics) UpdateEvent(topicID string, event EventState) {
 	if !ok {
 		s.topics[topicID] = s.newTopic(topicID)
 	}
-	t.updateEvent(event)
+	t.updateEvent(&event)
+}
+
+// AcknowledgeEvent sets whether the event is acknowledged and returns its updated state.
+func (s *Topics) AcknowledgeEvent(topic, event string, acknowledged bool) (EventState, bool) {
+	s.mu.RLock()
+	t, ok := s.topics[topic]
+	s.mu.RUnlock()
+	if !ok {
+		return EventState{}, false
+	}
+	return t.acknowledgeEvent(event, acknowledged)
 }
 
 func (s *Topics) EventState(topic, event string) (EventState, bool) {
@@ -95,22 +106,32 @@ func (s *Topics) EventState(topic, event string) (EventState, bool) {
 
 // Collect collects an event and handles the event.
 func (s *Topics) Collect(event Event) error {
+	return s.collectTopic(event.Topic).collect(event)
+}
+
+// Record updates the state of the event in its topic, without sending it to the handlers of the topic.
+func (s *Topics) Record(event Event) {
+	s.collectTopic(event.Topic).record(event)
+}
+
+// collectTopic returns the topic events are collected by, creating it if it does not exist.
+func (s *Topics) collectTopic(id string) *Topic {
 	s.mu.RLock()
-	topic := s.topics[event.Topic]
+	topic := s.topics[id]
 	s.mu.RUnlock()
 
 	if topic == nil {
 		// Create the empty topic
 		s.mu.Lock()
 		// Check again if the topic was created, now that we have the write lock
-		topic = s.topics[event.Topic]
+		topic = s.topics[id]
 		if topic == nil {
-			topic = s.newTopic(event.Topic)
-			s.topics[event.Topic] = topic
+			topic = s.newTopic(id)
+			s.topics[id] = topic
 		}
 		s.mu.Unlock()
 	}
-	return topic.collect(event)
+	return topic
 }
 
 func (s *Topics) DeleteTopic(topic string) {
@@ -319,7 +340,7 @@ func (t *Topic) close() {
 
 func (t *Topic) collect(event Event) error {
 
-	prev, ok := t.updateEvent(event.State)
+	prev, ok := t.updateEvent(&event.State)
 	if ok {
 		event.previousState = prev
 	}
@@ -329,6 +350,11 @@ func (t *Topic) collect(event Event) error {
 	return t.handleEvent(event)
 }
 
+func (t *Topic) record(event Event) {
+	t.updateEvent(&event.State)
+	t.collected.Add(1)
+}
+
 func (t *Topic) handleEvent(event Event) error {
 
 	t.mu.RLock()
@@ -354,7 +380,9 @@ func (t *Topic) Collected() int64 {
 }
 
 // updateEvent will store the latest state for the given ID.
-func (t *Topic) updateEvent(state EventState) (EventState, bool) {
+// An acknowledgement of the previous state is carried over to the new state,
+// unless the event recovered or its level increased.
+func (t *Topic) updateEvent(state *EventState) (EventState, bool) {
 	var hasPrev, needSort bool
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -370,7 +398,10 @@ func (t *Topic) updateEvent(state EventState) (EventState, bool) {
 	needSort = needSort || cur.Level != state.Level
 
 	prev := *cur
-	*cur = state
+	if hasPrev && prev.Acknowledged && state.Level != OK && state.Level <= prev.Level {
+		state.Acknowledged = true
+	}
+	*cur = *state
 
 	if needSort {
 		sort.Sort(sortedStates(t.sorted))
@@ -378,6 +409,18 @@ func (t *Topic) updateEvent(state EventState) (EventState, bool) {
 	return prev, hasPrev
 }
 
+// acknowledgeEvent sets whether the event with the given ID is acknowledged.
+func (t *Topic) acknowledgeEvent(event string, acknowledged bool) (EventState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cur, ok := t.events[event]
+	if !ok {
+		return EventState{}, false
+	}
+	cur.Acknowledged = acknowledged
+	return *cur, true
+}
+
 type sortedStates []*EventState
 
 // TODO(docmerlin): replaced sortedStates with a heap or something similar
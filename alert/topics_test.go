@@ -0,0 +1,74 @@
+package alert_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+func TestTopics_AcknowledgeEvent(t *testing.T) {
+	testCases := []struct {
+		name  string
+		level alert.Level
+		want  bool
+	}{
+		{
+			name:  "same level",
+			level: alert.Warning,
+			want:  true,
+		},
+		{
+			name:  "lower level",
+			level: alert.Info,
+			want:  true,
+		},
+		{
+			name:  "higher level",
+			level: alert.Critical,
+			want:  false,
+		},
+		{
+			name:  "recovered",
+			level: alert.OK,
+			want:  false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topics := alert.NewTopics(0)
+			defer topics.Close()
+
+			if _, ok := topics.AcknowledgeEvent("topic", "event", true); ok {
+				t.Fatal("expected unknown event not to be acknowledged")
+			}
+
+			if err := topics.Collect(alert.Event{
+				Topic: "topic",
+				State: alert.EventState{ID: "event", Level: alert.Warning},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			state, ok := topics.AcknowledgeEvent("topic", "event", true)
+			if !ok {
+				t.Fatal("expected event to exist")
+			}
+			if !state.Acknowledged {
+				t.Fatal("expected event to be acknowledged")
+			}
+
+			if err := topics.Collect(alert.Event{
+				Topic: "topic",
+				State: alert.EventState{ID: "event", Level: tc.level},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			state, ok = topics.EventState("topic", "event")
+			if !ok {
+				t.Fatal("expected event to exist")
+			}
+			if got := state.Acknowledged; got != tc.want {
+				t.Errorf("unexpected acknowledged state: got %t want %t", got, tc.want)
+			}
+		})
+	}
+}
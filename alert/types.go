@@ -3,6 +3,7 @@ package alert
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -28,6 +29,10 @@ func (e Event) AlertData() Data {
 		Data:          e.Data.Result,
 		PreviousLevel: e.previousState.Level,
 		Recoverable:   e.Data.Recoverable,
+		Incidents:     e.State.Incidents,
+		FireCount:     e.State.FireCount,
+		Priority:      e.Data.Priority,
+		Sparkline:     e.Data.Sparkline,
 	}
 }
 
@@ -48,6 +53,11 @@ func (e Event) TemplateData() TemplateData {
 		Group:    e.Data.Group,
 		Tags:     e.Data.Tags,
 		Fields:   e.Data.Fields,
+
+		Incidents: e.State.Incidents,
+		FireCount: e.State.FireCount,
+		Priority:  e.Data.Priority,
+		Sparkline: e.Data.Sparkline,
 	}
 }
 
@@ -56,6 +66,32 @@ type Handler interface {
 	Handle(event Event)
 }
 
+// Renderer is implemented by handlers that can render the payload they send for an event without sending it.
+type Renderer interface {
+	// Render returns the payload the handler would send for the event.
+	Render(event Event) (Payload, error)
+}
+
+// Payload is the data a handler sends for an event.
+type Payload struct {
+	// Where the payload is sent, e.g. a URL, an address, a file path or a command.
+	Destination string
+	// The body of the payload, e.g. the body of an HTTP request.
+	Body []byte
+}
+
+// NewPayload reads the body of a payload sent to the destination.
+func NewPayload(destination string, body io.Reader) (Payload, error) {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return Payload{}, err
+	}
+	return Payload{
+		Destination: destination,
+		Body:        b,
+	}, nil
+}
+
 type EventState struct {
 	ID       string
 	Message  string
@@ -63,6 +99,25 @@ type EventState struct {
 	Time     time.Time
 	Duration time.Duration
 	Level    Level
+	// Acknowledged reports whether the event has been acknowledged.
+	// An acknowledgement lasts until the event recovers or its level increases.
+	Acknowledged bool
+	// Incidents are the most recent past incidents of the event, most recent first.
+	// They are only kept when the alert has an incident history.
+	Incidents []Incident
+	// FireCount is the number of times the event has fired,
+	// when the alert has an incident history.
+	FireCount int64
+}
+
+// Incident is a past occurrence of an event, from when it fired until it recovered.
+type Incident struct {
+	// Time the event fired.
+	Time time.Time `json:"time"`
+	// Duration of the incident.
+	Duration time.Duration `json:"duration"`
+	// Highest level of the event during the incident.
+	Level Level `json:"level"`
 }
 
 type EventData struct {
@@ -88,6 +143,12 @@ type EventData struct {
 	Recoverable bool
 
 	Result models.Result
+
+	// Priority of the event, computed by the priority expression of the alert.
+	Priority int64
+
+	// Sparkline of the recent values of the sparkline field of the alert.
+	Sparkline string
 }
 
 // TemplateData is a structure containing all information available to use in templates for an Event.
@@ -125,6 +186,18 @@ type TemplateData struct {
 
 	// Fields of alerting data point.
 	Fields map[string]interface{}
+
+	// Past incidents of the event, most recent first.
+	Incidents []Incident `json:",omitempty"`
+
+	// Number of times the event has fired.
+	FireCount int64 `json:",omitempty"`
+
+	// Priority of the event.
+	Priority int64 `json:",omitempty"`
+
+	// Sparkline of the recent values of a field.
+	Sparkline string `json:",omitempty"`
 }
 
 type Level int
@@ -190,4 +263,8 @@ type Data struct {
 	Data          models.Result `json:"data"`
 	PreviousLevel Level         `json:"previousLevel"`
 	Recoverable   bool          `json:"recoverable"`
+	Incidents     []Incident    `json:"incidents,omitempty"`
+	FireCount     int64         `json:"fireCount,omitempty"`
+	Priority      int64         `json:"priority,omitempty"`
+	Sparkline     string        `json:"sparkline,omitempty"`
 }
@@ -0,0 +1,133 @@
+package kapacitor
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// The handler kind of the captured events of the topic of an alert node.
+const capturedTopicHandler = "topic"
+
+// AlertCapture replaces the handlers of alert nodes with stubs that capture
+// the events dispatched to them, and the payloads the handlers would have sent, instead of sending them.
+// It makes it possible to test the alert conditions and the rendered messages
+// and payloads of a task by replaying points through it.
+//
+// The real handlers are still created, so that invalid handler options fail the task as they would in production,
+// but they only render their payloads, with the configuration of their services, and never send them.
+// Handlers that cannot render their payloads, such as kafka or smtp, only capture the events.
+// The events published to the topic of an alert node are captured as well, instead of being sent to the handlers of the topic.
+type AlertCapture struct {
+	mu     sync.Mutex
+	alerts []CapturedAlert
+}
+
+// CapturedAlert is an event that was dispatched to a handler or the topic of an alert node.
+type CapturedAlert struct {
+	// The ID of the task and the name of the alert node.
+	TaskID string
+	Node   string
+	// The kind of the replaced handler, i.e. the name of the handler type or of its service, e.g. slack or log,
+	// or topic for the events published to the topic of the alert node.
+	Handler string
+	// The event, as it would have been handled.
+	Event alert.Event
+	// The payload the handler would have sent for the event,
+	// nil if the handler cannot render its payloads or failed to.
+	Payload *alert.Payload
+	// The error rendering the payload, if any.
+	Err error
+}
+
+func NewAlertCapture() *AlertCapture {
+	return &AlertCapture{}
+}
+
+// Alerts returns the captured alerts, in the order they were dispatched.
+func (c *AlertCapture) Alerts() []CapturedAlert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	alerts := make([]CapturedAlert, len(c.alerts))
+	copy(alerts, c.alerts)
+	return alerts
+}
+
+// Reset discards the captured alerts.
+func (c *AlertCapture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alerts = nil
+}
+
+func (c *AlertCapture) capture(a CapturedAlert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alerts = append(c.alerts, a)
+}
+
+// stubs returns a capturing stub for each of the handlers of an alert node.
+func (c *AlertCapture) stubs(taskID, node string, handlers []alert.Handler) []alert.Handler {
+	stubs := make([]alert.Handler, len(handlers))
+	for i, h := range handlers {
+		// The stubs of filtered handlers are only sent the filtered events.
+		f, filtered := h.(*levelFilterHandler)
+		if filtered {
+			h = f.h
+		}
+		stubs[i] = &captureHandler{
+			c:       c,
+			taskID:  taskID,
+			node:    node,
+			handler: handlerKind(h),
+			h:       h,
+		}
+		if filtered {
+			stubs[i] = newLevelFilterHandler(stubs[i], f.minLevel)
+		}
+	}
+	return stubs
+}
+
+type captureHandler struct {
+	c       *AlertCapture
+	taskID  string
+	node    string
+	handler string
+	// The replaced handler, which renders the payloads if it can.
+	h alert.Handler
+}
+
+func (h *captureHandler) Handle(event alert.Event) {
+	a := CapturedAlert{
+		TaskID:  h.taskID,
+		Node:    h.node,
+		Handler: h.handler,
+		Event:   event,
+	}
+	if r, ok := h.h.(alert.Renderer); ok {
+		if p, err := r.Render(event); err != nil {
+			a.Err = err
+		} else {
+			a.Payload = &p
+		}
+	}
+	h.c.capture(a)
+}
+
+// handlerKind returns the name of the type of the handler, without the Handler suffix,
+// or the name of its package for types that are only named handler.
+func handlerKind(h alert.Handler) string {
+	t := reflect.TypeOf(h)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := strings.TrimSuffix(t.Name(), "Handler")
+	if name == "" || name == "handler" {
+		return path.Base(t.PkgPath())
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,142 @@
+package kapacitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/pkg/errors"
+)
+
+// AlertDeduplicatorConfig configures the deduplication of alerts across the tasks of a TaskMaster.
+type AlertDeduplicatorConfig struct {
+	// Window is the minimum time between the events dispatched for an incident at the same level.
+	Window time.Duration
+	// Tag is the tag of the events holding their dedup key.
+	// If empty the ID of the events is their dedup key.
+	Tag string
+}
+
+// AlertDeduplicator collapses the alerts of all tasks sharing a dedup key into a single incident,
+// so that several tasks firing for the same underlying problem only notify once.
+//
+// The alerts of an incident are identified by their task, alert node and original event ID,
+// so that several alert nodes or groups of a task may share a key.
+// An incident is active while any alert reports a level other than OK for its key.
+// When alerts report different levels for the same key, the highest level takes precedence:
+// an event is dispatched when it changes the level of the incident to its own level,
+// and otherwise at most once per window, while all other events are duplicates.
+// When the alert with the highest level recovers the incident is lowered with the next event at the new highest level.
+// The incident recovers once all of its alerts have recovered,
+// at which point the recovery event of the last alert is dispatched.
+// The alerts of a task are removed from the incidents when the task is stopped.
+//
+// The window is measured using the times of the events themselves,
+// so that replays and live data are deduplicated the same way.
+// When the key is a tag, the dispatched events have the key as their ID,
+// so that the handlers see a single incident whichever task produced the event.
+// Events without the tag, and the events of alerts with noRecoveries, are not deduplicated.
+type AlertDeduplicator struct {
+	c AlertDeduplicatorConfig
+
+	mu        sync.Mutex
+	incidents map[string]*dedupIncident
+}
+
+type dedupIncident struct {
+	// The level of the incident and when an event was last dispatched for it.
+	level      alert.Level
+	dispatched time.Time
+	// The latest level of each alert with an active event for the key.
+	alerts map[dedupAlert]alert.Level
+}
+
+// dedupAlert identifies an alert of an incident,
+// as the alert nodes of a task, and the groups of a node, may share a dedup key.
+type dedupAlert struct {
+	task string
+	node string
+	id   string
+}
+
+func NewAlertDeduplicator(c AlertDeduplicatorConfig) (*AlertDeduplicator, error) {
+	if c.Window <= 0 {
+		return nil, errors.New("alert dedup window must be positive")
+	}
+	return &AlertDeduplicator{
+		c:         c,
+		incidents: make(map[string]*dedupIncident),
+	}, nil
+}
+
+// Deduplicate returns the event of the alert node of the task to dispatch,
+// and whether it is dispatched or is a duplicate of the active incident of its key.
+func (d *AlertDeduplicator) Deduplicate(taskID, nodeID string, event alert.Event) (alert.Event, bool) {
+	member := dedupAlert{task: taskID, node: nodeID, id: event.State.ID}
+	key := event.State.ID
+	if d.c.Tag != "" {
+		var ok bool
+		key, ok = event.Data.Tags[d.c.Tag]
+		if !ok {
+			return event, true
+		}
+		event.State.ID = key
+	}
+	t := event.State.Time
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	incident, ok := d.incidents[key]
+	if !ok {
+		incident = &dedupIncident{
+			level:  alert.OK,
+			alerts: make(map[dedupAlert]alert.Level),
+		}
+		d.incidents[key] = incident
+	}
+	if event.State.Level == alert.OK {
+		delete(incident.alerts, member)
+	} else {
+		incident.alerts[member] = event.State.Level
+	}
+
+	// The highest level of the alerts of the incident.
+	level := alert.OK
+	for _, l := range incident.alerts {
+		if l > level {
+			level = l
+		}
+	}
+
+	switch {
+	case level == alert.OK:
+		delete(d.incidents, key)
+		// Only recover an incident which was dispatched.
+		return event, incident.level != alert.OK
+	case event.State.Level == level && (level != incident.level || t.Sub(incident.dispatched) >= d.c.Window):
+		incident.level = level
+		incident.dispatched = t
+		return event, true
+	default:
+		return event, false
+	}
+}
+
+// StopTask removes the alerts of the task from the incidents,
+// so that a stopped task does not keep its incidents active.
+// Incidents without any other alert are removed without dispatching a recovery.
+func (d *AlertDeduplicator) StopTask(taskID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, incident := range d.incidents {
+		for member := range incident.alerts {
+			if member.task == taskID {
+				delete(incident.alerts, member)
+			}
+		}
+		if len(incident.alerts) == 0 {
+			delete(d.incidents, key)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestAlertDeduplicator(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	type taskEvent struct {
+		task   string
+		node   string
+		id     string
+		offset time.Duration
+		level  alert.Level
+		tags   models.Tags
+	}
+	incident := models.Tags{"incident": "db"}
+	testCases := []struct {
+		name   string
+		tag    string
+		events []taskEvent
+		// The indexes of the dispatched events.
+		expDispatched []int
+	}{
+		{
+			name: "same level",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", offset: 0, level: alert.Critical, tags: incident},
+				{task: "b", offset: time.Second, level: alert.Critical, tags: incident},
+				{task: "a", offset: 2 * time.Second, level: alert.OK, tags: incident},
+				{task: "b", offset: 3 * time.Second, level: alert.OK, tags: incident},
+			},
+			expDispatched: []int{0, 3},
+		},
+		{
+			name: "highest level takes precedence",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", offset: 0, level: alert.Warning, tags: incident},
+				{task: "b", offset: time.Second, level: alert.Critical, tags: incident},
+				{task: "a", offset: 2 * time.Second, level: alert.Warning, tags: incident},
+				{task: "b", offset: 3 * time.Second, level: alert.OK, tags: incident},
+				// The incident is lowered.
+				{task: "a", offset: 4 * time.Second, level: alert.Warning, tags: incident},
+				{task: "a", offset: 5 * time.Second, level: alert.OK, tags: incident},
+			},
+			expDispatched: []int{0, 1, 4, 5},
+		},
+		{
+			name: "window",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", offset: 0, level: alert.Critical, tags: incident},
+				{task: "b", offset: time.Second, level: alert.Critical, tags: incident},
+				{task: "a", offset: 5 * time.Second, level: alert.Critical, tags: incident},
+				// A reminder once the window has elapsed.
+				{task: "b", offset: 12 * time.Second, level: alert.Critical, tags: incident},
+				{task: "a", offset: 13 * time.Second, level: alert.OK, tags: incident},
+				{task: "b", offset: 14 * time.Second, level: alert.OK, tags: incident},
+			},
+			expDispatched: []int{0, 3, 5},
+		},
+		{
+			name: "alerts of a task",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", node: "alert2", offset: 0, level: alert.Critical, tags: incident},
+				{task: "a", node: "alert3", offset: time.Second, level: alert.Critical, tags: incident},
+				{task: "a", node: "alert2", offset: 2 * time.Second, level: alert.OK, tags: incident},
+				{task: "a", node: "alert3", offset: 3 * time.Second, level: alert.OK, tags: incident},
+			},
+			expDispatched: []int{0, 3},
+		},
+		{
+			name: "groups of a task",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", id: "host=a", offset: 0, level: alert.Critical, tags: incident},
+				{task: "a", id: "host=b", offset: time.Second, level: alert.Critical, tags: incident},
+				{task: "a", id: "host=a", offset: 2 * time.Second, level: alert.OK, tags: incident},
+				{task: "a", id: "host=b", offset: 3 * time.Second, level: alert.OK, tags: incident},
+			},
+			expDispatched: []int{0, 3},
+		},
+		{
+			name: "missing tag",
+			tag:  "incident",
+			events: []taskEvent{
+				{task: "a", offset: 0, level: alert.Critical},
+				{task: "b", offset: time.Second, level: alert.Critical},
+			},
+			expDispatched: []int{0, 1},
+		},
+		{
+			name: "id",
+			events: []taskEvent{
+				{task: "a", offset: 0, level: alert.Critical},
+				{task: "b", offset: time.Second, level: alert.Critical},
+				{task: "a", offset: 2 * time.Second, level: alert.OK},
+			},
+			expDispatched: []int{0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewAlertDeduplicator(AlertDeduplicatorConfig{
+				Window: 10 * time.Second,
+				Tag:    tc.tag,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var dispatched []int
+			for i, e := range tc.events {
+				id := e.id
+				if id == "" {
+					id = "alert"
+				}
+				event, ok := d.Deduplicate(e.task, e.node, alert.Event{
+					State: alert.EventState{
+						ID:    id,
+						Time:  start.Add(e.offset),
+						Level: e.level,
+					},
+					Data: alert.EventData{
+						Tags: e.tags,
+					},
+				})
+				if !ok {
+					continue
+				}
+				dispatched = append(dispatched, i)
+				if _, tagged := e.tags[tc.tag]; tc.tag != "" && tagged && event.State.ID != e.tags[tc.tag] {
+					t.Errorf("unexpected ID of event %d: got %s exp %s", i, event.State.ID, e.tags[tc.tag])
+				}
+			}
+			if !reflect.DeepEqual(dispatched, tc.expDispatched) {
+				t.Errorf("unexpected dispatched events: got %v exp %v", dispatched, tc.expDispatched)
+			}
+		})
+	}
+}
+
+func TestAlertDeduplicator_StopTask(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, err := NewAlertDeduplicator(AlertDeduplicatorConfig{
+		Window: 10 * time.Second,
+		Tag:    "incident",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := func(offset time.Duration, level alert.Level) alert.Event {
+		return alert.Event{
+			State: alert.EventState{
+				ID:    "alert",
+				Time:  start.Add(offset),
+				Level: level,
+			},
+			Data: alert.EventData{
+				Tags: models.Tags{"incident": "db"},
+			},
+		}
+	}
+	if _, ok := d.Deduplicate("a", "alert2", event(0, alert.Critical)); !ok {
+		t.Fatal("expected the first event to be dispatched")
+	}
+	if _, ok := d.Deduplicate("b", "alert2", event(time.Second, alert.Warning)); ok {
+		t.Fatal("expected the lower level to be a duplicate")
+	}
+
+	// The incident is lowered to the level of the remaining task.
+	d.StopTask("a")
+	if _, ok := d.Deduplicate("b", "alert2", event(2*time.Second, alert.Warning)); !ok {
+		t.Fatal("expected the incident to be lowered once the task is stopped")
+	}
+
+	// An incident without any remaining alert is removed.
+	d.StopTask("b")
+	if len(d.incidents) != 0 {
+		t.Fatalf("unexpected incidents: %v", d.incidents)
+	}
+	if _, ok := d.Deduplicate("b", "alert2", event(3*time.Second, alert.Critical)); !ok {
+		t.Fatal("expected a new incident once the task is restarted")
+	}
+}
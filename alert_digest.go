@@ -0,0 +1,180 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/clock"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/pkg/errors"
+)
+
+const alertDigestID = "kapacitor/alert-digest"
+
+// AlertDigesterConfig configures the alert digests of a TaskMaster.
+type AlertDigesterConfig struct {
+	// Interval is the time between digests.
+	Interval time.Duration
+	// Topic receives the digest events, its handlers send the digests.
+	Topic string
+	// PerTask sends a digest per task instead of a single digest of all tasks.
+	PerTask bool
+}
+
+// AlertDigester accumulates the alert events of the alerts with a digest,
+// and sends a single digest event listing them to a topic at the end of each interval,
+// to reduce the number of notifications of non urgent alerts.
+//
+// CRITICAL events bypass the digest and are dispatched immediately, as is the event following a CRITICAL event,
+// so that the recovery or the downgrade of a critical alert is not delayed.
+// All other events, including recoveries, are part of the next digest. Intervals without events do not send a digest.
+// The digested events are still recorded in the topics of their alerts, without being sent to the handlers of the topics,
+// so that the state of the alerts, their acknowledgements and their restored state include them.
+// The intervals are measured with a clock, so that they can be controlled in tests,
+// and the pending events are sent as a last digest when the digester is closed.
+type AlertDigester struct {
+	c         AlertDigesterConfig
+	clock     clock.Clock
+	collector alertCollector
+
+	mu      sync.Mutex
+	pending map[string][]alert.Event
+	// The events of each task whose last event was CRITICAL.
+	critical map[string]bool
+	closed   bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewAlertDigester(c AlertDigesterConfig, clk clock.Clock, collector alertCollector) (*AlertDigester, error) {
+	if c.Interval <= 0 {
+		return nil, errors.New("alert digest interval must be positive")
+	}
+	if c.Topic == "" {
+		return nil, errors.New("alert digest topic must not be empty")
+	}
+	d := &AlertDigester{
+		c:         c,
+		clock:     clk,
+		collector: collector,
+		pending:   make(map[string][]alert.Event),
+		critical:  make(map[string]bool),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go d.run()
+	return d, nil
+}
+
+// Digest adds the event of the task to the next digest.
+// It reports whether the event is digested, otherwise it must be dispatched.
+func (d *AlertDigester) Digest(taskID string, event alert.Event) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := taskID + "/" + event.State.ID
+	if event.State.Level == alert.Critical {
+		d.critical[id] = true
+		return false
+	}
+	if d.critical[id] {
+		delete(d.critical, id)
+		return false
+	}
+	if d.closed {
+		return false
+	}
+	key := ""
+	if d.c.PerTask {
+		key = taskID
+	}
+	d.pending[key] = append(d.pending[key], event)
+	return true
+}
+
+// Close sends the pending events as a last digest.
+// Events received once closed are not digested.
+func (d *AlertDigester) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	close(d.done)
+	<-d.stopped
+	d.flush(time.Time{})
+}
+
+// run sends the digests at the end of each interval until the digester is closed.
+func (d *AlertDigester) run() {
+	defer close(d.stopped)
+	for end := d.clock.Zero().Add(d.c.Interval); d.clock.UntilOrDone(end, d.done); end = end.Add(d.c.Interval) {
+		d.flush(end)
+	}
+}
+
+// flush sends a digest of the pending events of each key at time t.
+// The time of a digest is the time of its last event if t is before it,
+// e.g. for the last digest when closed.
+func (d *AlertDigester) flush(t time.Time) {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[string][]alert.Event)
+	d.mu.Unlock()
+
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// The digest is sent directly to its topic.
+		_ = d.collector.Collect(d.digestEvent(key, t, pending[key]))
+	}
+}
+
+func (d *AlertDigester) digestEvent(taskID string, t time.Time, events []alert.Event) alert.Event {
+	id := alertDigestID
+	tags := models.Tags{}
+	if taskID != "" {
+		id += "/" + taskID
+		tags["task"] = taskID
+	}
+	level := alert.OK
+	var details strings.Builder
+	for _, e := range events {
+		if e.State.Level > level {
+			level = e.State.Level
+		}
+		if e.State.Time.After(t) {
+			t = e.State.Time
+		}
+		fmt.Fprintf(&details, "%s %s %s: %s\n", e.State.Time.UTC().Format(time.RFC3339), e.State.Level, e.State.ID, e.State.Message)
+	}
+	return alert.Event{
+		Topic: d.c.Topic,
+		State: alert.EventState{
+			ID:      id,
+			Message: fmt.Sprintf("%d alerts in the last %v", len(events), d.c.Interval),
+			Details: details.String(),
+			Time:    t,
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name:     "alert_digest",
+			TaskName: taskID,
+			Tags:     tags,
+			Fields: models.Fields{
+				"count": int64(len(events)),
+			},
+			Result: models.Result{},
+		},
+	}
+}
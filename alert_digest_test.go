@@ -0,0 +1,183 @@
+package kapacitor
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/clock"
+)
+
+// testDigestCollector collects the digests sent from the run loop of a digester.
+type testDigestCollector struct {
+	mu     sync.Mutex
+	events []alert.Event
+}
+
+func (c *testDigestCollector) Collect(e alert.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *testDigestCollector) wait(t *testing.T, n int) []alert.Event {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		events := append([]alert.Event(nil), c.events...)
+		c.mu.Unlock()
+		if len(events) >= n || time.Now().After(deadline) {
+			return events
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAlertDigester(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	newEvent := func(id string, offset time.Duration, level alert.Level) alert.Event {
+		return alert.Event{
+			State: alert.EventState{
+				ID:      id,
+				Message: id + " is " + level.String(),
+				Time:    start.Add(offset),
+				Level:   level,
+			},
+		}
+	}
+	c := clock.New(start)
+	collector := new(testDigestCollector)
+	d, err := NewAlertDigester(AlertDigesterConfig{
+		Interval: time.Hour,
+		Topic:    "digest",
+	}, c, collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []alert.Event{
+		newEvent("a", time.Minute, alert.Info),
+		newEvent("b", 2*time.Minute, alert.Warning),
+		newEvent("a", 3*time.Minute, alert.OK),
+	} {
+		if !d.Digest("task1", e) {
+			t.Errorf("expected event %s to be digested", e.State.ID)
+		}
+	}
+	if d.Digest("task1", newEvent("c", 4*time.Minute, alert.Critical)) {
+		t.Error("expected critical event to bypass the digest")
+	}
+	if d.Digest("task1", newEvent("c", 5*time.Minute, alert.OK)) {
+		t.Error("expected recovery of critical event to bypass the digest")
+	}
+	if got := collector.wait(t, 0); len(got) != 0 {
+		t.Fatalf("expected no digest before the end of the interval, got %d", len(got))
+	}
+
+	c.Set(start.Add(time.Hour))
+	got := collector.wait(t, 1)
+	if len(got) != 1 {
+		t.Fatalf("expected a digest at the end of the interval, got %d", len(got))
+	}
+	digest := got[0]
+	if digest.Topic != "digest" || digest.State.ID != alertDigestID {
+		t.Errorf("unexpected digest topic %q or ID %q", digest.Topic, digest.State.ID)
+	}
+	if exp := start.Add(time.Hour); !digest.State.Time.Equal(exp) {
+		t.Errorf("unexpected digest time: got %v exp %v", digest.State.Time, exp)
+	}
+	if digest.State.Level != alert.Warning {
+		t.Errorf("unexpected digest level: got %v exp %v", digest.State.Level, alert.Warning)
+	}
+	if exp := "3 alerts in the last 1h0m0s"; digest.State.Message != exp {
+		t.Errorf("unexpected digest message: got %q exp %q", digest.State.Message, exp)
+	}
+	expDetails := "1971-01-01T00:01:00Z INFO a: a is INFO\n" +
+		"1971-01-01T00:02:00Z WARNING b: b is WARNING\n" +
+		"1971-01-01T00:03:00Z OK a: a is OK\n"
+	if digest.State.Details != expDetails {
+		t.Errorf("unexpected digest details:\ngot %q\nexp %q", digest.State.Details, expDetails)
+	}
+
+	// Empty intervals do not send a digest.
+	c.Set(start.Add(2 * time.Hour))
+	if !d.Digest("task1", newEvent("d", 2*time.Hour+time.Minute, alert.Warning)) {
+		t.Error("expected event d to be digested")
+	}
+	// Closing sends the pending events.
+	d.Close()
+	got = collector.wait(t, 2)
+	if len(got) != 2 {
+		t.Fatalf("expected a digest when closed, got %d", len(got))
+	}
+	if exp := start.Add(2*time.Hour + time.Minute); !got[1].State.Time.Equal(exp) {
+		t.Errorf("unexpected last digest time: got %v exp %v", got[1].State.Time, exp)
+	}
+	if d.Digest("task1", newEvent("e", 3*time.Hour, alert.Warning)) {
+		t.Error("expected event to not be digested once closed")
+	}
+}
+
+func TestAlertDigester_PerTask(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.New(start)
+	collector := new(testDigestCollector)
+	d, err := NewAlertDigester(AlertDigesterConfig{
+		Interval: time.Minute,
+		Topic:    "digest",
+		PerTask:  true,
+	}, c, collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []string{"task2", "task1", "task2"} {
+		d.Digest(task, alert.Event{State: alert.EventState{ID: "a", Time: start, Level: alert.Warning}})
+	}
+	c.Set(start.Add(time.Minute))
+	got := collector.wait(t, 2)
+
+	var ids []string
+	var counts []interface{}
+	for _, e := range got {
+		ids = append(ids, e.State.ID)
+		counts = append(counts, e.Data.Fields["count"])
+	}
+	if exp := []string{alertDigestID + "/task1", alertDigestID + "/task2"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("unexpected digests: got %v exp %v", ids, exp)
+	}
+	if exp := []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(counts, exp) {
+		t.Errorf("unexpected digest counts: got %v exp %v", counts, exp)
+	}
+	d.Close()
+}
+
+func TestAlertDigester_Close(t *testing.T) {
+	collector := new(testDigestCollector)
+	d, err := NewAlertDigester(AlertDigesterConfig{
+		Interval: time.Hour,
+		Topic:    "digest",
+	}, clock.Wall(), collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Digest("task", alert.Event{State: alert.EventState{ID: "a", Time: time.Now(), Level: alert.Warning}})
+
+	// Closing stops waiting for the end of the interval.
+	closed := make(chan struct{})
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the digester to close")
+	}
+	if got := collector.wait(t, 1); len(got) != 1 {
+		t.Errorf("unexpected digests: got %d exp 1", len(got))
+	}
+}
@@ -0,0 +1,72 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// appendHandler adds a handler of the alert, only sent the events at or above its minimum level if it has one.
+func (n *AlertNode) appendHandler(h alert.Handler, l pipeline.AlertHandlerLevel) error {
+	if l.MinLevel == "" {
+		n.handlers = append(n.handlers, h)
+		return nil
+	}
+	minLevel, err := alert.ParseLevel(l.MinLevel)
+	if err != nil {
+		return fmt.Errorf("invalid minLevel %q for %s handler, must be one of OK, INFO, WARNING or CRITICAL", l.MinLevel, handlerKind(h))
+	}
+	if minLevel == alert.OK {
+		n.handlers = append(n.handlers, h)
+		return nil
+	}
+	n.handlers = append(n.handlers, newLevelFilterHandler(h, minLevel))
+	return nil
+}
+
+// levelFilterHandler sends a handler the events at or above a minimum level,
+// and the recoveries of the alerts it was sent.
+type levelFilterHandler struct {
+	h        alert.Handler
+	minLevel alert.Level
+
+	mu sync.Mutex
+	// The IDs of the alerts sent to the handler that have not recovered.
+	sent map[string]bool
+}
+
+func newLevelFilterHandler(h alert.Handler, minLevel alert.Level) *levelFilterHandler {
+	return &levelFilterHandler{
+		h:        h,
+		minLevel: minLevel,
+		sent:     make(map[string]bool),
+	}
+}
+
+func (f *levelFilterHandler) Handle(event alert.Event) {
+	if f.filter(event.State, event.PreviousState()) {
+		f.h.Handle(event)
+	}
+}
+
+// filter returns whether the event is sent to the handler.
+// The previous state is the state of the event in its topic, which is restored when the task restarts,
+// so the recoveries of alerts sent before a restart are sent too.
+func (f *levelFilterHandler) filter(state, previous alert.EventState) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if previous.Level >= f.minLevel {
+		f.sent[state.ID] = true
+	}
+	if state.Level >= f.minLevel {
+		f.sent[state.ID] = true
+		return true
+	}
+	if state.Level == alert.OK && f.sent[state.ID] {
+		delete(f.sent, state.ID)
+		return true
+	}
+	return false
+}
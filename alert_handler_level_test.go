@@ -0,0 +1,40 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type testLevelHandler []alert.EventState
+
+func (h *testLevelHandler) Handle(event alert.Event) {
+	*h = append(*h, event.State)
+}
+
+func TestLevelFilterHandler(t *testing.T) {
+	h := new(testLevelHandler)
+	f := newLevelFilterHandler(h, alert.Critical)
+	events := []alert.EventState{
+		{ID: "a", Level: alert.Warning},
+		{ID: "a", Level: alert.OK},
+		{ID: "a", Level: alert.Critical},
+		{ID: "b", Level: alert.Warning},
+		{ID: "a", Level: alert.Warning},
+		{ID: "b", Level: alert.OK},
+		{ID: "a", Level: alert.OK},
+		{ID: "a", Level: alert.OK},
+	}
+	for _, state := range events {
+		f.Handle(alert.Event{State: state})
+	}
+	// Only the critical event and the recovery of its alert are sent.
+	exp := []alert.EventState{
+		{ID: "a", Level: alert.Critical},
+		{ID: "a", Level: alert.OK},
+	}
+	if got := []alert.EventState(*h); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected events:\ngot %v\nexp %v", got, exp)
+	}
+}
@@ -0,0 +1,138 @@
+package kapacitor
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// alertJitter delays the dispatch of alert events by a random duration up to a maximum.
+//
+// Events are dispatched in the order they are received,
+// so an event is never dispatched before the event preceding it,
+// even if its own random delay is shorter.
+// Closing the jitter dispatches all pending events immediately,
+// and events received after it is closed are dispatched without delay.
+type alertJitter struct {
+	max      time.Duration
+	dispatch func(alert.Event)
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	pending []jitteredAlert
+	lastDue time.Time
+	closed  bool
+
+	// flushMu orders the events dispatched by Close before any event received once closed.
+	flushMu sync.Mutex
+
+	wakeC chan struct{}
+	stopC chan struct{}
+	wg    sync.WaitGroup
+}
+
+type jitteredAlert struct {
+	event alert.Event
+	due   time.Time
+}
+
+// newAlertJitter creates and starts an alertJitter.
+// The random delays are drawn from r, so that they are deterministic for a seeded source.
+func newAlertJitter(max time.Duration, r *rand.Rand, dispatch func(alert.Event)) *alertJitter {
+	j := &alertJitter{
+		max:      max,
+		dispatch: dispatch,
+		rand:     r,
+		wakeC:    make(chan struct{}, 1),
+		stopC:    make(chan struct{}),
+	}
+	j.wg.Add(1)
+	go j.run()
+	return j
+}
+
+// Dispatch schedules the event to be dispatched after a random delay.
+func (j *alertJitter) Dispatch(event alert.Event) {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		j.flushMu.Lock()
+		defer j.flushMu.Unlock()
+		j.dispatch(event)
+		return
+	}
+	due := time.Now().Add(time.Duration(j.rand.Int63n(int64(j.max))))
+	if due.Before(j.lastDue) {
+		due = j.lastDue
+	}
+	j.lastDue = due
+	j.pending = append(j.pending, jitteredAlert{event: event, due: due})
+	j.mu.Unlock()
+
+	// Wake the run loop, a signal already buffered is sufficient.
+	select {
+	case j.wakeC <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the delays and dispatches all pending events.
+func (j *alertJitter) Close() {
+	j.flushMu.Lock()
+	defer j.flushMu.Unlock()
+
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return
+	}
+	j.closed = true
+	j.mu.Unlock()
+
+	close(j.stopC)
+	j.wg.Wait()
+
+	j.mu.Lock()
+	pending := j.pending
+	j.pending = nil
+	j.mu.Unlock()
+	for _, p := range pending {
+		j.dispatch(p.event)
+	}
+}
+
+func (j *alertJitter) run() {
+	defer j.wg.Done()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		j.mu.Lock()
+		if len(j.pending) > 0 {
+			next := j.pending[0]
+			wait := time.Until(next.due)
+			if wait <= 0 {
+				j.pending = j.pending[1:]
+				j.mu.Unlock()
+				j.dispatch(next.event)
+				continue
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+		}
+		j.mu.Unlock()
+
+		select {
+		case <-timer.C:
+		case <-j.wakeC:
+		case <-j.stopC:
+			return
+		}
+	}
+}
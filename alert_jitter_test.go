@@ -0,0 +1,84 @@
+package kapacitor
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type testJitterDispatcher struct {
+	mu         sync.Mutex
+	dispatched []string
+	times      []time.Time
+}
+
+func (d *testJitterDispatcher) dispatch(e alert.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dispatched = append(d.dispatched, e.State.ID)
+	d.times = append(d.times, time.Now())
+}
+
+func (d *testJitterDispatcher) get() ([]string, []time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.dispatched...), append([]time.Time(nil), d.times...)
+}
+
+func TestAlertJitter_Order(t *testing.T) {
+	d := new(testJitterDispatcher)
+	j := newAlertJitter(50*time.Millisecond, rand.New(rand.NewSource(1)), d.dispatch)
+	defer j.Close()
+
+	start := time.Now()
+	exp := []string{"a", "b", "c", "d", "e"}
+	for _, id := range exp {
+		j.Dispatch(alert.Event{State: alert.EventState{ID: id}})
+	}
+	if got, _ := d.get(); len(got) == len(exp) {
+		t.Fatal("expected events to be delayed")
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		got, times := d.get()
+		if len(got) == len(exp) {
+			if !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected dispatch order: got %v exp %v", got, exp)
+			}
+			for _, tm := range times {
+				if delay := tm.Sub(start); delay > time.Second {
+					t.Errorf("event delayed by %v, more than the jitter", delay)
+				}
+			}
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for events, dispatched %v", got)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestAlertJitter_Close(t *testing.T) {
+	d := new(testJitterDispatcher)
+	j := newAlertJitter(time.Hour, rand.New(rand.NewSource(1)), d.dispatch)
+
+	j.Dispatch(alert.Event{State: alert.EventState{ID: "a"}})
+	j.Dispatch(alert.Event{State: alert.EventState{ID: "b"}})
+	j.Close()
+	if got, exp := d.dispatched, []string{"a", "b"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected dispatched events after close: got %v exp %v", got, exp)
+	}
+
+	// Events received after close are dispatched immediately.
+	j.Dispatch(alert.Event{State: alert.EventState{ID: "c"}})
+	if got, exp := d.dispatched, []string{"a", "b", "c"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected dispatched events: got %v exp %v", got, exp)
+	}
+}
@@ -0,0 +1,242 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/clock"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	AlertRateLimitDrop  = "drop"
+	AlertRateLimitDefer = "defer"
+)
+
+// AlertRateLimiterConfig configures the global alert rate limit of a TaskMaster.
+type AlertRateLimiterConfig struct {
+	// Limit is the maximum number of events dispatched per Interval.
+	Limit int
+	// Interval is the length of each rate limit interval.
+	Interval time.Duration
+	// Overflow is either AlertRateLimitDrop or AlertRateLimitDefer.
+	Overflow string
+	// MaxDeferred is the maximum number of deferred events, further events are dropped.
+	// If zero it is the Limit.
+	MaxDeferred int
+	// ExemptCritical allows CRITICAL events to bypass the limit without being counted.
+	ExemptCritical bool
+	// Topic receives a summary event for each interval in which events were limited,
+	// once the interval has ended.
+	// If empty no summary events are sent.
+	Topic string
+}
+
+type alertCollector interface {
+	Collect(alert.Event) error
+}
+
+// AlertRateLimiter is a circuit breaker for alert storms.
+// It caps the number of alert events dispatched across all tasks of a TaskMaster.
+//
+// The intervals are measured with a clock, so that they can be controlled in tests.
+// Once the limit is reached within an interval, further events are either dropped
+// or deferred to following intervals, depending on the overflow policy.
+// At the end of each interval its summary is sent, and the deferred events which fit in the next interval
+// are dispatched in their original order, before any new events of the interval are counted.
+// Once MaxDeferred events are deferred, further events are dropped.
+// The deferred events of a task are dropped when it stops,
+// and the remaining deferred events are dispatched regardless of the limit when the limiter is closed.
+type AlertRateLimiter struct {
+	c         AlertRateLimiterConfig
+	clock     clock.Clock
+	collector alertCollector
+
+	mu       sync.Mutex
+	start    time.Time
+	count    int
+	limited  int
+	deferred []deferredAlert
+	closed   bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+type deferredAlert struct {
+	taskID   string
+	event    alert.Event
+	dispatch func(alert.Event)
+}
+
+func NewAlertRateLimiter(c AlertRateLimiterConfig, clk clock.Clock, collector alertCollector) (*AlertRateLimiter, error) {
+	if c.Limit <= 0 {
+		return nil, errors.New("alert rate limit must be positive")
+	}
+	if c.Interval <= 0 {
+		return nil, errors.New("alert rate limit interval must be positive")
+	}
+	if c.MaxDeferred < 0 {
+		return nil, errors.New("alert rate limit max deferred must not be negative")
+	}
+	if c.MaxDeferred == 0 {
+		c.MaxDeferred = c.Limit
+	}
+	switch c.Overflow {
+	case "":
+		c.Overflow = AlertRateLimitDrop
+	case AlertRateLimitDrop, AlertRateLimitDefer:
+	default:
+		return nil, fmt.Errorf("invalid alert rate limit overflow %q", c.Overflow)
+	}
+	l := &AlertRateLimiter{
+		c:         c,
+		clock:     clk,
+		collector: collector,
+		start:     clk.Zero(),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// Dispatch calls dispatch with the event of the task if the rate limit allows it.
+// It reports whether the event was limited, either dropped or deferred.
+// Events are not limited once the limiter is closed.
+func (l *AlertRateLimiter) Dispatch(taskID string, event alert.Event, dispatch func(alert.Event)) bool {
+	l.mu.Lock()
+	allowed := true
+	if !l.closed && !(l.c.ExemptCritical && event.State.Level == alert.Critical) {
+		if l.count < l.c.Limit {
+			l.count++
+		} else {
+			allowed = false
+			l.limited++
+			if l.c.Overflow == AlertRateLimitDefer && len(l.deferred) < l.c.MaxDeferred {
+				l.deferred = append(l.deferred, deferredAlert{taskID: taskID, event: event, dispatch: dispatch})
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	if allowed {
+		dispatch(event)
+	}
+	return !allowed
+}
+
+// StopTask drops the deferred events of the task, so that they are not dispatched once it has stopped.
+func (l *AlertRateLimiter) StopTask(taskID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	deferred := l.deferred[:0]
+	for _, d := range l.deferred {
+		if d.taskID != taskID {
+			deferred = append(deferred, d)
+		}
+	}
+	// Do not retain the dropped events.
+	for i := len(deferred); i < len(l.deferred); i++ {
+		l.deferred[i] = deferredAlert{}
+	}
+	l.deferred = deferred
+}
+
+// Close stops the intervals, dispatches all deferred events regardless of the limit,
+// and sends the summary of the current interval if any events were limited during it.
+// It is called when the TaskMaster is closing, before its tasks are stopped, so that deferred events are not lost.
+func (l *AlertRateLimiter) Close() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	close(l.done)
+	<-l.stopped
+
+	l.mu.Lock()
+	released := l.deferred
+	l.deferred = nil
+	summary := l.summary()
+	l.mu.Unlock()
+	l.release(released, summary)
+}
+
+// run ends each interval until the limiter is closed.
+func (l *AlertRateLimiter) run() {
+	defer close(l.stopped)
+	for end := l.clock.Zero().Add(l.c.Interval); l.clock.UntilOrDone(end, l.done); end = end.Add(l.c.Interval) {
+		l.end(end)
+	}
+}
+
+// end ends the current interval at time t, sends its summary,
+// and dispatches the deferred events which fit in the next interval.
+func (l *AlertRateLimiter) end(t time.Time) {
+	l.mu.Lock()
+	summary := l.summary()
+	l.start = t
+	n := len(l.deferred)
+	if n > l.c.Limit {
+		n = l.c.Limit
+	}
+	released := l.deferred[:n:n]
+	l.deferred = l.deferred[n:]
+	l.count = n
+	l.mu.Unlock()
+	l.release(released, summary)
+}
+
+// summary returns the summary of the current interval if any events were limited during it,
+// and resets the count of limited events.
+// Must be called with the lock held.
+func (l *AlertRateLimiter) summary() *alert.Event {
+	limited := l.limited
+	l.limited = 0
+	if limited == 0 || l.c.Topic == "" {
+		return nil
+	}
+	s := l.summaryEvent(l.start.Add(l.c.Interval), limited)
+	return &s
+}
+
+func (l *AlertRateLimiter) release(released []deferredAlert, summary *alert.Event) {
+	for _, d := range released {
+		d.dispatch(d.event)
+	}
+	if summary != nil && l.collector != nil {
+		// The summary is sent directly to its topic and is not itself rate limited.
+		_ = l.collector.Collect(*summary)
+	}
+}
+
+func (l *AlertRateLimiter) summaryEvent(t time.Time, limited int) alert.Event {
+	action := "dropped"
+	if l.c.Overflow == AlertRateLimitDefer {
+		action = "deferred"
+	}
+	return alert.Event{
+		Topic: l.c.Topic,
+		State: alert.EventState{
+			ID:      "kapacitor/alert-rate-limit",
+			Message: fmt.Sprintf("%d alerts were %s by the global alert rate limit of %d per %v", limited, action, l.c.Limit, l.c.Interval),
+			Time:    t,
+			Level:   alert.Warning,
+		},
+		Data: alert.EventData{
+			Name: "alert_rate_limit",
+			Fields: models.Fields{
+				"limited": int64(limited),
+				"limit":   int64(l.c.Limit),
+			},
+			Result: models.Result{},
+		},
+	}
+}
@@ -0,0 +1,249 @@
+package kapacitor
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/clock"
+)
+
+type testAlertCollector struct {
+	mu     sync.Mutex
+	events []alert.Event
+}
+
+func (c *testAlertCollector) Collect(e alert.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func TestAlertRateLimiter(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	newEvent := func(id string, level alert.Level) alert.Event {
+		return alert.Event{
+			State: alert.EventState{
+				ID:    id,
+				Level: level,
+			},
+		}
+	}
+	// An empty event ends the interval.
+	end := alert.Event{}
+	testCases := []struct {
+		name           string
+		overflow       string
+		maxDeferred    int
+		exemptCritical bool
+		events         []alert.Event
+		expDispatched  []string
+		expLimited     []string
+		expSummaries   int
+	}{
+		{
+			name:     "drop",
+			overflow: AlertRateLimitDrop,
+			events: []alert.Event{
+				newEvent("a", alert.Warning),
+				newEvent("b", alert.Warning),
+				newEvent("c", alert.Warning),
+				end,
+				newEvent("d", alert.Warning),
+			},
+			expDispatched: []string{"a", "b", "d"},
+			expLimited:    []string{"c"},
+			expSummaries:  1,
+		},
+		{
+			name:     "defer",
+			overflow: AlertRateLimitDefer,
+			events: []alert.Event{
+				newEvent("a", alert.Warning),
+				newEvent("b", alert.Warning),
+				newEvent("c", alert.Warning),
+				newEvent("d", alert.Warning),
+				end,
+				newEvent("e", alert.Warning),
+			},
+			// c and d are released at the start of the next interval, and use up its budget.
+			expDispatched: []string{"a", "b", "c", "d"},
+			expLimited:    []string{"c", "d", "e"},
+			expSummaries:  1,
+		},
+		{
+			name:        "max deferred",
+			overflow:    AlertRateLimitDefer,
+			maxDeferred: 1,
+			events: []alert.Event{
+				newEvent("a", alert.Warning),
+				newEvent("b", alert.Warning),
+				newEvent("c", alert.Warning),
+				newEvent("d", alert.Warning),
+				end,
+			},
+			// d is dropped as c is already deferred.
+			expDispatched: []string{"a", "b", "c"},
+			expLimited:    []string{"c", "d"},
+			expSummaries:  1,
+		},
+		{
+			name:           "exempt critical",
+			overflow:       AlertRateLimitDrop,
+			exemptCritical: true,
+			events: []alert.Event{
+				newEvent("a", alert.Warning),
+				newEvent("b", alert.Warning),
+				newEvent("c", alert.Critical),
+				newEvent("d", alert.Warning),
+			},
+			expDispatched: []string{"a", "b", "c"},
+			expLimited:    []string{"d"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			collector := new(testAlertCollector)
+			// The clock is never set, the intervals are ended by the test.
+			rl, err := NewAlertRateLimiter(AlertRateLimiterConfig{
+				Limit:          2,
+				Interval:       10 * time.Second,
+				Overflow:       tc.overflow,
+				MaxDeferred:    tc.maxDeferred,
+				ExemptCritical: tc.exemptCritical,
+				Topic:          "limits",
+			}, clock.New(start), collector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rl.Close()
+			var dispatched, limited []string
+			for _, e := range tc.events {
+				if e.State.ID == "" {
+					rl.end(start.Add(10 * time.Second))
+					continue
+				}
+				if rl.Dispatch("task", e, func(e alert.Event) { dispatched = append(dispatched, e.State.ID) }) {
+					limited = append(limited, e.State.ID)
+				}
+			}
+			if !reflect.DeepEqual(dispatched, tc.expDispatched) {
+				t.Errorf("unexpected dispatched events: got %v exp %v", dispatched, tc.expDispatched)
+			}
+			if !reflect.DeepEqual(limited, tc.expLimited) {
+				t.Errorf("unexpected limited events: got %v exp %v", limited, tc.expLimited)
+			}
+			if got := len(collector.events); got != tc.expSummaries {
+				t.Fatalf("unexpected number of summary events: got %d exp %d", got, tc.expSummaries)
+			}
+			for _, s := range collector.events {
+				if s.Topic != "limits" {
+					t.Errorf("unexpected summary topic: got %s exp limits", s.Topic)
+				}
+				if exp := start.Add(10 * time.Second); !s.State.Time.Equal(exp) {
+					t.Errorf("unexpected summary time: got %v exp %v", s.State.Time, exp)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertRateLimiter_Clock(t *testing.T) {
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.New(start)
+	collector := new(testAlertCollector)
+	rl, err := NewAlertRateLimiter(AlertRateLimiterConfig{
+		Limit:    1,
+		Interval: time.Minute,
+		Overflow: AlertRateLimitDefer,
+		Topic:    "limits",
+	}, clk, collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+	released := make(chan string, 2)
+	dispatch := func(e alert.Event) { released <- e.State.ID }
+	rl.Dispatch("task", alert.Event{State: alert.EventState{ID: "a", Level: alert.Warning}}, dispatch)
+	<-released
+	rl.Dispatch("task", alert.Event{State: alert.EventState{ID: "b", Level: alert.Warning}}, dispatch)
+
+	// The deferred event is released at the end of the interval without any other event.
+	clk.Set(start.Add(time.Minute))
+	select {
+	case id := <-released:
+		if id != "b" {
+			t.Errorf("unexpected released event: got %s exp b", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the deferred event to be released at the end of the interval")
+	}
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.events) != 1 {
+		t.Fatalf("expected a single summary, got %d", len(collector.events))
+	}
+}
+
+func TestAlertRateLimiter_StopTask(t *testing.T) {
+	rl, err := NewAlertRateLimiter(AlertRateLimiterConfig{
+		Limit:       1,
+		Interval:    time.Minute,
+		Overflow:    AlertRateLimitDefer,
+		MaxDeferred: 10,
+	}, clock.New(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dispatched []string
+	dispatch := func(e alert.Event) { dispatched = append(dispatched, e.State.ID) }
+	for _, task := range []string{"a", "a", "b", "a"} {
+		rl.Dispatch(task, alert.Event{State: alert.EventState{ID: task, Level: alert.Warning}}, dispatch)
+	}
+	rl.StopTask("a")
+	rl.Close()
+	// Only the deferred event of the running task is dispatched when closed.
+	if exp := []string{"a", "b"}; !reflect.DeepEqual(dispatched, exp) {
+		t.Errorf("unexpected dispatched events: got %v exp %v", dispatched, exp)
+	}
+}
+
+func TestAlertRateLimiter_Close(t *testing.T) {
+	collector := new(testAlertCollector)
+	rl, err := NewAlertRateLimiter(AlertRateLimiterConfig{
+		Limit:       1,
+		Interval:    time.Minute,
+		Overflow:    AlertRateLimitDefer,
+		MaxDeferred: 10,
+		Topic:       "limits",
+	}, clock.Wall(), collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dispatched int
+	dispatch := func(alert.Event) { dispatched++ }
+	for i := 0; i < 3; i++ {
+		rl.Dispatch("task", alert.Event{State: alert.EventState{Level: alert.Warning}}, dispatch)
+	}
+	if dispatched != 1 {
+		t.Fatalf("unexpected dispatched count before close: got %d exp 1", dispatched)
+	}
+	rl.Close()
+	if dispatched != 3 {
+		t.Fatalf("unexpected dispatched count after close: got %d exp 3", dispatched)
+	}
+	// The interval has not ended, its summary is sent when closed.
+	if len(collector.events) != 1 {
+		t.Fatalf("expected a single summary, got %d", len(collector.events))
+	}
+	if exp, got := int64(2), collector.events[0].Data.Fields["limited"]; got != exp {
+		t.Errorf("unexpected limited count: got %v exp %v", got, exp)
+	}
+	// Events are not limited once closed.
+	if rl.Dispatch("task", alert.Event{State: alert.EventState{Level: alert.Warning}}, dispatch) {
+		t.Error("unexpected limited event once closed")
+	}
+}
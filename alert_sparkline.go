@@ -0,0 +1,65 @@
+package kapacitor
+
+import (
+	"strings"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+// The characters of a sparkline, from the lowest to the highest value.
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// recordSparkline adds the value of the sparkline field of a point to the recent values of the group,
+// if the alert has a sparkline.
+func (a *alertState) recordSparkline(fields models.Fields) {
+	if a.sparklineValues == nil {
+		return
+	}
+	value, ok := numToFloat(fields[a.n.a.SparklineField])
+	if !ok {
+		return
+	}
+	if int64(a.sparklineValues.Len) == a.n.a.SparklinePoints {
+		a.sparklineValues.Dequeue(1)
+	}
+	a.sparklineValues.Enqueue(value)
+}
+
+// sparkline returns the sparkline of the recent values of the group, empty if the alert has no sparkline.
+func (a *alertState) sparkline() string {
+	if a.sparklineValues == nil {
+		return ""
+	}
+	values := make([]float64, a.sparklineValues.Len)
+	for i := range values {
+		values[i] = a.sparklineValues.Peek(i)
+	}
+	return sparkline(values)
+}
+
+// sparkline draws the values with one block per value, scaled from the lowest to the highest value.
+// Equal values are drawn with the lowest block.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	top := len(sparklineBlocks) - 1
+	for _, v := range values {
+		i := 0
+		if max > min {
+			i = int((v-min)/(max-min)*float64(top) + 0.5)
+		}
+		b.WriteRune(sparklineBlocks[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package kapacitor
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	testCases := []struct {
+		values []float64
+		exp    string
+	}{
+		{
+			values: nil,
+			exp:    "",
+		},
+		{
+			values: []float64{5, 5, 5},
+			exp:    "▁▁▁",
+		},
+		{
+			values: []float64{0, 1, 2, 3, 4, 5, 6, 7},
+			exp:    "▁▂▃▄▅▆▇█",
+		},
+		{
+			values: []float64{-10, 10, 0, 4.9},
+			exp:    "▁█▅▆",
+		},
+	}
+	for _, tc := range testCases {
+		if got := sparkline(tc.values); got != tc.exp {
+			t.Errorf("unexpected sparkline of %v: got %q exp %q", tc.values, got, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type AutocorrelationNode struct {
+	node
+	a *pipeline.AutocorrelationNode
+}
+
+// Create a new autocorrelation node.
+func newAutocorrelationNode(et *ExecutingTask, n *pipeline.AutocorrelationNode, d NodeDiagnostic) (*AutocorrelationNode, error) {
+	an := &AutocorrelationNode{
+		node: node{Node: n, et: et, diag: d},
+		a:    n,
+	}
+	an.node.runF = an.runAutocorrelation
+	return an, nil
+}
+
+func (n *AutocorrelationNode) runAutocorrelation([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *AutocorrelationNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *AutocorrelationNode) newGroup() *autocorrelationGroup {
+	return &autocorrelationGroup{
+		n:      n,
+		values: NewCircularQueue[float64](make([]float64, 0, n.a.Size)...),
+	}
+}
+
+type autocorrelationGroup struct {
+	n      *AutocorrelationNode
+	values *CircularQueue[float64]
+}
+
+func (g *autocorrelationGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *autocorrelationGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doAutocorrelation(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *autocorrelationGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *autocorrelationGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doAutocorrelation(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doAutocorrelation adds the value of p to the window and sets the coefficient as a field on p.
+// Points without a numeric value are dropped and are not added to the window.
+func (g *autocorrelationGroup) doAutocorrelation(p edge.FieldsTagsTimeSetter) bool {
+	a := g.n.a
+	value, ok := numToFloat(p.Fields()[a.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute autocorrelation",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", a.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[a.Field])),
+		)
+		return false
+	}
+
+	if int64(g.values.Len) == a.Size {
+		g.values.Dequeue(1)
+	}
+	g.values.Enqueue(value)
+
+	if r, ok := g.coefficient(); ok {
+		fields := p.Fields().Copy()
+		fields[a.As] = r
+		p.SetFields(fields)
+	}
+	return true
+}
+
+// coefficient returns the autocorrelation coefficient of the window,
+// or false if the window is not full or has zero variance.
+func (g *autocorrelationGroup) coefficient() (float64, bool) {
+	n := g.values.Len
+	if int64(n) < g.n.a.Size {
+		return 0, false
+	}
+	mean := 0.0
+	for i := 0; i < n; i++ {
+		mean += g.values.Peek(i)
+	}
+	mean /= float64(n)
+
+	lag := int(g.n.a.Lag)
+	variance, covariance := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		d := g.values.Peek(i) - mean
+		variance += d * d
+		if i+lag < n {
+			covariance += d * (g.values.Peek(i+lag) - mean)
+		}
+	}
+	if variance == 0 {
+		return 0, false
+	}
+	return covariance / variance, true
+}
+
+func (g *autocorrelationGroup) reset() {
+	g.values.Dequeue(g.values.Len)
+}
+
+func (g *autocorrelationGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *autocorrelationGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *autocorrelationGroup) Done() {}
@@ -0,0 +1,263 @@
+package kapacitor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+// The precision of the points of stream recordings.
+const recordingPrecision = "n"
+
+type BaselineNode struct {
+	node
+	b *pipeline.BaselineNode
+
+	// The points of the recording, nil until loaded.
+	points []edge.FieldsTagsTimeGetter
+	// The baselines of the groups, indexed by the tags matching the groups and then by group.
+	baselines map[string]map[string]baselineGroup
+}
+
+// The mean value of each numeric field of a group of the recording.
+type baselineGroup map[string]float64
+
+// Create a new baseline node.
+func newBaselineNode(et *ExecutingTask, n *pipeline.BaselineNode, d NodeDiagnostic) (*BaselineNode, error) {
+	if et.tm.RecordingService == nil {
+		return nil, errors.New("recordings are not available for baseline")
+	}
+	bn := &BaselineNode{
+		node:      node{Node: n, et: et, diag: d},
+		b:         n,
+		baselines: make(map[string]map[string]baselineGroup),
+	}
+	bn.node.runF = bn.runBaseline
+	return bn, nil
+}
+
+func (n *BaselineNode) runBaseline([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *BaselineNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	// The recording is loaded with the first point, once the task and the services have started.
+	if n.points == nil {
+		if err := n.loadRecording(); err != nil {
+			return nil, errors.Wrapf(err, "failed to load baseline recording %s", n.b.Recording)
+		}
+	}
+	tags := n.b.OnTags
+	if len(tags) == 0 {
+		tags = group.Dimensions.TagNames
+	}
+	baseline, ok := n.baselineOf(tags)[baselineKey(tags, group.Tags)]
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &baselineCompareGroup{
+			n:        n,
+			baseline: baseline,
+			missing:  !ok,
+		}),
+	), nil
+}
+
+// loadRecording reads the points of the recording of the baseline.
+func (n *BaselineNode) loadRecording() error {
+	readers, stream, err := n.et.tm.RecordingService.RecordingReaders(n.b.Recording)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	points := make([]edge.FieldsTagsTimeGetter, 0)
+	for _, r := range readers {
+		if stream {
+			pointsC := make(chan edge.PointMessage)
+			errC := make(chan error, 1)
+			go func(r io.ReadCloser) {
+				errC <- readPointsFromIO(r, pointsC, recordingPrecision)
+			}(r)
+			for p := range pointsC {
+				points = append(points, p)
+			}
+			if err := <-errC; err != nil {
+				return err
+			}
+			continue
+		}
+		dec := edge.NewBufferedBatchMessageDecoder(r)
+		for dec.More() {
+			b, err := dec.Decode()
+			if err != nil {
+				return err
+			}
+			for _, bp := range b.Points() {
+				// The points of a batch have the tags of their group.
+				tags := b.Tags().Copy()
+				for k, v := range bp.Tags() {
+					tags[k] = v
+				}
+				p := edge.NewPointMessage(b.Name(), "", "", models.Dimensions{}, bp.Fields(), tags, bp.Time())
+				points = append(points, p)
+			}
+		}
+	}
+	n.points = points
+	return nil
+}
+
+// baselineOf returns the baselines of the groups of the recording matched by the tags.
+func (n *BaselineNode) baselineOf(tags []string) map[string]baselineGroup {
+	index := strings.Join(tags, ",")
+	if baselines, ok := n.baselines[index]; ok {
+		return baselines
+	}
+	sums := make(map[string]map[string]float64)
+	counts := make(map[string]map[string]int)
+	for _, p := range n.points {
+		key := baselineKey(tags, p.Tags())
+		if sums[key] == nil {
+			sums[key] = make(map[string]float64)
+			counts[key] = make(map[string]int)
+		}
+		for f, v := range p.Fields() {
+			if value, ok := baselineValue(v); ok {
+				sums[key][f] += value
+				counts[key][f]++
+			}
+		}
+	}
+	baselines := make(map[string]baselineGroup, len(sums))
+	for key, fields := range sums {
+		g := make(baselineGroup, len(fields))
+		for f, sum := range fields {
+			g[f] = sum / float64(counts[key][f])
+		}
+		baselines[key] = g
+	}
+	n.baselines[index] = baselines
+	return baselines
+}
+
+// baselineKey returns the key of the group with the values of the tags.
+func baselineKey(names []string, tags models.Tags) string {
+	sorted := make([]string, len(names))
+	copy(sorted, names)
+	sort.Strings(sorted)
+	var key strings.Builder
+	for _, name := range sorted {
+		fmt.Fprintf(&key, "%s=%s,", name, tags[name])
+	}
+	return key.String()
+}
+
+// baselineValue returns the value of a numeric field, booleans and strings are not part of the baseline.
+func baselineValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+type baselineCompareGroup struct {
+	n        *BaselineNode
+	baseline baselineGroup
+	missing  bool
+}
+
+func (g *baselineCompareGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if g.drop() {
+		return nil, nil
+	}
+	return begin, nil
+}
+
+func (g *baselineCompareGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if g.drop() {
+		return nil, nil
+	}
+	if g.missing {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	g.compare(bp)
+	return bp, nil
+}
+
+func (g *baselineCompareGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if g.drop() {
+		return nil, nil
+	}
+	return end, nil
+}
+
+// drop reports whether the group has no baseline and its points are dropped.
+func (g *baselineCompareGroup) drop() bool {
+	return g.missing && g.n.b.Missing == pipeline.BaselineMissingDrop
+}
+
+func (g *baselineCompareGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if g.drop() {
+		return nil, nil
+	}
+	if g.missing {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	g.compare(p)
+	return p, nil
+}
+
+// compare sets the baseline value, the difference and the ratio of the compared fields of p.
+func (g *baselineCompareGroup) compare(p edge.FieldsTagsTimeSetter) {
+	fields := p.Fields().Copy()
+	compared := g.n.b.FieldsList
+	if len(compared) == 0 {
+		compared = make([]string, 0, len(fields))
+		for f := range p.Fields() {
+			compared = append(compared, f)
+		}
+	}
+	for _, f := range compared {
+		base, ok := g.baseline[f]
+		if !ok {
+			continue
+		}
+		value, ok := baselineValue(p.Fields()[f])
+		if !ok {
+			continue
+		}
+		fields[f+"_baseline"] = base
+		fields[f+"_diff"] = value - base
+		if base != 0 {
+			fields[f+"_ratio"] = value / base
+		}
+	}
+	p.SetFields(fields)
+}
+
+func (g *baselineCompareGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *baselineCompareGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *baselineCompareGroup) Done() {}
@@ -18,6 +18,8 @@ import (
 const (
 	statsBatchesQueried = "batches_queried"
 	statsPointsQueried  = "points_queried"
+	statsQueryDuration  = "query_duration_ns"
+	statsQueryPoints    = "query_points"
 )
 
 type BatchNode struct {
@@ -177,6 +179,8 @@ type QueryNode struct {
 
 	batchesQueried *expvar.Int
 	pointsQueried  *expvar.Int
+	queryDuration  *expvar.Int
+	queryPoints    *expvar.Int
 	byName         bool
 }
 
@@ -309,9 +313,13 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 	defer in.Close()
 	n.batchesQueried = &expvar.Int{}
 	n.pointsQueried = &expvar.Int{}
+	n.queryDuration = &expvar.Int{}
+	n.queryPoints = &expvar.Int{}
 
 	n.statMap.Set(statsBatchesQueried, n.batchesQueried)
 	n.statMap.Set(statsPointsQueried, n.pointsQueried)
+	n.statMap.Set(statsQueryDuration, n.queryDuration)
+	n.statMap.Set(statsQueryPoints, n.queryPoints)
 
 	if n.et.tm.InfluxDBService == nil {
 		return errors.New("InfluxDB not configured, cannot query InfluxDB for batch query")
@@ -342,7 +350,10 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 			q := influxdb.Query{
 				Command: qStr,
 			}
+			queryStart := time.Now()
 			resp, err := con.Query(q)
+			duration := time.Since(queryStart)
+			n.queryDuration.Set(int64(duration))
 			if err != nil {
 				n.diag.Error("error executing query", err)
 				n.timer.Stop()
@@ -350,6 +361,8 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 			}
 
 			// Collect batches
+			var queried []edge.BufferedBatchMessage
+			points := 0
 			for _, res := range resp.Results {
 				batches, err := edge.ResultToBufferedBatches(res, n.byName)
 				if err != nil {
@@ -357,26 +370,51 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 					continue
 				}
 				for _, bch := range batches {
-					// Set stop time based off query bounds
-					if bch.Begin().Time().IsZero() || !n.query.IsGroupedByTime() {
-						bch.Begin().SetTime(stop)
-					}
+					points += len(bch.Points())
+				}
+				queried = append(queried, batches...)
+			}
+			n.queryPoints.Set(int64(points))
 
-					n.batchesQueried.Add(1)
-					n.pointsQueried.Add(int64(len(bch.Points())))
+			for _, bch := range queried {
+				// Set stop time based off query bounds
+				if bch.Begin().Time().IsZero() || !n.query.IsGroupedByTime() {
+					bch.Begin().SetTime(stop)
+				}
+				n.setQueryFields(bch, duration, points)
 
-					n.timer.Pause()
-					if err := in.Collect(bch); err != nil {
-						return err
-					}
-					n.timer.Resume()
+				n.batchesQueried.Add(1)
+				n.pointsQueried.Add(int64(len(bch.Points())))
+
+				n.timer.Pause()
+				if err := in.Collect(bch); err != nil {
+					return err
 				}
+				n.timer.Resume()
 			}
 			n.timer.Stop()
 		}
 	}
 }
 
+// setQueryFields sets the duration of the query and the number of points it returned
+// as fields on the points of the batch, if configured.
+func (n *QueryNode) setQueryFields(bch edge.BufferedBatchMessage, duration time.Duration, points int) {
+	if n.b.QueryDurationAs == "" && n.b.QueryPointsAs == "" {
+		return
+	}
+	for _, bp := range bch.Points() {
+		fields := bp.Fields().Copy()
+		if n.b.QueryDurationAs != "" {
+			fields[n.b.QueryDurationAs] = duration.Seconds()
+		}
+		if n.b.QueryPointsAs != "" {
+			fields[n.b.QueryPointsAs] = int64(points)
+		}
+		bp.SetFields(fields)
+	}
+}
+
 func (n *QueryNode) runBatch([]byte) error {
 	errC := make(chan error, 1)
 	go func() {
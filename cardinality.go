@@ -0,0 +1,225 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/pkg/estimator/hll"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CardinalityNode struct {
+	node
+	c *pipeline.CardinalityNode
+
+	slice time.Duration
+}
+
+// Create a new CardinalityNode which estimates the number of distinct values over a sliding window.
+func newCardinalityNode(et *ExecutingTask, n *pipeline.CardinalityNode, d NodeDiagnostic) (*CardinalityNode, error) {
+	cn := &CardinalityNode{
+		node:  node{Node: n, et: et, diag: d},
+		c:     n,
+		slice: n.Period / time.Duration(n.Slices),
+	}
+	cn.node.runF = cn.runCardinality
+	return cn, nil
+}
+
+func (n *CardinalityNode) runCardinality([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CardinalityNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g, err := n.newGroup()
+	if err != nil {
+		return nil, err
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+func (n *CardinalityNode) newGroup() (*cardinalityGroup, error) {
+	g := &cardinalityGroup{
+		n:      n,
+		slices: NewCircularQueue[cardinalitySlice](),
+	}
+	if err := g.reset(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (n *CardinalityNode) newSketch() (*hll.Plus, error) {
+	return hll.NewPlus(uint8(n.c.Precision))
+}
+
+type cardinalityGroup struct {
+	n *CardinalityNode
+
+	// The sketches of the slices of the window, oldest first.
+	slices *CircularQueue[cardinalitySlice]
+	// The sketch of the whole window, the union of the slices.
+	window *hll.Plus
+
+	buf []byte
+}
+
+// The sketch of the values of a slice of the window.
+type cardinalitySlice struct {
+	start  time.Time
+	sketch *hll.Plus
+}
+
+func (g *cardinalityGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if err := g.reset(); err != nil {
+		return nil, err
+	}
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *cardinalityGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	ok, err := g.doCardinality(bp)
+	if !ok {
+		return nil, err
+	}
+	return bp, nil
+}
+
+func (g *cardinalityGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *cardinalityGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	ok, err := g.doCardinality(p)
+	if !ok {
+		return nil, err
+	}
+	return p, nil
+}
+
+// doCardinality adds the value of p to the window and sets the estimate as a field on p.
+// Points without a value are dropped.
+func (g *cardinalityGroup) doCardinality(p edge.FieldsTagsTimeSetter) (bool, error) {
+	c := g.n.c
+	var ok bool
+	g.buf, ok = appendCardinalityValue(g.buf[:0], p.Fields()[c.Field])
+	if !ok {
+		var v string
+		v, ok = p.Tags()[c.Field]
+		g.buf = append(g.buf, v...)
+	}
+	if !ok {
+		g.n.diag.Error("cannot estimate cardinality",
+			errors.New("field or tag is missing or the wrong type"),
+			keyvalue.KV("field", c.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[c.Field])),
+		)
+		return false, nil
+	}
+
+	current, err := g.slide(p.Time())
+	if err != nil {
+		return false, err
+	}
+	current.Add(g.buf)
+	g.window.Add(g.buf)
+
+	fields := p.Fields().Copy()
+	fields[c.As] = int64(g.window.Count())
+	p.SetFields(fields)
+	return true, nil
+}
+
+// slide moves the window to include time t, and returns the sketch of the slice of t.
+// Points older than the current slice are added to the current slice.
+func (g *cardinalityGroup) slide(t time.Time) (*hll.Plus, error) {
+	start := t.Truncate(g.n.slice)
+	if g.slices.Len > 0 {
+		last := g.slices.Peek(g.slices.Len - 1)
+		if !start.After(last.start) {
+			return last.sketch, nil
+		}
+	}
+
+	// Drop the slices that are no longer in the window.
+	oldest := start.Add(-g.n.c.Period + g.n.slice)
+	expired := 0
+	for expired < g.slices.Len && g.slices.Peek(expired).start.Before(oldest) {
+		expired++
+	}
+	if expired > 0 {
+		g.slices.Dequeue(expired)
+		// Values cannot be removed from a sketch, so the window is rebuilt from the remaining slices.
+		window, err := g.n.newSketch()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < g.slices.Len; i++ {
+			if err := window.Merge(g.slices.Peek(i).sketch); err != nil {
+				return nil, err
+			}
+		}
+		g.window = window
+	}
+
+	sketch, err := g.n.newSketch()
+	if err != nil {
+		return nil, err
+	}
+	g.slices.Enqueue(cardinalitySlice{
+		start:  start,
+		sketch: sketch,
+	})
+	return sketch, nil
+}
+
+func (g *cardinalityGroup) reset() error {
+	g.slices.Dequeue(g.slices.Len)
+	window, err := g.n.newSketch()
+	if err != nil {
+		return err
+	}
+	g.window = window
+	return nil
+}
+
+// appendCardinalityValue appends the bytes identifying the value to buf,
+// or returns false if the value cannot be counted.
+func appendCardinalityValue(buf []byte, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		return append(buf, v...), true
+	case int64:
+		return strconv.AppendInt(buf, v, 10), true
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64), true
+	case bool:
+		return strconv.AppendBool(buf, v), true
+	default:
+		return buf, false
+	}
+}
+
+func (g *cardinalityGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *cardinalityGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *cardinalityGroup) Done() {}
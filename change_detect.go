@@ -2,16 +2,24 @@ package kapacitor
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/keyvalue"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 )
 
+const (
+	statPointsSuppressed = "points_suppressed"
+)
+
 type ChangeDetectNode struct {
 	node
 	d *pipeline.ChangeDetectNode
+
+	pointsSuppressed *expvar.Int
 }
 
 // Create a new changeDetect node.
@@ -19,6 +27,8 @@ func newChangeDetectNode(et *ExecutingTask, n *pipeline.ChangeDetectNode, d Node
 	dn := &ChangeDetectNode{
 		node: node{Node: n, et: et, diag: d},
 		d:    n,
+
+		pointsSuppressed: new(expvar.Int),
 	}
 	// Create stateful expressions
 	dn.node.runF = dn.runChangeDetect
@@ -26,6 +36,7 @@ func newChangeDetectNode(et *ExecutingTask, n *pipeline.ChangeDetectNode, d Node
 }
 
 func (n *ChangeDetectNode) runChangeDetect([]byte) error {
+	n.statMap.Set(statPointsSuppressed, n.pointsSuppressed)
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -48,8 +59,9 @@ func (n *ChangeDetectNode) newGroup() *changeDetectGroup {
 }
 
 type changeDetectGroup struct {
-	n        *ChangeDetectNode
-	previous edge.FieldsTagsTimeGetter
+	n *ChangeDetectNode
+	// The last emitted value of each watched field.
+	previous models.Fields
 }
 
 func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -62,11 +74,15 @@ func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Messa
 }
 
 func (g *changeDetectGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
-	changed := g.doChangeDetect(bp)
-	if changed {
-		return bp, nil
+	fields, changed := g.doChangeDetect(bp.Fields())
+	if !changed {
+		return nil, nil
+	}
+	if g.n.d.ChangedOnlyFlag {
+		bp = bp.ShallowCopy()
+		bp.SetFields(fields)
 	}
-	return nil, nil
+	return bp, nil
 }
 
 func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
@@ -74,28 +90,34 @@ func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, er
 }
 
 func (g *changeDetectGroup) Point(p edge.PointMessage) (edge.Message, error) {
-	changed := g.doChangeDetect(p)
-	if changed {
-		return p, nil
+	fields, changed := g.doChangeDetect(p.Fields())
+	if !changed {
+		return nil, nil
+	}
+	if g.n.d.ChangedOnlyFlag {
+		p = p.ShallowCopy()
+		p.SetFields(fields)
 	}
-	return nil, nil
+	return p, nil
 }
 
-// doChangeDetect computes the changeDetect with respect to g.previous and p.
-// The resulting changeDetect value will be set on n.
-func (g *changeDetectGroup) doChangeDetect(p edge.FieldsTagsTimeGetter) bool {
-	var prevFields, currFields models.Fields
-	if g.previous != nil {
-		prevFields = g.previous.Fields()
+// doChangeDetect reports whether curr changed with respect to the previous values,
+// and returns the watched fields that changed.
+func (g *changeDetectGroup) doChangeDetect(curr models.Fields) (models.Fields, bool) {
+	changed := g.n.changeDetect(g.previous, curr)
+	if len(changed) == 0 {
+		g.n.pointsSuppressed.Add(1)
+		return nil, false
 	}
-	currFields = p.Fields()
-	changed := g.n.changeDetect(prevFields, currFields)
-
-	if !changed {
-		return false
+	// Only the changed fields are updated, so that a field drifting slowly
+	// is still compared to its last emitted value while other fields change.
+	if g.previous == nil {
+		g.previous = make(models.Fields, len(g.n.d.Fields))
 	}
-	g.previous = p
-	return true
+	for field, value := range changed {
+		g.previous[field] = value
+	}
+	return changed, true
 }
 
 func (g *changeDetectGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
@@ -106,8 +128,9 @@ func (g *changeDetectGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message
 }
 func (g *changeDetectGroup) Done() {}
 
-// changeDetect reports whether there was a change between prev and cur.
-func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) bool {
+// changeDetect returns the watched fields that changed between prev and curr.
+func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) models.Fields {
+	changed := make(models.Fields)
 	for _, field := range n.d.Fields {
 		value, ok := curr[field]
 		if !ok {
@@ -116,9 +139,21 @@ func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) bool {
 				keyvalue.KV("field", field))
 			continue
 		}
-		if prev[field] != value {
-			return true
+		if n.changed(prev[field], value) {
+			changed[field] = value
+		}
+	}
+	return changed
+}
+
+// changed reports whether the value of a field changed from prev to curr.
+func (n *ChangeDetectNode) changed(prev, curr interface{}) bool {
+	if n.d.Tolerance > 0 {
+		p, pok := numToFloat(prev)
+		c, cok := numToFloat(curr)
+		if pok && cok {
+			return math.Abs(c-p) > n.d.Tolerance
 		}
 	}
-	return false
+	return prev != curr
 }
@@ -2216,11 +2216,12 @@ type TopicEvent struct {
 }
 
 type EventState struct {
-	Message  string    `json:"message"`
-	Details  string    `json:"details"`
-	Time     time.Time `json:"time"`
-	Duration Duration  `json:"duration"`
-	Level    string    `json:"level"`
+	Message      string    `json:"message"`
+	Details      string    `json:"details"`
+	Time         time.Time `json:"time"`
+	Duration     Duration  `json:"duration"`
+	Level        string    `json:"level"`
+	Acknowledged bool      `json:"acknowledged"`
 }
 
 // TopicEvent retrieves details for a single event of a topic
@@ -2243,6 +2244,38 @@ func (c *Client) TopicEvent(link Link) (TopicEvent, error) {
 	return e, err
 }
 
+type TopicEventAcknowledgeOptions struct {
+	Acknowledged bool `json:"acknowledged"`
+}
+
+// AcknowledgeTopicEvent sets whether an event of a topic is acknowledged.
+// An acknowledged event suppresses reminders of its alert until it recovers or its level increases.
+// Errors if no event exists.
+func (c *Client) AcknowledgeTopicEvent(link Link, opt TopicEventAcknowledgeOptions) (TopicEvent, error) {
+	e := TopicEvent{}
+	if link.Href == "" {
+		return e, fmt.Errorf("invalid link %v", link)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return e, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return e, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &e, http.StatusOK)
+	return e, err
+}
+
 type ListTopicEventsOptions struct {
 	MinLevel string
 }
@@ -2715,6 +2715,55 @@ func Test_TopicEvent(t *testing.T) {
 	}
 }
 
+func Test_AcknowledgeTopicEvent(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var opt client.TopicEventAcknowledgeOptions
+		json.NewDecoder(r.Body).Decode(&opt)
+		if r.URL.String() == "/kapacitor/v1/alerts/topics/system/events/cpu" &&
+			r.Method == "POST" &&
+			opt.Acknowledged {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+	"link":{"rel":"self","href":"/kapacitor/v1/alerts/topics/system/events/cpu"},
+	"id": "cpu",
+	"state": {
+		"level": "WARNING",
+		"message": "cpu is WARNING",
+		"time": "2016-12-01T00:00:00Z",
+		"duration": "5m",
+		"acknowledged": true
+	}
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	topicEvent, err := c.AcknowledgeTopicEvent(c.TopicEventLink("system", "cpu"), client.TopicEventAcknowledgeOptions{Acknowledged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := client.TopicEvent{
+		ID:   "cpu",
+		Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/alerts/topics/system/events/cpu"},
+		State: client.EventState{
+			Message:      "cpu is WARNING",
+			Time:         time.Date(2016, 12, 1, 0, 0, 0, 0, time.UTC),
+			Duration:     client.Duration(5 * time.Minute),
+			Level:        "WARNING",
+			Acknowledged: true,
+		},
+	}
+	if !cmp.Equal(exp, topicEvent) {
+		t.Errorf("unexpected acknowledge topic event result:\ngot:\n%v\nexp:\n%v", topicEvent, exp)
+	}
+}
+
 func Test_ListTopicEvents(t *testing.T) {
 	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.String() == "/kapacitor/v1/alerts/topics/system/events?min-level=OK" &&
@@ -14,6 +14,8 @@ type Clock interface {
 	Setter
 	// Wait until time t has arrived. If t is in the past it immediately returns.
 	Until(t time.Time)
+	// Wait until time t has arrived or done is closed, and report whether time t has arrived.
+	UntilOrDone(t time.Time, done <-chan struct{}) bool
 }
 
 type Setter interface {
@@ -41,6 +43,17 @@ func (w *wallclock) Until(t time.Time) {
 	time.Sleep(time.Until(t))
 }
 
+func (w *wallclock) UntilOrDone(t time.Time, done <-chan struct{}) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
 func (w *wallclock) Set(t time.Time) {}
 
 // implementation of clock that is always in the future
@@ -59,6 +72,15 @@ func (f *fastclock) Zero() time.Time {
 
 func (f *fastclock) Until(t time.Time) {}
 
+func (f *fastclock) UntilOrDone(t time.Time, done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
 func (f *fastclock) Set(t time.Time) {}
 
 // setable implementation of the clock
@@ -91,6 +113,33 @@ func (c *setclock) Until(t time.Time) {
 	c.cond.L.Unlock()
 }
 
+func (c *setclock) UntilOrDone(t time.Time, done <-chan struct{}) bool {
+	// Wake up the waiting loop once done is closed.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-done:
+			c.cond.L.Lock()
+			c.cond.Broadcast()
+			c.cond.L.Unlock()
+		case <-stop:
+		}
+	}()
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	for t.After(c.now) {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		c.cond.Wait()
+	}
+	return true
+}
+
 func (c *setclock) Set(t time.Time) {
 	if t.Before(c.now) {
 		panic("cannot set time backwards")
@@ -163,3 +163,35 @@ func TestWallClock(t *testing.T) {
 		t.Fatal("expected return from c.Until")
 	}
 }
+
+func TestClockUntilOrDone(t *testing.T) {
+	c := clock.New(time.Time{})
+	zero := c.Zero()
+
+	done := make(chan struct{})
+	result := make(chan bool)
+	go func() {
+		result <- c.UntilOrDone(zero.Add(10*time.Microsecond), done)
+	}()
+
+	select {
+	case <-result:
+		t.Fatal("unexpected return from c.UntilOrDone")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(done)
+	select {
+	case arrived := <-result:
+		if arrived {
+			t.Error("expected the time not to have arrived")
+		}
+	case <-time.After(20 * time.Millisecond):
+		t.Fatal("expected return from c.UntilOrDone")
+	}
+
+	c.Set(zero.Add(10 * time.Microsecond))
+	if !c.UntilOrDone(zero.Add(10*time.Microsecond), make(chan struct{})) {
+		t.Error("expected the time to have arrived")
+	}
+}
@@ -0,0 +1,192 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+type CoincidenceNode struct {
+	node
+	c *pipeline.CoincidenceNode
+
+	left  coincidenceCondition
+	right coincidenceCondition
+}
+
+// A compiled condition of a CoincidenceNode.
+type coincidenceCondition struct {
+	expression stateful.Expression
+	scopePool  stateful.ScopePool
+	references []string
+}
+
+// Create a new CoincidenceNode which keeps the points for which two conditions hold.
+func newCoincidenceNode(et *ExecutingTask, n *pipeline.CoincidenceNode, d NodeDiagnostic) (*CoincidenceNode, error) {
+	if n.Left == nil || n.Right == nil {
+		return nil, errors.New("nil expression passed to CoincidenceNode")
+	}
+	cn := &CoincidenceNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	var err error
+	cn.left, err = newCoincidenceCondition(n.Left)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile left expression in coincidence node: %v", err)
+	}
+	cn.right, err = newCoincidenceCondition(n.Right)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile right expression in coincidence node: %v", err)
+	}
+	cn.node.runF = cn.runCoincidence
+	return cn, nil
+}
+
+func newCoincidenceCondition(l *ast.LambdaNode) (coincidenceCondition, error) {
+	expr, err := stateful.NewExpression(l.Expression)
+	if err != nil {
+		return coincidenceCondition{}, err
+	}
+	references := ast.FindReferenceVariables(l.Expression)
+	return coincidenceCondition{
+		expression: expr,
+		scopePool:  stateful.NewScopePool(references),
+		references: references,
+	}, nil
+}
+
+func (n *CoincidenceNode) runCoincidence([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CoincidenceNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *CoincidenceNode) newGroup() *coincidenceGroup {
+	return &coincidenceGroup{
+		n: n,
+		left: coincidenceState{
+			condition: n.left,
+			expr:      n.left.expression.CopyReset(),
+		},
+		right: coincidenceState{
+			condition: n.right,
+			expr:      n.right.expression.CopyReset(),
+		},
+	}
+}
+
+type coincidenceGroup struct {
+	n *CoincidenceNode
+
+	left  coincidenceState
+	right coincidenceState
+}
+
+// The state of a condition in a group.
+type coincidenceState struct {
+	condition coincidenceCondition
+	expr      stateful.Expression
+
+	// The last result of the condition and the time it was evaluated.
+	evaluated bool
+	last      bool
+	lastTime  time.Time
+}
+
+func (g *coincidenceGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.left.reset()
+	g.right.reset()
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *coincidenceGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return g.doCoincidence(bp), nil
+}
+
+func (g *coincidenceGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *coincidenceGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return g.doCoincidence(p), nil
+}
+
+// doCoincidence returns the point if both conditions hold for it, otherwise nil.
+// Both conditions are always evaluated, so that their last results are up to date.
+func (g *coincidenceGroup) doCoincidence(p edge.FieldsTagsTimeGetterMessage) edge.Message {
+	left := g.left.holds(g.n, p)
+	right := g.right.holds(g.n, p)
+	if left && right {
+		return p
+	}
+	return nil
+}
+
+// holds reports whether the condition holds for the point.
+// If the point is missing a field or tag referenced by the condition,
+// the last result is used when it is within the tolerance.
+func (s *coincidenceState) holds(n *CoincidenceNode, p edge.FieldsTagsTimeGetterMessage) bool {
+	if !s.available(p) {
+		return s.evaluated && p.Time().Sub(s.lastTime) <= n.c.Tolerance && s.last
+	}
+	pass, err := EvalPredicate(s.expr, s.condition.scopePool, p)
+	if err != nil {
+		n.diag.Error("error while evaluating expression", err)
+		return false
+	}
+	s.evaluated = true
+	s.last = pass
+	s.lastTime = p.Time()
+	return pass
+}
+
+// available reports whether the point has all the fields or tags referenced by the condition.
+func (s *coincidenceState) available(p edge.FieldsTagsTimeGetter) bool {
+	fields := p.Fields()
+	tags := p.Tags()
+	for _, r := range s.condition.references {
+		if r == "time" {
+			continue
+		}
+		if v, ok := fields[r]; ok && v != nil {
+			continue
+		}
+		if _, ok := tags[r]; ok {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func (s *coincidenceState) reset() {
+	s.evaluated = false
+	s.last = false
+	s.lastTime = time.Time{}
+}
+
+func (g *coincidenceGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *coincidenceGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *coincidenceGroup) Done() {}
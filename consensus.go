@@ -0,0 +1,217 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type ConsensusNode struct {
+	node
+	c *pipeline.ConsensusNode
+}
+
+// Create a new consensus node.
+func newConsensusNode(et *ExecutingTask, n *pipeline.ConsensusNode, d NodeDiagnostic) (*ConsensusNode, error) {
+	cn := &ConsensusNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runConsensus
+	return cn, nil
+}
+
+func (n *ConsensusNode) runConsensus([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *ConsensusNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *ConsensusNode) newGroup() *consensusGroup {
+	return &consensusGroup{
+		n:     n,
+		votes: make(map[string]consensusVote),
+	}
+}
+
+// The latest value of a replica.
+type consensusVote struct {
+	value float64
+	time  time.Time
+}
+
+type consensusGroup struct {
+	n *ConsensusNode
+
+	votes map[string]consensusVote
+
+	begin  edge.BeginBatchMessage
+	points int
+}
+
+func (g *consensusGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.points = 0
+	return nil, nil
+}
+
+func (g *consensusGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if g.vote(bp) {
+		g.points++
+	}
+	return nil, nil
+}
+
+func (g *consensusGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if g.points == 0 {
+		return nil, nil
+	}
+	t := g.begin.Time()
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		g.consensus(t),
+		g.begin.GroupInfo().Tags,
+		t,
+	), nil
+}
+
+func (g *consensusGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if !g.vote(p) {
+		return nil, nil
+	}
+	return edge.NewPointMessage(
+		p.Name(), p.Database(), p.RetentionPolicy(),
+		p.Dimensions(),
+		g.consensus(p.Time()),
+		p.GroupInfo().Tags,
+		p.Time(),
+	), nil
+}
+
+// vote updates the vote of the replica of p, and returns whether p has a replica and a value.
+func (g *consensusGroup) vote(p edge.FieldsTagsTimeGetter) bool {
+	c := g.n.c
+	replica, ok := p.Tags()[c.Replica]
+	if !ok {
+		g.n.diag.Error("cannot compute consensus",
+			errors.New("point has no replica tag"),
+			keyvalue.KV("replica", c.Replica),
+		)
+		return false
+	}
+	value, ok := numToFloat(p.Fields()[c.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute consensus",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", c.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[c.Field])),
+		)
+		return false
+	}
+	if v, ok := g.votes[replica]; !ok || !p.Time().Before(v.time) {
+		g.votes[replica] = consensusVote{value: value, time: p.Time()}
+	}
+	return true
+}
+
+// consensus returns the fields of the consensus of the votes within the period before t.
+// The replicas without a vote within the period are silent and removed.
+func (g *consensusGroup) consensus(t time.Time) models.Fields {
+	c := g.n.c
+	start := t.Add(-c.Period)
+	replicas := make([]string, 0, len(g.votes))
+	for replica, v := range g.votes {
+		if !v.time.After(start) {
+			delete(g.votes, replica)
+			continue
+		}
+		replicas = append(replicas, replica)
+	}
+	// Sort the replicas so that the outlier of a tie is the first replica.
+	sort.Strings(replicas)
+	values := make([]float64, len(replicas))
+	for i, replica := range replicas {
+		values[i] = g.votes[replica].value
+	}
+
+	fields := models.Fields{
+		c.ReplicasAs: int64(len(values)),
+	}
+	if len(values) == 0 {
+		return fields
+	}
+	var consensus, disagreement float64
+	if c.Method == pipeline.ConsensusMode {
+		var votes int
+		consensus, votes = consensusMode(values)
+		disagreement = float64(len(values)-votes) / float64(len(values))
+	} else {
+		consensus = consensusMedian(values)
+	}
+	outlier, deviation := "", 0.0
+	for i, v := range values {
+		if d := math.Abs(v - consensus); d > deviation {
+			outlier, deviation = replicas[i], d
+		}
+	}
+	if c.Method == pipeline.ConsensusMedian {
+		disagreement = deviation
+	}
+	fields[c.As] = consensus
+	fields[c.DisagreementAs] = disagreement
+	fields[c.OutlierAs] = outlier
+	return fields
+}
+
+// consensusMedian returns the median of the values.
+func consensusMedian(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	m := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[m-1] + sorted[m]) / 2
+	}
+	return sorted[m]
+}
+
+// consensusMode returns the most common of the values, the smallest on a tie, and its number of votes.
+func consensusMode(values []float64) (float64, int) {
+	counts := make(map[float64]int, len(values))
+	for _, v := range values {
+		counts[v]++
+	}
+	mode, votes := 0.0, 0
+	for v, c := range counts {
+		if c > votes || (c == votes && v < mode) {
+			mode, votes = v, c
+		}
+	}
+	return mode, votes
+}
+
+func (g *consensusGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *consensusGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *consensusGroup) Done() {}
@@ -0,0 +1,124 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CounterDeltaNode struct {
+	node
+	c *pipeline.CounterDeltaNode
+}
+
+// Create a new counter delta node.
+func newCounterDeltaNode(et *ExecutingTask, n *pipeline.CounterDeltaNode, d NodeDiagnostic) (*CounterDeltaNode, error) {
+	cn := &CounterDeltaNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runCounterDelta
+	return cn, nil
+}
+
+func (n *CounterDeltaNode) runCounterDelta([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CounterDeltaNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *CounterDeltaNode) newGroup() *counterDeltaGroup {
+	return &counterDeltaGroup{
+		n: n,
+	}
+}
+
+type counterDeltaGroup struct {
+	n *CounterDeltaNode
+
+	begin edge.BeginBatchMessage
+
+	// Whether the batch has any values, and the previous value.
+	seen    bool
+	prev    float64
+	delta   float64
+	allInts bool
+}
+
+func (g *counterDeltaGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.seen = false
+	g.delta = 0
+	g.allInts = true
+	return nil, nil
+}
+
+func (g *counterDeltaGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	field := g.n.c.Field
+	value, ok := numToFloat(bp.Fields()[field])
+	if !ok {
+		g.n.diag.Error("cannot compute counter delta",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", field),
+			keyvalue.KV("type", fmt.Sprintf("%T", bp.Fields()[field])),
+		)
+		return nil, nil
+	}
+	if _, ok := bp.Fields()[field].(int64); !ok {
+		g.allInts = false
+	}
+	if g.seen {
+		if value >= g.prev {
+			g.delta += value - g.prev
+		} else {
+			// The counter was reset, and restarted from zero.
+			g.delta += value
+		}
+	}
+	g.seen = true
+	g.prev = value
+	return nil, nil
+}
+
+func (g *counterDeltaGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if !g.seen {
+		return nil, nil
+	}
+	var delta interface{} = g.delta
+	if g.allInts {
+		delta = int64(g.delta)
+	}
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		models.Fields{g.n.c.As: delta},
+		g.begin.GroupInfo().Tags,
+		g.begin.Time(),
+	), nil
+}
+
+func (g *counterDeltaGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *counterDeltaGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *counterDeltaGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *counterDeltaGroup) Done() {}
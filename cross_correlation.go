@@ -0,0 +1,191 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CrossCorrelationNode struct {
+	node
+	c *pipeline.CrossCorrelationNode
+}
+
+// Create a new crossCorrelation node.
+func newCrossCorrelationNode(et *ExecutingTask, n *pipeline.CrossCorrelationNode, d NodeDiagnostic) (*CrossCorrelationNode, error) {
+	cn := &CrossCorrelationNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runCrossCorrelation
+	return cn, nil
+}
+
+func (n *CrossCorrelationNode) runCrossCorrelation([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CrossCorrelationNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *CrossCorrelationNode) newGroup() *crossCorrelationGroup {
+	return &crossCorrelationGroup{
+		n:       n,
+		leading: NewCircularQueue[float64](make([]float64, 0, n.c.Size)...),
+		lagging: NewCircularQueue[float64](make([]float64, 0, n.c.Size)...),
+	}
+}
+
+type crossCorrelationGroup struct {
+	n *CrossCorrelationNode
+	// The values of the leading and lagging fields of the same points.
+	leading *CircularQueue[float64]
+	lagging *CircularQueue[float64]
+}
+
+func (g *crossCorrelationGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *crossCorrelationGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doCrossCorrelation(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *crossCorrelationGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *crossCorrelationGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doCrossCorrelation(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doCrossCorrelation adds the values of p to the window and sets the best lag and its correlation as fields on p.
+// Points without a numeric value for both fields are dropped and are not added to the window.
+func (g *crossCorrelationGroup) doCrossCorrelation(p edge.FieldsTagsTimeSetter) bool {
+	c := g.n.c
+	values := make([]float64, 0, 2)
+	for _, f := range []string{c.Field, c.Lagging} {
+		v, ok := numToFloat(p.Fields()[f])
+		if !ok {
+			g.n.diag.Error("cannot compute cross-correlation",
+				errors.New("field is missing or the wrong type"),
+				keyvalue.KV("field", f),
+				keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[f])),
+			)
+			return false
+		}
+		values = append(values, v)
+	}
+
+	if int64(g.leading.Len) == c.Size {
+		g.leading.Dequeue(1)
+		g.lagging.Dequeue(1)
+	}
+	g.leading.Enqueue(values[0])
+	g.lagging.Enqueue(values[1])
+
+	if lag, r, ok := g.bestLag(); ok {
+		fields := p.Fields().Copy()
+		fields[c.LagAs] = lag
+		fields[c.CorrelationAs] = r
+		p.SetFields(fields)
+	}
+	return true
+}
+
+// bestLag returns the lag with the highest correlation and its correlation,
+// or false if the window is not full or no lag has a defined correlation.
+func (g *crossCorrelationGroup) bestLag() (int64, float64, bool) {
+	if int64(g.leading.Len) < g.n.c.Size {
+		return 0, 0, false
+	}
+	var best int64
+	bestR := math.Inf(-1)
+	for lag := g.n.c.MinLag; lag <= g.n.c.MaxLag; lag++ {
+		r, ok := g.correlation(int(lag))
+		if !ok {
+			continue
+		}
+		if r > bestR || (r == bestR && absLag(lag) < absLag(best)) {
+			best, bestR = lag, r
+		}
+	}
+	if math.IsInf(bestR, -1) {
+		return 0, 0, false
+	}
+	return best, bestR, true
+}
+
+// correlation returns the Pearson correlation coefficient of the leading values and the lagging values shifted by lag,
+// or false if either has zero variance.
+func (g *crossCorrelationGroup) correlation(lag int) (float64, bool) {
+	start, end := 0, g.leading.Len
+	if lag < 0 {
+		start = -lag
+	} else {
+		end -= lag
+	}
+	count := float64(end - start)
+	meanX, meanY := 0.0, 0.0
+	for i := start; i < end; i++ {
+		meanX += g.leading.Peek(i)
+		meanY += g.lagging.Peek(i + lag)
+	}
+	meanX /= count
+	meanY /= count
+
+	varX, varY, covariance := 0.0, 0.0, 0.0
+	for i := start; i < end; i++ {
+		dx := g.leading.Peek(i) - meanX
+		dy := g.lagging.Peek(i+lag) - meanY
+		varX += dx * dx
+		varY += dy * dy
+		covariance += dx * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return covariance / math.Sqrt(varX*varY), true
+}
+
+func absLag(lag int64) int64 {
+	if lag < 0 {
+		return -lag
+	}
+	return lag
+}
+
+func (g *crossCorrelationGroup) reset() {
+	g.leading.Dequeue(g.leading.Len)
+	g.lagging.Dequeue(g.lagging.Len)
+}
+
+func (g *crossCorrelationGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *crossCorrelationGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *crossCorrelationGroup) Done() {}
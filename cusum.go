@@ -0,0 +1,130 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CusumNode struct {
+	node
+	c *pipeline.CusumNode
+}
+
+// Create a new cusum node.
+func newCusumNode(et *ExecutingTask, n *pipeline.CusumNode, d NodeDiagnostic) (*CusumNode, error) {
+	cn := &CusumNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runCusum
+	return cn, nil
+}
+
+func (n *CusumNode) runCusum([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CusumNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *CusumNode) newGroup() *cusumGroup {
+	return &cusumGroup{
+		n: n,
+	}
+}
+
+type cusumGroup struct {
+	n   *CusumNode
+	pos float64
+	neg float64
+}
+
+func (g *cusumGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *cusumGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doCusum(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *cusumGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *cusumGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doCusum(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doCusum updates the statistics with the value of p and sets them as fields on p.
+// Points without a numeric value are dropped and do not affect the statistics.
+func (g *cusumGroup) doCusum(p edge.FieldsTagsTimeSetter) bool {
+	c := g.n.c
+	value, ok := numToFloat(p.Fields()[c.Field])
+	if !ok {
+		g.n.diag.Error("cannot perform cusum",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", c.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[c.Field])),
+		)
+		return false
+	}
+
+	g.pos = math.Max(0, g.pos+value-(c.Target+c.Slack))
+	g.neg = math.Max(0, g.neg+(c.Target-c.Slack)-value)
+
+	signal := int64(0)
+	switch {
+	case g.pos > c.Threshold:
+		signal = 1
+	case g.neg > c.Threshold:
+		signal = -1
+	}
+
+	fields := p.Fields().Copy()
+	fields[c.PositiveAs] = g.pos
+	fields[c.NegativeAs] = g.neg
+	fields[c.SignalAs] = signal
+	p.SetFields(fields)
+
+	// Restart detection after a change has been signaled.
+	if signal != 0 {
+		g.reset()
+	}
+	return true
+}
+
+func (g *cusumGroup) reset() {
+	g.pos = 0
+	g.neg = 0
+}
+
+func (g *cusumGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *cusumGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *cusumGroup) Done() {}
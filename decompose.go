@@ -0,0 +1,159 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type DecomposeNode struct {
+	node
+	d *pipeline.DecomposeNode
+}
+
+// Create a new decompose node.
+func newDecomposeNode(et *ExecutingTask, n *pipeline.DecomposeNode, d NodeDiagnostic) (*DecomposeNode, error) {
+	dn := &DecomposeNode{
+		node: node{Node: n, et: et, diag: d},
+		d:    n,
+	}
+	dn.node.runF = dn.runDecompose
+	return dn, nil
+}
+
+func (n *DecomposeNode) runDecompose([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *DecomposeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *DecomposeNode) newGroup() *decomposeGroup {
+	return &decomposeGroup{
+		n:        n,
+		values:   NewCircularQueue[float64](),
+		seasonal: make([]float64, n.d.Season),
+	}
+}
+
+type decomposeGroup struct {
+	n *DecomposeNode
+
+	// The values of the previous season and their sum.
+	values *CircularQueue[float64]
+	sum    float64
+
+	// The seasonal component of each position in the season,
+	// averaged over the number of seasons.
+	seasonal []float64
+	seasons  int64
+
+	// The position of the next value in the season.
+	position int64
+}
+
+func (g *decomposeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *decomposeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doDecompose(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *decomposeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *decomposeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doDecompose(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doDecompose sets the components of the value of p as fields on p,
+// and then adds the value to the history.
+// Points without a numeric value are dropped and are not added to the history.
+func (g *decomposeGroup) doDecompose(p edge.FieldsTagsTimeSetter) bool {
+	d := g.n.d
+	value, ok := numToFloat(p.Fields()[d.Field])
+	if !ok {
+		g.n.diag.Error("cannot decompose",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", d.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[d.Field])),
+		)
+		return false
+	}
+
+	season := int(d.Season)
+	if g.values.Len == season {
+		trend := g.sum / float64(season)
+		seasonal := g.seasonal[g.position]
+
+		fields := p.Fields().Copy()
+		fields[d.As] = value - trend - seasonal
+		fields[d.TrendAs] = trend
+		fields[d.SeasonalAs] = seasonal
+		p.SetFields(fields)
+
+		// Update the average of the seasonal component with this season.
+		// The first season has already been accounted for when the warm-up completed.
+		seasons := float64(g.seasons + 1)
+		g.seasonal[g.position] += (value - trend - seasonal) / seasons
+
+		g.sum -= g.values.Peek(0)
+		g.values.Dequeue(1)
+	}
+	g.values.Enqueue(value)
+	g.sum += value
+
+	g.position = (g.position + 1) % d.Season
+	if g.position == 0 {
+		if g.seasons == 0 {
+			// The warm-up is complete, estimate the seasonal component from the first season.
+			trend := g.sum / float64(season)
+			for i := 0; i < season; i++ {
+				g.seasonal[i] = g.values.Peek(i) - trend
+			}
+		}
+		g.seasons++
+	}
+	return true
+}
+
+func (g *decomposeGroup) reset() {
+	g.values.Dequeue(g.values.Len)
+	g.sum = 0
+	for i := range g.seasonal {
+		g.seasonal[i] = 0
+	}
+	g.seasons = 0
+	g.position = 0
+}
+
+func (g *decomposeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *decomposeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *decomposeGroup) Done() {}
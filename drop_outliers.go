@@ -0,0 +1,145 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsOutliersDropped = "outliers_dropped"
+)
+
+// The minimum number of points in a batch for its quartiles to be computed.
+const minOutlierBatchSize = 4
+
+type DropOutliersNode struct {
+	node
+	d *pipeline.DropOutliersNode
+
+	outliersDropped *expvar.Int
+}
+
+// Create a new dropOutliers node.
+func newDropOutliersNode(et *ExecutingTask, n *pipeline.DropOutliersNode, d NodeDiagnostic) (*DropOutliersNode, error) {
+	dn := &DropOutliersNode{
+		node:            node{Node: n, et: et, diag: d},
+		d:               n,
+		outliersDropped: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDropOutliers
+	return dn, nil
+}
+
+func (n *DropOutliersNode) runDropOutliers([]byte) error {
+	n.statMap.Set(statsOutliersDropped, n.outliersDropped)
+
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *DropOutliersNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *DropOutliersNode) newGroup() *dropOutliersGroup {
+	return &dropOutliersGroup{
+		n:      n,
+		buffer: new(edge.BatchBuffer),
+	}
+}
+
+type dropOutliersGroup struct {
+	n      *DropOutliersNode
+	buffer *edge.BatchBuffer
+	values []float64
+}
+
+func (g *dropOutliersGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.values = g.values[:0]
+	return nil, g.buffer.BeginBatch(begin)
+}
+
+func (g *dropOutliersGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	field := g.n.d.Field
+	value, ok := numToFloat(bp.Fields()[field])
+	if !ok {
+		g.n.diag.Error("cannot detect outliers",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", field),
+			keyvalue.KV("type", fmt.Sprintf("%T", bp.Fields()[field])),
+		)
+		return nil, nil
+	}
+	g.values = append(g.values, value)
+	return nil, g.buffer.BatchPoint(bp)
+}
+
+func (g *dropOutliersGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	b := g.buffer.BufferedBatchMessage(end)
+	if len(g.values) < minOutlierBatchSize {
+		return b, nil
+	}
+
+	low, high := g.fences()
+	points := make([]edge.BatchPointMessage, 0, len(b.Points()))
+	for i, bp := range b.Points() {
+		// Values are in the same order as the points.
+		if v := g.values[i]; v < low || v > high {
+			g.n.outliersDropped.Add(1)
+			continue
+		}
+		points = append(points, bp)
+	}
+	b.SetPoints(points)
+	b.Begin().SetSizeHint(len(points))
+	return b, nil
+}
+
+// fences returns the lower and upper fences of the values.
+func (g *dropOutliersGroup) fences() (float64, float64) {
+	sorted := make([]float64, len(g.values))
+	copy(sorted, g.values)
+	sort.Float64s(sorted)
+
+	q1 := quantile(sorted, 0.25)
+	q3 := quantile(sorted, 0.75)
+	iqr := q3 - q1
+	return q1 - g.n.d.K*iqr, q3 + g.n.d.K*iqr
+}
+
+// quantile returns the q quantile of the sorted values, linearly interpolating between values.
+func quantile(sorted []float64, q float64) float64 {
+	h := float64(len(sorted)-1) * q
+	lo := math.Floor(h)
+	i := int(lo)
+	if i+1 >= len(sorted) {
+		return sorted[i]
+	}
+	return sorted[i] + (h-lo)*(sorted[i+1]-sorted[i])
+}
+
+func (g *dropOutliersGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *dropOutliersGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *dropOutliersGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *dropOutliersGroup) Done() {}
@@ -0,0 +1,318 @@
+package edge
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+// ParallelGroupedReceiver creates forward receivers as groups are created and deleted.
+// The receivers of different groups may be called concurrently, so they must not share mutable state.
+type ParallelGroupedReceiver interface {
+	// NewGroup signals that a new group has been discovered in the data.
+	// Information on the group and the message that first triggered its creation are provided.
+	NewGroup(group GroupInfo, first PointMeta) (ForwardReceiver, error)
+}
+
+type parallelGroupedConsumer struct {
+	consumer    Consumer
+	outs        []StatsEdge
+	gr          ParallelGroupedReceiver
+	timer       timer.Timer
+	groups      map[models.GroupID]ForwardReceiver
+	cardinality *expvar.Int
+
+	workers []chan parallelJob
+	wg      sync.WaitGroup
+	// The results of the batches being processed, in the order they were received,
+	// which are forwarded as soon as they are ready.
+	pending   chan chan parallelResult
+	forwarded chan struct{}
+
+	mu sync.Mutex
+	// Set once forwarding failed, after which results are discarded.
+	err error
+
+	// The batch being received, for batches that are not buffered.
+	buffer  BatchBuffer
+	current ForwardReceiver
+}
+
+type parallelJob struct {
+	r      ForwardReceiver
+	batch  BufferedBatchMessage
+	result chan parallelResult
+}
+
+type parallelResult struct {
+	msgs []Message
+	err  error
+	// Closed once all previous results have been forwarded, instead of forwarding messages.
+	flushed chan struct{}
+}
+
+// NewParallelGroupedConsumer creates a new grouped consumer for edge e and grouped receiver r,
+// which processes the batches of different groups concurrently on the given number of workers.
+// The messages returned by the receivers are forwarded to outs in the order the batches were received,
+// so the output is the same as when the batches are processed serially.
+//
+// The batches of a group are always processed by the same worker, in order,
+// and their messages are forwarded as soon as the batches before them have been forwarded.
+// Other messages are processed once all previous batches have been forwarded.
+// The timer measures the time spent waiting for the batches in flight and processing the other messages.
+func NewParallelGroupedConsumer(e Edge, outs []StatsEdge, r ParallelGroupedReceiver, t timer.Timer, workers int) GroupedConsumer {
+	if workers < 1 {
+		workers = 1
+	}
+	pc := &parallelGroupedConsumer{
+		outs:        outs,
+		gr:          r,
+		timer:       t,
+		groups:      make(map[models.GroupID]ForwardReceiver),
+		cardinality: new(expvar.Int),
+		workers:     make([]chan parallelJob, workers),
+		// Bound the number of batches in flight.
+		pending:   make(chan chan parallelResult, 2*workers),
+		forwarded: make(chan struct{}),
+	}
+	pc.consumer = NewConsumerWithReceiver(e, pc)
+	return pc
+}
+
+func (c *parallelGroupedConsumer) Consume() error {
+	for i := range c.workers {
+		jobs := make(chan parallelJob, 1)
+		c.workers[i] = jobs
+		c.wg.Add(1)
+		go c.work(jobs)
+	}
+	go c.forward()
+	err := c.consumer.Consume()
+	close(c.pending)
+	<-c.forwarded
+	for _, jobs := range c.workers {
+		close(jobs)
+	}
+	c.wg.Wait()
+	if err != nil {
+		return err
+	}
+	return c.getErr()
+}
+
+func (c *parallelGroupedConsumer) CardinalityVar() expvar.IntVar {
+	return c.cardinality
+}
+
+func (c *parallelGroupedConsumer) work(jobs <-chan parallelJob) {
+	defer c.wg.Done()
+	for job := range jobs {
+		msgs, err := forwardBufferedBatch(job.r, job.batch)
+		job.result <- parallelResult{
+			msgs: msgs,
+			err:  err,
+		}
+	}
+}
+
+// forwardBufferedBatch passes the batch to the receiver and returns the messages to forward.
+func forwardBufferedBatch(r ForwardReceiver, batch BufferedBatchMessage) ([]Message, error) {
+	if b, ok := r.(ForwardBufferedReceiver); ok {
+		m, err := b.BufferedBatch(batch)
+		if err != nil || m == nil {
+			return nil, err
+		}
+		return []Message{m}, nil
+	}
+	var msgs []Message
+	add := func(m Message, err error) error {
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			msgs = append(msgs, m)
+		}
+		return nil
+	}
+	if err := add(r.BeginBatch(batch.Begin())); err != nil {
+		return nil, err
+	}
+	for _, bp := range batch.Points() {
+		if err := add(r.BatchPoint(bp)); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(r.EndBatch(batch.End())); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (c *parallelGroupedConsumer) getOrCreateGroup(group GroupInfo, first PointMeta) (ForwardReceiver, error) {
+	r, ok := c.groups[group.ID]
+	if !ok {
+		c.cardinality.Add(1)
+		recv, err := c.gr.NewGroup(group, first)
+		if err != nil {
+			return nil, err
+		}
+		c.groups[group.ID] = recv
+		r = recv
+	}
+	return r, nil
+}
+
+// submit queues the batch for processing by the worker of its group.
+func (c *parallelGroupedConsumer) submit(r ForwardReceiver, batch BufferedBatchMessage) error {
+	if err := c.getErr(); err != nil {
+		return err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(batch.Begin().GroupID()))
+	result := make(chan parallelResult, 1)
+	c.pending <- result
+	c.workers[h.Sum32()%uint32(len(c.workers))] <- parallelJob{
+		r:      r,
+		batch:  batch,
+		result: result,
+	}
+	return nil
+}
+
+// forward forwards the messages of the batches in flight in the order they were received.
+func (c *parallelGroupedConsumer) forward() {
+	defer close(c.forwarded)
+	for result := range c.pending {
+		res := <-result
+		if res.flushed != nil {
+			close(res.flushed)
+			continue
+		}
+		if c.getErr() != nil {
+			continue
+		}
+		if res.err != nil {
+			c.setErr(res.err)
+			continue
+		}
+		for _, m := range res.msgs {
+			if err := Forward(c.outs, m); err != nil {
+				c.setErr(err)
+				break
+			}
+		}
+	}
+}
+
+func (c *parallelGroupedConsumer) getErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *parallelGroupedConsumer) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// flush waits until the messages of all batches in flight have been forwarded.
+func (c *parallelGroupedConsumer) flush() error {
+	flushed := make(chan struct{})
+	result := make(chan parallelResult, 1)
+	result <- parallelResult{flushed: flushed}
+
+	c.timer.Start()
+	c.pending <- result
+	<-flushed
+	c.timer.Stop()
+
+	return c.getErr()
+}
+
+// process processes a message synchronously, once all batches in flight have been forwarded.
+func (c *parallelGroupedConsumer) process(f func() (Message, error)) error {
+	if err := c.flush(); err != nil {
+		return err
+	}
+	c.timer.Start()
+	m, err := f()
+	c.timer.Stop()
+	if err != nil || m == nil {
+		return err
+	}
+	return Forward(c.outs, m)
+}
+
+func (c *parallelGroupedConsumer) BeginBatch(begin BeginBatchMessage) error {
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
+	if err != nil {
+		return err
+	}
+	c.current = r
+	return c.buffer.BeginBatch(begin)
+}
+
+func (c *parallelGroupedConsumer) BatchPoint(bp BatchPointMessage) error {
+	if c.current == nil {
+		return errors.New("received batch point without batch")
+	}
+	return c.buffer.BatchPoint(bp)
+}
+
+func (c *parallelGroupedConsumer) EndBatch(end EndBatchMessage) error {
+	if c.current == nil {
+		return errors.New("received end batch without batch")
+	}
+	r := c.current
+	c.current = nil
+	return c.submit(r, c.buffer.BufferedBatchMessage(end))
+}
+
+func (c *parallelGroupedConsumer) BufferedBatch(batch BufferedBatchMessage) error {
+	begin := batch.Begin()
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
+	if err != nil {
+		return err
+	}
+	return c.submit(r, batch)
+}
+
+func (c *parallelGroupedConsumer) Point(p PointMessage) error {
+	r, err := c.getOrCreateGroup(p.GroupInfo(), p)
+	if err != nil {
+		return err
+	}
+	return c.process(func() (Message, error) { return r.Point(p) })
+}
+
+func (c *parallelGroupedConsumer) Barrier(b BarrierMessage) error {
+	r, err := c.getOrCreateGroup(b.GroupInfo(), b)
+	if err != nil {
+		return err
+	}
+	return c.process(func() (Message, error) { return r.Barrier(b) })
+}
+
+func (c *parallelGroupedConsumer) DeleteGroup(d DeleteGroupMessage) error {
+	id := d.GroupID()
+	r, ok := c.groups[id]
+	if !ok {
+		return nil
+	}
+	delete(c.groups, id)
+	c.cardinality.Add(-1)
+	return c.process(func() (Message, error) { return r.DeleteGroup(d) })
+}
+
+func (c *parallelGroupedConsumer) Done() {
+	// Errors are reported by Consume.
+	_ = c.flush()
+	for _, r := range c.groups {
+		r.Done()
+	}
+}
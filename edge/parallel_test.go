@@ -0,0 +1,178 @@
+package edge_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+type parallelReceiver struct{}
+
+func (parallelReceiver) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.ForwardReceiver, error) {
+	return &parallelGroup{}, nil
+}
+
+// parallelGroup sums the values of a batch, and fails if it is called concurrently.
+type parallelGroup struct {
+	active int32
+	begin  edge.BeginBatchMessage
+	sum    int64
+}
+
+func (g *parallelGroup) enter() error {
+	if !atomic.CompareAndSwapInt32(&g.active, 0, 1) {
+		return fmt.Errorf("group %s called concurrently", g.begin.GroupID())
+	}
+	return nil
+}
+
+func (g *parallelGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.sum = 0
+	return nil, g.enter()
+}
+func (g *parallelGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.sum += bp.Fields()["value"].(int64)
+	// Slow down the groups unevenly, so that the batches finish out of order.
+	time.Sleep(time.Duration(g.sum%3) * time.Millisecond)
+	return nil, nil
+}
+func (g *parallelGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	atomic.StoreInt32(&g.active, 0)
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		models.Fields{"sum": g.sum},
+		g.begin.Tags(),
+		g.begin.Time(),
+	), nil
+}
+func (g *parallelGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+func (g *parallelGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *parallelGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *parallelGroup) Done() {}
+
+func TestParallelGroupedConsumer(t *testing.T) {
+	const (
+		groups  = 8
+		batches = 40
+	)
+	in := edge.NewChannelEdge(pipeline.BatchEdge, batches+1)
+	out := edge.NewChannelEdge(pipeline.StreamEdge, batches+1)
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	for i := 0; i < batches; i++ {
+		tags := models.Tags{"host": fmt.Sprintf("host%d", i%groups)}
+		points := make([]edge.BatchPointMessage, 3)
+		for j := range points {
+			points[j] = edge.NewBatchPointMessage(models.Fields{"value": int64(i + j)}, tags, now)
+		}
+		in.Collect(edge.NewBufferedBatchMessage(
+			edge.NewBeginBatchMessage(name, tags, dims.ByName, now.Add(time.Duration(i)*time.Second), len(points)),
+			points,
+			edge.NewEndBatchMessage(),
+		))
+	}
+	// A point is only processed once all previous batches have been forwarded.
+	in.Collect(point)
+	in.Close()
+
+	c := edge.NewParallelGroupedConsumer(
+		in,
+		[]edge.StatsEdge{edge.NewStatsEdge(out)},
+		parallelReceiver{},
+		timer.New(1, 10, new(expvar.Int)),
+		4,
+	)
+	if err := c.Consume(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	for i := 0; i < batches; i++ {
+		m, ok := out.Emit()
+		if !ok {
+			t.Fatalf("expected %d messages, got %d", batches+1, i)
+		}
+		p, ok := m.(edge.PointMessage)
+		if !ok {
+			t.Fatalf("unexpected message %d of type %T", i, m)
+		}
+		if got, exp := p.Time(), now.Add(time.Duration(i)*time.Second); !got.Equal(exp) {
+			t.Errorf("unexpected time of message %d: got %v exp %v", i, got, exp)
+		}
+		if got, exp := p.Fields()["sum"], int64(3*i+3); got != exp {
+			t.Errorf("unexpected sum of message %d: got %v exp %v", i, got, exp)
+		}
+	}
+	if m, ok := out.Emit(); !ok || m != point {
+		t.Errorf("expected the point last, got %v", m)
+	}
+	if got := c.CardinalityVar().IntValue(); got != groups+1 {
+		t.Errorf("unexpected cardinality: got %d exp %d", got, groups+1)
+	}
+}
+
+func TestParallelGroupedConsumer_ForwardsWhileOpen(t *testing.T) {
+	const batches = 2
+	in := edge.NewChannelEdge(pipeline.BatchEdge, batches)
+	out := edge.NewChannelEdge(pipeline.StreamEdge, batches)
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	for i := 0; i < batches; i++ {
+		tags := models.Tags{"host": fmt.Sprintf("host%d", i)}
+		in.Collect(edge.NewBufferedBatchMessage(
+			edge.NewBeginBatchMessage(name, tags, dims.ByName, now.Add(time.Duration(i)*time.Second), 1),
+			[]edge.BatchPointMessage{edge.NewBatchPointMessage(models.Fields{"value": int64(i)}, tags, now)},
+			edge.NewEndBatchMessage(),
+		))
+	}
+
+	c := edge.NewParallelGroupedConsumer(
+		in,
+		[]edge.StatsEdge{edge.NewStatsEdge(out)},
+		parallelReceiver{},
+		timer.New(1, 10, new(expvar.Int)),
+		4,
+	)
+	errC := make(chan error, 1)
+	go func() { errC <- c.Consume() }()
+
+	// Fewer batches than can be in flight are forwarded without waiting for more input.
+	emitted := make(chan int, 1)
+	go func() {
+		n := 0
+		for n < batches {
+			if _, ok := out.Emit(); !ok {
+				break
+			}
+			n++
+		}
+		emitted <- n
+	}()
+	select {
+	case n := <-emitted:
+		if n != batches {
+			t.Errorf("unexpected number of messages: got %d exp %d", n, batches)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the batches to be forwarded while the input is open")
+	}
+
+	in.Close()
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+}
@@ -0,0 +1,121 @@
+package kapacitor
+
+import (
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	enrichStreamSrc = 0
+	enrichSourceSrc = 1
+)
+
+type EnrichNode struct {
+	node
+	e *pipeline.EnrichNode
+
+	// The prefixed default fields.
+	defaults models.Fields
+	// The latest prefixed fields from the source, per group.
+	fields map[models.GroupID]models.Fields
+}
+
+// Create a new EnrichNode which sets the latest fields from the source onto stream points.
+func newEnrichNode(et *ExecutingTask, n *pipeline.EnrichNode, d NodeDiagnostic) (*EnrichNode, error) {
+	en := &EnrichNode{
+		node:     node{Node: n, et: et, diag: d},
+		e:        n,
+		defaults: make(models.Fields, len(n.DefaultFields)),
+		fields:   make(map[models.GroupID]models.Fields),
+	}
+	for k, v := range n.DefaultFields {
+		en.defaults[n.Prefix+k] = v
+	}
+	en.node.runF = en.runEnrich
+	return en, nil
+}
+
+func (n *EnrichNode) runEnrich([]byte) error {
+	consumer := edge.NewMultiConsumerWithStats(n.ins, n)
+	return consumer.Consume()
+}
+
+func (n *EnrichNode) BufferedBatch(src int, batch edge.BufferedBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == enrichSourceSrc {
+		points := batch.Points()
+		if len(points) > 0 {
+			n.cache(batch.GroupID(), points[len(points)-1].Fields())
+		}
+		return nil
+	}
+
+	batch = batch.ShallowCopy()
+	points := make([]edge.BatchPointMessage, len(batch.Points()))
+	for i, bp := range batch.Points() {
+		bp = bp.ShallowCopy()
+		bp.SetFields(n.enrich(batch.GroupID(), bp.Fields()))
+		points[i] = bp
+	}
+	batch.SetPoints(points)
+	return edge.Forward(n.outs, batch)
+}
+
+func (n *EnrichNode) Point(src int, p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == enrichSourceSrc {
+		n.cache(p.GroupID(), p.Fields())
+		return nil
+	}
+
+	p = p.ShallowCopy()
+	p.SetFields(n.enrich(p.GroupID(), p.Fields()))
+	return edge.Forward(n.outs, p)
+}
+
+// cache replaces the fields of the group with a prefixed copy of fields.
+func (n *EnrichNode) cache(group models.GroupID, fields models.Fields) {
+	prefixed := make(models.Fields, len(fields))
+	for k, v := range fields {
+		prefixed[n.e.Prefix+k] = v
+	}
+	n.fields[group] = prefixed
+}
+
+// enrich returns a copy of fields with the cached fields of the group,
+// or the default fields if none have been received.
+func (n *EnrichNode) enrich(group models.GroupID, fields models.Fields) models.Fields {
+	extra, ok := n.fields[group]
+	if !ok {
+		extra = n.defaults
+	}
+	enriched := fields.Copy()
+	for k, v := range extra {
+		enriched[k] = v
+	}
+	return enriched
+}
+
+func (n *EnrichNode) Barrier(src int, b edge.BarrierMessage) error {
+	if src != enrichStreamSrc {
+		return nil
+	}
+	return edge.Forward(n.outs, b)
+}
+
+func (n *EnrichNode) Delete(src int, d edge.DeleteGroupMessage) error {
+	if src != enrichStreamSrc {
+		delete(n.fields, d.GroupID())
+		return nil
+	}
+	return edge.Forward(n.outs, d)
+}
+
+func (n *EnrichNode) Finish() error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestEnrichNode_Enrich(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	source := stream.From()
+	e := stream.From().Enrich(source)
+	e.Prefix = "threshold_"
+	e.DefaultField("p99", 500.0)
+
+	n, err := newEnrichNode(nil, e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := models.GroupID("service=api")
+	web := models.GroupID("service=web")
+	fields := models.Fields{"latency": 250.0}
+
+	if got, exp := n.enrich(api, fields), (models.Fields{"latency": 250.0, "threshold_p99": 500.0}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected default fields: got %v exp %v", got, exp)
+	}
+
+	n.cache(api, models.Fields{"p99": 300.0, "p50": 100.0})
+	if got, exp := n.enrich(api, fields), (models.Fields{"latency": 250.0, "threshold_p99": 300.0, "threshold_p50": 100.0}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected cached fields: got %v exp %v", got, exp)
+	}
+	if got, exp := n.enrich(web, fields), (models.Fields{"latency": 250.0, "threshold_p99": 500.0}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected fields for other group: got %v exp %v", got, exp)
+	}
+
+	// A new result replaces all of the fields of the group.
+	n.cache(api, models.Fields{"p99": 350.0})
+	if got, exp := n.enrich(api, fields), (models.Fields{"latency": 250.0, "threshold_p99": 350.0}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected refreshed fields: got %v exp %v", got, exp)
+	}
+	if exp := (models.Fields{"latency": 250.0}); !reflect.DeepEqual(fields, exp) {
+		t.Errorf("point fields were modified: got %v exp %v", fields, exp)
+	}
+}
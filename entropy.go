@@ -0,0 +1,192 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statCategories    = "categories"
+	statPointsDropped = "points_dropped"
+)
+
+type EntropyNode struct {
+	node
+	e *pipeline.EntropyNode
+
+	categories    *expvar.Int
+	pointsDropped *expvar.Int
+}
+
+// Create a new entropy node.
+func newEntropyNode(et *ExecutingTask, n *pipeline.EntropyNode, d NodeDiagnostic) (*EntropyNode, error) {
+	en := &EntropyNode{
+		node: node{Node: n, et: et, diag: d},
+		e:    n,
+
+		categories:    new(expvar.Int),
+		pointsDropped: new(expvar.Int),
+	}
+	en.node.runF = en.runEntropy
+	return en, nil
+}
+
+func (n *EntropyNode) runEntropy([]byte) error {
+	n.statMap.Set(statCategories, n.categories)
+	n.statMap.Set(statPointsDropped, n.pointsDropped)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *EntropyNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *EntropyNode) newGroup() *entropyGroup {
+	return &entropyGroup{
+		n:      n,
+		points: NewCircularQueue[entropyPoint](),
+		counts: make(map[string]int64),
+	}
+}
+
+type entropyPoint struct {
+	time     time.Time
+	category string
+}
+
+type entropyGroup struct {
+	n *EntropyNode
+
+	// The points within the period, oldest first.
+	points *CircularQueue[entropyPoint]
+	counts map[string]int64
+}
+
+func (g *entropyGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *entropyGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doEntropy(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *entropyGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *entropyGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doEntropy(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doEntropy adds the category of p to the window and sets the entropy of the window as a field on p.
+// Points without a category, or of a new category beyond the max categories, are dropped.
+func (g *entropyGroup) doEntropy(p edge.FieldsTagsTimeSetter) bool {
+	e := g.n.e
+	category, ok := g.category(p)
+	if !ok {
+		g.n.diag.Error("cannot compute entropy",
+			errors.New("point has no category tag or field"),
+			keyvalue.KV("category", e.Category),
+		)
+		return false
+	}
+
+	t := p.Time()
+	start := t.Add(-e.Period)
+	for g.points.Len > 0 && !g.points.Peek(0).time.After(start) {
+		g.remove(g.points.Peek(0).category)
+		g.points.Dequeue(1)
+	}
+
+	if _, ok := g.counts[category]; !ok {
+		if int64(len(g.counts)) >= e.MaxCategories {
+			g.n.pointsDropped.Add(1)
+			return false
+		}
+		g.n.categories.Add(1)
+	}
+	g.counts[category]++
+	g.points.Enqueue(entropyPoint{time: t, category: category})
+
+	fields := p.Fields().Copy()
+	fields[e.As] = g.entropy()
+	fields[e.CategoriesAs] = int64(len(g.counts))
+	p.SetFields(fields)
+	return true
+}
+
+// category returns the category of p, the value of its tag, or otherwise of its field.
+func (g *entropyGroup) category(p edge.FieldsTagsTimeGetter) (string, bool) {
+	if v, ok := p.Tags()[g.n.e.Category]; ok {
+		return v, true
+	}
+	if v, ok := p.Fields()[g.n.e.Category]; ok {
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// entropy returns the entropy in bits of the counts of the categories.
+func (g *entropyGroup) entropy() float64 {
+	total := float64(g.points.Len)
+	h := 0.0
+	for _, c := range g.counts {
+		f := float64(c) / total
+		h -= f * math.Log2(f)
+	}
+	if g.n.e.NormalizeFlag {
+		if len(g.counts) < 2 {
+			return 0
+		}
+		h /= math.Log2(float64(len(g.counts)))
+	}
+	// Avoid a negative zero from rounding errors.
+	return math.Max(h, 0)
+}
+
+func (g *entropyGroup) remove(category string) {
+	g.counts[category]--
+	if g.counts[category] == 0 {
+		delete(g.counts, category)
+		g.n.categories.Add(-1)
+	}
+}
+
+func (g *entropyGroup) reset() {
+	g.n.categories.Add(-int64(len(g.counts)))
+	g.points.Dequeue(g.points.Len)
+	g.counts = make(map[string]int64)
+}
+
+func (g *entropyGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *entropyGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.reset()
+	return d, nil
+}
+func (g *entropyGroup) Done() {}
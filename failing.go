@@ -0,0 +1,172 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+type FailingNode struct {
+	node
+	f *pipeline.FailingNode
+
+	expression stateful.Expression
+	scopePool  stateful.ScopePool
+
+	begin    edge.BeginBatchMessage
+	interval time.Time
+	groups   map[models.GroupID]*failingGroup
+}
+
+type failingGroup struct {
+	info edge.GroupInfo
+	expr stateful.Expression
+	// Whether the latest evaluation failed, and the time of the first failing evaluation since.
+	failing bool
+	since   time.Time
+}
+
+// Create a new FailingNode which emits the set of groups that are currently failing a condition.
+func newFailingNode(et *ExecutingTask, n *pipeline.FailingNode, d NodeDiagnostic) (*FailingNode, error) {
+	expr, err := stateful.NewExpression(n.Lambda.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile expression in failing: %v", err)
+	}
+	fn := &FailingNode{
+		node:       node{Node: n, et: et, diag: d},
+		f:          n,
+		expression: expr,
+		scopePool:  stateful.NewScopePool(ast.FindReferenceVariables(n.Lambda.Expression)),
+		groups:     make(map[models.GroupID]*failingGroup),
+	}
+	fn.node.runF = fn.runFailing
+	return fn, nil
+}
+
+func (n *FailingNode) runFailing([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// eval evaluates the expression for the point of the group,
+// and updates whether the group is failing.
+func (n *FailingNode) eval(info edge.GroupInfo, p edge.FieldsTagsTimeGetter) {
+	group, ok := n.groups[info.ID]
+	if !ok {
+		group = &failingGroup{
+			info: info,
+			expr: n.expression.CopyReset(),
+		}
+		n.groups[info.ID] = group
+	}
+	failing, err := EvalPredicate(group.expr, n.scopePool, p)
+	if err != nil {
+		n.diag.Error("error while evaluating expression", err)
+		return
+	}
+	if failing && !group.failing {
+		group.since = p.Time()
+	}
+	group.failing = failing
+}
+
+func (n *FailingNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(p.Time()); err != nil {
+		return err
+	}
+	n.eval(p.GroupInfo(), p)
+	return nil
+}
+
+func (n *FailingNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(begin.Time()); err != nil {
+		return err
+	}
+	n.begin = begin
+	return nil
+}
+
+func (n *FailingNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	n.eval(n.begin.GroupInfo(), bp)
+	return nil
+}
+
+func (n *FailingNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *FailingNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emit(b.Time())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return edge.Forward(n.outs, edge.NewBarrierMessage(n.newBegin(b.Time(), 0).GroupInfo(), b.Time()))
+}
+
+func (n *FailingNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	// A deleted group is no longer failing.
+	delete(n.groups, d.GroupID())
+	return nil
+}
+
+func (n *FailingNode) Done() {}
+
+func (n *FailingNode) newBegin(t time.Time, sizeHint int) edge.BeginBatchMessage {
+	return edge.NewBeginBatchMessage(n.f.Measurement, nil, false, t, sizeHint)
+}
+
+// emit sends the set of failing groups to children nodes, once time t is in a later interval.
+// The node timer must be started when calling this method.
+func (n *FailingNode) emit(t time.Time) error {
+	interval := t.Truncate(n.f.Every)
+	if !interval.After(n.interval) {
+		return nil
+	}
+	first := n.interval.IsZero()
+	n.interval = interval
+	if first {
+		return nil
+	}
+
+	var failing []*failingGroup
+	for _, group := range n.groups {
+		if group.failing {
+			failing = append(failing, group)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool { return failing[i].info.ID < failing[j].info.ID })
+
+	points := make([]edge.BatchPointMessage, len(failing))
+	for i, group := range failing {
+		points[i] = edge.NewBatchPointMessage(
+			models.Fields{n.f.As: float64(interval.Sub(group.since)) / float64(n.f.Unit)},
+			group.info.Tags,
+			interval,
+		)
+	}
+	b := edge.NewBufferedBatchMessage(n.newBegin(interval, len(points)), points, edge.NewEndBatchMessage())
+
+	n.timer.Pause()
+	defer n.timer.Resume()
+	return edge.Forward(n.outs, b)
+}
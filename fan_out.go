@@ -0,0 +1,169 @@
+package kapacitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type FanOutNode struct {
+	node
+	f *pipeline.FanOutNode
+
+	values map[string]bool
+
+	begin    edge.BeginBatchMessage
+	lastTime time.Time
+	groups   map[models.GroupID]edge.BufferedBatchMessage
+}
+
+// Create a new FanOutNode which splits the data into one branch per value of a tag.
+func newFanOutNode(et *ExecutingTask, n *pipeline.FanOutNode, d NodeDiagnostic) (*FanOutNode, error) {
+	values := make(map[string]bool, len(n.Values))
+	for _, v := range n.Values {
+		values[v] = true
+	}
+	fn := &FanOutNode{
+		node:   node{Node: n, et: et, diag: d},
+		f:      n,
+		values: values,
+		groups: make(map[models.GroupID]edge.BufferedBatchMessage),
+	}
+	fn.node.runF = fn.runFanOut
+	return fn, nil
+}
+
+func (n *FanOutNode) runFanOut([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// branch reports whether the tags belong to one of the branches.
+func (n *FanOutNode) branch(tags models.Tags) bool {
+	v, ok := tags[n.f.Tag]
+	return ok && n.values[v]
+}
+
+// dimensions returns dims with the tag of the branches added.
+func (n *FanOutNode) dimensions(dims models.Dimensions) models.Dimensions {
+	for _, t := range dims.TagNames {
+		if t == n.f.Tag {
+			return dims
+		}
+	}
+	tagNames := make([]string, len(dims.TagNames), len(dims.TagNames)+1)
+	copy(tagNames, dims.TagNames)
+	tagNames = append(tagNames, n.f.Tag)
+	sort.Strings(tagNames)
+	return models.Dimensions{
+		ByName:   dims.ByName,
+		TagNames: tagNames,
+	}
+}
+
+func (n *FanOutNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	if !n.branch(p.Tags()) {
+		n.timer.Stop()
+		return nil
+	}
+	p = p.ShallowCopy()
+	p.SetDimensions(n.dimensions(p.Dimensions()))
+	n.timer.Stop()
+	return edge.Forward(n.outs, p)
+}
+
+func (n *FanOutNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(begin.Time()); err != nil {
+		return err
+	}
+
+	n.begin = begin
+	return nil
+}
+
+func (n *FanOutNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	tags := bp.Tags()
+	if !n.branch(tags) {
+		return nil
+	}
+
+	dims := n.dimensions(n.begin.Dimensions())
+	groupID := models.ToGroupID(n.begin.Name(), tags, dims)
+	group, ok := n.groups[groupID]
+	if !ok {
+		// Create new begin message
+		newBegin := n.begin.ShallowCopy()
+		newBegin.SetTagsAndDimensions(tags, dims)
+
+		// Create buffer for group batch
+		group = edge.NewBufferedBatchMessage(
+			newBegin,
+			make([]edge.BatchPointMessage, 0, newBegin.SizeHint()),
+			edge.NewEndBatchMessage(),
+		)
+		n.groups[groupID] = group
+	}
+	group.SetPoints(append(group.Points(), bp))
+
+	return nil
+}
+
+func (n *FanOutNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *FanOutNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emit(b.Time())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return edge.Forward(n.outs, b)
+}
+
+func (n *FanOutNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	n.timer.Start()
+	delete(n.groups, d.GroupID())
+	n.timer.Stop()
+	return edge.Forward(n.outs, d)
+}
+
+func (n *FanOutNode) Done() {}
+
+// emit sends all branches before time t to children nodes.
+// The node timer must be started when calling this method.
+func (n *FanOutNode) emit(t time.Time) error {
+	if !t.Equal(n.lastTime) {
+		n.lastTime = t
+		// Emit all groups
+		for id, group := range n.groups {
+			// Update SizeHint since we know the final point count
+			group.Begin().SetSizeHint(len(group.Points()))
+			// Sort points since we didn't guarantee insertion order was sorted
+			sort.Sort(edge.BatchPointMessages(group.Points()))
+			// Send group batch to all children
+			n.timer.Pause()
+			if err := edge.Forward(n.outs, group); err != nil {
+				return err
+			}
+			n.timer.Resume()
+			// Remove from group
+			delete(n.groups, id)
+		}
+	}
+	return nil
+}
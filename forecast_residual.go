@@ -0,0 +1,187 @@
+package kapacitor
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	forecastResidualDataSrc     = 0
+	forecastResidualForecastSrc = 1
+)
+
+type ForecastResidualNode struct {
+	node
+	r *pipeline.ForecastResidualNode
+
+	// The points of the latest forecast in time order, per group.
+	forecasts map[models.GroupID][]forecastPoint
+	// The previous residuals, per group.
+	residuals map[models.GroupID]*CircularQueue[float64]
+}
+
+type forecastPoint struct {
+	time  time.Time
+	value float64
+}
+
+// Create a new ForecastResidualNode which compares data with the latest forecast.
+func newForecastResidualNode(et *ExecutingTask, n *pipeline.ForecastResidualNode, d NodeDiagnostic) (*ForecastResidualNode, error) {
+	rn := &ForecastResidualNode{
+		node:      node{Node: n, et: et, diag: d},
+		r:         n,
+		forecasts: make(map[models.GroupID][]forecastPoint),
+		residuals: make(map[models.GroupID]*CircularQueue[float64]),
+	}
+	rn.node.runF = rn.runForecastResidual
+	return rn, nil
+}
+
+func (n *ForecastResidualNode) runForecastResidual([]byte) error {
+	consumer := edge.NewMultiConsumerWithStats(n.ins, n)
+	return consumer.Consume()
+}
+
+func (n *ForecastResidualNode) BufferedBatch(src int, batch edge.BufferedBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == forecastResidualForecastSrc {
+		// A batch is a whole new forecast of the group.
+		forecast := make([]forecastPoint, 0, len(batch.Points()))
+		for _, bp := range batch.Points() {
+			if value, ok := numToFloat(bp.Fields()[n.r.ForecastField]); ok {
+				forecast = append(forecast, forecastPoint{time: bp.Time(), value: value})
+			}
+		}
+		sort.Slice(forecast, func(i, j int) bool { return forecast[i].time.Before(forecast[j].time) })
+		n.forecasts[batch.GroupID()] = forecast
+		return nil
+	}
+
+	batch = batch.ShallowCopy()
+	points := make([]edge.BatchPointMessage, len(batch.Points()))
+	for i, bp := range batch.Points() {
+		if fields, ok := n.score(batch.GroupID(), bp.Time(), bp.Fields()); ok {
+			bp = bp.ShallowCopy()
+			bp.SetFields(fields)
+		}
+		points[i] = bp
+	}
+	batch.SetPoints(points)
+	return edge.Forward(n.outs, batch)
+}
+
+func (n *ForecastResidualNode) Point(src int, p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == forecastResidualForecastSrc {
+		if value, ok := numToFloat(p.Fields()[n.r.ForecastField]); ok {
+			n.add(p.GroupID(), forecastPoint{time: p.Time(), value: value})
+		}
+		return nil
+	}
+
+	if fields, ok := n.score(p.GroupID(), p.Time(), p.Fields()); ok {
+		p = p.ShallowCopy()
+		p.SetFields(fields)
+	}
+	return edge.Forward(n.outs, p)
+}
+
+// add adds a point to the forecast of the group, replacing the forecast point at the same time.
+func (n *ForecastResidualNode) add(group models.GroupID, fp forecastPoint) {
+	forecast := n.forecasts[group]
+	i := sort.Search(len(forecast), func(i int) bool { return !forecast[i].time.Before(fp.time) })
+	if i < len(forecast) && forecast[i].time.Equal(fp.time) {
+		forecast[i] = fp
+		return
+	}
+	forecast = append(forecast, forecastPoint{})
+	copy(forecast[i+1:], forecast[i:])
+	forecast[i] = fp
+	n.forecasts[group] = forecast
+}
+
+// forecast returns the forecast value of the group at time t, and whether there is one within the tolerance.
+// The forecast points before the matched one are discarded.
+func (n *ForecastResidualNode) forecast(group models.GroupID, t time.Time) (float64, bool) {
+	forecast := n.forecasts[group]
+	i := sort.Search(len(forecast), func(i int) bool { return forecast[i].time.After(t) })
+	if i == 0 {
+		return 0, false
+	}
+	fp := forecast[i-1]
+	n.forecasts[group] = forecast[i-1:]
+	if n.r.Tolerance > 0 && t.Sub(fp.time) > n.r.Tolerance {
+		return 0, false
+	}
+	return fp.value, true
+}
+
+// score returns a copy of fields with the forecast value, the residual and the score of the point,
+// and whether the point has a forecast value.
+func (n *ForecastResidualNode) score(group models.GroupID, t time.Time, fields models.Fields) (models.Fields, bool) {
+	value, ok := numToFloat(fields[n.r.Field])
+	if !ok {
+		return nil, false
+	}
+	forecast, ok := n.forecast(group, t)
+	if !ok {
+		return nil, false
+	}
+	residual := value - forecast
+
+	scored := fields.Copy()
+	scored[n.r.ForecastAs] = forecast
+	scored[n.r.ResidualAs] = residual
+
+	residuals, ok := n.residuals[group]
+	if !ok {
+		residuals = NewCircularQueue[float64](make([]float64, 0, n.r.Size)...)
+		n.residuals[group] = residuals
+	}
+	if int64(residuals.Len) == n.r.Size {
+		mean, variance := 0.0, 0.0
+		for i := 0; i < residuals.Len; i++ {
+			mean += residuals.Peek(i)
+		}
+		mean /= float64(residuals.Len)
+		for i := 0; i < residuals.Len; i++ {
+			d := residuals.Peek(i) - mean
+			variance += d * d
+		}
+		if variance > 0 {
+			scored[n.r.ScoreAs] = (residual - mean) / math.Sqrt(variance/float64(residuals.Len))
+		}
+		residuals.Dequeue(1)
+	}
+	residuals.Enqueue(residual)
+	return scored, true
+}
+
+func (n *ForecastResidualNode) Barrier(src int, b edge.BarrierMessage) error {
+	if src != forecastResidualDataSrc {
+		return nil
+	}
+	return edge.Forward(n.outs, b)
+}
+
+func (n *ForecastResidualNode) Delete(src int, d edge.DeleteGroupMessage) error {
+	if src != forecastResidualDataSrc {
+		delete(n.forecasts, d.GroupID())
+		return nil
+	}
+	delete(n.residuals, d.GroupID())
+	return edge.Forward(n.outs, d)
+}
+
+func (n *ForecastResidualNode) Finish() error {
+	return nil
+}
@@ -0,0 +1,255 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+const (
+	statsGroupLimitDrops = "group_limit_drops"
+)
+
+type GroupByExprNode struct {
+	node
+	g *pipeline.GroupByExprNode
+
+	expression stateful.Expression
+	scopePool  stateful.ScopePool
+
+	begin      edge.BeginBatchMessage
+	dimensions models.Dimensions
+
+	groupLimitDrops *expvar.Int
+
+	mu sync.RWMutex
+	// The number of input groups with points in each known group,
+	// so that a group no longer counts towards the limit once all of its input groups are deleted.
+	known map[string]int
+	// The groups of the points of each input group.
+	inputs   map[models.GroupID]map[string]bool
+	lastTime time.Time
+	groups   map[models.GroupID]edge.BufferedBatchMessage
+}
+
+// Create a new GroupByExprNode which splits the stream dynamically based on the result of an expression.
+func newGroupByExprNode(et *ExecutingTask, n *pipeline.GroupByExprNode, d NodeDiagnostic) (*GroupByExprNode, error) {
+	if n.Lambda == nil {
+		return nil, errors.New("nil expression passed to GroupByExprNode")
+	}
+	expr, err := stateful.NewExpression(n.Lambda.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile group expression: %v", err)
+	}
+	gn := &GroupByExprNode{
+		node:       node{Node: n, et: et, diag: d},
+		g:          n,
+		expression: expr,
+		scopePool:  stateful.NewScopePool(ast.FindReferenceVariables(n.Lambda.Expression)),
+		dimensions: models.Dimensions{
+			ByName:   n.ByMeasurementFlag,
+			TagNames: []string{n.As},
+		},
+		known:  make(map[string]int),
+		inputs: make(map[models.GroupID]map[string]bool),
+		groups: make(map[models.GroupID]edge.BufferedBatchMessage),
+	}
+	gn.node.runF = gn.runGroupByExpr
+	return gn, nil
+}
+
+func (n *GroupByExprNode) runGroupByExpr([]byte) error {
+	valueF := func() int64 {
+		n.mu.RLock()
+		l := len(n.known)
+		n.mu.RUnlock()
+		return int64(l)
+	}
+	n.statMap.Set(statCardinalityGauge, expvar.NewIntFuncGauge(valueF))
+	n.groupLimitDrops = &expvar.Int{}
+	n.statMap.Set(statsGroupLimitDrops, n.groupLimitDrops)
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// groupTags returns the tags of p, from the input group, with the group of p added,
+// or false if p should be dropped.
+func (n *GroupByExprNode) groupTags(input models.GroupID, p edge.FieldsTagsTimeGetter) (models.Tags, bool) {
+	group, err := n.evalGroup(p)
+	if err != nil {
+		n.diag.Error("error evaluating group expression", err)
+		return nil, false
+	}
+
+	n.mu.Lock()
+	groups := n.inputs[input]
+	if !groups[group] {
+		if n.known[group] == 0 && int64(len(n.known)) >= n.g.MaxGroups {
+			n.mu.Unlock()
+			n.groupLimitDrops.Add(1)
+			n.diag.Error("dropping point", errors.New("group limit reached"),
+				keyvalue.KV("group", group),
+				keyvalue.KV("max_groups", fmt.Sprint(n.g.MaxGroups)),
+			)
+			return nil, false
+		}
+		if groups == nil {
+			groups = make(map[string]bool)
+			n.inputs[input] = groups
+		}
+		groups[group] = true
+		n.known[group]++
+	}
+	n.mu.Unlock()
+
+	tags := p.Tags().Copy()
+	tags[n.g.As] = group
+	return tags, true
+}
+
+func (n *GroupByExprNode) evalGroup(p edge.FieldsTagsTimeGetter) (string, error) {
+	vars := n.scopePool.Get()
+	defer n.scopePool.Put(vars)
+	if err := fillScope(vars, n.scopePool.ReferenceVariables(), p); err != nil {
+		return "", err
+	}
+	v, err := n.expression.Eval(vars)
+	if err != nil {
+		return "", err
+	}
+	group, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("group expression must evaluate to a string, got %T", v)
+	}
+	return group, nil
+}
+
+func (n *GroupByExprNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	tags, ok := n.groupTags(p.GroupID(), p)
+	if !ok {
+		n.timer.Stop()
+		return nil
+	}
+	p = p.ShallowCopy()
+	dims := n.dimensions
+	dims.ByName = dims.ByName || p.Dimensions().ByName
+	p.SetTagsAndDimensions(tags, dims)
+	n.timer.Stop()
+	return edge.Forward(n.outs, p)
+}
+
+func (n *GroupByExprNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(begin.Time()); err != nil {
+		return err
+	}
+
+	n.begin = begin
+	return nil
+}
+
+func (n *GroupByExprNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	tags, ok := n.groupTags(n.begin.GroupID(), bp)
+	if !ok {
+		return nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetTags(tags)
+
+	dims := n.dimensions
+	dims.ByName = dims.ByName || n.begin.Dimensions().ByName
+	groupID := models.ToGroupID(n.begin.Name(), tags, dims)
+	group, ok := n.groups[groupID]
+	if !ok {
+		// Create new begin message
+		newBegin := n.begin.ShallowCopy()
+		newBegin.SetTagsAndDimensions(tags, dims)
+
+		// Create buffer for group batch
+		group = edge.NewBufferedBatchMessage(
+			newBegin,
+			make([]edge.BatchPointMessage, 0, newBegin.SizeHint()),
+			edge.NewEndBatchMessage(),
+		)
+		n.groups[groupID] = group
+	}
+	group.SetPoints(append(group.Points(), bp))
+
+	return nil
+}
+
+func (n *GroupByExprNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *GroupByExprNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emit(b.Time())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return edge.Forward(n.outs, b)
+}
+
+func (n *GroupByExprNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	n.timer.Start()
+	delete(n.groups, d.GroupID())
+	n.mu.Lock()
+	for group := range n.inputs[d.GroupID()] {
+		n.known[group]--
+		if n.known[group] == 0 {
+			delete(n.known, group)
+		}
+	}
+	delete(n.inputs, d.GroupID())
+	n.mu.Unlock()
+	n.timer.Stop()
+	return edge.Forward(n.outs, d)
+}
+
+func (n *GroupByExprNode) Done() {}
+
+// emit sends all groups before time t to children nodes.
+// The node timer must be started when calling this method.
+func (n *GroupByExprNode) emit(t time.Time) error {
+	if !t.Equal(n.lastTime) {
+		n.lastTime = t
+		// Emit all groups
+		for id, group := range n.groups {
+			// Update SizeHint since we know the final point count
+			group.Begin().SetSizeHint(len(group.Points()))
+			// Sort points since we didn't guarantee insertion order was sorted
+			sort.Sort(edge.BatchPointMessages(group.Points()))
+			// Send group batch to all children
+			n.timer.Pause()
+			if err := edge.Forward(n.outs, group); err != nil {
+				return err
+			}
+			n.timer.Resume()
+			// Remove from group
+			delete(n.groups, id)
+		}
+	}
+	return nil
+}
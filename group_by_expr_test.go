@@ -0,0 +1,84 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+type testNodeDiagnostic struct {
+	NodeDiagnostic
+}
+
+func (testNodeDiagnostic) Error(msg string, err error, ctx ...keyvalue.T) {}
+
+func TestGroupByExprMaxGroups(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	g := stream.From().GroupByExpr(&ast.LambdaNode{Expression: &ast.ReferenceNode{Reference: "service"}})
+	g.MaxGroups = 1
+	n, err := newGroupByExprNode(nil, g, testNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+	n.outs = []edge.StatsEdge{edge.NewStatsEdge(out)}
+	n.timer = timer.NewNoOp()
+	n.groupLimitDrops = new(expvar.Int)
+
+	zero := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	info := func(host string) edge.GroupInfo {
+		tags := models.Tags{"host": host}
+		return edge.GroupInfo{ID: models.ToGroupID("cpu", tags, dims), Tags: tags, Dimensions: dims}
+	}
+	point := func(host, service string) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "db", "rp", dims, models.Fields{"service": service}, models.Tags{"host": host}, zero)
+	}
+
+	for _, p := range []edge.PointMessage{
+		point("a", "api"),
+		point("b", "api"),
+		// The limit is reached.
+		point("a", "web"),
+	} {
+		if err := n.Point(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := n.groupLimitDrops.IntValue(); got != 1 {
+		t.Fatalf("unexpected group limit drops: got %d exp 1", got)
+	}
+
+	// The group is still active while one of its incoming groups is not deleted.
+	if err := n.DeleteGroup(edge.NewDeleteGroupMessage(info("a"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Point(point("a", "web")); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.groupLimitDrops.IntValue(); got != 2 {
+		t.Fatalf("unexpected group limit drops: got %d exp 2", got)
+	}
+
+	// Points are accepted again once all the incoming groups of the group are deleted.
+	if err := n.DeleteGroup(edge.NewDeleteGroupMessage(info("b"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Point(point("a", "web")); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.groupLimitDrops.IntValue(); got != 2 {
+		t.Errorf("unexpected group limit drops: got %d exp 2", got)
+	}
+	if got := len(n.known); got != 1 {
+		t.Errorf("unexpected number of groups: got %d exp 1", got)
+	}
+}
@@ -0,0 +1,131 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	groupCreatedEvent = "created"
+	groupDeletedEvent = "deleted"
+)
+
+type GroupEventsNode struct {
+	node
+	g *pipeline.GroupEventsNode
+}
+
+// Create a new group events node.
+func newGroupEventsNode(et *ExecutingTask, n *pipeline.GroupEventsNode, d NodeDiagnostic) (*GroupEventsNode, error) {
+	gn := &GroupEventsNode{
+		node: node{Node: n, et: et, diag: d},
+		g:    n,
+	}
+	gn.node.runF = gn.runGroupEvents
+	return gn, nil
+}
+
+func (n *GroupEventsNode) runGroupEvents([]byte) error {
+	// The grouped consumer tracks the active groups,
+	// creating a receiver when a group is first seen and deleting it with the group.
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *GroupEventsNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group)),
+	), nil
+}
+
+func (n *GroupEventsNode) newGroup(group edge.GroupInfo) *groupEventsGroup {
+	return &groupEventsGroup{
+		n:     n,
+		group: group,
+	}
+}
+
+type groupEventsGroup struct {
+	n     *GroupEventsNode
+	group edge.GroupInfo
+
+	// The name of the group, known once data has been seen.
+	name    string
+	created bool
+	last    time.Time
+}
+
+func (g *groupEventsGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return g.seen(begin.Name(), begin.Time()), nil
+}
+
+func (g *groupEventsGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.advance(bp.Time())
+	return nil, nil
+}
+
+func (g *groupEventsGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return nil, nil
+}
+
+func (g *groupEventsGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return g.seen(p.Name(), p.Time()), nil
+}
+
+// seen records data of the group at time t,
+// and returns the created event if it is the first data of the group.
+func (g *groupEventsGroup) seen(name string, t time.Time) edge.Message {
+	g.advance(t)
+	if g.created {
+		return nil
+	}
+	g.created = true
+	g.name = name
+	return g.event(groupCreatedEvent, t)
+}
+
+func (g *groupEventsGroup) advance(t time.Time) {
+	if t.After(g.last) {
+		g.last = t
+	}
+}
+
+func (g *groupEventsGroup) event(event string, t time.Time) edge.PointMessage {
+	return edge.NewPointMessage(
+		g.name, "", "",
+		g.group.Dimensions,
+		models.Fields{g.n.g.As: event},
+		g.group.Tags,
+		t,
+	)
+}
+
+func (g *groupEventsGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	g.advance(b.Time())
+	return b, nil
+}
+
+func (g *groupEventsGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	if !g.created {
+		// The group never had any data.
+		return d, nil
+	}
+	// Send the event before the delete, so that children see it while the group still exists.
+	g.n.timer.Pause()
+	err := edge.Forward(g.n.outs, g.event(groupDeletedEvent, g.last))
+	g.n.timer.Resume()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (g *groupEventsGroup) Done() {}
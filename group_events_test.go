@@ -0,0 +1,55 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+func TestGroupEventsGroup(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	n, err := newGroupEventsNode(nil, stream.From().GroupEvents(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := edge.NewChannelEdge(pipeline.StreamEdge, 1)
+	n.outs = []edge.StatsEdge{edge.NewStatsEdge(out)}
+	n.timer = timer.NewNoOp()
+
+	tags := models.Tags{"host": "a"}
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	info := edge.GroupInfo{ID: models.ToGroupID("cpu", tags, dims), Tags: tags, Dimensions: dims}
+	g := n.newGroup(info)
+
+	zero := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	point := func(s int) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "db", "rp", dims, models.Fields{"value": 1.0}, tags, zero.Add(time.Duration(s)*time.Second))
+	}
+	event := func(event string, s int) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "", "", dims, models.Fields{"event": event}, tags, zero.Add(time.Duration(s)*time.Second))
+	}
+
+	if m, _ := g.Point(point(1)); !reflect.DeepEqual(m, event("created", 1)) {
+		t.Errorf("unexpected created event: got %v", m)
+	}
+	if m, _ := g.Point(point(2)); m != nil {
+		t.Errorf("unexpected event for existing group: got %v", m)
+	}
+	if m, _ := g.Barrier(edge.NewBarrierMessage(info, zero.Add(5*time.Second))); m == nil {
+		t.Error("expected barrier to be forwarded")
+	}
+	// The deleted event is sent before the delete is forwarded.
+	d := edge.NewDeleteGroupMessage(info)
+	if m, err := g.DeleteGroup(d); err != nil || m != d {
+		t.Errorf("expected delete to be forwarded: got %v %v", m, err)
+	}
+	if m, _ := out.Emit(); !reflect.DeepEqual(m, event("deleted", 5)) {
+		t.Errorf("unexpected deleted event: got %v", m)
+	}
+}
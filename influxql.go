@@ -3,9 +3,11 @@ package kapacitor
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/pkg/errors"
@@ -14,6 +16,10 @@ import (
 // tmpl -- go get github.com/benbjohnson/tmpl
 //go:generate tmpl -data=@tmpldata.json influxql.gen.go.tmpl
 
+const (
+	statsPointsSkipped = "points_skipped"
+)
+
 type createReduceContextFunc func(c baseReduceContext) reduceContext
 
 type InfluxQLNode struct {
@@ -22,7 +28,11 @@ type InfluxQLNode struct {
 	createFn               createReduceContextFunc
 	isStreamTransformation bool
 
+	// Protects the create function, which is shared by the groups processed in parallel.
+	mu          sync.Mutex
 	currentKind reflect.Kind
+
+	pointsSkipped *expvar.Int
 }
 
 func newInfluxQLNode(et *ExecutingTask, n *pipeline.InfluxQLNode, d NodeDiagnostic) (*InfluxQLNode, error) {
@@ -51,10 +61,27 @@ type baseReduceContext struct {
 }
 
 func (n *InfluxQLNode) runInfluxQL([]byte) error {
-	consumer := edge.NewGroupedConsumer(
-		n.ins[0],
-		n,
-	)
+	n.pointsSkipped = &expvar.Int{}
+	if n.n.ReduceCreater.IsSkipping {
+		n.statMap.Set(statsPointsSkipped, n.pointsSkipped)
+	}
+
+	var consumer edge.GroupedConsumer
+	if n.Wants() == pipeline.BatchEdge && n.et.tm.BatchParallelism > 1 {
+		// The groups are independent, so their batches can be processed concurrently.
+		consumer = edge.NewParallelGroupedConsumer(
+			n.ins[0],
+			n.outs,
+			influxqlParallelGroups{n: n},
+			n.timer,
+			n.et.tm.BatchParallelism,
+		)
+	} else {
+		consumer = edge.NewGroupedConsumer(
+			n.ins[0],
+			n,
+		)
+	}
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 	return consumer.Consume()
 }
@@ -66,6 +93,15 @@ func (n *InfluxQLNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edg
 	), nil
 }
 
+// influxqlParallelGroups creates the groups of an InfluxQLNode whose batches are processed in parallel.
+type influxqlParallelGroups struct {
+	n *InfluxQLNode
+}
+
+func (r influxqlParallelGroups) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.ForwardReceiver, error) {
+	return r.n.newGroup(first), nil
+}
+
 func (n *InfluxQLNode) newGroup(first edge.PointMeta) edge.ForwardReceiver {
 	bc := baseReduceContext{
 		as:         n.n.As,
@@ -95,6 +131,9 @@ type influxqlGroup struct {
 
 	batchSize int
 	begin     edge.BeginBatchMessage
+
+	// The number of points aggregated into the current context.
+	count int64
 }
 
 func (g *influxqlGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -102,6 +141,7 @@ func (g *influxqlGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message,
 	g.batchSize = 0
 	g.bc.time = begin.Time()
 	g.rc = nil
+	g.count = 0
 	return nil, nil
 }
 
@@ -114,6 +154,8 @@ func (g *influxqlGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, err
 	}
 	if err := g.rc.AggregatePoint(g.begin.Name(), bp); err != nil {
 		g.n.diag.Error("failed to aggregate point in batch", err)
+	} else {
+		g.count++
 	}
 	g.batchSize++
 	return nil, nil
@@ -135,7 +177,7 @@ func (g *influxqlGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error)
 		g.n.diag.Error("failed to emit batch", err)
 		return nil, nil
 	}
-	return m, nil
+	return g.n.setCount(m, g.count), nil
 }
 
 func (g *influxqlGroup) Point(p edge.PointMessage) (edge.Message, error) {
@@ -149,13 +191,14 @@ func (g *influxqlGroup) Point(p edge.PointMessage) (edge.Message, error) {
 			if err != nil {
 				g.n.diag.Error("failed to emit stream", err)
 			}
-			msg = m
+			msg = g.n.setCount(m, g.count)
 		}
 
 		// Reset context
 		g.bc.name = p.Name()
 		g.bc.time = p.Time()
 		g.rc = nil
+		g.count = 0
 
 		// Aggregate the current point
 		g.aggregatePoint(p)
@@ -175,7 +218,9 @@ func (g *influxqlGroup) aggregatePoint(p edge.PointMessage) {
 	err := g.rc.AggregatePoint(p.Name(), p)
 	if err != nil {
 		g.n.diag.Error("failed to aggregate point", err)
+		return
 	}
+	g.count++
 }
 
 func (g *influxqlGroup) getFieldKind(fields models.Fields) (reflect.Kind, error) {
@@ -275,6 +320,8 @@ func (g *influxqlStreamingTransformGroup) Barrier(b edge.BarrierMessage) (edge.M
 }
 
 func (n *InfluxQLNode) getCreateFn(kind reflect.Kind) (createReduceContextFunc, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	changed := n.currentKind != kind
 	if !changed && n.createFn != nil {
 		return n.createFn, nil
@@ -289,6 +336,9 @@ func (n *InfluxQLNode) getCreateFn(kind reflect.Kind) (createReduceContextFunc,
 }
 
 func (n *InfluxQLNode) emit(context reduceContext) (edge.Message, error) {
+	if n.n.ReduceCreater.IsSkipping {
+		n.pointsSkipped.Add(skipped(context))
+	}
 	switch n.Provides() {
 	case pipeline.StreamEdge:
 		return context.EmitPoint()
@@ -297,3 +347,50 @@ func (n *InfluxQLNode) emit(context reduceContext) (edge.Message, error) {
 	}
 	return nil, nil
 }
+
+// skipped returns the number of values skipped by the reducer of the context.
+func skipped(context reduceContext) int64 {
+	var reducer interface{}
+	switch c := context.(type) {
+	case *floatReduceContext:
+		reducer = c.floatPointAggregator.aggregator
+	case *integerFloatReduceContext:
+		reducer = c.integerPointAggregator.aggregator
+	}
+	if s, ok := reducer.(interface{ Skipped() int64 }); ok {
+		return s.Skipped()
+	}
+	return 0
+}
+
+// setCount adds the number of aggregated points to the points of the emitted message, if enabled.
+// The fields are copied, as the fields of simple selectors are those of the selected point,
+// which may be shared with other nodes.
+func (n *InfluxQLNode) setCount(m edge.Message, count int64) edge.Message {
+	if n.n.CountAs == "" {
+		return m
+	}
+	switch m := m.(type) {
+	case edge.PointMessage:
+		if m != nil {
+			m = m.ShallowCopy()
+			fields := m.Fields().Copy()
+			fields[n.n.CountAs] = count
+			m.SetFields(fields)
+			return m
+		}
+	case edge.BufferedBatchMessage:
+		m = m.ShallowCopy()
+		points := make([]edge.BatchPointMessage, len(m.Points()))
+		for i, bp := range m.Points() {
+			bp = bp.ShallowCopy()
+			fields := bp.Fields().Copy()
+			fields[n.n.CountAs] = count
+			bp.SetFields(fields)
+			points[i] = bp
+		}
+		m.SetPoints(points)
+		return m
+	}
+	return m
+}
@@ -169,6 +169,17 @@ func (ts taskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
 	return nil, errors.New("not implemented")
 }
 
+// snapshotTaskStore restores every task from a snapshot.
+type snapshotTaskStore struct {
+	taskStore
+	snapshot *kapacitor.TaskSnapshot
+}
+
+func (ts snapshotTaskStore) HasSnapshot(name string) bool { return true }
+func (ts snapshotTaskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
+	return ts.snapshot, nil
+}
+
 type deadman struct {
 	interval  time.Duration
 	threshold float64
@@ -17,7 +17,9 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -63,6 +65,7 @@ import (
 	"github.com/influxdata/kapacitor/services/pagerduty2/pagerduty2test"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/pushover/pushovertest"
+	"github.com/influxdata/kapacitor/services/secrets"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/sensu/sensutest"
 	"github.com/influxdata/kapacitor/services/servicenow"
@@ -261,6 +264,107 @@ func TestStream_ChangeDetect_Many(t *testing.T) {
 	testStreamerWithOutput(t, "TestStream_ChangeDetect_Many", script, 15*time.Second, er, false, nil)
 }
 
+func TestStream_ChangeDetect_Tolerance(t *testing.T) {
+
+	var script = `stream
+	|from().measurement('disk')
+	|changeDetect('used', 'free')
+		.tolerance(0.5)
+		.changedOnly()
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_ChangeDetect_Tolerance')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "disk",
+				Tags:    nil,
+				Columns: []string{"time", "free", "used"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						50.0,
+						100.0,
+					},
+					{
+						// used drifted by more than the tolerance since the last emitted point.
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						nil,
+						100.9,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						51.0,
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						nil,
+						99.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ChangeDetect_Tolerance", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_ChangeDetect_Drift(t *testing.T) {
+
+	var script = `stream
+	|from().measurement('disk')
+	|changeDetect('used', 'ops')
+		.tolerance(0.5)
+		.changedOnly()
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_ChangeDetect_Drift')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "disk",
+				Tags:    nil,
+				Columns: []string{"time", "ops", "used"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						50.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+						nil,
+					},
+					{
+						// used drifted by more than the tolerance since it was last emitted,
+						// while ops changed at every point.
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+						50.8,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						4.0,
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ChangeDetect_Drift", script, 15*time.Second, er, false, nil)
+}
+
 func TestStream_Derivative(t *testing.T) {
 
 	var script = `
@@ -480,1770 +584,3941 @@ stream
 	testStreamerWithOutput(t, "TestStream_DerivativeNN", script, 15*time.Second, er, false, nil)
 }
 
-func TestStream_HoltWinters(t *testing.T) {
+func TestStream_Autocorrelation(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
-		.groupBy('host')
+		.measurement('jobs')
+	|groupBy('job')
+	|autocorrelation('runs')
+		.lag(2)
+		.size(4)
+	// Warm-up and zero variance points have no coefficient.
+	|where(lambda: isPresent("autocorrelation"))
 	|window()
 		.period(10s)
 		.every(10s)
-	|holtWinters('value', 3, 0, 1s)
-	|where(lambda: "host" == 'serverA')
-	|httpOut('TestStream_HoltWinters')
+		.align()
+	|httpOut('TestStream_Autocorrelation')
 `
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    models.Tags{"host": "serverA"},
-				Columns: []string{"time", "holtWinters"},
+				Name:    "jobs",
+				Tags:    map[string]string{"job": "backup"},
+				Columns: []string{"time", "autocorrelation", "runs"},
 				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-						1009.324690106368,
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						0.5,
+						3.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						1009.7524349889708,
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						-0.16666666666666666,
+						3.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
-						1010.105056042826,
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-0.5,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						0.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						-0.4090909090909091,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						-0.16666666666666666,
+						5.0,
 					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_HoltWinters", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Autocorrelation", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_HoltWintersWithFit(t *testing.T) {
+func TestStream_CrossCorrelation(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
+		.measurement('service')
 		.groupBy('host')
+	|crossCorrelation('depth', 'latency')
+		.minLag(-1)
+		.maxLag(2)
+		.size(4)
+	// Warm-up points have no lag.
+	|where(lambda: isPresent("lag"))
 	|window()
 		.period(10s)
 		.every(10s)
-	|holtWintersWithFit('value', 3, 0, 1s)
-	|where(lambda: "host" == 'serverA')
-	|httpOut('TestStream_HoltWinters')
+		.align()
+	|httpOut('TestStream_CrossCorrelation')
 `
+	// The latency follows the depth by one point.
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    models.Tags{"host": "serverA"},
-				Columns: []string{"time", "holtWinters"},
+				Name:    "service",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "correlation", "depth", "lag", "latency"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						1000.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-						1000.7349380776699,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-						1001.8935462884633,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						1003.1750039651934,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						1004.4245269000132,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						1005.5685498251902,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						1006.5782508658309,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						1007.4488388165385,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						1008.1877681696025,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						1008.8080773333872,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-						1009.324690106368,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						1009.7524349889708,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
-						1010.105056042826,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0, 5.0, 1.0, 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 1.0, 4.0, 1.0, 5.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 1.0, 6.0, 1.0, 4.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 1.0, 8.0, 1.0, 6.0},
+					{time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), 1.0, 7.0, 1.0, 8.0},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_HoltWinters", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_CrossCorrelation", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Elapsed(t *testing.T) {
-
+func TestStream_FanOut(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
-	|elapsed('value', 1s)
+		.measurement('requests')
+		.groupBy('service')
+	|fanOut('region', 'us', 'eu')
 	|window()
 		.period(10s)
 		.every(10s)
-	|max('elapsed')
-	|httpOut('TestStream_Elapsed')
+		.align()
+	|mean('latency')
+	|httpOut('TestStream_FanOut')
 `
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    nil,
-				Columns: []string{"time", "max"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-					4.0,
-				}},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "eu", "service": "api"},
+				Columns: []string{"time", "mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						24.5,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"region": "us", "service": "api"},
+				Columns: []string{"time", "mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						14.5,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"region": "us", "service": "web"},
+				Columns: []string{"time", "mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						44.5,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Elapsed", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_FanOut", script, 13*time.Second, er, true, nil)
 }
 
-func TestStream_Difference(t *testing.T) {
-
+func TestStream_Percentiles(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
-	|difference('value')
+		.measurement('requests')
+		.groupBy('service')
 	|window()
 		.period(10s)
 		.every(10s)
-	|max('difference')
-	|httpOut('TestStream_Difference')
+		.align()
+	|percentiles('latency', 50.0, 90.0, 99.0)
+	|httpOut('TestStream_Percentiles')
 `
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    nil,
-				Columns: []string{"time", "max"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-					5.0,
-				}},
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "p50", "p90", "p99"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						5.0,
+						9.0,
+						10.0,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "web"},
+				Columns: []string{"time", "p50", "p90", "p99"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						2.5,
+						4.5,
+						4.5,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Difference", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Percentiles", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_MovingAverage(t *testing.T) {
-
+func TestStream_PercentilesFields(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
-	|movingAverage('value', 4)
+		.measurement('endpoints')
+		.groupBy('service')
 	|window()
 		.period(10s)
 		.every(10s)
-	|httpOut('TestStream_MovingAverage')
+		.align()
+	|percentiles('login', 50.0, 90.0)
+		.fields('search', 'checkout')
+	|httpOut('TestStream_PercentilesFields')
 `
+	// The search and checkout fields are missing from some of the points.
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    nil,
-				Columns: []string{"time", "movingAverage"},
+				Name:    "endpoints",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "checkout_p50", "checkout_p90", "login_p50", "login_p90", "search_p50", "search_p90"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						1001.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						1005.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						1008.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						1012.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						1015.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						1016.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						1017.5,
-					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-						1018.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						1019.5,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
-						1020.5,
+						3.0,
+						7.0,
+						30.0,
+						50.0,
+						200.0,
+						300.0,
 					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_MovingAverage", script, 16*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_PercentilesFields", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_CumulativeSum(t *testing.T) {
+func TestStream_DropOutliers(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.measurement('packets')
-	|cumulativeSum('value')
+		.measurement('requests')
+		.groupBy('service')
 	|window()
 		.period(10s)
 		.every(10s)
-	|httpOut('TestStream_CumulativeSum')
+		.align()
+	|dropOutliers('latency')
+	|mean('latency')
+	|httpOut('TestStream_DropOutliers')
 `
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "packets",
-				Tags:    nil,
-				Columns: []string{"time", "cumulativeSum"},
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "mean"},
 				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						0.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-						0.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-						1.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						6.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						10.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						15.0,
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						11.0,
 					},
+				},
+			},
+			{
+				// Too few points to detect outliers.
+				Name:    "requests",
+				Tags:    map[string]string{"service": "web"},
+				Columns: []string{"time", "mean"},
+				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
 						21.0,
 					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						28.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						36.0,
-					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_CumulativeSum", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_DropOutliers", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_WindowMissing(t *testing.T) {
-
+func TestStream_GeometricMean(t *testing.T) {
 	var script = `
-var period = 3s
-var every = 2s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('growth')
+		.groupBy('service')
 	|window()
-		.period(period)
-		.every(every)
-	|count('value')
-	|httpOut('TestStream_WindowMissing')
+		.period(10s)
+		.every(10s)
+		.align()
+	|geometricMean('ratio')
+	|httpOut('TestStream_GeometricMean')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-					3.0,
-				}},
+				// The zero and negative values are skipped.
+				Name:    "growth",
+				Tags:    map[string]string{"service": "a"},
+				Columns: []string{"time", "geometricMean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						4.0,
+					},
+				},
+			},
+			{
+				Name:    "growth",
+				Tags:    map[string]string{"service": "b"},
+				Columns: []string{"time", "geometricMean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						5.196152422706632,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_WindowMissing", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_GeometricMean", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_WindowMissingAligned(t *testing.T) {
-
+func TestStream_GeometricMean_Skipped(t *testing.T) {
 	var script = `
-var period = 3s
-var every = 2s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('growth')
+		.groupBy('service')
 	|window()
-		.period(period)
-		.every(every)
+		.period(10s)
+		.every(10s)
 		.align()
-	|count('value')
-	|httpOut('TestStream_WindowMissing')
+	|geometricMean('ratio')
 `
+	// Uses the data of TestStream_GeometricMean.
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_GeometricMean", script, nil)
+	defer checkDeferredErrors(t, tm.Close)()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The four zero and negative values of service a and the two zero values of service b in the first window.
+	if got, exp := stats.NodeStats["geometricMean3"]["points_skipped"], int64(6); got != exp {
+		t.Errorf("unexpected points_skipped: got %v exp %v", got, exp)
+	}
+}
 
+func TestStream_CounterDelta(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('http')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|counterDelta('requests')
+		.as('increase')
+	|httpOut('TestStream_CounterDelta')
+`
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					3.0,
-				}},
+				// Integer counter with resets at 3s and 7s.
+				Name:    "http",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "increase"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						28.0,
+					},
+				},
+			},
+			{
+				// Float counter with resets at 2s and 4s.
+				Name:    "http",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "increase"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						6.0,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_WindowMissing", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_CounterDelta", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window(t *testing.T) {
-
+func TestStream_GroupEvents(t *testing.T) {
 	var script = `
-var period = 10s
-var every = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-	|window()
-		.period(period)
-		.every(every)
-	|httpOut('TestStream_Window')
+		.groupBy('host')
+	|groupEvents()
+	|httpOut('TestStream_GroupEvents')
 `
-
-	nums := []float64{
-		97.1,
-		92.6,
-		95.6,
-		93.1,
-		92.6,
-		95.8,
-		92.7,
-		96.0,
-		93.4,
-		95.3,
-	}
-
-	values := make([][]interface{}, len(nums))
-	for i, num := range nums {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
-			"serverA",
-			"idle",
-			num,
-		}
-	}
-
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "event"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"created",
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "event"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						"created",
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_GroupEvents", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Count(t *testing.T) {
-
+func TestStream_MannKendall(t *testing.T) {
 	var script = `
-var period = 10
-var every = 10
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('disk')
+		.groupBy('host')
 	|window()
-		.periodCount(period)
-		.everyCount(every)
-	|httpOut('TestStream_Window_Count')
+		.period(10s)
+		.every(10s)
+		.align()
+	|mannKendall('used')
+	|httpOut('TestStream_MannKendall')
 `
-
-	count := 10
-	values := make([][]interface{}, count)
-	for i := 0; i < count; i++ {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-			"serverA",
-			"idle",
-			float64(i + 1),
-		}
-	}
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				// No ties, the p-value is exact.
+				Name:    "disk",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "mk_p", "mk_s", "mk_trend", "mk_z"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						2.9761904761904762e-05,
+						41.0,
+						1.0,
+						3.5777087639996634,
+					},
+				},
+			},
+			{
+				// Ties, the p-value is from the normal approximation.
+				Name:    "disk",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "mk_p", "mk_s", "mk_trend", "mk_z"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.8197075378297883,
+						-3.0,
+						0.0,
+						-0.2279211529192759,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_MannKendall", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Count_Overlapping(t *testing.T) {
-
+func TestStream_Pivot(t *testing.T) {
 	var script = `
-var period = 3
-var every = 1
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('stats')
+		.groupBy('host')
 	|window()
-		.periodCount(period)
-		.everyCount(every)
-	|httpOut('TestStream_Window_Count')
+		.period(10s)
+		.every(10s)
+		.align()
+	|pivot()
+		.on('aggregate')
+		.columns('cpu_mean', 'cpu_max', 'mem_mean', 'mem_max')
+		.fill(0.0)
+	|httpOut('TestStream_Pivot')
 `
-
-	count := 3
-	values := make([][]interface{}, count)
-	for i := 0; i < count; i++ {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-			"serverA",
-			"idle",
-			float64(i + 10),
-		}
-	}
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				Name:    "stats",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "cpu_max", "cpu_mean", "mem_max", "mem_mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						80.0,
+						12.0,
+						65.0,
+						40.0,
+					},
+				},
+			},
+			{
+				// The max aggregate is missing and filled.
+				Name:    "stats",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "cpu_max", "cpu_mean", "mem_max", "mem_mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.0,
+						20.0,
+						0.0,
+						30.0,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Pivot", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Count_Every_1(t *testing.T) {
-
+func TestStream_Sequence(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
+		.measurement('packets')
 		.groupBy('host')
+	|sequence('seq')
+		.max(7)
+	|groupBy()
 	|window()
-		.periodCount(3)
-		.everyCount(1)
-	|count('value')
-	|window()
-		.periodCount(20)
-		.everyCount(1)
-	|httpOut('TestStream_Window_Count')
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_Sequence')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "count"},
+				Name:    "packets",
+				Columns: []string{"time", "expected", "host", "missing", "seq", "sequence_error"},
 				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						1.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						2.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						// A duplicate of host b.
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
 						3.0,
+						"b",
+						0.0,
+						2.0,
+						"regression",
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						// A gap of host a, after the sequence wrapped around.
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						2.0,
+						"a",
+						1.0,
 						3.0,
+						"gap",
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						3.0,
+						// The missing point of host a, out of order.
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						4.0,
+						"a",
+						0.0,
+						2.0,
+						"regression",
 					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Sequence", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Count_FillPeriod(t *testing.T) {
-
+func TestStream_Accumulate(t *testing.T) {
 	var script = `
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.groupBy('host')
-	|window()
-		.periodCount(4)
-		.everyCount(1)
-		.fillPeriod()
-	|count('value')
+		.measurement('billing')
+		.groupBy('account')
+	|accumulate('cost')
+		.reset('*/10 * * * * * *')
+		.budget(100.0)
+		.fractions(0.5, 0.8, 1.0)
 	|window()
-		.periodCount(20)
-		.everyCount(1)
-	|httpOut('TestStream_Window_Count')
+		.period(20s)
+		.every(20s)
+		.align()
+	|httpOut('TestStream_Accumulate')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "count"},
+				Name:    "billing",
+				Tags:    map[string]string{"account": "a"},
+				Columns: []string{"time", "budget_crossed", "budget_fraction", "cost", "total"},
 				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
+						0.0,
+						0.3,
+						30.0,
+						30.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.5,
+						0.6,
+						30.0,
+						60.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						0.8,
+						0.9,
+						30.0,
+						90.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						1.0,
+						1.2,
+						30.0,
+						120.0,
 					},
 					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						4.0,
+						// The total was reset at 10s.
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						0.5,
+						0.6,
+						60.0,
+						60.0,
 					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Accumulate", script, 23*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Every_0(t *testing.T) {
-
+func TestStream_Entropy(t *testing.T) {
 	var script = `
-var period = 10s
-// Emit the window on every point
-var every = 0s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.groupBy('host')
-	|window()
-		.period(period)
-		.every(every)
-	|count('value')
+		.measurement('requests')
+		.groupBy('service')
+	|entropy('endpoint')
+		.period(4s)
 	|window()
-		.period(10s)
-		.every(10s)
-	|httpOut('TestStream_Window')
+		.period(8s)
+		.every(8s)
+		.align()
+	|httpOut('TestStream_Entropy')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"service": "s"},
+				Columns: []string{"time", "categories", "endpoint", "entropy", "value"},
 				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
 						1.0,
+						"a",
+						0.0,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
 						2.0,
+						"b",
+						1.0,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-						3.0,
+						2.0,
+						"a",
+						0.9182958340544896,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						4.0,
+						2.0,
+						"b",
+						1.0,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						5.0,
+						3.0,
+						"c",
+						1.5,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						6.0,
+						3.0,
+						"c",
+						1.5,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						7.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						8.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						9.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						10.0,
-					},
-				},
-			},
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverB"},
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						1.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
 						2.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-						3.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						4.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						6.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						7.0,
+						"c",
+						0.8112781244591328,
+						1.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						8.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						9.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						10.0,
+						1.0,
+						"c",
+						0.0,
+						1.0,
 					},
 				},
 			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Entropy", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_LeakyBucket(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('errors')
+		.groupBy('service')
+	|leakyBucket('count')
+		.capacity(10.0)
+		.leakRate(10.0)
+		.interval(10s)
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_LeakyBucket')
+`
+	// The bucket leaks 1 per second, the burst at 1s fits in the bucket while the rate from 5s overflows it.
+	er := models.Result{
+		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverC"},
-				Columns: []string{"time", "count"},
+				Name:    "errors",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "count", "level", "overflow"},
 				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						1.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						2.0,
-					},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 5.0, 5.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 5.0, 9.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 1.0, 9.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 1.0, 9.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 1.0, 9.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 4.0, 10.0, 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 4.0, 10.0, 3.0},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_LeakyBucket", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Every_0_FillPeriod(t *testing.T) {
-
+func TestStream_TokenBucket(t *testing.T) {
 	var script = `
-var period = 5s
-// Emit the window on every point
-var every = 0s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.groupBy('host')
-	|window()
-		.period(period)
-		.every(every)
-		.fillPeriod()
-	|count('value')
+		.measurement('api')
+		.groupBy('key')
+	|tokenBucket('calls')
+		.limit(10.0)
+		.interval(10s)
+		.limitField('quota')
 	|window()
 		.period(10s)
-		.every(0s)
-	|httpOut('TestStream_Window')
+		.every(10s)
+		.align()
+	|httpOut('TestStream_TokenBucket')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "count"},
+				Name:    "api",
+				Tags:    map[string]string{"key": "a"},
+				Columns: []string{"time", "calls", "limit", "overage"},
 				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						5.0,
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						3.0,
+						10.0,
+						1.0,
 					},
-				},
-			},
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverB"},
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{
 					{
 						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						5.0,
+						3.0,
+						10.0,
+						2.0,
 					},
 					{
 						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-						5.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						5.0,
+						3.0,
+						10.0,
+						2.0,
 					},
 				},
 			},
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverC"},
-				Columns: []string{"time", "count"},
+				// The quota of b is its limit.
+				Name:    "api",
+				Tags:    map[string]string{"key": "b"},
+				Columns: []string{"time", "calls", "limit", "overage", "quota"},
 				Values: [][]interface{}{
 					{
-						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-						1.0,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-						1.0,
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						5.0,
+						20.0,
+						3.0,
+						20.0,
 					},
 				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_TokenBucket", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Window_Overlapping(t *testing.T) {
+// testRecordingService serves the data of stream recordings from memory.
+type testRecordingService map[string]string
 
+func (s testRecordingService) RecordingReaders(id string) ([]io.ReadCloser, bool, error) {
+	data, ok := s[id]
+	if !ok {
+		return nil, false, fmt.Errorf("unknown recording %s", id)
+	}
+	return []io.ReadCloser{io.NopCloser(strings.NewReader(data))}, true, nil
+}
+
+func TestStream_Baseline(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('requests')
+		.groupBy('host')
+	|baseline('known-good')
+		.fields('latency')
 	|window()
-		.period(period)
-		.every(every)
-	|httpOut('TestStream_Window_FillPeriod')
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_Baseline')
 `
-
-	nums := []float64{
-		93.1,
-		97.1,
-		92.6,
-		95.6,
-		93.1,
-		92.6,
-		95.8,
-		92.7,
-		96.0,
-		93.4,
-	}
-
-	values := make([][]interface{}, len(nums))
-	for i, num := range nums {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
-			"serverA",
-			"idle",
-			num,
-		}
+	// The baseline of a is the mean of its points, b has a zero baseline and c has no baseline.
+	recordings := testRecordingService{
+		"known-good": "dbname\nrpname\nrequests,host=a latency=10 0\n" +
+			"dbname\nrpname\nrequests,host=a latency=20 1000000000\n" +
+			"dbname\nrpname\nrequests,host=b latency=0 0\n",
 	}
-
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				Name:    "requests",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "latency", "latency_baseline", "latency_diff", "latency_ratio"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					18.0,
+					15.0,
+					3.0,
+					1.2,
+				}},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "b"},
+				Columns: []string{"time", "latency", "latency_baseline", "latency_diff"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+					5.0,
+					0.0,
+					5.0,
+				}},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "c"},
+				Columns: []string{"time", "latency"},
+				Values: [][]interface{}{{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					7.0,
+				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_FillPeriod", script, 16*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Baseline", script, 13*time.Second, er, true, func(tm *kapacitor.TaskMaster) {
+		tm.RecordingService = recordings
+	})
 }
 
-func TestStream_Window_FillPeriod(t *testing.T) {
-
+func TestStream_Consensus(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
+		.measurement('temperature')
+		.groupBy('room')
+	|consensus('value')
+		.replica('sensor')
+		.period(5s)
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_Consensus')
+`
+	// The sensor s2 goes silent after its first point.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "temperature",
+				Tags:    map[string]string{"room": "a"},
+				Columns: []string{"time", "consensus", "disagreement", "outlier", "replicas"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 20.0, 0.0, "", 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 21.0, 1.0, "s1", 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 21.0, 1.0, "s1", 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 22.0, 8.0, "s3", 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 22.0, 8.0, "s3", 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 22.0, 8.0, "s3", 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 25.5, 4.5, "s1", 2.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Consensus", script, 13*time.Second, er, false, nil)
+}
+
+const percentileThresholdScript = `
+stream
+	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|percentileThreshold('value')
+		.percentile(50.0)
+		.period(1h)
+		.slices(1)
+		.minPoints(5)
+	|where(lambda: isPresent("threshold"))
 	|window()
-		.period(period)
-		.every(every)
-		.fillPeriod()
-	|httpOut('TestStream_Window_FillPeriod')
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('%s')
 `
 
-	nums := []float64{
-		93.1,
-		97.1,
-		92.6,
-		95.6,
-		93.1,
-		92.6,
-		95.8,
-		92.7,
-		96.0,
-		93.4,
-		95.3,
-		96.4,
-		95.1,
-		91.1,
+func TestStream_PercentileThreshold(t *testing.T) {
+	script := fmt.Sprintf(percentileThresholdScript, "TestStream_PercentileThreshold")
+	// The threshold is set once 5 points are learned, and is learned before the value of the point.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "threshold", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 10.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 10.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), 10.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 10.0, 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 10.0, 50.0},
+				},
+			},
+		},
 	}
 
-	values := make([][]interface{}, len(nums))
-	for i, num := range nums {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
-			"serverA",
-			"idle",
-			num,
-		}
+	testStreamerWithOutput(t, "TestStream_PercentileThreshold", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_PercentileThreshold_Restore(t *testing.T) {
+	// Learn the values of the first task and snapshot it.
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_PercentileThreshold", fmt.Sprintf(percentileThresholdScript, "learn"), nil)
+	if err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := et.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.Close(); err != nil {
+		t.Fatal(err)
 	}
 
+	// The restored task has learned enough points to set the threshold of its first point.
+	script := fmt.Sprintf(percentileThresholdScript, "TestStream_PercentileThreshold_Restore")
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "threshold", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0, 50.0},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_FillPeriod", script, 16*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_PercentileThreshold_Restore", script, 13*time.Second, er, false, func(tm *kapacitor.TaskMaster) {
+		tm.TaskStore = snapshotTaskStore{snapshot: snapshot}
+	})
 }
-func TestStream_Window_FillPeriod_Aligned(t *testing.T) {
 
+func TestStream_Ratio(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('requests')
+		.groupBy('service')
 	|window()
-		.period(period)
-		.every(every)
-		.fillPeriod()
+		.period(10s)
+		.every(10s)
 		.align()
-	|httpOut('TestStream_Window_FillPeriod_Aligned')
+	|ratio()
+		.numerator('sum', 'errors')
+		.denominator('count', 'duration')
+	|httpOut('TestStream_Ratio')
 `
-
-	nums := []float64{
-		95.8,
-		92.7,
-		96.0,
-		93.4,
-		95.3,
-		96.4,
-		95.1,
-		91.1,
-		95.7,
-		96.2,
-		96.6,
-		91.2,
-		98.2,
-		96.1,
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "a"},
+				Columns: []string{"time", "denominator", "numerator", "ratio"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						4.0,
+						2.0,
+						0.5,
+					},
+				},
+			},
+			{
+				// The denominator is zero, so the ratio is null.
+				Name:    "requests",
+				Tags:    map[string]string{"service": "b"},
+				Columns: []string{"time", "denominator", "numerator"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.0,
+						1.0,
+					},
+				},
+			},
+		},
 	}
 
-	values := make([][]interface{}, len(nums))
-	for i, num := range nums {
-		values[i] = []interface{}{
-			time.Date(1971, 1, 1, 0, 0, i+6, 0, time.UTC),
-			"serverA",
-			"idle",
-			num,
-		}
-	}
+	testStreamerWithOutput(t, "TestStream_Ratio", script, 13*time.Second, er, false, nil)
+}
 
+func TestStream_Uptime(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('healthcheck')
+		.groupBy('service')
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|uptime(lambda: "status" == 'up')
+	|httpOut('TestStream_Uptime')
+`
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "host", "type", "value"},
-				Values:  values,
+				Name:    "healthcheck",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "uptime"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.7,
+					},
+				},
+			},
+			{
+				// The state before the first point at 4s is unknown.
+				Name:    "healthcheck",
+				Tags:    map[string]string{"service": "web"},
+				Columns: []string{"time", "uptime"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.6666666666666666,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Window_FillPeriod_Aligned", script, 21*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Uptime", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Barrier_Idle_No_Data(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC())
-	requestCount := int32(0)
-
-	testValues := make([][]interface{}, 1)
-	for i := 0; i < 1; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
-	}
-
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-	}))
-	defer ts.Close()
+func TestStream_Decompose(t *testing.T) {
 
 	var script = `
-var period = 14s
-var every = 2s
-var idle = 2s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-	|barrier().idle(idle)
+		.measurement('requests')
+		.groupBy('service')
+	|decompose('value')
+		.season(4)
 	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
+		.period(4s)
+		.every(4s)
+		.align()
+	|httpOut('TestStream_Decompose')
 `
 
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle_No_Data", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "residual", "seasonal", "trend", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 2.0, 5.5, 15.5, 23.0},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 1.5, -4.0, 16.5, 14.0},
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 21.0, 3.5, 17.5, 42.0},
+					{time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), -4.5, 0.0, 23.5, 19.0},
+				},
+			},
+		},
+	}
 
-		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
-			t.Errorf("got %v exp %v", rc, 2)
-		}
-	}()
+	testStreamerWithOutput(t, "TestStream_Decompose", script, 13*time.Second, er, false, nil)
+}
 
-	// groupedconsumers do not run any logic until at least one message is seen
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
-	}
-	for _, point := range data {
-		dataChannel <- point
+func TestStream_Coincidence(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('health')
+		.groupBy('service')
+	|coincidence(lambda: "errors" > 10.0, lambda: "latency" > 500.0)
+		.tolerance(2s)
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_Coincidence')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "health",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "errors", "latency"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 20.0, 600.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 20.0, nil},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 15.0, nil},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 15.0, nil},
+					{time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), nil, 800.0},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 30.0, 900.0},
+				},
+			},
+		},
 	}
 
-	time.Sleep(5 * time.Second)
-	close(dataChannel)
+	testStreamerWithOutput(t, "TestStream_Coincidence", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_Barrier_Idle(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
-	clock.Set(time.Now().UTC())
-	testValues := make([][]interface{}, 21)
-	for i := 0; i < 21; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
+func TestStream_Lag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('service')
+	|stamp()
+	|lag()
+		.unit(1u)
+	// The lag depends on the processing time, only check that it is present.
+	|where(lambda: "lag" >= 0.0 AND "ingested" >= 0)
+	|delete()
+		.field('ingested')
+		.field('lag')
+	|window()
+		.period(3s)
+		.every(3s)
+		.align()
+	|httpOut('TestStream_Lag')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 3.0},
+				},
+			},
+		},
 	}
-	requestCount := int32(0)
 
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
+	testStreamerWithOutput(t, "TestStream_Lag", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_AlertCapture(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "alert.log")
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.message('{{ .ID }} is {{ .Level }} value={{ index .Fields "value" }}')
+		.crit(lambda: "value" > 90.0)
+		.stateChangesOnly()
+		.topic('TestStream_AlertCapture')
+		.log('%s')
+		.post('http://127.0.0.1:1/unreachable')
+		.slack()
+			.channel('#alerts')
+`, logPath)
+
+	type alertMessage struct {
+		Level   alert.Level
+		Message string
+	}
+	exp := []alertMessage{
+		{Level: alert.Critical, Message: "cpu:serverA is CRITICAL value=95"},
+		{Level: alert.OK, Message: "cpu:serverA is OK value=20"},
+	}
+
+	alerts := testStreamerWithCapture(t, "TestStream_AlertCapture", script, 13*time.Second, func(tm *kapacitor.TaskMaster) {
+		c := slack.NewConfig()
+		c.Enabled = true
+		c.URL = "http://127.0.0.1:1/slack"
+		sl, err := slack.NewService([]slack.Config{c}, diagService.NewSlackHandler())
 		if err != nil {
 			t.Fatal(err)
 		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
+		tm.SlackService = sl
+	})
+
+	// Each handler dispatches its events independently, so only compare the order per handler.
+	got := make(map[string][]alertMessage)
+	payloads := make(map[string][]alert.Payload)
+	for _, a := range alerts {
+		if a.TaskID != "TestStream_AlertCapture" || a.Node != "alert2" {
+			t.Errorf("unexpected alert source: task %s node %s", a.TaskID, a.Node)
+		}
+		if a.Err != nil {
+			t.Errorf("unexpected error rendering the payload of the %s handler: %v", a.Handler, a.Err)
+		}
+		got[a.Handler] = append(got[a.Handler], alertMessage{
+			Level:   a.Event.State.Level,
+			Message: a.Event.State.Message,
+		})
+		if a.Payload != nil {
+			payloads[a.Handler] = append(payloads[a.Handler], *a.Payload)
+		}
+	}
+	for _, handler := range []string{"log", "httppost", "slack", "topic"} {
+		if !reflect.DeepEqual(got[handler], exp) {
+			t.Errorf("unexpected alerts captured for %s handler:\ngot %v\nexp %v", handler, got[handler], exp)
+		}
+	}
+	if len(got) != 4 {
+		t.Errorf("unexpected handlers captured: %v", got)
+	}
 
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[0:10],
-					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[6:20],
-					},
-				},
+	// The payloads are rendered as the handlers would have sent them.
+	for _, handler := range []string{"log", "httppost"} {
+		for i, p := range payloads[handler] {
+			ad := alert.Data{}
+			if err := json.Unmarshal(p.Body, &ad); err != nil {
+				t.Fatal(err)
 			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[16:21],
-					},
-				},
+			if ad.Level != exp[i].Level || ad.Message != exp[i].Message {
+				t.Errorf("unexpected %s payload: got %s", handler, p.Body)
 			}
 		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+	}
+	if len(payloads["log"]) != 2 || payloads["log"][0].Destination != logPath {
+		t.Errorf("unexpected log payloads: %v", payloads["log"])
+	}
+	if len(payloads["httppost"]) != 2 || payloads["httppost"][0].Destination != "http://127.0.0.1:1/unreachable" {
+		t.Errorf("unexpected httppost payloads: %v", payloads["httppost"])
+	}
+	if len(payloads["slack"]) != 2 {
+		t.Fatalf("unexpected slack payloads: %v", payloads["slack"])
+	}
+	for i, color := range []string{"danger", "good"} {
+		p := payloads["slack"][i]
+		var post struct {
+			Channel     string `json:"channel"`
+			Attachments []struct {
+				Text  string `json:"text"`
+				Color string `json:"color"`
+			} `json:"attachments"`
 		}
-	}))
-	defer ts.Close()
+		if err := json.Unmarshal(p.Body, &post); err != nil {
+			t.Fatal(err)
+		}
+		if p.Destination != "http://127.0.0.1:1/slack" || post.Channel != "#alerts" || len(post.Attachments) != 1 ||
+			post.Attachments[0].Text != exp[i].Message || post.Attachments[0].Color != color {
+			t.Errorf("unexpected slack payload: %s", p.Body)
+		}
+	}
+	if len(payloads["topic"]) != 0 {
+		t.Errorf("unexpected topic payloads: %v", payloads["topic"])
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("expected no alert to be logged, got %v", err)
+	}
+}
 
-	var script = `
-var period = 14s
-var every = 10s
-var idle = 10s
+func TestStream_AlertMinLevel(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "alert.log")
+	var script = fmt.Sprintf(`
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
 		.measurement('cpu')
-	|barrier().idle(idle)
-	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
-`
-
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
+		.groupBy('host')
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 70.0)
+		.crit(lambda: "value" > 90.0)
+		.stateChangesOnly()
+		.log('%s')
+		.post('http://127.0.0.1:1/unreachable')
+			.minLevel('CRITICAL')
+`, logPath)
 
-		// Force emit should force the last window to emit
-		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
-			t.Errorf("got %v exp %v", rc, 3)
-		}
-	}()
+	alerts := testStreamerWithCapture(t, "TestStream_AlertMinLevel", script, 13*time.Second, nil)
 
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
+	got := make(map[string][]alert.Level)
+	for _, a := range alerts {
+		got[a.Handler] = append(got[a.Handler], a.Event.State.Level)
 	}
-	for _, point := range data {
-		dataChannel <- point
+	// The post handler is only sent the critical event and its recovery,
+	// not the later warning and its recovery.
+	exp := map[string][]alert.Level{
+		"log":      {alert.Warning, alert.Critical, alert.Warning, alert.OK, alert.Warning, alert.OK},
+		"httppost": {alert.Critical, alert.OK},
 	}
-	time.Sleep(11 * time.Second)
-	close(dataChannel)
-}
-
-func TestStream_Barrier_Idle_No_Idle(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
-	clock.Set(time.Now().UTC())
-	testValues := make([][]interface{}, 21)
-	for i := 0; i < 21; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alerts captured:\ngot %v\nexp %v", got, exp)
 	}
-	requestCount := int32(0)
+}
 
+func TestStream_AlertMinLevel_Restart(t *testing.T) {
+	posted := make(chan alert.Level, 10)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
-
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[0:10],
-					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[6:20],
-					},
-				},
-			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[16:21],
-					},
-				},
-			}
-		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
 		}
+		posted <- ad.Level
 	}))
 	defer ts.Close()
 
 	var script = `
-var period = 14s
-var every = 10s
-var idle = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
 		.measurement('cpu')
-	|barrier().idle(idle)
-	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
+		.groupBy('host')
+	|alert()
+		.id('cpu:{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 70.0)
+		.crit(lambda: "value" > 90.0)
+		.post('` + ts.URL + `')
+			.minLevel('CRITICAL')
 `
-
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle_No_Idle", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
-
-		// Force emit should force the last window to emit
-		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
-			t.Errorf("got %v exp %v", rc, 2)
+	var levels []alert.Level
+	run := func(tm *kapacitor.TaskMaster, value float64) *kapacitor.TaskMaster {
+		now := time.Now().UTC()
+		clck := clock.New(now)
+		clck.Set(now)
+		pointsC := make(chan edge.PointMessage, 1)
+		pointsC <- edge.NewPointMessage(
+			"cpu", "dbname", "rpname",
+			models.Dimensions{TagNames: []string{"host"}},
+			models.Fields{"value": value},
+			models.Tags{"host": "serverA"},
+			now,
+		)
+		tm, _, cleanup := testStreamerWithInputChannel(t, "TestStream_AlertMinLevel_Restart", script, pointsC, clck, tm, nil, true)
+		// Wait for the alert to be posted before stopping the task.
+		select {
+		case l := <-posted:
+			levels = append(levels, l)
+		case <-time.After(time.Second):
 		}
-	}()
+		close(pointsC)
+		cleanup()
+		return tm
+	}
 
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
+	tm := run(nil, 95)
+	defer checkDeferredErrors(t, tm.Close)()
+	if err := tm.StopTask("TestStream_AlertMinLevel_Restart"); err != nil {
+		t.Fatal(err)
 	}
-	for _, point := range data {
-		dataChannel <- point
+	// The task restarts, and the handler is sent the recovery of the critical alert it was sent before.
+	run(tm, 10)
+
+	if exp := []alert.Level{alert.Critical, alert.OK}; !reflect.DeepEqual(levels, exp) {
+		t.Errorf("unexpected alerts posted: got %v exp %v", levels, exp)
 	}
-	close(dataChannel)
 }
 
-func TestStream_Barrier_Idle_Replay_After_Idle(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
-	clock.Set(time.Now().UTC())
-	testValues := make([][]interface{}, 21)
-	for i := 0; i < 21; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
+func TestStream_Mirror(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('dc', 'service')
+	|mirror('east', 'west')
+		.field('value')
+	|window()
+		.period(5s)
+		.every(5s)
+		.align()
+	|httpOut('TestStream_Mirror')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "value_abs_diff", "value_rel_diff"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 20.0, 0.2},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 0.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 30.0, 0.5},
+				},
+			},
+		},
 	}
-	requestCount := int32(0)
 
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
+	testStreamerWithOutput(t, "TestStream_Mirror", script, 13*time.Second, er, false, nil)
+}
 
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[0:10],
-					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[6:20],
-					},
-				},
-			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[16:21],
-					},
+func TestStream_Mirror_CarryForward(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('dc', 'service')
+	|mirror('east', 'west')
+		.field('value')
+		.carryForward()
+	|window()
+		.period(5s)
+		.every(5s)
+		.align()
+	|httpOut('TestStream_Mirror_CarryForward')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "value_abs_diff", "value_rel_diff"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 20.0, 0.2},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 0.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 10.0, 0.2},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 0.0, 0.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 30.0, 0.5},
 				},
-			}
-		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
-		}
-	}))
-	defer ts.Close()
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Mirror_CarryForward", script, 13*time.Second, er, false, nil)
+}
 
+func TestStream_MeanCountAs(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
-var idle = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
 		.measurement('cpu')
-	|barrier().idle(idle)
+		.groupBy('host')
+	|where(lambda: "value" >= 0.0)
 	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
+		.period(10s)
+		.every(10s)
+		.align()
+	|mean('value')
+		.countAs('count')
+	|httpOut('TestStream_MeanCountAs')
 `
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count", "mean"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 6.0, 35.0},
+				},
+			},
+		},
+	}
 
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
+	testStreamerWithOutput(t, "TestStream_MeanCountAs", script, 13*time.Second, er, false, nil)
+}
 
-		// Force emit should force the last window to emit
-		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
-			t.Errorf("got %v exp %v", rc, 3)
-		}
-	}()
+func TestStream_MinCountAs_Shared(t *testing.T) {
+	var script = `
+var data = stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|where(lambda: "value" >= 0.0)
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
 
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
-	}
-	for _, point := range data {
-		dataChannel <- point
-	}
-	time.Sleep(11 * time.Second)
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
-	}
-	for _, point := range data {
-		dataChannel <- point
+// The selected point is shared with the other branch, which must not see the count.
+data
+	|min('value')
+		.as('value')
+		.countAs('count')
+	|httpOut('min')
+
+data
+	|httpOut('TestStream_MinCountAs_Shared')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 20.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 30.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 40.0},
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 50.0},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 60.0},
+				},
+			},
+		},
 	}
-	close(dataChannel)
-}
 
-func TestStream_Barrier_Period_No_Data(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC())
-	requestCount := int32(0)
+	testStreamerWithOutput(t, "TestStream_MinCountAs_Shared", script, 13*time.Second, er, false, nil)
+}
 
-	testValues := make([][]interface{}, 1)
-	for i := 0; i < 1; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
+func TestStream_CardinalityEstimate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('service')
+	|cardinality('user')
+		.period(4s)
+		.slices(4)
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_CardinalityEstimate')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "cardinality", "user"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0, "a"},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 2.0, "b"},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 2.0, "a"},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 3.0, "c"},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 4.0, "d"},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 3.0, "a"},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 4.0, "e"},
+					{time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), 3.0, "e"},
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 3.0, "f"},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 3.0, "g"},
+				},
+			},
+		},
 	}
 
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-	}))
-	defer ts.Close()
+	testStreamerWithOutput(t, "TestStream_CardinalityEstimate", script, 13*time.Second, er, false, nil)
+}
 
-	// NOTE: using a slightly higher than 2s idle because timer was going off within a few ms of window period 50% of
-	// the time
+func TestStream_Failing(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 2s
-var idle = 2100ms
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
 		.measurement('cpu')
-	|barrier().period(idle)
+		.groupBy('host')
+	|failing(lambda: "usage_idle" < 10)
+		.every(5s)
+	|httpOut('TestStream_Failing')
+`
+	// hostA and hostB have recovered, only hostC is still failing.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "failing",
+				Columns: []string{"time", "failing_duration", "host"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 9.0, "hostC"},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Failing", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_SeasonalZScore(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('service')
+	|seasonalZScore('rate')
+		.season(4s)
+		.bucket(1s)
+		.minSeasons(2)
+	// The points of the first two seasons have no z-score.
+	|where(lambda: isPresent("seasonal_zscore"))
 	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
+		.period(12s)
+		.every(12s)
+		.align()
+	|httpOut('TestStream_SeasonalZScore')
 `
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "rate", "seasonal_zscore"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 14.0, 2.1213203435596424},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 24.0, 2.1213203435596424},
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 34.0, 2.1213203435596424},
+					{time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), 50.0, 6.363961030678928},
+				},
+			},
+		},
+	}
 
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Period_No_Data", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
+	testStreamerWithOutput(t, "TestStream_SeasonalZScore", script, 15*time.Second, er, false, nil)
+}
 
-		// barrier should emit at least 4 times
-		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
-			t.Errorf("got %v exp %v", rc, 2)
-		}
-	}()
-
-	// groupedconsumers do not run any logic until at least one message is seen
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
-	}
-	for _, point := range data {
-		dataChannel <- point
-	}
-
-	time.Sleep(5 * time.Second)
-	close(dataChannel)
-}
-
-func TestStream_Barrier_Period(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
-	clock.Set(time.Now().UTC())
-	testValues := make([][]interface{}, 21)
-	for i := 0; i < 21; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
-	}
-	requestCount := int32(0)
-
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
-
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
+func TestStream_PercentileRank(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('latency')
+		.groupBy('service')
+	|percentileRank('p99')
+		.size(4)
+		.minPoints(3)
+	// Warm-up points have no rank.
+	|where(lambda: isPresent("percentile_rank"))
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_PercentileRank')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "latency",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "p99", "percentile_rank"},
+				Values: [][]interface{}{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[0:10],
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						40.0,
+						100.0,
 					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[6:20],
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						25.0,
+						50.0,
 					},
-				},
-			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[16:21],
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						50.0,
+						100.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						5.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						30.0,
+						50.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						30.0,
+						62.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						35.0,
+						75.0,
 					},
 				},
-			}
-		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
-		}
-	}))
-	defer ts.Close()
+			},
+		},
+	}
 
+	testStreamerWithOutput(t, "TestStream_PercentileRank", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_PercentChange(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
-var idle = 10s
 stream
 	|from()
-		.database('dbname')
-		.retentionPolicy('rpname')
-		.measurement('cpu')
-	|barrier().period(idle)
+		.measurement('requests')
+	|groupBy('service')
+	|percentChange('rate')
+		.period(2s)
+	// Warm-up and division by zero points have no percentage change.
+	|where(lambda: isPresent("percent_change"))
 	|window()
-		.period(period)
-		.every(every)
-	|httpPost('` + ts.URL + `')
+		.period(10s)
+		.every(10s)
+		.align()
+	|httpOut('TestStream_PercentChange')
 `
-
-	dataChannel := make(chan edge.PointMessage)
-	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Period", script, dataChannel, clock, nil, nil, false)
-	defer checkDeferredErrors(t, tm.Close)()
-	defer func() {
-		cleanupTest()
-
-		// Force emit should force the last window to emit
-		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
-			t.Errorf("got %v exp %v", rc, 3)
-		}
-	}()
-
-	data := make([]edge.PointMessage, len(testValues))
-	for i, testValue := range testValues {
-		data[i] = edge.NewPointMessage(
-			"cpu",
-			"dbname",
-			"rpname",
-			models.Dimensions{},
-			models.Fields{"value": testValue[1]},
-			models.Tags{},
-			testValue[0].(time.Time),
-		)
-	}
-	for _, point := range data {
-		dataChannel <- point
-	}
-	time.Sleep(11 * time.Second)
-	close(dataChannel)
-}
-
-func TestStream_Barrier_Period_No_Idle(t *testing.T) {
-	// Set up clock to current time - 22 seconds since we're going to send 21 data points
-	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
-	clock.Set(time.Now().UTC())
-	testValues := make([][]interface{}, 21)
-	for i := 0; i < 21; i++ {
-		testValues[i] = []interface{}{
-			clock.Zero().Add(time.Duration(i) * time.Second),
-			rand.Float64(),
-		}
-	}
-	requestCount := int32(0)
-
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
-
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "percent_change", "rate"},
+				Values: [][]interface{}{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[0:10],
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-100.0,
+						0.0,
 					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[6:20],
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						-25.0,
+						15.0,
 					},
-				},
-			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Columns: []string{"time", "value"},
-						Values:  testValues[16:21],
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						200.0,
+						45.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						100.0,
+						60.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						33.33333333333333,
+						60.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						-50.0,
+						30.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						-50.0,
+						30.0,
 					},
 				},
-			}
-		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
-		}
-	}))
-	defer ts.Close()
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_PercentChange", script, 13*time.Second, er, false, nil)
+}
 
+func TestStream_Cusum(t *testing.T) {
 	var script = `
-var period = 14s
-var every = 10s
-var idle = 10s
+stream
+	|from()
+		.measurement('latency')
+	|groupBy('host')
+	|cusum('value')
+		.target(10.0)
+		.slack(1.0)
+		.threshold(5.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Cusum')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "latency",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "cusum_neg", "cusum_pos", "cusum_signal", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+						0.0,
+						0.0,
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						0.0,
+						1.0,
+						0.0,
+						12.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.0,
+						4.0,
+						0.0,
+						14.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						0.0,
+						7.0,
+						1.0,
+						14.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						0.0,
+						0.0,
+						0.0,
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						3.0,
+						0.0,
+						0.0,
+						6.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						6.0,
+						0.0,
+						-1.0,
+						6.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						0.0,
+						0.0,
+						0.0,
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						0.0,
+						1.0,
+						0.0,
+						12.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						0.0,
+						0.0,
+						0.0,
+						10.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Cusum", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_Residual(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|groupBy('host')
+	|residual('rate', lambda: if("host" == 'serverA', 10.0, 20.0) + float(minute("time")))
+	|window()
+		.period(2m)
+		.every(2m)
+	|httpOut('TestStream_Residual')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "rate", "residual"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						15.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC),
+						8.0,
+						-2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC),
+						14.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 1, 30, 0, time.UTC),
+						10.0,
+						-1.0,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "rate", "residual"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						25.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC),
+						18.0,
+						-3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Residual", script, 3*time.Minute, er, false, nil)
+}
+
+func TestStream_Monotonic(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('mem')
+	|groupBy('host')
+	|monotonic('used')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Monotonic')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "mem",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "run_direction", "run_length", "used"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+						0.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+						2.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						1.0,
+						3.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1.0,
+						4.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-1.0,
+						1.0,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						-1.0,
+						2.0,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						-1.0,
+						3.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						-1.0,
+						4.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						1.0,
+						1.0,
+						6.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Monotonic", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_MonotonicStrict(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('mem')
+	|groupBy('host')
+	|monotonic('used')
+		.strict()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Monotonic')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "mem",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "run_direction", "run_length", "used"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+						0.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.0,
+						0.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						1.0,
+						1.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1.0,
+						2.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-1.0,
+						1.0,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						0.0,
+						0.0,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						-1.0,
+						1.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						0.0,
+						0.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						1.0,
+						1.0,
+						6.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Monotonic", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_HoltWinters(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|holtWinters('value', 3, 0, 1s)
+	|where(lambda: "host" == 'serverA')
+	|httpOut('TestStream_HoltWinters')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    models.Tags{"host": "serverA"},
+				Columns: []string{"time", "holtWinters"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						1009.324690106368,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						1009.7524349889708,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						1010.105056042826,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_HoltWinters", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_HoltWintersWithFit(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|holtWintersWithFit('value', 3, 0, 1s)
+	|where(lambda: "host" == 'serverA')
+	|httpOut('TestStream_HoltWinters')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    models.Tags{"host": "serverA"},
+				Columns: []string{"time", "holtWinters"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1000.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1000.7349380776699,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1001.8935462884633,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						1003.1750039651934,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1004.4245269000132,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						1005.5685498251902,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						1006.5782508658309,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						1007.4488388165385,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						1008.1877681696025,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						1008.8080773333872,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						1009.324690106368,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						1009.7524349889708,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						1010.105056042826,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_HoltWinters", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Elapsed(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+	|elapsed('value', 1s)
+	|window()
+		.period(10s)
+		.every(10s)
+	|max('elapsed')
+	|httpOut('TestStream_Elapsed')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "max"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					4.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Elapsed", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Difference(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+	|difference('value')
+	|window()
+		.period(10s)
+		.every(10s)
+	|max('difference')
+	|httpOut('TestStream_Difference')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "max"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					5.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Difference", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_MovingAverage(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+	|movingAverage('value', 4)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_MovingAverage')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "movingAverage"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						1001.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1005.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						1008.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						1012.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						1015.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						1016.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						1017.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						1018.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						1019.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						1020.5,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_MovingAverage", script, 16*time.Second, er, false, nil)
+}
+
+func TestStream_CumulativeSum(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('packets')
+	|cumulativeSum('value')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_CumulativeSum')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "packets",
+				Tags:    nil,
+				Columns: []string{"time", "cumulativeSum"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						6.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						15.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						21.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						28.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						36.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_CumulativeSum", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_WindowMissing(t *testing.T) {
+
+	var script = `
+var period = 3s
+var every = 2s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+	|count('value')
+	|httpOut('TestStream_WindowMissing')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_WindowMissing", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_WindowMissingAligned(t *testing.T) {
+
+	var script = `
+var period = 3s
+var every = 2s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+		.align()
+	|count('value')
+	|httpOut('TestStream_WindowMissing')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_WindowMissing", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_Window(t *testing.T) {
+
+	var script = `
+var period = 10s
+var every = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+	|httpOut('TestStream_Window')
+`
+
+	nums := []float64{
+		97.1,
+		92.6,
+		95.6,
+		93.1,
+		92.6,
+		95.8,
+		92.7,
+		96.0,
+		93.4,
+		95.3,
+	}
+
+	values := make([][]interface{}, len(nums))
+	for i, num := range nums {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+			"serverA",
+			"idle",
+			num,
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Count(t *testing.T) {
+
+	var script = `
+var period = 10
+var every = 10
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.periodCount(period)
+		.everyCount(every)
+	|httpOut('TestStream_Window_Count')
+`
+
+	count := 10
+	values := make([][]interface{}, count)
+	for i := 0; i < count; i++ {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+			"serverA",
+			"idle",
+			float64(i + 1),
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Count_Overlapping(t *testing.T) {
+
+	var script = `
+var period = 3
+var every = 1
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.periodCount(period)
+		.everyCount(every)
+	|httpOut('TestStream_Window_Count')
+`
+
+	count := 3
+	values := make([][]interface{}, count)
+	for i := 0; i < count; i++ {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+			"serverA",
+			"idle",
+			float64(i + 10),
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Count_Every_1(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.periodCount(3)
+		.everyCount(1)
+	|count('value')
+	|window()
+		.periodCount(20)
+		.everyCount(1)
+	|httpOut('TestStream_Window_Count')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Count_FillPeriod(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.periodCount(4)
+		.everyCount(1)
+		.fillPeriod()
+	|count('value')
+	|window()
+		.periodCount(20)
+		.everyCount(1)
+	|httpOut('TestStream_Window_Count')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_Count", script, 2*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Every_0(t *testing.T) {
+
+	var script = `
+var period = 10s
+// Emit the window on every point
+var every = 0s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(period)
+		.every(every)
+	|count('value')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Window')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						6.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						7.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						8.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						9.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						10.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						6.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						7.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						8.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						9.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						10.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverC"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Every_0_FillPeriod(t *testing.T) {
+
+	var script = `
+var period = 5s
+// Emit the window on every point
+var every = 0s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(period)
+		.every(every)
+		.fillPeriod()
+	|count('value')
+	|window()
+		.period(10s)
+		.every(0s)
+	|httpOut('TestStream_Window')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						5.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						5.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverC"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+}
+
+func TestStream_Window_Overlapping(t *testing.T) {
+
+	var script = `
+var period = 14s
+var every = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+	|httpOut('TestStream_Window_FillPeriod')
+`
+
+	nums := []float64{
+		93.1,
+		97.1,
+		92.6,
+		95.6,
+		93.1,
+		92.6,
+		95.8,
+		92.7,
+		96.0,
+		93.4,
+	}
+
+	values := make([][]interface{}, len(nums))
+	for i, num := range nums {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+			"serverA",
+			"idle",
+			num,
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_FillPeriod", script, 16*time.Second, er, false, nil)
+}
+
+func TestStream_Window_FillPeriod(t *testing.T) {
+
+	var script = `
+var period = 14s
+var every = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+		.fillPeriod()
+	|httpOut('TestStream_Window_FillPeriod')
+`
+
+	nums := []float64{
+		93.1,
+		97.1,
+		92.6,
+		95.6,
+		93.1,
+		92.6,
+		95.8,
+		92.7,
+		96.0,
+		93.4,
+		95.3,
+		96.4,
+		95.1,
+		91.1,
+	}
+
+	values := make([][]interface{}, len(nums))
+	for i, num := range nums {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, i, 0, time.UTC),
+			"serverA",
+			"idle",
+			num,
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_FillPeriod", script, 16*time.Second, er, false, nil)
+}
+func TestStream_Window_FillPeriod_Aligned(t *testing.T) {
+
+	var script = `
+var period = 14s
+var every = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(period)
+		.every(every)
+		.fillPeriod()
+		.align()
+	|httpOut('TestStream_Window_FillPeriod_Aligned')
+`
+
+	nums := []float64{
+		95.8,
+		92.7,
+		96.0,
+		93.4,
+		95.3,
+		96.4,
+		95.1,
+		91.1,
+		95.7,
+		96.2,
+		96.6,
+		91.2,
+		98.2,
+		96.1,
+	}
+
+	values := make([][]interface{}, len(nums))
+	for i, num := range nums {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, i+6, 0, time.UTC),
+			"serverA",
+			"idle",
+			num,
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_FillPeriod_Aligned", script, 21*time.Second, er, false, nil)
+}
+
+func TestStream_Barrier_Idle_No_Data(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC())
+	requestCount := int32(0)
+
+	testValues := make([][]interface{}, 1)
+	for i := 0; i < 1; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 2s
+var idle = 2s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().idle(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle_No_Data", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
+			t.Errorf("got %v exp %v", rc, 2)
+		}
+	}()
+
+	// groupedconsumers do not run any logic until at least one message is seen
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+
+	time.Sleep(5 * time.Second)
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Idle(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
+	clock.Set(time.Now().UTC())
+	testValues := make([][]interface{}, 21)
+	for i := 0; i < 21; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[0:10],
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[6:20],
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[16:21],
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 10s
+var idle = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().idle(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		// Force emit should force the last window to emit
+		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
+			t.Errorf("got %v exp %v", rc, 3)
+		}
+	}()
+
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+	time.Sleep(11 * time.Second)
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Idle_No_Idle(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
+	clock.Set(time.Now().UTC())
+	testValues := make([][]interface{}, 21)
+	for i := 0; i < 21; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[0:10],
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[6:20],
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[16:21],
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 10s
+var idle = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().idle(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle_No_Idle", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		// Force emit should force the last window to emit
+		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
+			t.Errorf("got %v exp %v", rc, 2)
+		}
+	}()
+
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Idle_Replay_After_Idle(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
+	clock.Set(time.Now().UTC())
+	testValues := make([][]interface{}, 21)
+	for i := 0; i < 21; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[0:10],
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[6:20],
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[16:21],
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 10s
+var idle = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().idle(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Idle", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		// Force emit should force the last window to emit
+		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
+			t.Errorf("got %v exp %v", rc, 3)
+		}
+	}()
+
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+	time.Sleep(11 * time.Second)
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Period_No_Data(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC())
+	requestCount := int32(0)
+
+	testValues := make([][]interface{}, 1)
+	for i := 0; i < 1; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+	}))
+	defer ts.Close()
+
+	// NOTE: using a slightly higher than 2s idle because timer was going off within a few ms of window period 50% of
+	// the time
+	var script = `
+var period = 14s
+var every = 2s
+var idle = 2100ms
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().period(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Period_No_Data", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		// barrier should emit at least 4 times
+		if rc := atomic.LoadInt32(&requestCount); rc != 2 {
+			t.Errorf("got %v exp %v", rc, 2)
+		}
+	}()
+
+	// groupedconsumers do not run any logic until at least one message is seen
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+
+	time.Sleep(5 * time.Second)
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Period(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
+	clock.Set(time.Now().UTC())
+	testValues := make([][]interface{}, 21)
+	for i := 0; i < 21; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[0:10],
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[6:20],
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[16:21],
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 10s
+var idle = 10s
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+	|barrier().period(idle)
+	|window()
+		.period(period)
+		.every(every)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	tm, _, cleanupTest := testStreamerWithInputChannel(t, "TestStream_Barrier_Period", script, dataChannel, clock, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	defer func() {
+		cleanupTest()
+
+		// Force emit should force the last window to emit
+		if rc := atomic.LoadInt32(&requestCount); rc != 3 {
+			t.Errorf("got %v exp %v", rc, 3)
+		}
+	}()
+
+	data := make([]edge.PointMessage, len(testValues))
+	for i, testValue := range testValues {
+		data[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": testValue[1]},
+			models.Tags{},
+			testValue[0].(time.Time),
+		)
+	}
+	for _, point := range data {
+		dataChannel <- point
+	}
+	time.Sleep(11 * time.Second)
+	close(dataChannel)
+}
+
+func TestStream_Barrier_Period_No_Idle(t *testing.T) {
+	// Set up clock to current time - 22 seconds since we're going to send 21 data points
+	clock := clock.New(time.Now().UTC().Add(-22 * time.Second))
+	clock.Set(time.Now().UTC())
+	testValues := make([][]interface{}, 21)
+	for i := 0; i < 21; i++ {
+		testValues[i] = []interface{}{
+			clock.Zero().Add(time.Duration(i) * time.Second),
+			rand.Float64(),
+		}
+	}
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[0:10],
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[6:20],
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Columns: []string{"time", "value"},
+						Values:  testValues[16:21],
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var period = 14s
+var every = 10s
+var idle = 10s
 stream
 	|from()
 		.database('dbname')
@@ -3214,50 +5489,341 @@ stream
 			},
 			{
 				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverC"},
+				Tags:    map[string]string{"host": "serverC"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					6.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Delete_GroupBy", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_AllMeasurements(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_AllMeasurements')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					23.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_AllMeasurements", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_HttpPost(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+							97.1,
+						}},
+					},
+				},
+			}
+		case 2:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+							92.6,
+						}},
+					},
+				},
+			}
+		case 3:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+							95.6,
+						}},
+					},
+				},
+			}
+		case 4:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+							93.1,
+						}},
+					},
+				},
+			}
+		case 5:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+							92.6,
+						}},
+					},
+				},
+			}
+		case 6:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+							95.8,
+						}},
+					},
+				},
+			}
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost('` + ts.URL + `')
+	|httpOut('TestStream_HttpPost')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
 				Columns: []string{"time", "value"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-					6.0,
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					95.8,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_Delete_GroupBy", script, 15*time.Second, er, true, nil)
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, nil)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
+		t.Errorf("got %v exp %v", rc, 6)
+	}
 }
 
-func TestStream_AllMeasurements(t *testing.T) {
+func TestStream_HttpPost_URL_Template(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		er := struct {
+			models.Result
+			url string
+		}{}
+		switch rc {
+		case 1:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+							97.1,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=a`
+		case 2:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "b"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+							92.6,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=b`
+		case 3:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "b"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+							95.6,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=b`
+		case 4:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "c"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+							93.1,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=c`
+		case 5:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "c"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+							92.6,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=c`
+		case 6:
+			er.Result = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+							95.8,
+						}},
+					},
+				},
+			}
+			er.url = `/cpu/?host=serverA&cpu=a`
+		}
+		if eq, msg := compareResults(er.Result, result); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+		if er.url != r.URL.String() {
+			t.Errorf("enexpected url for data, expected: %s  got: %s", er.url, r.URL.String())
+		}
+	}))
+	defer ts.Close()
 
 	var script = `
 stream
 	|from()
-	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
-	|httpOut('TestStream_AllMeasurements')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost('` + ts.URL + `/{{ .Name }}/?host={{ index .Tags "host"}}&cpu={{ index .Tags "cpu" }}')
+	|httpOut('TestStream_HttpPost_URL_Template')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
+				Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
+				Columns: []string{"time", "value"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					23.0,
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					95.8,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_AllMeasurements", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_HttpPost_URL_Template", script, 13*time.Second, er, false, nil)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
+		t.Errorf("got %v exp %v", rc, 6)
+	}
 }
 
-func TestStream_HttpPost(t *testing.T) {
+func TestStream_HttpPostEndpoint(t *testing.T) {
+	headers := map[string]string{"my": "header"}
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			nv := r.Header.Get(k)
+			if nv != v {
+				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
+			}
+		}
 		result := models.Result{}
 		dec := json.NewDecoder(r.Body)
 		err := dec.Decode(&result)
@@ -3366,7 +5932,88 @@ stream
 		.measurement('cpu')
 		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
-	|httpPost('` + ts.URL + `')
+	|httpPost()
+	  .endpoint('test')
+	  .header('my', 'header')
+	|httpOut('TestStream_HttpPost')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					95.8,
+				}},
+			},
+		},
+	}
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URLTemplate = ts.URL
+		c.Endpoint = "test"
+		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
+		tm.HTTPPostService = sl
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
+		t.Errorf("got %v exp %v", rc, 6)
+	}
+}
+func TestStream_HttpPostEndpoint_CustomBody(t *testing.T) {
+	headers := map[string]string{"my": "header"}
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			nv := r.Header.Get(k)
+			if nv != v {
+				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
+			}
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var exp string
+		switch rc {
+		case 1:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:00 +0000 UTC 97.1"
+		case 2:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:01 +0000 UTC 92.6"
+		case 3:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:02 +0000 UTC 95.6"
+		case 4:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:03 +0000 UTC 93.1"
+		case 5:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:04 +0000 UTC 92.6"
+		case 6:
+			exp = "cpu host=serverA type=idle 1971-01-01 00:00:05 +0000 UTC 95.8"
+		}
+		if exp != got {
+			t.Errorf("unexpected alert data for request: %d\n%s\n%s\n", rc, exp, got)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost()
+	  .endpoint('test')
+	  .header('my', 'header')
 	|httpOut('TestStream_HttpPost')
 `
 
@@ -3384,875 +6031,1017 @@ stream
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, nil)
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URLTemplate = ts.URL
+		c.Endpoint = "test"
+		c.RowTemplate = `{{.Name}} host={{index .Tags "host"}} type={{index .Tags "type"}}{{range .Values}} {{index . "time"}} {{index . "value"}}{{end}}`
+		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
+		tm.HTTPPostService = sl
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
 
 	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
 		t.Errorf("got %v exp %v", rc, 6)
 	}
 }
 
-func TestStream_HttpPost_URL_Template(t *testing.T) {
+func TestStream_HttpPostEndpoint_StatusCodes(t *testing.T) {
+	headers := map[string]string{"my": "header"}
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
+		for k, v := range headers {
+			nv := r.Header.Get(k)
+			if nv != v {
+				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
+			}
 		}
 		atomic.AddInt32(&requestCount, 1)
 		rc := atomic.LoadInt32(&requestCount)
 
-		er := struct {
-			models.Result
-			url string
-		}{}
 		switch rc {
 		case 1:
-			er.Result = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-							97.1,
-						}},
-					},
-				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=a`
+			w.WriteHeader(http.StatusOK)
 		case 2:
-			er.Result = models.Result{
-				Series: models.Rows{
+			w.WriteHeader(http.StatusCreated)
+		case 3:
+			w.WriteHeader(http.StatusNotFound)
+		case 4:
+			w.WriteHeader(http.StatusForbidden)
+		case 5:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 6:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|httpPost()
+	  .endpoint('test')
+	  .header('my', 'header')
+	  .codeField('code')
+	|window()
+		.every(5s)
+		.period(5s)
+	|httpOut('TestStream_HttpPost')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "code", "type", "value"},
+				Values: [][]interface{}{
 					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "b"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-							92.6,
-						}},
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						200.0,
+						"idle",
+						97.1,
 					},
-				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=b`
-		case 3:
-			er.Result = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "b"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-							95.6,
-						}},
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						201.0,
+						"idle",
+						92.6,
 					},
-				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=b`
-		case 4:
-			er.Result = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "c"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-							93.1,
-						}},
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						404.0,
+						"idle",
+						95.6,
 					},
-				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=c`
-		case 5:
-			er.Result = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "c"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-							92.6,
-						}},
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						403.0,
+						"idle",
+						93.1,
 					},
-				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=c`
-		case 6:
-			er.Result = models.Result{
-				Series: models.Rows{
 					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-							95.8,
-						}},
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						500.0,
+						"idle",
+						92.6,
 					},
 				},
-			}
-			er.url = `/cpu/?host=serverA&cpu=a`
-		}
-		if eq, msg := compareResults(er.Result, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
-		}
-		if er.url != r.URL.String() {
-			t.Errorf("enexpected url for data, expected: %s  got: %s", er.url, r.URL.String())
-		}
-	}))
-	defer ts.Close()
+			},
+		},
+	}
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := httppost.Config{}
+		c.URLTemplate = ts.URL
+		c.Endpoint = "test"
+		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
+		tm.HTTPPostService = sl
+	}
+
+	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
+		t.Errorf("got %v exp %v", rc, 6)
+	}
+}
+
+func TestStream_HttpOutPassThrough(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('unused')
+	|httpOut('TestStream_SimpleMR')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_BatchGroupBy(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|window()
+		.period(5s)
+		.every(5s)
+	|groupBy('host')
+	|count('value')
+	|httpOut('TestStream_BatchGroupBy')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					5.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					5.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverC"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					1.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_BatchGroupByAll(t *testing.T) {
 
 	var script = `
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-		.groupBy('host')
-	|httpPost('` + ts.URL + `/{{ .Name }}/?host={{ index .Tags "host"}}&cpu={{ index .Tags "cpu" }}')
-	|httpOut('TestStream_HttpPost_URL_Template')
+	|window()
+		.period(5s)
+		.every(5s)
+	|groupBy(*)
+	|count('value')
+	|httpOut('TestStream_BatchGroupBy')
 `
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					5.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB", "type": "idle"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					5.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverC", "type": "idle"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					1.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_BatchGroupByAllExclude(t *testing.T) {
 
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|window()
+		.period(5s)
+		.every(5s)
+	|groupBy(*)
+		.exclude('host')
+	|count('value')
+	|httpOut('TestStream_BatchGroupBy')
+`
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "type": "idle", "cpu": "a"},
-				Columns: []string{"time", "value"},
+				Tags:    map[string]string{"type": "idle"},
+				Columns: []string{"time", "count"},
 				Values: [][]interface{}{[]interface{}{
 					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					95.8,
+					11.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_HttpPost_URL_Template", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+}
 
-	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
-		t.Errorf("got %v exp %v", rc, 6)
+func TestStream_GroupByAllExclude(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('mock')
+	|groupBy(*)
+		.exclude('s')
+	|window()
+		.period(2s)
+		.every(2s)
+	|count('value')
+	|httpOut('TestStream_GroupByExclude')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "mock",
+				Tags:    map[string]string{"t": "A"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					4.0,
+				}},
+			},
+			{
+				Name:    "mock",
+				Tags:    map[string]string{"t": "B"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					4.0,
+				}},
+			},
+		},
 	}
+
+	testStreamerWithOutput(t, "TestStream_GroupByExclude", script, 5*time.Second, er, true, nil)
 }
 
-func TestStream_HttpPostEndpoint(t *testing.T) {
-	headers := map[string]string{"my": "header"}
-	requestCount := int32(0)
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		for k, v := range headers {
-			nv := r.Header.Get(k)
-			if nv != v {
-				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
-			}
-		}
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
+func TestStream_SimpleWhere(t *testing.T) {
 
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-							97.1,
-						}},
-					},
-				},
-			}
-		case 2:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-							92.6,
-						}},
-					},
-				},
-			}
-		case 3:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-							95.6,
-						}},
-					},
-				},
-			}
-		case 4:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-							93.1,
-						}},
-					},
-				},
-			}
-		case 5:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-							92.6,
-						}},
-					},
-				},
-			}
-		case 6:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-							95.8,
-						}},
-					},
-				},
-			}
-		}
-		if eq, msg := compareResults(er, result); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
-		}
-	}))
-	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.where(lambda: "host" != 'serverB')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|where(lambda: "count" > 0)
+	|where(lambda: "count" < 12)
+	|httpOut('TestStream_SimpleMR')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Where_NoSideEffect(t *testing.T) {
 
 	var script = `
-stream
+var data = stream
 	|from()
 		.measurement('cpu')
 		.where(lambda: "host" == 'serverA')
-		.groupBy('host')
-	|httpPost()
-	  .endpoint('test')
-	  .header('my', 'header')
-	|httpOut('TestStream_HttpPost')
-`
+		.where(lambda: "host" != 'serverB')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|where(lambda: "count" > 0)
+
+// Unused where clause should not side-effect
+data
+	|where(lambda: FALSE)
 
+data
+	|httpOut('TestStream_SimpleMR')
+`
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "type": "idle"},
-				Columns: []string{"time", "value"},
+				Tags:    nil,
+				Columns: []string{"time", "count"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					95.8,
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
 				}},
 			},
 		},
 	}
 
-	tmInit := func(tm *kapacitor.TaskMaster) {
-		c := httppost.Config{}
-		c.URLTemplate = ts.URL
-		c.Endpoint = "test"
-		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
-		tm.HTTPPostService = sl
-	}
-
-	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
-
-	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
-		t.Errorf("got %v exp %v", rc, 6)
-	}
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
 }
-func TestStream_HttpPostEndpoint_CustomBody(t *testing.T) {
-	headers := map[string]string{"my": "header"}
-	requestCount := int32(0)
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		for k, v := range headers {
-			nv := r.Header.Get(k)
-			if nv != v {
-				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
-			}
-		}
-		data, err := io.ReadAll(r.Body)
-		if err != nil {
-			t.Fatal(err)
-		}
-		got := string(data)
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
 
-		var exp string
-		switch rc {
-		case 1:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:00 +0000 UTC 97.1"
-		case 2:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:01 +0000 UTC 92.6"
-		case 3:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:02 +0000 UTC 95.6"
-		case 4:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:03 +0000 UTC 93.1"
-		case 5:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:04 +0000 UTC 92.6"
-		case 6:
-			exp = "cpu host=serverA type=idle 1971-01-01 00:00:05 +0000 UTC 95.8"
-		}
-		if exp != got {
-			t.Errorf("unexpected alert data for request: %d\n%s\n%s\n", rc, exp, got)
-		}
-	}))
-	defer ts.Close()
+func TestStream_VarWhereString(t *testing.T) {
 
 	var script = `
+var serverStr = 'serverA'
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-		.groupBy('host')
-	|httpPost()
-	  .endpoint('test')
-	  .header('my', 'header')
-	|httpOut('TestStream_HttpPost')
+		.where(lambda: "host" == serverStr )
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_SimpleMR')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "type": "idle"},
-				Columns: []string{"time", "value"},
+				Tags:    nil,
+				Columns: []string{"time", "count"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					95.8,
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
 				}},
 			},
 		},
 	}
 
-	tmInit := func(tm *kapacitor.TaskMaster) {
-		c := httppost.Config{}
-		c.URLTemplate = ts.URL
-		c.Endpoint = "test"
-		c.RowTemplate = `{{.Name}} host={{index .Tags "host"}} type={{index .Tags "type"}}{{range .Values}} {{index . "time"}} {{index . "value"}}{{end}}`
-		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
-		tm.HTTPPostService = sl
-	}
-
-	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
-
-	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
-		t.Errorf("got %v exp %v", rc, 6)
-	}
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
 }
 
-func TestStream_HttpPostEndpoint_StatusCodes(t *testing.T) {
-	headers := map[string]string{"my": "header"}
-	requestCount := int32(0)
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		for k, v := range headers {
-			nv := r.Header.Get(k)
-			if nv != v {
-				t.Fatalf("got '%s:%s', exp '%s:%s'", k, nv, k, v)
-			}
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
-
-		switch rc {
-		case 1:
-			w.WriteHeader(http.StatusOK)
-		case 2:
-			w.WriteHeader(http.StatusCreated)
-		case 3:
-			w.WriteHeader(http.StatusNotFound)
-		case 4:
-			w.WriteHeader(http.StatusForbidden)
-		case 5:
-			w.WriteHeader(http.StatusInternalServerError)
-		case 6:
-			w.WriteHeader(http.StatusBadGateway)
-		}
-	}))
-	defer ts.Close()
+func TestStream_VarWhereRegex(t *testing.T) {
 
 	var script = `
+var serverPattern = /^serverA$/
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-		.groupBy('host')
-	|httpPost()
-	  .endpoint('test')
-	  .header('my', 'header')
-	  .codeField('code')
+		.where(lambda: "host" =~ serverPattern )
 	|window()
-		.every(5s)
-		.period(5s)
-	|httpOut('TestStream_HttpPost')
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_SimpleMR')
 `
-
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "code", "type", "value"},
-				Values: [][]interface{}{
-					{
-						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-						200.0,
-						"idle",
-						97.1,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
-						201.0,
-						"idle",
-						92.6,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-						404.0,
-						"idle",
-						95.6,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
-						403.0,
-						"idle",
-						93.1,
-					},
-					{
-						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-						500.0,
-						"idle",
-						92.6,
-					},
-				},
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
 			},
 		},
 	}
 
-	tmInit := func(tm *kapacitor.TaskMaster) {
-		c := httppost.Config{}
-		c.URLTemplate = ts.URL
-		c.Endpoint = "test"
-		sl, _ := httppost.NewService(httppost.Configs{c}, diagService.NewHTTPPostHandler())
-		tm.HTTPPostService = sl
-	}
-
-	testStreamerWithOutput(t, "TestStream_HttpPost", script, 13*time.Second, er, false, tmInit)
-
-	if rc := atomic.LoadInt32(&requestCount); rc != 6 {
-		t.Errorf("got %v exp %v", rc, 6)
-	}
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
 }
 
-func TestStream_HttpOutPassThrough(t *testing.T) {
+func TestStream_GroupBy(t *testing.T) {
 
 	var script = `
 stream
 	|from()
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
+		.measurement('errors')
+		.groupBy('service')
 	|window()
 		.period(10s)
 		.every(10s)
-	|count('value')
-	|httpOut('unused')
-	|httpOut('TestStream_SimpleMR')
+	|sum('value')
+	|httpOut('TestStream_GroupBy')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
+				Name:    "errors",
+				Tags:    map[string]string{"service": "cartA"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					47.0,
+				}},
+			},
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"service": "login"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					45.0,
+				}},
+			},
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"service": "front"},
+				Columns: []string{"time", "sum"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					32.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_GroupBy", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_BatchGroupBy(t *testing.T) {
+func TestStream_GroupByExpr(t *testing.T) {
 
 	var script = `
 stream
 	|from()
-		.measurement('cpu')
+		.measurement('requests')
+	|groupByExpr(lambda: regexReplace(/^([a-z]+)-.*$/, "host", '$1'))
+		.as('region')
 	|window()
-		.period(5s)
-		.every(5s)
-	|groupBy('host')
-	|count('value')
-	|httpOut('TestStream_BatchGroupBy')
+		.period(10s)
+		.every(10s)
+	|sum('value')
+	|httpOut('TestStream_GroupByExpr')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA"},
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					5.0,
-				}},
-			},
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverB"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "us"},
+				Columns: []string{"time", "sum"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					5.0,
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					30.0,
 				}},
 			},
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverC"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "eu"},
+				Columns: []string{"time", "sum"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					1.0,
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					30.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+	testStreamerWithOutput(t, "TestStream_GroupByExpr", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_BatchGroupByAll(t *testing.T) {
+func TestStream_Summary(t *testing.T) {
 
 	var script = `
 stream
 	|from()
-		.measurement('cpu')
+		.measurement('requests')
+		.groupBy('host')
 	|window()
-		.period(5s)
-		.every(5s)
-	|groupBy(*)
-	|count('value')
-	|httpOut('TestStream_BatchGroupBy')
+		.period(10s)
+		.every(10s)
+		.align()
+	|summary('value')
+		.aggregate('mean')
+	|httpOut('TestStream_Summary')
 `
+
+	// The last window is summarized when the task stops.
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "type": "idle"},
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					5.0,
-				}},
-			},
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverB", "type": "idle"},
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					5.0,
-				}},
-			},
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverC", "type": "idle"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "mean"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					1.0,
+					time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC),
+					14.0 / 3.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+	testStreamerWithOutput(t, "TestStream_Summary", script, 23*time.Second, er, false, nil)
 }
 
-func TestStream_BatchGroupByAllExclude(t *testing.T) {
+func TestStream_Rollup(t *testing.T) {
 
 	var script = `
 stream
 	|from()
-		.measurement('cpu')
-	|window()
-		.period(5s)
-		.every(5s)
-	|groupBy(*)
-		.exclude('host')
-	|count('value')
-	|httpOut('TestStream_BatchGroupBy')
+		.measurement('requests')
+		.groupBy('region', 'host')
+	|rollup('value')
+		.drop('host')
+		.as('requests')
+	|httpOut('TestStream_Rollup')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    map[string]string{"type": "idle"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "east"},
+				Columns: []string{"time", "requests"},
 				Values: [][]interface{}{[]interface{}{
 					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-					11.0,
+					15.0,
+				}},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"region": "west"},
+				Columns: []string{"time", "requests"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+					35.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_BatchGroupBy", script, 15*time.Second, er, true, nil)
+	testStreamerWithOutput(t, "TestStream_Rollup", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_GroupByAllExclude(t *testing.T) {
+func TestStream_Rollup_Window(t *testing.T) {
 
 	var script = `
 stream
 	|from()
-		.measurement('mock')
-	|groupBy(*)
-		.exclude('s')
+		.measurement('requests')
+		.groupBy('region', 'host')
 	|window()
 		.period(2s)
 		.every(2s)
-	|count('value')
-	|httpOut('TestStream_GroupByExclude')
+	|sum('value')
+		.as('value')
+	|rollup('value')
+		.drop('host')
+		.as('requests')
+	|httpOut('TestStream_Rollup_Window')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "mock",
-				Tags:    map[string]string{"t": "A"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "east"},
+				Columns: []string{"time", "requests"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-					4.0,
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					15.0,
 				}},
 			},
 			{
-				Name:    "mock",
-				Tags:    map[string]string{"t": "B"},
-				Columns: []string{"time", "count"},
+				Name:    "requests",
+				Tags:    map[string]string{"region": "west"},
+				Columns: []string{"time", "requests"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-					4.0,
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					35.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_GroupByExclude", script, 5*time.Second, er, true, nil)
+	testStreamerWithOutput(t, "TestStream_Rollup_Window", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_SimpleWhere(t *testing.T) {
+func TestStream_Enrich(t *testing.T) {
 
 	var script = `
+var thresholds = stream
+	|from()
+		.measurement('thresholds')
+		.groupBy('service')
+
 stream
 	|from()
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-		.where(lambda: "host" != 'serverB')
+		.measurement('requests')
+		.groupBy('service')
+	|enrich(thresholds)
+		.prefix('threshold_')
+		.defaultField('max', 100.0)
 	|window()
 		.period(10s)
 		.every(10s)
-	|count('value')
-	|where(lambda: "count" > 0)
-	|where(lambda: "count" < 12)
-	|httpOut('TestStream_SimpleMR')
+		.align()
+	|httpOut('TestStream_Enrich')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
-				}},
+				Name:    "requests",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "latency", "threshold_max"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 120.0, 100.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 80.0, 100.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 130.0, 150.0},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 160.0, 150.0},
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 170.0, 200.0},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"service": "web"},
+				Columns: []string{"time", "latency", "threshold_max"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 90.0, 100.0},
+					{time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), 110.0, 100.0},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	// Process the thresholds, at 2s and 7s, after the requests which precede them and before the requests which follow them.
+	steps := []time.Duration{1 * time.Second, 2 * time.Second, 6 * time.Second, 7 * time.Second}
+	testStreamerWithOutputSteps(t, "TestStream_Enrich", script, steps, 13*time.Second, er, true, nil)
 }
 
-func TestStream_Where_NoSideEffect(t *testing.T) {
+func TestStream_LastMarker(t *testing.T) {
+	var mu sync.Mutex
+	var got models.Result
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got.Series = append(got.Series, result.Series...)
+		mu.Unlock()
+	}))
+	defer ts.Close()
 
 	var script = `
-var data = stream
+var deploys = stream
 	|from()
-		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
-		.where(lambda: "host" != 'serverB')
+		.measurement('deploys')
+
+stream
+	|from()
+		.measurement('errors')
+		.groupBy('service')
+	|lastMarker(deploys)
+		.lookback(1h)
+		.on('service')
 	|window()
 		.period(10s)
 		.every(10s)
-	|count('value')
-	|where(lambda: "count" > 0)
+		.align()
+	|httpPost('` + ts.URL + `')
+`
 
-// Unused where clause should not side-effect
-data
-	|where(lambda: FALSE)
+	zero := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	point := func(name string, tags models.Tags, fields models.Fields, s int) edge.PointMessage {
+		return edge.NewPointMessage(name, "dbname", "rpname", models.Dimensions{}, fields, tags, zero.Add(time.Duration(s)*time.Second))
+	}
+	api := models.Tags{"service": "api"}
+	web := models.Tags{"service": "web"}
+	points := []edge.PointMessage{
+		point("deploys", api, models.Fields{"version": "v1"}, 0),
+		point("errors", api, models.Fields{"count": 1.0}, 1),
+		point("errors", api, models.Fields{"count": 2.0}, 2),
+		point("errors", web, models.Fields{"count": 3.0}, 3),
+		point("errors", api, models.Fields{"count": 4.0}, 6),
+		// The marker of the deploy at 5s arrives after the point at 6s,
+		// so that point keeps the previous marker.
+		point("deploys", api, models.Fields{"version": "v2"}, 5),
+		point("errors", api, models.Fields{"count": 5.0}, 7),
+		point("errors", api, models.Fields{"count": 0.0}, 10),
+		point("errors", web, models.Fields{"count": 0.0}, 10),
+	}
+
+	// The replay does not wait for the clock, the points are only spaced out to order the data and the markers.
+	clck := clock.New(zero)
+	clck.Set(zero.Add(time.Minute))
+	pointsC := make(chan edge.PointMessage)
+	tm, _, cleanup := testStreamerWithInputChannel(t, "TestStream_LastMarker", script, pointsC, clck, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	for _, p := range points {
+		pointsC <- p
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(pointsC)
+	cleanup()
 
-data
-	|httpOut('TestStream_SimpleMR')
-`
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
-				}},
+	exp := models.Result{Series: models.Rows{
+		{
+			Name:    "errors",
+			Tags:    map[string]string{"service": "api"},
+			Columns: []string{"time", "count", "marker_service", "marker_since", "marker_version"},
+			Values: [][]interface{}{
+				{zero.Add(time.Second), 1.0, "api", 1.0, "v1"},
+				{zero.Add(2 * time.Second), 2.0, "api", 2.0, "v1"},
+				{zero.Add(6 * time.Second), 4.0, "api", 6.0, "v1"},
+				{zero.Add(7 * time.Second), 5.0, "api", 2.0, "v2"},
+			},
+		},
+		// Markers are matched by service.
+		{
+			Name:    "errors",
+			Tags:    map[string]string{"service": "web"},
+			Columns: []string{"time", "count"},
+			Values: [][]interface{}{
+				{zero.Add(3 * time.Second), 3.0},
 			},
 		},
+	}}
+	mu.Lock()
+	defer mu.Unlock()
+	if eq, msg := compareResultsIgnoreSeriesOrder(exp, got); !eq {
+		t.Error(msg)
 	}
-
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
 }
 
-func TestStream_VarWhereString(t *testing.T) {
+func TestStream_ForecastResidual(t *testing.T) {
+	var mu sync.Mutex
+	var got models.Result
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got.Series = append(got.Series, result.Series...)
+		mu.Unlock()
+	}))
+	defer ts.Close()
 
 	var script = `
-var serverStr = 'serverA'
-stream
+var usage = stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == serverStr )
+		.groupBy('host')
+
+var forecast = usage
 	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
-	|httpOut('TestStream_SimpleMR')
+		.periodCount(4)
+		.everyCount(4)
+	|holtWinters('usage', 4, 0, 1s)
+
+// The data is windowed as the forecast is a batch.
+usage
+	|window()
+		.periodCount(1)
+		.everyCount(1)
+	|forecastResidual(forecast)
+		.field('usage')
+		.tolerance(1s)
+		.size(2)
+	|httpPost('` + ts.URL + `')
 `
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
-				}},
-			},
-		},
+
+	zero := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []struct {
+		s     int
+		usage float64
+	}{{0, 10}, {1, 10}, {2, 10}, {3, 10}, {4, 11}, {5, 12}, {6, 9}, {9, 14}}
+	points := make([]edge.PointMessage, len(values))
+	for i, v := range values {
+		points[i] = edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"usage": v.usage},
+			models.Tags{"host": "serverA"},
+			zero.Add(time.Duration(v.s)*time.Second),
+		)
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	// The replay does not wait for the clock, the points are only spaced out so that
+	// the forecast of the first four points, from 4s to 7s, is computed before the next point.
+	clck := clock.New(zero)
+	clck.Set(zero.Add(time.Minute))
+	pointsC := make(chan edge.PointMessage)
+	tm, _, cleanup := testStreamerWithInputChannel(t, "TestStream_ForecastResidual", script, pointsC, clck, nil, nil, false)
+	defer checkDeferredErrors(t, tm.Close)()
+	for _, p := range points {
+		pointsC <- p
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(pointsC)
+	cleanup()
+
+	row := func(s int, fields models.Fields) *models.Row {
+		columns := []string{"time"}
+		for c := range fields {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns[1:])
+		values := []interface{}{zero.Add(time.Duration(s) * time.Second)}
+		for _, c := range columns[1:] {
+			values = append(values, fields[c])
+		}
+		return &models.Row{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "serverA"},
+			Columns: columns,
+			Values:  [][]interface{}{values},
+		}
+	}
+	exp := models.Result{Series: models.Rows{
+		// The points before the first forecast pass through unchanged.
+		row(0, models.Fields{"usage": 10.0}),
+		row(1, models.Fields{"usage": 10.0}),
+		row(2, models.Fields{"usage": 10.0}),
+		row(3, models.Fields{"usage": 10.0}),
+		// The score is set once two residuals have been seen.
+		row(4, models.Fields{"usage": 11.0, "forecast": 10.0, "residual": 1.0}),
+		row(5, models.Fields{"usage": 12.0, "forecast": 10.0, "residual": 2.0}),
+		row(6, models.Fields{"usage": 9.0, "forecast": 10.0, "residual": -1.0, "score": -5.0}),
+		// The forecast ends at 7s, so the point at 9s is past its tolerance.
+		row(9, models.Fields{"usage": 14.0}),
+	}}
+	mu.Lock()
+	defer mu.Unlock()
+	if eq, msg := compareResults(exp, got); !eq {
+		t.Error(msg)
+	}
 }
 
-func TestStream_VarWhereRegex(t *testing.T) {
+func TestStream_Schema(t *testing.T) {
 
 	var script = `
-var serverPattern = /^serverA$/
-stream
+var validated = stream
 	|from()
-		.measurement('cpu')
-		.where(lambda: "host" =~ serverPattern )
+		.measurement('requests')
+	|schema()
+		.require('value', 'float')
+		.require('status', 'integer')
+
+validated
+	|quarantine()
+	|httpOut('quarantine')
+
+validated
 	|window()
 		.period(10s)
 		.every(10s)
 	|count('value')
-	|httpOut('TestStream_SimpleMR')
+	|httpOut('TestStream_Schema')
 `
+
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "cpu",
+				Name:    "requests",
 				Tags:    nil,
 				Columns: []string{"time", "count"},
 				Values: [][]interface{}{[]interface{}{
 					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					10.0,
+					7.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Schema", script, 13*time.Second, er, false, nil)
 }
 
-func TestStream_GroupBy(t *testing.T) {
+func TestStream_Schema_Quarantine(t *testing.T) {
 
 	var script = `
-stream
+var validated = stream
 	|from()
-		.measurement('errors')
-		.groupBy('service')
+		.measurement('requests')
+	|schema()
+		.require('value', 'float')
+		.require('status', 'integer')
+
+validated
+	|quarantine()
 	|window()
 		.period(10s)
 		.every(10s)
-	|sum('value')
-	|httpOut('TestStream_GroupBy')
+		.align()
+	|httpOut('TestStream_Schema_Quarantine')
+
+validated
+	|httpOut('valid')
 `
 
 	er := models.Result{
 		Series: models.Rows{
 			{
-				Name:    "errors",
-				Tags:    map[string]string{"service": "cartA"},
-				Columns: []string{"time", "sum"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					47.0,
-				}},
-			},
-			{
-				Name:    "errors",
-				Tags:    map[string]string{"service": "login"},
-				Columns: []string{"time", "sum"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					45.0,
-				}},
-			},
-			{
-				Name:    "errors",
-				Tags:    map[string]string{"service": "front"},
-				Columns: []string{"time", "sum"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
-					32.0,
-				}},
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "host", "schema_violation", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"serverA",
+						"field value is string, expected float",
+						200.0,
+						"bad",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						"serverA",
+						"missing field value",
+						200.0,
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						"serverA",
+						"field status is float, expected integer",
+						2.5,
+						4.0,
+					},
+				},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_GroupBy", script, 13*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_Schema_Quarantine", script, 13*time.Second, er, false, nil)
 }
 
 func TestStream_GroupByWhere(t *testing.T) {
@@ -8370,142 +11159,804 @@ func TestStream_Alert_WithReset_1(t *testing.T) {
 					},
 				},
 			}
-		case 13:
+		case 13:
+			expAd = alert.Data{
+				ID:            "kapacitor/cpu/serverA",
+				Message:       "kapacitor/cpu/serverA is WARNING",
+				Details:       "details",
+				Time:          time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+				Duration:      4 * time.Second,
+				Level:         alert.Warning,
+				PreviousLevel: alert.Warning,
+				Recoverable:   true,
+				Data: models.Result{
+					Series: models.Rows{
+						{
+							Name:    "cpu",
+							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Columns: []string{"time", "value"},
+							Values: [][]interface{}{[]interface{}{
+								time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+								50.0,
+							}},
+						},
+					},
+				},
+			}
+		case 14:
+			expAd = alert.Data{
+				ID:            "kapacitor/cpu/serverA",
+				Message:       "kapacitor/cpu/serverA is INFO",
+				Details:       "details",
+				Time:          time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
+				Duration:      5 * time.Second,
+				Level:         alert.Info,
+				PreviousLevel: alert.Warning,
+				Recoverable:   true,
+				Data: models.Result{
+					Series: models.Rows{
+						{
+							Name:    "cpu",
+							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Columns: []string{"time", "value"},
+							Values: [][]interface{}{[]interface{}{
+								time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
+								41.0,
+							}},
+						},
+					},
+				},
+			}
+		case 15:
+			expAd = alert.Data{
+				ID:            "kapacitor/cpu/serverA",
+				Message:       "kapacitor/cpu/serverA is OK",
+				Details:       "details",
+				Time:          time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
+				Duration:      6 * time.Second,
+				Level:         alert.OK,
+				PreviousLevel: alert.Info,
+				Recoverable:   true,
+				Data: models.Result{
+					Series: models.Rows{
+						{
+							Name:    "cpu",
+							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Columns: []string{"time", "value"},
+							Values: [][]interface{}{[]interface{}{
+								time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
+								25.0,
+							}},
+						},
+					},
+				},
+			}
+		}
+
+		if eq, msg := compareAlertData(expAd, ad); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var infoThreshold = 40.0
+var warnThreshold = 60.0
+var critThreshold = 80.0
+
+var infoResetThreshold = 30.0
+var warnResetThreshold = 50.0
+var critResetThreshold = 70.0
+
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.details('details')
+		.idField('id')
+		.idTag('id')
+		.levelField('level')
+		.levelTag('level')
+		.info(lambda: "value" > infoThreshold)
+		.infoReset(lambda: "value" < infoResetThreshold)
+		.warn(lambda: "value" > warnThreshold)
+		.warnReset(lambda: "value" < warnResetThreshold)
+		.crit(lambda: "value" > critThreshold)
+		.critReset(lambda: "value" < critResetThreshold)
+		.post('` + ts.URL + `')
+	|httpOut('TestStream_Alert_WithReset_1')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "level": "OK", "id": "kapacitor/cpu/serverA", "type": "usage"},
+				Columns: []string{"time", "id", "level", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
+					"kapacitor/cpu/serverA",
+					"OK",
+					25.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Alert_WithReset_1", script, 15*time.Second, er, false, nil)
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 15 {
+		t.Errorf("got %v exp %v", rc, 1)
+	}
+}
+
+func TestStream_AlertDuration(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		var expAd alert.Data
+		rc := atomic.LoadInt32(&requestCount)
+		switch rc {
+		case 1:
+			expAd = alert.Data{
+				ID:          "kapacitor/cpu/serverA",
+				Message:     "kapacitor/cpu/serverA is CRITICAL",
+				Details:     "details",
+				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+				Duration:    0,
+				Level:       alert.Critical,
+				Recoverable: true,
+				Data: models.Result{
+					Series: models.Rows{
+						{
+							Name:    "cpu",
+							Tags:    map[string]string{"host": "serverA", "type": "idle"},
+							Columns: []string{"time", "value"},
+							Values: [][]interface{}{[]interface{}{
+								time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+								9.0,
+							}},
+						},
+					},
+				},
+			}
+		case 2:
+			expAd = alert.Data{
+				ID:            "kapacitor/cpu/serverA",
+				Message:       "kapacitor/cpu/serverA is WARNING",
+				Details:       "details",
+				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+				Duration:      2 * time.Second,
+				Level:         alert.Warning,
+				PreviousLevel: alert.Critical,
+				Recoverable:   true,
+				Data: models.Result{
+					Series: models.Rows{
+						{
+							Name:    "cpu",
+							Tags:    map[string]string{"host": "serverA", "type": "idle"},
+							Columns: []string{"time", "value"},
+							Values: [][]interface{}{[]interface{}{
+								time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+								8.0,
+							}},
+						},
+					},
+				},
+			}
+		case 3:
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is WARNING",
+				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
 				Duration:      4 * time.Second,
-				Level:         alert.Warning,
+				Level:         alert.OK,
 				PreviousLevel: alert.Warning,
 				Recoverable:   true,
 				Data: models.Result{
 					Series: models.Rows{
 						{
 							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Tags:    map[string]string{"host": "serverA", "type": "idle"},
 							Columns: []string{"time", "value"},
 							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
-								50.0,
+								time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+								6.0,
 							}},
 						},
 					},
 				},
 			}
-		case 14:
+		case 4:
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is INFO",
+				Message:       "kapacitor/cpu/serverA is WARNING",
 				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
-				Duration:      5 * time.Second,
-				Level:         alert.Info,
-				PreviousLevel: alert.Warning,
+				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+				Duration:      0,
+				Level:         alert.Warning,
+				PreviousLevel: alert.OK,
 				Recoverable:   true,
 				Data: models.Result{
 					Series: models.Rows{
 						{
 							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Tags:    map[string]string{"host": "serverA", "type": "idle"},
 							Columns: []string{"time", "value"},
 							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
-								41.0,
+								time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+								8.0,
 							}},
 						},
 					},
 				},
 			}
-		case 15:
+		case 5:
 			expAd = alert.Data{
 				ID:            "kapacitor/cpu/serverA",
 				Message:       "kapacitor/cpu/serverA is OK",
 				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
-				Duration:      6 * time.Second,
+				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+				Duration:      3 * time.Second,
 				Level:         alert.OK,
-				PreviousLevel: alert.Info,
+				PreviousLevel: alert.Warning,
 				Recoverable:   true,
 				Data: models.Result{
 					Series: models.Rows{
 						{
 							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "usage"},
+							Tags:    map[string]string{"host": "serverA", "type": "idle"},
 							Columns: []string{"time", "value"},
 							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
-								25.0,
+								time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+								3.0,
 							}},
 						},
 					},
 				},
 			}
 		}
+		if eq, msg := compareAlertData(expAd, ad); !eq {
+			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+var warnThreshold = 7.0
+var critThreshold = 8.0
+
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.details('details')
+		.durationField('duration')
+		.warn(lambda: "value" > warnThreshold)
+		.crit(lambda: "value" > critThreshold)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+	|httpOut('TestStream_AlertDuration')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
+				Columns: []string{"time", "duration", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+					float64(3 * time.Second),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_AlertDuration", script, 13*time.Second, er, false, nil)
+
+	if exp, rc := 5, int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("got %v exp %v", rc, exp)
+	}
+}
+
+func TestStream_AlertIncidentHistory(t *testing.T) {
+	expAds := []alert.Data{
+		{
+			Message:   "fired 1 times",
+			Level:     alert.Critical,
+			FireCount: 1,
+		},
+		{
+			Message:   "fired 1 times",
+			Level:     alert.Warning,
+			FireCount: 1,
+		},
+		{
+			Message: "fired 1 times, last for 4s",
+			Level:   alert.OK,
+			Incidents: []alert.Incident{
+				{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Duration: 4 * time.Second, Level: alert.Critical},
+			},
+			FireCount: 1,
+		},
+		{
+			Message: "fired 2 times, last for 4s",
+			Level:   alert.Warning,
+			Incidents: []alert.Incident{
+				{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Duration: 4 * time.Second, Level: alert.Critical},
+			},
+			FireCount: 2,
+		},
+		{
+			Message: "fired 2 times, last for 3s",
+			Level:   alert.OK,
+			Incidents: []alert.Incident{
+				{Time: time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), Duration: 3 * time.Second, Level: alert.Warning},
+			},
+			FireCount: 2,
+		},
+	}
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := atomic.AddInt32(&requestCount, 1)
+		if int(rc) > len(expAds) {
+			t.Errorf("unexpected request %d", rc)
+			return
+		}
+		exp := expAds[rc-1]
+		got := alert.Data{
+			Message:   ad.Message,
+			Level:     ad.Level,
+			Incidents: ad.Incidents,
+			FireCount: ad.FireCount,
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("unexpected alert data for request: %d\ngot %v\nexp %v", rc, got, exp)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.message('fired {{ .FireCount }} times{{ with .Incidents }}, last for {{ (index . 0).Duration }}{{ end }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.incidentHistory(1)
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertIncidentHistory", script, 13*time.Second, nil)
+
+	if exp, rc := len(expAds), int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("got %v exp %v", rc, exp)
+	}
+}
+
+func TestStream_AlertEscalateReminders(t *testing.T) {
+	testStreamAlertEscalate(t, "TestStream_AlertEscalateReminders", `
+		.stateChangesOnly(2s)
+		.escalateReminders(2)`,
+		[]alert.Data{
+			{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Warning},
+			{Time: time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), Level: alert.Warning},
+			{Time: time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), Level: alert.Warning},
+			{Time: time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 9, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), Level: alert.OK},
+		},
+	)
+}
+
+func TestStream_AlertEscalateAfter(t *testing.T) {
+	testStreamAlertEscalate(t, "TestStream_AlertEscalateAfter", `
+		.stateChangesOnly()
+		.escalateAfter(5s)`,
+		[]alert.Data{
+			{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Warning},
+			{Time: time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), Level: alert.OK},
+		},
+	)
+}
+
+func TestStream_AlertRecoverAfter(t *testing.T) {
+	testStreamAlertEscalate(t, "TestStream_AlertRecoverAfter", `
+		.stateChangesOnly()
+		.recoverAfter(3s)`,
+		[]alert.Data{
+			{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), Level: alert.OK},
+			{Time: time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), Level: alert.OK},
+		},
+	)
+}
+
+func TestStream_AlertRecoverAfterCount(t *testing.T) {
+	testStreamAlertEscalate(t, "TestStream_AlertRecoverAfterCount", `
+		.stateChangesOnly()
+		.recoverAfterCount(3)`,
+		[]alert.Data{
+			{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), Level: alert.OK},
+			{Time: time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC), Level: alert.Critical},
+			{Time: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), Level: alert.OK},
+		},
+	)
+}
+
+func testStreamAlertEscalate(t *testing.T, name, properties string, exp []alert.Data) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got = append(got, alert.Data{Time: ad.Time, Level: ad.Level})
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)` + properties + `
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, name, script, 13*time.Second, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_AlertPriority(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got = append(got, alert.Data{Message: ad.Message, Level: ad.Level, Priority: ad.Priority})
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.priority(lambda: if("level" == 'CRITICAL', 10, 5) + if("tier" == 'gold', 5, 0))
+		.message('{{ .Level }} priority {{ .Priority }}')
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertPriority", script, 5*time.Second, nil)
+
+	exp := []alert.Data{
+		{Message: "WARNING priority 10", Level: alert.Warning, Priority: 10},
+		{Message: "CRITICAL priority 15", Level: alert.Critical, Priority: 15},
+		{Message: "OK priority 10", Level: alert.OK, Priority: 10},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_AlertSparkline(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got = append(got, alert.Data{Message: ad.Message, Level: ad.Level, Sparkline: ad.Sparkline})
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.message('{{ .Level }} {{ .Sparkline }}')
+		.sparkline('value', 4)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_AlertSparkline", script, 10*time.Second, nil)
+
+	exp := []alert.Data{
+		{Message: "WARNING ▁▂▂█", Level: alert.Warning, Sparkline: "▁▂▂█"},
+		{Message: "CRITICAL ▁▁▅█", Level: alert.Critical, Sparkline: "▁▁▅█"},
+		{Message: "OK ▁▅█▁", Level: alert.OK, Sparkline: "▁▅█▁"},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+type testDigestCollector struct {
+	mu     sync.Mutex
+	events []alert.Event
+}
+
+func (c *testDigestCollector) Collect(e alert.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func TestStream_AlertDigest(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got = append(got, alert.Data{Time: ad.Time, Level: ad.Level})
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.stateChangesOnly()
+		.digest()
+		.post('` + ts.URL + `')
+`
+
+	collector := new(testDigestCollector)
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		d, err := kapacitor.NewAlertDigester(kapacitor.AlertDigesterConfig{
+			Interval: time.Hour,
+			Topic:    "digest",
+		}, clock.New(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)), collector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm.AlertDigester = d
+	}
+	testStreamerNoOutput(t, "TestStream_AlertDigest", script, 5*time.Second, tmInit)
 
-		if eq, msg := compareAlertData(expAd, ad); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+	// The critical event and its recovery bypass the digest.
+	exp := []alert.Data{
+		{Time: time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), Level: alert.Critical},
+		{Time: time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), Level: alert.OK},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+
+	// The warning is sent in the digest when the task master closes.
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.events) != 1 {
+		t.Fatalf("expected a single digest, got %d", len(collector.events))
+	}
+	digest := collector.events[0]
+	if exp := "1 alerts in the last 1h0m0s"; digest.State.Message != exp {
+		t.Errorf("unexpected digest message: got %q exp %q", digest.State.Message, exp)
+	}
+	if exp := "1971-01-01T00:00:00Z WARNING cpu:host=serverA: cpu:host=serverA is WARNING\n"; digest.State.Details != exp {
+		t.Errorf("unexpected digest details: got %q exp %q", digest.State.Details, exp)
+	}
+}
+
+func TestStream_AlertDigest_Replay(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
 		}
+		mu.Lock()
+		got = append(got, alert.Data{Time: ad.Time, Level: ad.Level})
+		mu.Unlock()
 	}))
 	defer ts.Close()
 
 	var script = `
-var infoThreshold = 40.0
-var warnThreshold = 60.0
-var critThreshold = 80.0
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.stateChangesOnly()
+		.digest()
+		.post('` + ts.URL + `')
+`
 
-var infoResetThreshold = 30.0
-var warnResetThreshold = 50.0
-var critResetThreshold = 70.0
+	replayScript := `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.digest()
+`
+
+	collector := new(testDigestCollector)
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		d, err := kapacitor.NewAlertDigester(kapacitor.AlertDigesterConfig{
+			Interval: time.Hour,
+			Topic:    "digest",
+		}, clock.New(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)), collector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm.AlertDigester = d
+		// Closing the task master of the replay must not close the digester of the task.
+		testReplay(t, tm, "replay", replayScript, edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": 15.0},
+			models.Tags{"host": "serverA"},
+			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+		))
+	}
+	testStreamerNoOutput(t, "TestStream_AlertDigest_Replay", script, 5*time.Second, tmInit)
+
+	// The critical event and its recovery bypass the digest.
+	exp := []alert.Data{
+		{Time: time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), Level: alert.Critical},
+		{Time: time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), Level: alert.OK},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+
+	// The warning is sent in the digest when the task master closes.
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.events) != 1 {
+		t.Fatalf("expected a single digest, got %d", len(collector.events))
+	}
+	digest := collector.events[0]
+	if exp := "1 alerts in the last 1h0m0s"; digest.State.Message != exp {
+		t.Errorf("unexpected digest message: got %q exp %q", digest.State.Message, exp)
+	}
+	if exp := "1971-01-01T00:00:00Z WARNING cpu:host=serverA: cpu:host=serverA is WARNING\n"; digest.State.Details != exp {
+		t.Errorf("unexpected digest details: got %q exp %q", digest.State.Details, exp)
+	}
+}
+
+func TestStream_AlertDigest_Topic(t *testing.T) {
+	var posted int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posted, 1)
+	}))
+	defer ts.Close()
 
+	var script = `
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
 	|alert()
-		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
-		.details('details')
-		.idField('id')
-		.idTag('id')
-		.levelField('level')
-		.levelTag('level')
-		.info(lambda: "value" > infoThreshold)
-		.infoReset(lambda: "value" < infoResetThreshold)
-		.warn(lambda: "value" > warnThreshold)
-		.warnReset(lambda: "value" < warnResetThreshold)
-		.crit(lambda: "value" > critThreshold)
-		.critReset(lambda: "value" < critResetThreshold)
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.digest()
+		.topic('TestStream_AlertDigest_Topic')
 		.post('` + ts.URL + `')
-	|httpOut('TestStream_Alert_WithReset_1')
 `
 
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "level": "OK", "id": "kapacitor/cpu/serverA", "type": "usage"},
-				Columns: []string{"time", "id", "level", "value"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC),
-					"kapacitor/cpu/serverA",
-					"OK",
-					25.0,
-				}},
-			},
-		},
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		d, err := kapacitor.NewAlertDigester(kapacitor.AlertDigesterConfig{
+			Interval: time.Hour,
+			Topic:    "digest",
+		}, clock.New(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)), new(testDigestCollector))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm.AlertDigester = d
+	}
+	clck, et, replayErr, tm := testStreamer(t, "TestStream_AlertDigest_Topic", script, tmInit)
+	defer checkDeferredErrors(t, tm.Close)()
+	if err := fastForwardTask(clck, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
 	}
 
-	testStreamerWithOutput(t, "TestStream_Alert_WithReset_1", script, 15*time.Second, er, false, nil)
-
-	if rc := atomic.LoadInt32(&requestCount); rc != 15 {
-		t.Errorf("got %v exp %v", rc, 1)
+	// The digested warning is recorded in the topic of the alert, without being sent to the handlers.
+	if n := atomic.LoadInt32(&posted); n != 0 {
+		t.Errorf("unexpected posted alerts: got %d exp 0", n)
+	}
+	state, ok, err := tm.AlertService.EventState("TestStream_AlertDigest_Topic", "cpu:host=serverA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || state.Level != alert.Warning {
+		t.Errorf("unexpected event state: got %v %v exp WARNING", ok, state.Level)
 	}
 }
 
-func TestStream_AlertDuration(t *testing.T) {
-	requestCount := int32(0)
+func TestStream_AlertRateLimit_Replay(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ad := alert.Data{}
 		dec := json.NewDecoder(r.Body)
@@ -8513,175 +11964,112 @@ func TestStream_AlertDuration(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		atomic.AddInt32(&requestCount, 1)
-		var expAd alert.Data
-		rc := atomic.LoadInt32(&requestCount)
-		switch rc {
-		case 1:
-			expAd = alert.Data{
-				ID:          "kapacitor/cpu/serverA",
-				Message:     "kapacitor/cpu/serverA is CRITICAL",
-				Details:     "details",
-				Time:        time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-				Duration:    0,
-				Level:       alert.Critical,
-				Recoverable: true,
-				Data: models.Result{
-					Series: models.Rows{
-						{
-							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "idle"},
-							Columns: []string{"time", "value"},
-							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-								9.0,
-							}},
-						},
-					},
-				},
-			}
-		case 2:
-			expAd = alert.Data{
-				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is WARNING",
-				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-				Duration:      2 * time.Second,
-				Level:         alert.Warning,
-				PreviousLevel: alert.Critical,
-				Recoverable:   true,
-				Data: models.Result{
-					Series: models.Rows{
-						{
-							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "idle"},
-							Columns: []string{"time", "value"},
-							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
-								8.0,
-							}},
-						},
-					},
-				},
-			}
-		case 3:
-			expAd = alert.Data{
-				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is OK",
-				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-				Duration:      4 * time.Second,
-				Level:         alert.OK,
-				PreviousLevel: alert.Warning,
-				Recoverable:   true,
-				Data: models.Result{
-					Series: models.Rows{
-						{
-							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "idle"},
-							Columns: []string{"time", "value"},
-							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
-								6.0,
-							}},
-						},
-					},
-				},
-			}
-		case 4:
-			expAd = alert.Data{
-				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is WARNING",
-				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-				Duration:      0,
-				Level:         alert.Warning,
-				PreviousLevel: alert.OK,
-				Recoverable:   true,
-				Data: models.Result{
-					Series: models.Rows{
-						{
-							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "idle"},
-							Columns: []string{"time", "value"},
-							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
-								8.0,
-							}},
-						},
-					},
-				},
-			}
-		case 5:
-			expAd = alert.Data{
-				ID:            "kapacitor/cpu/serverA",
-				Message:       "kapacitor/cpu/serverA is OK",
-				Details:       "details",
-				Time:          time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-				Duration:      3 * time.Second,
-				Level:         alert.OK,
-				PreviousLevel: alert.Warning,
-				Recoverable:   true,
-				Data: models.Result{
-					Series: models.Rows{
-						{
-							Name:    "cpu",
-							Tags:    map[string]string{"host": "serverA", "type": "idle"},
-							Columns: []string{"time", "value"},
-							Values: [][]interface{}{[]interface{}{
-								time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-								3.0,
-							}},
-						},
-					},
-				},
-			}
+		mu.Lock()
+		got = append(got, alert.Data{Time: ad.Time, Level: ad.Level})
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 10)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	replayScript := `
+stream
+	|from()
+		.measurement('cpu')
+	|alert()
+		.warn(lambda: "value" > 10)
+`
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		rl, err := kapacitor.NewAlertRateLimiter(kapacitor.AlertRateLimiterConfig{
+			Limit:    1,
+			Interval: time.Hour,
+		}, clock.Wall(), nil)
+		if err != nil {
+			t.Fatal(err)
 		}
-		if eq, msg := compareAlertData(expAd, ad); !eq {
-			t.Errorf("unexpected alert data for request: %d %s", rc, msg)
+		tm.AlertRateLimiter = rl
+		// The alert of the replay must not spend the budget of the task.
+		testReplay(t, tm, "replay", replayScript, edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": 15.0},
+			models.Tags{"host": "serverA"},
+			time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+		))
+	}
+	testStreamerNoOutput(t, "TestStream_AlertRateLimit_Replay", script, 2*time.Second, tmInit)
+
+	exp := []alert.Data{
+		{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Warning},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_AlertJitter(t *testing.T) {
+	var mu sync.Mutex
+	var got []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
 		}
+		mu.Lock()
+		got = append(got, alert.Data{Time: ad.Time, Level: ad.Level})
+		mu.Unlock()
 	}))
 	defer ts.Close()
 
 	var script = `
-var warnThreshold = 7.0
-var critThreshold = 8.0
-
 stream
 	|from()
 		.measurement('cpu')
-		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
 	|alert()
-		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
-		.details('details')
-		.durationField('duration')
-		.warn(lambda: "value" > warnThreshold)
-		.crit(lambda: "value" > critThreshold)
-		.stateChangesOnly()
+		.warn(lambda: "value" > 10)
+		.crit(lambda: "value" > 20)
+		.jitter(10ms)
 		.post('` + ts.URL + `')
-	|httpOut('TestStream_AlertDuration')
 `
 
-	er := models.Result{
-		Series: models.Rows{
-			{
-				Name:    "cpu",
-				Tags:    map[string]string{"host": "serverA", "type": "idle"},
-				Columns: []string{"time", "duration", "value"},
-				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
-					float64(3 * time.Second),
-					3.0,
-				}},
-			},
-		},
+	var sources int32
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.AlertJitterSource = func() rand.Source {
+			atomic.AddInt32(&sources, 1)
+			return rand.NewSource(42)
+		}
 	}
+	testStreamerNoOutput(t, "TestStream_AlertJitter", script, 3*time.Second, tmInit)
 
-	testStreamerWithOutput(t, "TestStream_AlertDuration", script, 13*time.Second, er, false, nil)
-
-	if exp, rc := 5, int(atomic.LoadInt32(&requestCount)); rc != exp {
-		t.Errorf("got %v exp %v", rc, exp)
+	if n := atomic.LoadInt32(&sources); n != 1 {
+		t.Errorf("expected a single jitter source, got %d", n)
+	}
+	// The delayed events are still dispatched in order.
+	exp := []alert.Data{
+		{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Level: alert.Warning},
+		{Time: time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), Level: alert.Critical},
+		{Time: time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), Level: alert.OK},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", got, exp)
 	}
 }
 
@@ -8841,6 +12229,80 @@ stream
 	}
 }
 
+func TestStream_AlertSlackTokenRef(t *testing.T) {
+	ts := slacktest.NewServer()
+	defer ts.Close()
+
+	t.Setenv("KAPACITOR_SECRET_TEST_SLACK_TOKEN", "env_secret_token")
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.slack()
+		.channel('#alerts')
+		.tokenRef('KAPACITOR_SECRET_TEST_SLACK_TOKEN')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := slack.NewConfig()
+		c.Default = true
+		c.Enabled = true
+		c.URL = ts.URL + "/test/slack/url"
+		c.Token = "config_token"
+		c.Channel = "#channel"
+		d := diagService.NewSlackHandler().WithContext(keyvalue.KV("test", "slack"))
+		sl, err := slack.NewService([]slack.Config{c}, d)
+		if err != nil {
+			t.Error(err)
+		}
+		tm.SlackService = sl
+		tm.SecretsService = secrets.NewService(secrets.NewConfig())
+	}
+	testStreamerNoOutput(t, "TestStream_Alert", script, 13*time.Second, tmInit)
+
+	exp := []interface{}{
+		slacktest.Request{
+			URL:        "/test/slack/url",
+			AuthHeader: "Bearer env_secret_token",
+			PostData: slacktest.PostData{
+				Channel:  "#alerts",
+				Username: "kapacitor",
+				Text:     "",
+				Attachments: []slacktest.Attachment{
+					{
+						Fallback:  "kapacitor/cpu/serverA is CRITICAL",
+						Color:     "danger",
+						Text:      "kapacitor/cpu/serverA is CRITICAL",
+						Mrkdwn_in: []string{"text"},
+					},
+				},
+			},
+		},
+	}
+
+	ts.Close()
+	var got []interface{}
+	for _, g := range ts.Requests() {
+		got = append(got, g)
+	}
+
+	if err := compareListIgnoreOrder(got, exp, nil); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestStream_AlertKafka(t *testing.T) {
 	ts, err := kafkatest.NewServer()
 	if err != nil {
@@ -12409,6 +15871,78 @@ stream
 	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
 }
 
+func TestStream_Sideload_CSV(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('m')
+		.groupBy('t0', 't1', 't2')
+	|sideload()
+		.source('file://%s/testdata/sideload')
+		.order('thresholds.csv/{{.t0}}')
+		.field('threshold_crit', 2.0)
+	|eval(lambda: "value" > "threshold_crit")
+		.as('crit')
+		.keep()
+	|httpOut('TestStream_Sideload')
+`, wd)
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "a", "t1": "m", "t2": "x"},
+				Columns: []string{"time", "crit", "threshold_crit", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						false,
+						2.0,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "b", "t1": "n", "t2": "y"},
+				Columns: []string{"time", "crit", "threshold_crit", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						true,
+						0.5,
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "m",
+				Tags:    map[string]string{"t0": "c", "t1": "o", "t2": "y"},
+				Columns: []string{"time", "crit", "threshold_crit", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						false,
+						2.0,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.SideloadService = sideload.NewService(diagService.NewSideloadHandler())
+	}
+
+	testStreamerWithOutput(t, "TestStream_Sideload", script, 1*time.Second, er, true, tmInit)
+}
+
 func TestStream_Sideload_Multiple(t *testing.T) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -13208,6 +16742,46 @@ stream
 	testStreamerCardinality(t, "TestStream_Cardinality", script, es, nil)
 }
 
+func TestStream_GroupByExprCardinality(t *testing.T) {
+
+	var script = `
+stream
+    |from()
+        .measurement('cpu')
+    |groupByExpr(lambda: "cpu")
+        .as('core')
+        .maxGroups(5)
+`
+
+	// Expected Stats
+	es := map[string]map[string]interface{}{
+		"stream0": map[string]interface{}{
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(0),
+			"working_cardinality": int64(0),
+			"collected":           int64(90),
+			"emitted":             int64(90),
+		},
+		"from1": map[string]interface{}{
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(0),
+			"working_cardinality": int64(0),
+			"collected":           int64(90),
+			"emitted":             int64(90),
+		},
+		"groupby_expr2": map[string]interface{}{
+			"emitted":             int64(0),
+			"working_cardinality": int64(5),
+			"avg_exec_time_ns":    int64(0),
+			"errors":              int64(40),
+			"collected":           int64(90),
+			"group_limit_drops":   int64(40),
+		},
+	}
+
+	testStreamerCardinality(t, "TestStream_Cardinality", script, es, nil)
+}
+
 func TestStream_AlertCardinality(t *testing.T) {
 
 	var script = `
@@ -13529,6 +17103,60 @@ stream
 	testStreamerCardinality(t, "TestStream_Cardinality", script, es, nil)
 }
 
+func TestStream_ExecutionGraph(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ExecutionGraph')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_ExecutionGraph", script, nil)
+	defer checkDeferredErrors(t, tm.Close)()
+
+	err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, ok := tm.ExecutionGraph("TestStream_ExecutionGraph")
+	if !ok {
+		t.Fatal("expected the task to be executing")
+	}
+
+	type nodeCounts struct {
+		Name, Type         string
+		Collected, Emitted interface{}
+	}
+	var nodes []nodeCounts
+	for _, n := range graph.Nodes {
+		nodes = append(nodes, nodeCounts{
+			Name:      n.Name,
+			Type:      n.Type,
+			Collected: n.Stats["collected"],
+			Emitted:   n.Stats["emitted"],
+		})
+	}
+	expNodes := []nodeCounts{
+		{Name: "stream0", Type: "stream", Collected: int64(11), Emitted: int64(11)},
+		{Name: "from1", Type: "from", Collected: int64(11), Emitted: int64(11)},
+		{Name: "window2", Type: "window", Collected: int64(11), Emitted: int64(1)},
+		{Name: "http_out3", Type: "http_out", Collected: int64(1), Emitted: int64(0)},
+	}
+	if !reflect.DeepEqual(nodes, expNodes) {
+		t.Errorf("unexpected nodes:\ngot %v\nexp %v", nodes, expNodes)
+	}
+	expEdges := []kapacitor.ExecutionGraphEdge{
+		{Parent: "stream0", Child: "from1", Processed: 11},
+		{Parent: "from1", Child: "window2", Processed: 11},
+		{Parent: "window2", Child: "http_out3", Processed: 1},
+	}
+	if !reflect.DeepEqual(graph.Edges, expEdges) {
+		t.Errorf("unexpected edges:\ngot %v\nexp %v", graph.Edges, expEdges)
+	}
+}
+
 func testStreamerCardinality(
 	t *testing.T,
 	name, script string,
@@ -13954,6 +17582,27 @@ func testStreamerWithInputChannel(
 	return tm, store, cleanup
 }
 
+// Runs a stream task with the points in a child task master of tm, the same way the replay service does.
+func testReplay(t *testing.T, tm *kapacitor.TaskMaster, name, script string, points ...edge.PointMessage) {
+	t.Helper()
+	child := tm.New(name)
+	// The test store belongs to tm.
+	child.TestCloser = nil
+	if err := child.Open(); err != nil {
+		t.Fatal(err)
+	}
+	pointsC := make(chan edge.PointMessage, len(points))
+	for _, p := range points {
+		pointsC <- p
+	}
+	close(pointsC)
+	_, _, cleanup := testStreamerWithInputChannel(t, name, script, pointsC, clock.Wall(), child, nil, false)
+	cleanup()
+	if err := child.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func testStreamerNoOutput(
 	t *testing.T,
 	name,
@@ -13970,6 +17619,26 @@ func testStreamerNoOutput(
 	}
 }
 
+// testStreamerWithCapture replays the test data through the task with its alert handlers
+// replaced by capturing stubs, and returns the events that would have been sent to the handlers and topics.
+func testStreamerWithCapture(
+	t *testing.T,
+	name,
+	script string,
+	duration time.Duration,
+	tmInit func(tm *kapacitor.TaskMaster),
+) []kapacitor.CapturedAlert {
+	t.Helper()
+	capture := kapacitor.NewAlertCapture()
+	testStreamerNoOutput(t, name, script, duration, func(tm *kapacitor.TaskMaster) {
+		tm.AlertCapture = capture
+		if tmInit != nil {
+			tmInit(tm)
+		}
+	})
+	return capture.Alerts()
+}
+
 func testStreamerWithOutput(
 	t *testing.T,
 	name,
@@ -13978,11 +17647,31 @@ func testStreamerWithOutput(
 	er models.Result,
 	ignoreOrder bool,
 	tmInit func(tm *kapacitor.TaskMaster),
+) {
+	t.Helper()
+	testStreamerWithOutputSteps(t, name, script, nil, duration, er, ignoreOrder, tmInit)
+}
+
+// Same as testStreamerWithOutput, but moves time forward to each step first, giving the task time to process the data up to the step.
+// This orders the data of nodes with several parents, such as the data of the other parent before the points which follow it.
+func testStreamerWithOutputSteps(
+	t *testing.T,
+	name,
+	script string,
+	steps []time.Duration,
+	duration time.Duration,
+	er models.Result,
+	ignoreOrder bool,
+	tmInit func(tm *kapacitor.TaskMaster),
 ) {
 	t.Helper()
 	clock, et, replayErr, tm := testStreamer(t, name, script, tmInit)
 	defer checkDeferredErrors(t, tm.Close)()
 
+	for _, s := range steps {
+		clock.Set(clock.Zero().Add(s))
+		time.Sleep(10 * time.Millisecond)
+	}
 	err := fastForwardTask(clock, et, replayErr, tm, duration)
 	if err != nil {
 		t.Error(err)
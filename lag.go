@@ -0,0 +1,160 @@
+package kapacitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// The reference of the monotonic clock used to record ingestion times.
+// Durations since it are computed from the monotonic clock reading of time.Now,
+// so they are not affected by changes to the wall clock.
+var monotonicEpoch = time.Now()
+
+// monotonicNow returns the nanoseconds elapsed on the monotonic clock since monotonicEpoch.
+func monotonicNow() int64 {
+	return int64(time.Since(monotonicEpoch))
+}
+
+type StampNode struct {
+	node
+	s *pipeline.StampNode
+
+	// The ingestion time of the current batch.
+	batchStamp int64
+}
+
+// Create a new StampNode which records the ingestion time of points.
+func newStampNode(et *ExecutingTask, n *pipeline.StampNode, d NodeDiagnostic) (*StampNode, error) {
+	sn := &StampNode{
+		node: node{Node: n, et: et, diag: d},
+		s:    n,
+	}
+	sn.node.runF = sn.runStamp
+	return sn, nil
+}
+
+func (n *StampNode) runStamp([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *StampNode) stamp(fields models.Fields, t int64) models.Fields {
+	fields = fields.Copy()
+	fields[n.s.As] = t
+	return fields
+}
+
+// The points of a batch are ingested together, so they all have the time the batch began.
+func (n *StampNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchStamp = monotonicNow()
+	return begin, nil
+}
+
+func (n *StampNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	bp.SetFields(n.stamp(bp.Fields(), n.batchStamp))
+	return bp, nil
+}
+
+func (n *StampNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *StampNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	p.SetFields(n.stamp(p.Fields(), monotonicNow()))
+	return p, nil
+}
+
+func (n *StampNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *StampNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *StampNode) Done() {}
+
+type LagNode struct {
+	node
+	l *pipeline.LagNode
+}
+
+// Create a new LagNode which computes the time elapsed since points were ingested.
+func newLagNode(et *ExecutingTask, n *pipeline.LagNode, d NodeDiagnostic) (*LagNode, error) {
+	ln := &LagNode{
+		node: node{Node: n, et: et, diag: d},
+		l:    n,
+	}
+	ln.node.runF = ln.runLag
+	return ln, nil
+}
+
+func (n *LagNode) runLag([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// lag returns the fields with the lag added, or the fields unchanged
+// if they do not have an ingestion time.
+func (n *LagNode) lag(fields models.Fields) models.Fields {
+	v, ok := fields[n.l.Stamp]
+	if !ok {
+		return fields
+	}
+	stamp, ok := v.(int64)
+	if !ok {
+		n.diag.Error("cannot compute lag",
+			fmt.Errorf("invalid ingestion time field type %T", v),
+			keyvalue.KV("field", n.l.Stamp),
+		)
+		return fields
+	}
+	fields = fields.Copy()
+	fields[n.l.As] = float64(monotonicNow()-stamp) / float64(n.l.Unit)
+	return fields
+}
+
+func (n *LagNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *LagNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	bp.SetFields(n.lag(bp.Fields()))
+	return bp, nil
+}
+
+func (n *LagNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *LagNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	p.SetFields(n.lag(p.Fields()))
+	return p, nil
+}
+
+func (n *LagNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *LagNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *LagNode) Done() {}
@@ -0,0 +1,158 @@
+package kapacitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	lastMarkerDataSrc   = 0
+	lastMarkerMarkerSrc = 1
+)
+
+type LastMarkerNode struct {
+	node
+	m *pipeline.LastMarkerNode
+
+	// The recent markers in time order, per value of the on tags.
+	markers map[models.GroupID][]marker
+}
+
+type marker struct {
+	time time.Time
+	// The prefixed fields and tags of the marker.
+	fields models.Fields
+}
+
+// Create a new LastMarkerNode which annotates data with the most recent event marker.
+func newLastMarkerNode(et *ExecutingTask, n *pipeline.LastMarkerNode, d NodeDiagnostic) (*LastMarkerNode, error) {
+	mn := &LastMarkerNode{
+		node:    node{Node: n, et: et, diag: d},
+		m:       n,
+		markers: make(map[models.GroupID][]marker),
+	}
+	mn.node.runF = mn.runLastMarker
+	return mn, nil
+}
+
+func (n *LastMarkerNode) runLastMarker([]byte) error {
+	consumer := edge.NewMultiConsumerWithStats(n.ins, n)
+	return consumer.Consume()
+}
+
+func (n *LastMarkerNode) BufferedBatch(src int, batch edge.BufferedBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == lastMarkerMarkerSrc {
+		for _, bp := range batch.Points() {
+			n.add(bp.Time(), bp.Fields(), bp.Tags())
+		}
+		return nil
+	}
+
+	batch = batch.ShallowCopy()
+	points := make([]edge.BatchPointMessage, len(batch.Points()))
+	for i, bp := range batch.Points() {
+		if fields, ok := n.annotate(bp.Time(), bp.Fields(), bp.Tags()); ok {
+			bp = bp.ShallowCopy()
+			bp.SetFields(fields)
+		}
+		points[i] = bp
+	}
+	batch.SetPoints(points)
+	return edge.Forward(n.outs, batch)
+}
+
+func (n *LastMarkerNode) Point(src int, p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if src == lastMarkerMarkerSrc {
+		n.add(p.Time(), p.Fields(), p.Tags())
+		return nil
+	}
+
+	if fields, ok := n.annotate(p.Time(), p.Fields(), p.Tags()); ok {
+		p = p.ShallowCopy()
+		p.SetFields(fields)
+	}
+	return edge.Forward(n.outs, p)
+}
+
+// key returns the key of the markers matching the tags.
+func (n *LastMarkerNode) key(tags models.Tags) models.GroupID {
+	if len(n.m.OnTags) == 0 {
+		return models.NilGroup
+	}
+	on := make(models.Tags, len(n.m.OnTags))
+	for _, t := range n.m.OnTags {
+		on[t] = tags[t]
+	}
+	return models.ToGroupID("", on, models.Dimensions{TagNames: n.m.OnTags})
+}
+
+// add adds a marker at time t, and removes the markers that are outside of the lookback from the latest marker.
+func (n *LastMarkerNode) add(t time.Time, fields models.Fields, tags models.Tags) {
+	prefixed := make(models.Fields, len(fields)+len(tags))
+	for k, v := range tags {
+		prefixed[n.m.Prefix+k] = v
+	}
+	for k, v := range fields {
+		prefixed[n.m.Prefix+k] = v
+	}
+
+	key := n.key(tags)
+	markers := n.markers[key]
+	i := sort.Search(len(markers), func(i int) bool { return markers[i].time.After(t) })
+	markers = append(markers, marker{})
+	copy(markers[i+1:], markers[i:])
+	markers[i] = marker{time: t, fields: prefixed}
+
+	oldest := markers[len(markers)-1].time.Add(-n.m.Lookback)
+	expired := sort.Search(len(markers), func(i int) bool { return !markers[i].time.Before(oldest) })
+	n.markers[key] = markers[expired:]
+}
+
+// annotate returns a copy of fields with the most recent marker before time t within the lookback,
+// and whether there is such a marker.
+func (n *LastMarkerNode) annotate(t time.Time, fields models.Fields, tags models.Tags) (models.Fields, bool) {
+	markers := n.markers[n.key(tags)]
+	i := sort.Search(len(markers), func(i int) bool { return markers[i].time.After(t) })
+	if i == 0 {
+		return nil, false
+	}
+	m := markers[i-1]
+	since := t.Sub(m.time)
+	if since > n.m.Lookback {
+		return nil, false
+	}
+	annotated := fields.Copy()
+	for k, v := range m.fields {
+		annotated[k] = v
+	}
+	annotated[n.m.SinceAs] = float64(since) / float64(n.m.Unit)
+	return annotated, true
+}
+
+func (n *LastMarkerNode) Barrier(src int, b edge.BarrierMessage) error {
+	if src != lastMarkerDataSrc {
+		return nil
+	}
+	return edge.Forward(n.outs, b)
+}
+
+func (n *LastMarkerNode) Delete(src int, d edge.DeleteGroupMessage) error {
+	if src != lastMarkerDataSrc {
+		return nil
+	}
+	return edge.Forward(n.outs, d)
+}
+
+func (n *LastMarkerNode) Finish() error {
+	return nil
+}
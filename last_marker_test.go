@@ -0,0 +1,91 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestLastMarkerNode_Annotate(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	markers := stream.From()
+	m := stream.From().LastMarker(markers)
+	m.Lookback = 10 * time.Minute
+	m.On("service")
+	m.Unit = time.Minute
+
+	n, err := newLastMarkerNode(nil, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	api := models.Tags{"service": "api", "host": "serverA"}
+	web := models.Tags{"service": "web", "host": "serverB"}
+	fields := models.Fields{"count": 150.0}
+
+	if _, ok := n.annotate(start, fields, api); ok {
+		t.Error("expected no annotation without markers")
+	}
+
+	n.add(start.Add(time.Minute), models.Fields{"version": "1.2.0"}, models.Tags{"service": "api"})
+	n.add(start.Add(5*time.Minute), models.Fields{"version": "1.3.0"}, models.Tags{"service": "api"})
+
+	testCases := []struct {
+		name string
+		t    time.Time
+		tags models.Tags
+		exp  models.Fields
+	}{
+		{
+			name: "before the markers",
+			t:    start,
+			tags: api,
+		},
+		{
+			name: "between the markers",
+			t:    start.Add(3 * time.Minute),
+			tags: api,
+			exp:  models.Fields{"count": 150.0, "marker_version": "1.2.0", "marker_service": "api", "marker_since": 2.0},
+		},
+		{
+			name: "after the markers",
+			t:    start.Add(8 * time.Minute),
+			tags: api,
+			exp:  models.Fields{"count": 150.0, "marker_version": "1.3.0", "marker_service": "api", "marker_since": 3.0},
+		},
+		{
+			name: "outside of the lookback",
+			t:    start.Add(16 * time.Minute),
+			tags: api,
+		},
+		{
+			name: "other service",
+			t:    start.Add(8 * time.Minute),
+			tags: web,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := n.annotate(tc.t, fields, tc.tags)
+			if ok != (tc.exp != nil) {
+				t.Fatalf("unexpected annotation: got %t exp %t", ok, tc.exp != nil)
+			}
+			if ok && !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected fields: got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+	if exp := (models.Fields{"count": 150.0}); !reflect.DeepEqual(fields, exp) {
+		t.Errorf("point fields were modified: got %v exp %v", fields, exp)
+	}
+
+	// Markers outside of the lookback from the latest marker are removed.
+	n.add(start.Add(20*time.Minute), models.Fields{"version": "1.4.0"}, models.Tags{"service": "api"})
+	if got, exp := len(n.markers[n.key(api)]), 1; got != exp {
+		t.Errorf("unexpected number of markers: got %d exp %d", got, exp)
+	}
+}
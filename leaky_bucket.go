@@ -0,0 +1,128 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type LeakyBucketNode struct {
+	node
+	b *pipeline.LeakyBucketNode
+}
+
+// Create a new leakyBucket node.
+func newLeakyBucketNode(et *ExecutingTask, n *pipeline.LeakyBucketNode, d NodeDiagnostic) (*LeakyBucketNode, error) {
+	bn := &LeakyBucketNode{
+		node: node{Node: n, et: et, diag: d},
+		b:    n,
+	}
+	bn.node.runF = bn.runLeakyBucket
+	return bn, nil
+}
+
+func (n *LeakyBucketNode) runLeakyBucket([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *LeakyBucketNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *LeakyBucketNode) newGroup() *leakyBucketGroup {
+	return &leakyBucketGroup{
+		n: n,
+	}
+}
+
+type leakyBucketGroup struct {
+	n *LeakyBucketNode
+
+	// The level of the bucket at the time of the last point, zero before the first point.
+	level float64
+	last  time.Time
+}
+
+func (g *leakyBucketGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *leakyBucketGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doLeakyBucket(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *leakyBucketGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *leakyBucketGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doLeakyBucket(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doLeakyBucket adds the value of p to the bucket and sets the level and the overflow of the bucket as fields on p.
+// Points without a numeric value are dropped and are not added to the bucket.
+func (g *leakyBucketGroup) doLeakyBucket(p edge.FieldsTagsTimeSetter) bool {
+	b := g.n.b
+	value, ok := numToFloat(p.Fields()[b.Field])
+	if !ok {
+		g.n.diag.Error("cannot apply leaky bucket",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", b.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[b.Field])),
+		)
+		return false
+	}
+
+	// Leak the bucket at the leak rate since the last point.
+	t := p.Time()
+	if !g.last.IsZero() {
+		if elapsed := t.Sub(g.last); elapsed > 0 {
+			g.level = math.Max(0, g.level-b.LeakRate*float64(elapsed)/float64(b.Interval))
+		}
+	}
+	if t.After(g.last) {
+		g.last = t
+	}
+
+	g.level += value
+	overflow := 0.0
+	if g.level > b.Capacity {
+		overflow = g.level - b.Capacity
+		g.level = b.Capacity
+	}
+
+	fields := p.Fields().Copy()
+	fields[b.LevelAs] = g.level
+	fields[b.OverflowAs] = overflow
+	p.SetFields(fields)
+	return true
+}
+
+func (g *leakyBucketGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *leakyBucketGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *leakyBucketGroup) Done() {}
@@ -0,0 +1,222 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	// The minimum number of values for which a trend is computed.
+	minMannKendallSize = 3
+	// The maximum number of values for which the exact distribution of S is used.
+	maxMannKendallExactSize = 10
+)
+
+type MannKendallNode struct {
+	node
+	m *pipeline.MannKendallNode
+}
+
+// Create a new Mann-Kendall node.
+func newMannKendallNode(et *ExecutingTask, n *pipeline.MannKendallNode, d NodeDiagnostic) (*MannKendallNode, error) {
+	mn := &MannKendallNode{
+		node: node{Node: n, et: et, diag: d},
+		m:    n,
+	}
+	mn.node.runF = mn.runMannKendall
+	return mn, nil
+}
+
+func (n *MannKendallNode) runMannKendall([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *MannKendallNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *MannKendallNode) newGroup() *mannKendallGroup {
+	return &mannKendallGroup{
+		n: n,
+	}
+}
+
+type mannKendallGroup struct {
+	n *MannKendallNode
+
+	begin  edge.BeginBatchMessage
+	values []float64
+}
+
+func (g *mannKendallGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.values = g.values[:0]
+	return nil, nil
+}
+
+func (g *mannKendallGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	field := g.n.m.Field
+	value, ok := numToFloat(bp.Fields()[field])
+	if !ok {
+		g.n.diag.Error("cannot perform mann-kendall test",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", field),
+			keyvalue.KV("type", fmt.Sprintf("%T", bp.Fields()[field])),
+		)
+		return nil, nil
+	}
+	g.values = append(g.values, value)
+	return nil, nil
+}
+
+func (g *mannKendallGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if len(g.values) < minMannKendallSize {
+		return nil, nil
+	}
+	m := g.n.m
+	s, z, p := mannKendall(g.values)
+
+	trend := int64(0)
+	if p < m.Alpha {
+		if s > 0 {
+			trend = 1
+		} else if s < 0 {
+			trend = -1
+		}
+	}
+
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		models.Fields{
+			m.SAs:     s,
+			m.ZAs:     z,
+			m.PAs:     p,
+			m.TrendAs: trend,
+		},
+		g.begin.GroupInfo().Tags,
+		g.begin.Time(),
+	), nil
+}
+
+func (g *mannKendallGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *mannKendallGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *mannKendallGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *mannKendallGroup) Done() {}
+
+// mannKendall returns the S statistic, the Z score and the two-sided p-value of the values.
+func mannKendall(values []float64) (int64, float64, float64) {
+	n := len(values)
+	var s int64
+	for i := 0; i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case values[j] > values[i]:
+				s++
+			case values[j] < values[i]:
+				s--
+			}
+		}
+	}
+
+	// Correct the variance for groups of tied values.
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	ties := false
+	var tieSum float64
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && sorted[j] == sorted[i] {
+			j++
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieSum += t * (t - 1) * (2*t + 5)
+		}
+		i = j
+	}
+	fn := float64(n)
+	variance := (fn*(fn-1)*(2*fn+5) - tieSum) / 18
+
+	var z float64
+	if variance > 0 {
+		switch {
+		case s > 0:
+			z = float64(s-1) / math.Sqrt(variance)
+		case s < 0:
+			z = float64(s+1) / math.Sqrt(variance)
+		}
+	}
+
+	var p float64
+	if n <= maxMannKendallExactSize && !ties {
+		p = mannKendallExactP(n, s)
+	} else {
+		p = math.Erfc(math.Abs(z) / math.Sqrt2)
+	}
+	return s, z, p
+}
+
+// mannKendallExactP returns the two-sided p-value of S for n values without ties,
+// from the exact distribution of S.
+// Without ties S = N - 2I, where N = n(n-1)/2 is the number of pairs
+// and I is the number of inversions of the permutation of the values,
+// so the distribution follows from the number of permutations with each number of inversions.
+func mannKendallExactP(n int, s int64) float64 {
+	pairs := n * (n - 1) / 2
+	counts := make([]float64, pairs+1)
+	counts[0] = 1
+	for m := 2; m <= n; m++ {
+		// Inserting the m-th value adds from 0 to m-1 inversions.
+		next := make([]float64, pairs+1)
+		for k, c := range counts {
+			if c == 0 {
+				continue
+			}
+			for j := 0; j < m && k+j <= pairs; j++ {
+				next[k+j] += c
+			}
+		}
+		counts = next
+	}
+
+	abs := s
+	if abs < 0 {
+		abs = -abs
+	}
+	var extreme, total float64
+	for inversions, c := range counts {
+		total += c
+		v := int64(pairs - 2*inversions)
+		if v < 0 {
+			v = -v
+		}
+		if v >= abs {
+			extreme += c
+		}
+	}
+	return extreme / total
+}
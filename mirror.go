@@ -0,0 +1,277 @@
+package kapacitor
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type MirrorNode struct {
+	node
+	m *pipeline.MirrorNode
+
+	begin    edge.BeginBatchMessage
+	lastTime time.Time
+	groups   map[models.GroupID]*mirrorGroup
+	// The order the groups were first seen, so they are emitted deterministically.
+	order []models.GroupID
+	// The last fields of each data center of the groups, to carry them forward.
+	last map[models.GroupID]*[2]models.Fields
+}
+
+type mirrorGroup struct {
+	name  string
+	info  edge.GroupInfo
+	begin edge.BeginBatchMessage
+	// The fields of each data center for each point time.
+	pairs map[time.Time]*[2]models.Fields
+}
+
+// Create a new MirrorNode which computes the difference of fields between two data centers.
+func newMirrorNode(et *ExecutingTask, n *pipeline.MirrorNode, d NodeDiagnostic) (*MirrorNode, error) {
+	mn := &MirrorNode{
+		node:   node{Node: n, et: et, diag: d},
+		m:      n,
+		groups: make(map[models.GroupID]*mirrorGroup),
+		last:   make(map[models.GroupID]*[2]models.Fields),
+	}
+	mn.node.runF = mn.runMirror
+	return mn, nil
+}
+
+func (n *MirrorNode) runMirror([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// groupInfo returns the group of the paired data, without the data center tag.
+func (n *MirrorNode) groupInfo(name string, tags models.Tags, dims models.Dimensions) edge.GroupInfo {
+	tagNames := make([]string, 0, len(dims.TagNames))
+	newTags := make(models.Tags, len(dims.TagNames))
+	for _, t := range dims.TagNames {
+		if t == n.m.Tag {
+			continue
+		}
+		tagNames = append(tagNames, t)
+		if v, ok := tags[t]; ok {
+			newTags[t] = v
+		}
+	}
+	newDims := models.Dimensions{
+		ByName:   dims.ByName,
+		TagNames: tagNames,
+	}
+	return edge.GroupInfo{
+		ID:         models.ToGroupID(name, newTags, newDims),
+		Tags:       newTags,
+		Dimensions: newDims,
+	}
+}
+
+// side returns the index of the data center of the tags, or -1 if it is not mirrored.
+func (n *MirrorNode) side(tags models.Tags) int {
+	switch tags[n.m.Tag] {
+	case n.m.DCs[0]:
+		return 0
+	case n.m.DCs[1]:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// add records the fields at time t for the data center of the tags.
+func (n *MirrorNode) add(name string, tags models.Tags, dims models.Dimensions, begin edge.BeginBatchMessage, t time.Time, fields models.Fields) {
+	side := n.side(tags)
+	if side < 0 {
+		return
+	}
+	info := n.groupInfo(name, tags, dims)
+	group, ok := n.groups[info.ID]
+	if !ok {
+		group = &mirrorGroup{
+			name:  name,
+			info:  info,
+			begin: begin,
+			pairs: make(map[time.Time]*[2]models.Fields),
+		}
+		n.groups[info.ID] = group
+		n.order = append(n.order, info.ID)
+	}
+	pair, ok := group.pairs[t]
+	if !ok {
+		pair = new([2]models.Fields)
+		group.pairs[t] = pair
+	}
+	pair[side] = fields
+}
+
+func (n *MirrorNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(p.Time()); err != nil {
+		return err
+	}
+	n.add(p.Name(), p.Tags(), p.Dimensions(), nil, p.Time(), p.Fields())
+	return nil
+}
+
+func (n *MirrorNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(begin.Time()); err != nil {
+		return err
+	}
+	n.begin = begin
+	return nil
+}
+
+func (n *MirrorNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	begin := n.begin
+	n.add(begin.Name(), begin.Tags(), begin.Dimensions(), begin, bp.Time(), bp.Fields())
+	return nil
+}
+
+func (n *MirrorNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *MirrorNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emit(b.Time())
+	info := n.groupInfo(b.Name(), b.Tags(), b.Dimensions())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return edge.Forward(n.outs, edge.NewBarrierMessage(info, b.Time()))
+}
+
+func (n *MirrorNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	// The paired group still has the other data center, so it is not deleted.
+	return nil
+}
+
+func (n *MirrorNode) Done() {}
+
+// diff returns the differences of the fields of the pair,
+// and whether any field could be compared.
+func (n *MirrorNode) diff(pair *[2]models.Fields) (models.Fields, bool) {
+	fields := make(models.Fields, 2*len(n.m.Fields))
+	for _, f := range n.m.Fields {
+		a, ok := numToFloat(pair[0][f])
+		if !ok {
+			continue
+		}
+		b, ok := numToFloat(pair[1][f])
+		if !ok {
+			continue
+		}
+		abs := math.Abs(a - b)
+		rel := 0.0
+		if m := math.Max(math.Abs(a), math.Abs(b)); m > 0 {
+			rel = abs / m
+		}
+		fields[f+"_abs_diff"] = abs
+		fields[f+"_rel_diff"] = rel
+	}
+	return fields, len(fields) > 0
+}
+
+// pair returns the fields of both data centers for a time, carrying the last fields forward if enabled,
+// or false if a data center is missing.
+func (n *MirrorNode) pair(id models.GroupID, pair *[2]models.Fields) (*[2]models.Fields, bool) {
+	last, ok := n.last[id]
+	if !ok {
+		last = new([2]models.Fields)
+		n.last[id] = last
+	}
+	complete := new([2]models.Fields)
+	for i, fields := range pair {
+		if fields != nil {
+			last[i] = fields
+			complete[i] = fields
+		} else if n.m.CarryForwardFlag {
+			complete[i] = last[i]
+		}
+	}
+	if complete[0] == nil || complete[1] == nil {
+		return nil, false
+	}
+	return complete, true
+}
+
+// emit sends all paired groups before time t to children nodes.
+// The node timer must be started when calling this method.
+func (n *MirrorNode) emit(t time.Time) error {
+	if t.Equal(n.lastTime) {
+		return nil
+	}
+	n.lastTime = t
+
+	order := n.order
+	n.order = nil
+	for _, id := range order {
+		group := n.groups[id]
+		delete(n.groups, id)
+
+		times := make([]time.Time, 0, len(group.pairs))
+		for pt := range group.pairs {
+			times = append(times, pt)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+		var points []edge.BatchPointMessage
+		for _, pt := range times {
+			// The last fields must be updated for every time, even when the pair is dropped.
+			pair, ok := n.pair(id, group.pairs[pt])
+			if !ok {
+				continue
+			}
+			fields, ok := n.diff(pair)
+			if !ok {
+				continue
+			}
+			points = append(points, edge.NewBatchPointMessage(fields, group.info.Tags, pt))
+		}
+
+		var msgs []edge.Message
+		if group.begin == nil {
+			for _, p := range points {
+				msgs = append(msgs, edge.NewPointMessage(
+					group.name, "", "",
+					group.info.Dimensions,
+					p.Fields(),
+					group.info.Tags,
+					p.Time(),
+				))
+			}
+		} else {
+			begin := group.begin.ShallowCopy()
+			begin.SetTagsAndDimensions(group.info.Tags, group.info.Dimensions)
+			begin.SetSizeHint(len(points))
+			msgs = append(msgs, edge.NewBufferedBatchMessage(begin, points, edge.NewEndBatchMessage()))
+		}
+
+		n.timer.Pause()
+		for _, m := range msgs {
+			if err := edge.Forward(n.outs, m); err != nil {
+				return err
+			}
+		}
+		n.timer.Resume()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type MonotonicNode struct {
+	node
+	m *pipeline.MonotonicNode
+}
+
+// Create a new monotonic node.
+func newMonotonicNode(et *ExecutingTask, n *pipeline.MonotonicNode, d NodeDiagnostic) (*MonotonicNode, error) {
+	mn := &MonotonicNode{
+		node: node{Node: n, et: et, diag: d},
+		m:    n,
+	}
+	mn.node.runF = mn.runMonotonic
+	return mn, nil
+}
+
+func (n *MonotonicNode) runMonotonic([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *MonotonicNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *MonotonicNode) newGroup() *monotonicGroup {
+	return &monotonicGroup{
+		n: n,
+	}
+}
+
+type monotonicGroup struct {
+	n *MonotonicNode
+
+	hasPrev   bool
+	prev      float64
+	length    int64
+	direction int64
+}
+
+func (g *monotonicGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *monotonicGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doMonotonic(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *monotonicGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *monotonicGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doMonotonic(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doMonotonic updates the run with the value of p and sets the run length and direction as fields on p.
+// Points without a numeric value are dropped and do not affect the run.
+func (g *monotonicGroup) doMonotonic(p edge.FieldsTagsTimeSetter) bool {
+	m := g.n.m
+	value, ok := numToFloat(p.Fields()[m.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute monotonic run",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", m.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[m.Field])),
+		)
+		return false
+	}
+
+	if g.hasPrev {
+		var direction int64
+		switch {
+		case value > g.prev:
+			direction = 1
+		case value < g.prev:
+			direction = -1
+		}
+		switch {
+		case direction == 0 && m.StrictFlag:
+			// A plateau ends a strict run.
+			g.length = 0
+			g.direction = 0
+		case direction == 0:
+			// A plateau continues a non-strict run, if there is one.
+			if g.direction != 0 {
+				g.length++
+			}
+		case direction == g.direction:
+			g.length++
+		default:
+			g.direction = direction
+			g.length = 1
+		}
+	}
+	g.hasPrev = true
+	g.prev = value
+
+	fields := p.Fields().Copy()
+	fields[m.As] = g.length
+	fields[m.DirectionAs] = g.direction
+	p.SetFields(fields)
+	return true
+}
+
+func (g *monotonicGroup) reset() {
+	g.hasPrev = false
+	g.prev = 0
+	g.length = 0
+	g.direction = 0
+}
+
+func (g *monotonicGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *monotonicGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *monotonicGroup) Done() {}
@@ -95,6 +95,8 @@ type Node interface {
 
 	// executing dot
 	edot(buf *bytes.Buffer, labels bool)
+	// executing graph edges to the children
+	graphEdges() []ExecutionGraphEdge
 
 	nodeStatsByGroup() map[models.GroupID]nodeStats
 
@@ -327,6 +329,18 @@ func (n *node) edot(buf *bytes.Buffer, labels bool) {
 	}
 }
 
+func (n *node) graphEdges() []ExecutionGraphEdge {
+	edges := make([]ExecutionGraphEdge, len(n.children))
+	for i, c := range n.children {
+		edges[i] = ExecutionGraphEdge{
+			Parent:    n.Name(),
+			Child:     c.Name(),
+			Processed: n.outs[i].Collected(),
+		}
+	}
+	return edges
+}
+
 // node collected count is the sum of emitted counts of parent edges
 func (n *node) collectedCount() (count int64) {
 	for _, in := range n.ins {
@@ -0,0 +1,129 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type PercentChangeNode struct {
+	node
+	p *pipeline.PercentChangeNode
+}
+
+// Create a new percentChange node.
+func newPercentChangeNode(et *ExecutingTask, n *pipeline.PercentChangeNode, d NodeDiagnostic) (*PercentChangeNode, error) {
+	pn := &PercentChangeNode{
+		node: node{Node: n, et: et, diag: d},
+		p:    n,
+	}
+	pn.node.runF = pn.runPercentChange
+	return pn, nil
+}
+
+func (n *PercentChangeNode) runPercentChange([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PercentChangeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *PercentChangeNode) newGroup() *percentChangeGroup {
+	return &percentChangeGroup{
+		n:      n,
+		values: NewCircularQueue[percentChangeValue](),
+	}
+}
+
+type percentChangeValue struct {
+	time  time.Time
+	value float64
+}
+
+type percentChangeGroup struct {
+	n      *PercentChangeNode
+	values *CircularQueue[percentChangeValue]
+}
+
+func (g *percentChangeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *percentChangeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doPercentChange(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *percentChangeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *percentChangeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doPercentChange(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doPercentChange adds the value of p to the window and sets the percentage change as a field on p.
+// Points without a numeric value are dropped and are not added to the window.
+func (g *percentChangeGroup) doPercentChange(p edge.FieldsTagsTimeSetter) bool {
+	pc := g.n.p
+	value, ok := numToFloat(p.Fields()[pc.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute percent change",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", pc.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[pc.Field])),
+		)
+		return false
+	}
+
+	t := p.Time()
+	g.values.Enqueue(percentChangeValue{time: t, value: value})
+
+	// Keep only the most recent value at or before the start of the period,
+	// it is the reference for the current and following points.
+	start := t.Add(-pc.Period)
+	for g.values.Len > 1 && !g.values.Peek(1).time.After(start) {
+		g.values.Dequeue(1)
+	}
+
+	ref := g.values.Peek(0)
+	if !ref.time.After(start) && ref.value != 0 {
+		fields := p.Fields().Copy()
+		fields[pc.As] = (value - ref.value) / ref.value * 100
+		p.SetFields(fields)
+	}
+	return true
+}
+
+func (g *percentChangeGroup) reset() {
+	g.values.Dequeue(g.values.Len)
+}
+
+func (g *percentChangeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *percentChangeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *percentChangeGroup) Done() {}
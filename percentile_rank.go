@@ -0,0 +1,127 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type PercentileRankNode struct {
+	node
+	p *pipeline.PercentileRankNode
+}
+
+// Create a new percentile rank node.
+func newPercentileRankNode(et *ExecutingTask, n *pipeline.PercentileRankNode, d NodeDiagnostic) (*PercentileRankNode, error) {
+	pn := &PercentileRankNode{
+		node: node{Node: n, et: et, diag: d},
+		p:    n,
+	}
+	pn.node.runF = pn.runPercentileRank
+	return pn, nil
+}
+
+func (n *PercentileRankNode) runPercentileRank([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PercentileRankNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *PercentileRankNode) newGroup() *percentileRankGroup {
+	return &percentileRankGroup{
+		n:      n,
+		values: NewCircularQueue[float64](),
+	}
+}
+
+type percentileRankGroup struct {
+	n      *PercentileRankNode
+	values *CircularQueue[float64]
+}
+
+func (g *percentileRankGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset()
+	return begin, nil
+}
+
+func (g *percentileRankGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doPercentileRank(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *percentileRankGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *percentileRankGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doPercentileRank(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doPercentileRank sets the rank of the value of p within the window as a field on p,
+// and then adds the value to the window.
+// Points without a numeric value are dropped and are not added to the window.
+func (g *percentileRankGroup) doPercentileRank(p edge.FieldsTagsTimeSetter) bool {
+	pr := g.n.p
+	value, ok := numToFloat(p.Fields()[pr.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute percentile rank",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", pr.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[pr.Field])),
+		)
+		return false
+	}
+
+	if size := g.values.Len; int64(size) >= pr.MinPoints {
+		var below, equal int
+		for i := 0; i < size; i++ {
+			switch v := g.values.Peek(i); {
+			case v < value:
+				below++
+			case v == value:
+				equal++
+			}
+		}
+		fields := p.Fields().Copy()
+		fields[pr.As] = (float64(below) + float64(equal)/2) / float64(size) * 100
+		p.SetFields(fields)
+	}
+
+	g.values.Enqueue(value)
+	if int64(g.values.Len) > pr.Size {
+		g.values.Dequeue(1)
+	}
+	return true
+}
+
+func (g *percentileRankGroup) reset() {
+	g.values.Dequeue(g.values.Len)
+}
+
+func (g *percentileRankGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *percentileRankGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *percentileRankGroup) Done() {}
@@ -0,0 +1,242 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/tdigest"
+	pkgerrors "github.com/pkg/errors"
+)
+
+type PercentileThresholdNode struct {
+	node
+	p *pipeline.PercentileThresholdNode
+
+	slice time.Duration
+
+	// mu guards the groups, which are snapshotted while the points are processed.
+	mu     sync.Mutex
+	groups map[models.GroupID]*percentileThresholdGroup
+	// The learned values of the groups restored from the snapshot, until the groups are created.
+	restored map[models.GroupID][]percentileThresholdSliceSnapshot
+}
+
+// Create a new PercentileThresholdNode which learns a percentile of a field over a long period.
+func newPercentileThresholdNode(et *ExecutingTask, n *pipeline.PercentileThresholdNode, d NodeDiagnostic) (*PercentileThresholdNode, error) {
+	pn := &PercentileThresholdNode{
+		node:   node{Node: n, et: et, diag: d},
+		p:      n,
+		slice:  n.Period / time.Duration(n.Slices),
+		groups: make(map[models.GroupID]*percentileThresholdGroup),
+	}
+	pn.node.runF = pn.runPercentileThreshold
+	return pn, nil
+}
+
+// The learned values of a slice, as snapshotted.
+type percentileThresholdSliceSnapshot struct {
+	Start     time.Time            `json:"start"`
+	Centroids tdigest.CentroidList `json:"centroids"`
+}
+
+func (n *PercentileThresholdNode) runPercentileThreshold(snapshot []byte) error {
+	if len(snapshot) > 0 {
+		if err := json.Unmarshal(snapshot, &n.restored); err != nil {
+			return pkgerrors.Wrap(err, "failed to restore percentileThreshold snapshot")
+		}
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PercentileThresholdNode) snapshot() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	groups := make(map[models.GroupID][]percentileThresholdSliceSnapshot, len(n.groups)+len(n.restored))
+	// Keep the restored groups that have not received a point yet.
+	for id, slices := range n.restored {
+		groups[id] = slices
+	}
+	for id, g := range n.groups {
+		slices := make([]percentileThresholdSliceSnapshot, g.slices.Len)
+		for i := range slices {
+			s := g.slices.Peek(i)
+			slices[i] = percentileThresholdSliceSnapshot{
+				Start:     s.start,
+				Centroids: s.digest.Centroids(nil),
+			}
+		}
+		groups[id] = slices
+	}
+	return json.Marshal(groups)
+}
+
+func (n *PercentileThresholdNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	g := n.newGroup()
+	if slices, ok := n.restored[group.ID]; ok {
+		g.restore(slices)
+		delete(n.restored, group.ID)
+	}
+	n.groups[group.ID] = g
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+func (n *PercentileThresholdNode) newGroup() *percentileThresholdGroup {
+	return &percentileThresholdGroup{
+		n:      n,
+		slices: NewCircularQueue[percentileThresholdSlice](),
+		window: n.newDigest(),
+	}
+}
+
+func (n *PercentileThresholdNode) newDigest() *tdigest.TDigest {
+	return tdigest.NewWithCompression(n.p.Compression)
+}
+
+type percentileThresholdGroup struct {
+	n *PercentileThresholdNode
+
+	// The digests of the slices of the period, oldest first.
+	slices *CircularQueue[percentileThresholdSlice]
+	// The digest of the whole period, the merge of the slices.
+	window *tdigest.TDigest
+}
+
+// The digest of the values of a slice of the period.
+type percentileThresholdSlice struct {
+	start  time.Time
+	digest *tdigest.TDigest
+}
+
+func (g *percentileThresholdGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *percentileThresholdGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doPercentileThreshold(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *percentileThresholdGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *percentileThresholdGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doPercentileThreshold(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doPercentileThreshold sets the threshold learned before p as a field on p, once warmed up, and then learns the value of p.
+// Points without a value are dropped.
+func (g *percentileThresholdGroup) doPercentileThreshold(p edge.FieldsTagsTimeSetter) bool {
+	pt := g.n.p
+	value, ok := numToFloat(p.Fields()[pt.Field])
+	if !ok {
+		g.n.diag.Error("cannot learn percentile threshold",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", pt.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[pt.Field])),
+		)
+		return false
+	}
+
+	g.n.mu.Lock()
+	defer g.n.mu.Unlock()
+	current := g.slide(p.Time())
+	if g.window.Count() >= float64(pt.MinPoints) {
+		fields := p.Fields().Copy()
+		fields[pt.As] = g.window.Quantile(pt.Percentile / 100)
+		p.SetFields(fields)
+	}
+	current.Add(value, 1)
+	g.window.Add(value, 1)
+	return true
+}
+
+// slide moves the period to include time t, and returns the digest of the slice of t.
+// Points older than the current slice are added to the current slice.
+func (g *percentileThresholdGroup) slide(t time.Time) *tdigest.TDigest {
+	start := t.Truncate(g.n.slice)
+	if g.slices.Len > 0 {
+		last := g.slices.Peek(g.slices.Len - 1)
+		if !start.After(last.start) {
+			return last.digest
+		}
+	}
+
+	// Drop the slices that are no longer in the period.
+	oldest := start.Add(-g.n.p.Period + g.n.slice)
+	expired := 0
+	for expired < g.slices.Len && g.slices.Peek(expired).start.Before(oldest) {
+		expired++
+	}
+	if expired > 0 {
+		g.slices.Dequeue(expired)
+		// Values cannot be removed from a digest, so the window is rebuilt from the remaining slices.
+		g.rebuild()
+	}
+
+	digest := g.n.newDigest()
+	g.slices.Enqueue(percentileThresholdSlice{
+		start:  start,
+		digest: digest,
+	})
+	return digest
+}
+
+// restore replaces the learned values with the snapshotted slices.
+func (g *percentileThresholdGroup) restore(slices []percentileThresholdSliceSnapshot) {
+	g.slices.Dequeue(g.slices.Len)
+	for _, s := range slices {
+		digest := g.n.newDigest()
+		digest.AddCentroidList(s.Centroids)
+		g.slices.Enqueue(percentileThresholdSlice{
+			start:  s.Start,
+			digest: digest,
+		})
+	}
+	g.rebuild()
+}
+
+// rebuild merges the slices into the window.
+func (g *percentileThresholdGroup) rebuild() {
+	g.window = g.n.newDigest()
+	for i := 0; i < g.slices.Len; i++ {
+		g.window.Merge(g.slices.Peek(i).digest)
+	}
+}
+
+func (g *percentileThresholdGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *percentileThresholdGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.n.mu.Lock()
+	defer g.n.mu.Unlock()
+	delete(g.n.groups, d.GroupID())
+	return d, nil
+}
+func (g *percentileThresholdGroup) Done() {}
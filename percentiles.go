@@ -0,0 +1,181 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type PercentilesNode struct {
+	node
+	p *pipeline.PercentilesNode
+
+	fields []string
+}
+
+// Create a new percentiles node.
+func newPercentilesNode(et *ExecutingTask, n *pipeline.PercentilesNode, d NodeDiagnostic) (*PercentilesNode, error) {
+	pn := &PercentilesNode{
+		node:   node{Node: n, et: et, diag: d},
+		p:      n,
+		fields: n.AllFields(),
+	}
+	pn.node.runF = pn.runPercentiles
+	return pn, nil
+}
+
+func (n *PercentilesNode) runPercentiles([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PercentilesNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(first)),
+	), nil
+}
+
+func (n *PercentilesNode) newGroup(first edge.PointMeta) *percentilesGroup {
+	g := &percentilesGroup{
+		n:         n,
+		groupInfo: first.GroupInfo(),
+		values:    make([][]float64, len(n.fields)),
+		allInts:   make([]bool, len(n.fields)),
+	}
+	g.reset(first.Name(), first.Time())
+	return g
+}
+
+type percentilesGroup struct {
+	n *PercentilesNode
+
+	name      string
+	groupInfo edge.GroupInfo
+	time      time.Time
+
+	// The values of each field, and whether they are all integers.
+	values  [][]float64
+	allInts []bool
+}
+
+func (g *percentilesGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.reset(begin.Name(), begin.Time())
+	return nil, nil
+}
+
+func (g *percentilesGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.add(bp.Fields())
+	return nil, nil
+}
+
+func (g *percentilesGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return g.emit(), nil
+}
+
+func (g *percentilesGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if p.Time().Equal(g.time) {
+		g.add(p.Fields())
+		return nil, nil
+	}
+	// Time has elapsed, emit the current values
+	m := g.emit()
+	g.reset(p.Name(), p.Time())
+	g.add(p.Fields())
+	return m, nil
+}
+
+// add adds the values of the fields to the values.
+// Fields missing from the point are ignored, unless the point has none of the fields.
+func (g *percentilesGroup) add(fields models.Fields) {
+	missing := 0
+	for i, field := range g.n.fields {
+		v, ok := fields[field]
+		if !ok {
+			missing++
+			continue
+		}
+		value, ok := numToFloat(v)
+		if !ok {
+			g.n.diag.Error("cannot compute percentiles",
+				errors.New("field is the wrong type"),
+				keyvalue.KV("field", field),
+				keyvalue.KV("type", fmt.Sprintf("%T", v)),
+			)
+			continue
+		}
+		if _, ok := v.(int64); !ok {
+			g.allInts[i] = false
+		}
+		g.values[i] = append(g.values[i], value)
+	}
+	if missing == len(g.n.fields) {
+		g.n.diag.Error("cannot compute percentiles",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", g.n.p.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", fields[g.n.p.Field])),
+		)
+	}
+}
+
+// emit returns a point with the percentiles of the values of each field,
+// or nil if no percentile selects a value.
+func (g *percentilesGroup) emit() edge.Message {
+	fields := make(models.Fields, len(g.n.fields)*len(g.n.p.Percentiles))
+	for f, field := range g.n.fields {
+		values := g.values[f]
+		if len(values) == 0 {
+			continue
+		}
+		sort.Float64s(values)
+		for _, p := range g.n.p.Percentiles {
+			i := int(math.Floor(float64(len(values))*p/100.0+0.5)) - 1
+			if i < 0 || i >= len(values) {
+				continue
+			}
+			if g.allInts[f] {
+				fields[g.n.p.FieldName(field, p)] = int64(values[i])
+			} else {
+				fields[g.n.p.FieldName(field, p)] = values[i]
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return edge.NewPointMessage(
+		g.name, "", "",
+		g.groupInfo.Dimensions,
+		fields,
+		g.groupInfo.Tags,
+		g.time,
+	)
+}
+
+func (g *percentilesGroup) reset(name string, t time.Time) {
+	g.name = name
+	g.time = t
+	for i := range g.values {
+		g.values[i] = g.values[i][:0]
+		g.allInts[i] = true
+	}
+}
+
+func (g *percentilesGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *percentilesGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *percentilesGroup) Done() {}
@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Accumulate the sum of a field since its last reset, with resets on a schedule, such as the start of every month.
+// This is useful to alert on the burn of a budget, such as the cost of cloud resources or the use of a quota.
+//
+// Each point is set the running total of its group, `total`, and when a budget is defined,
+// the fraction of the budget used, `budget_fraction`, and the fraction of the budget crossed, `budget_crossed`.
+// When the total of a point crosses one of the fractions of the budget, the largest fraction crossed
+// is the fraction crossed of the point, otherwise it is 0. Each fraction is crossed at most once between resets.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('billing')
+//	        .groupBy('account')
+//	    |accumulate('cost')
+//	        .reset('0 0 1 * *')
+//	        .budget(10000.0)
+//	        .fractions(0.5, 0.8, 1.0)
+//	    |where(lambda: "budget_crossed" > 0.0)
+//	    |alert()
+//	        .warn(lambda: "budget_crossed" < 1.0)
+//	        .crit(lambda: "budget_crossed" >= 1.0)
+//	        .message('{{ index .Tags "account" }} used {{ index .Fields "budget_crossed" }} of its monthly budget')
+//
+// The above example alerts when an account has used half, 80 percent and all of its budget for the month.
+//
+// The resets are defined with a cron syntax, in local time.
+// The specific cron implementation is documented here:
+// https://github.com/gorhill/cronexpr#implementation
+//
+// The schedule is applied to the times of the points, so that replayed data is reset the same way as live data:
+// the total is reset by the first point at or after each reset time.
+// The first total of a group starts at its first point.
+// Points without a numeric value are dropped and do not affect the total.
+// State is kept per group across batches.
+type AccumulateNode struct {
+	chainnode `json:"-"`
+
+	// The field to accumulate.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The schedule of the resets of the total, using a cron syntax.
+	Reset string `json:"reset"`
+
+	// The budget of the total between resets.
+	// If zero the total has no budget.
+	Budget float64 `json:"budget"`
+
+	// The fractions of the budget to notify when crossed.
+	// tick:ignore
+	FractionsList []float64 `tick:"Fractions" json:"fractions"`
+
+	// The name of the running total field.
+	// Default: total
+	As string `json:"as"`
+
+	// The name of the fraction of the budget used field.
+	// Default: budget_fraction
+	FractionAs string `json:"fractionAs"`
+
+	// The name of the fraction of the budget crossed field.
+	// Default: budget_crossed
+	CrossedAs string `json:"crossedAs"`
+}
+
+func newAccumulateNode(wants EdgeType, field string) *AccumulateNode {
+	return &AccumulateNode{
+		chainnode:  newBasicChainNode("accumulate", wants, wants),
+		Field:      field,
+		As:         "total",
+		FractionAs: "budget_fraction",
+		CrossedAs:  "budget_crossed",
+	}
+}
+
+// MarshalJSON converts AccumulateNode to JSON
+// tick:ignore
+func (n *AccumulateNode) MarshalJSON() ([]byte, error) {
+	type Alias AccumulateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "accumulate",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an AccumulateNode
+// tick:ignore
+func (n *AccumulateNode) UnmarshalJSON(data []byte) error {
+	type Alias AccumulateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "accumulate" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as AccumulateNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// The fractions of the budget to notify when crossed, e.g. 0.8 for 80 percent of the budget.
+// tick:property
+func (n *AccumulateNode) Fractions(fractions ...float64) *AccumulateNode {
+	n.FractionsList = fractions
+	return n
+}
+
+func (n *AccumulateNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for accumulate")
+	}
+	if n.Reset == "" {
+		return errors.New("must specify a reset schedule for accumulate")
+	}
+	if n.Budget < 0 {
+		return errors.New("accumulate budget must not be negative")
+	}
+	if len(n.FractionsList) > 0 && n.Budget == 0 {
+		return errors.New("must specify a budget for the fractions of accumulate")
+	}
+	for _, f := range n.FractionsList {
+		if f <= 0 {
+			return fmt.Errorf("accumulate fraction %v must be greater than zero", f)
+		}
+	}
+	if n.As == "" || n.FractionAs == "" || n.CrossedAs == "" {
+		return errors.New("accumulate field names must not be empty")
+	}
+	if n.As == n.FractionAs || n.As == n.CrossedAs || n.FractionAs == n.CrossedAs {
+		return errors.New("accumulate as, fractionAs and crossedAs must be different")
+	}
+	return nil
+}
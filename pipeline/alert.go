@@ -14,6 +14,12 @@ import (
 // Number of previous states to remember when computing flapping percentage.
 const defaultFlapHistory = 21
 
+// Maximum number of past incidents remembered for each event.
+const maxIncidentHistory = 100
+
+// The maximum number of values of a sparkline
+const maxSparklinePoints = 100
+
 // Default template for constructing an ID
 const defaultIDTmpl = "{{ .Name }}:{{ .Group }}"
 
@@ -261,6 +267,26 @@ type AlertNodeData struct {
 	// Filter expression for reseting the CRITICAL alert level to lower level.
 	CritReset *ast.LambdaNode `json:"critReset"`
 
+	// Expression computing the integer priority of the alert events, for handlers to route on.
+	// The expression has access to the fields and tags of the alerting data, its time,
+	// e.g. with the hour and weekday functions, and to the level of the event as "level",
+	// which is one of OK, INFO, WARNING or CRITICAL and takes precedence over a field or tag named level.
+	// The priority is part of the alert data sent to handlers, and is available in the message and details templates as .Priority.
+	//
+	// Example:
+	//   stream
+	//       |alert()
+	//           .warn(lambda: "value" > 80)
+	//           .crit(lambda: "value" > 90)
+	//           .priority(lambda: if("level" == 'CRITICAL', 10, 5) + if("tier" == 'gold', 5, 0) + if(hour("time") >= 9 AND hour("time") < 17, 0, 2))
+	//
+	// Events of gold tier entities have a higher priority, as do events outside of business hours.
+	// An expression whose type is known when the task is defined, such as a float or a comparison, must be an integer,
+	// otherwise the task definition fails.
+	// If the expression fails to evaluate, e.g. as a field is not an integer, the priority is 0.
+	// Default: no priority, the priority is 0
+	Priority *ast.LambdaNode `json:"priority"`
+
 	//tick:ignore
 	UseFlapping bool `tick:"Flapping" json:"useFlapping"`
 	//tick:ignore
@@ -308,6 +334,89 @@ type AlertNodeData struct {
 	// tick:ignore
 	StateChangesOnlyDuration time.Duration `json:"stateChangesOnlyDuration"`
 
+	// Delay the dispatch of each event to the handlers by a random duration up to jitter.
+	// When a condition fires across many tasks at once, jitter spreads
+	// the requests to the handlers instead of sending them all at the same time.
+	// Events are never reordered or dropped, each event is dispatched no sooner than the previous one.
+	// Pending events are dispatched immediately when the task stops.
+	// Default: 0, no jitter
+	Jitter time.Duration `json:"jitter"`
+
+	// Number of past incidents to remember for each event, at most 100.
+	// An incident lasts from when the event fires until it recovers.
+	// The incidents, most recent first, and the number of times the event has fired
+	// are added to the alert data as `incidents` and `fireCount`,
+	// and are available in the message and details templates as .Incidents and .FireCount.
+	// The incident history is persisted with the state of the event.
+	//
+	// Example:
+	//   stream
+	//       |alert()
+	//           .incidentHistory(5)
+	//           .message('{{ .ID }} is {{ .Level }}{{ with .Incidents }}, last fired {{ (index . 0).Time }} for {{ (index . 0).Duration }}{{ end }}')
+	//
+	// Default: 0, incident history is not kept
+	IncidentHistory int64 `json:"incidentHistory"`
+
+	// Escalate an alert to the next level after this many reminders at the same level,
+	// unless it is acknowledged or recovers.
+	// A reminder is an event sent while the level of the alert does not change,
+	// for example the events sent by stateChangesOnly after its duration has elapsed.
+	// The escalated level is sent to the handlers and the escalation continues up to CRITICAL.
+	// The escalation is reset when the level of the alert changes.
+	//
+	// Example:
+	//   stream
+	//       |alert()
+	//           .warn(lambda: "value" > 10)
+	//           .crit(lambda: "value" > 20)
+	//           .stateChangesOnly(10m)
+	//           .escalateReminders(3)
+	//
+	// An unacknowledged WARNING alert is escalated to CRITICAL after 3 reminders, i.e. after 30m.
+	// Default: 0, alerts are not escalated after reminders
+	EscalateReminders int64 `json:"escalateReminders"`
+
+	// Escalate an alert to the next level after it has been at the same level for this duration,
+	// unless it is acknowledged or recovers.
+	// The escalation is evaluated for each point, even when no event is sent.
+	// Default: 0, alerts are not escalated after a duration
+	EscalateAfter time.Duration `json:"escalateAfter"`
+
+	// Recover an alert only once the OK level has held for this duration,
+	// measured from the time of the first OK point.
+	// While the recovery is pending the alert keeps its level and no events are sent,
+	// so that a flapping service does not send premature recoveries.
+	// A point that is not OK restarts the recovery.
+	//
+	// Example:
+	//   stream
+	//       |alert()
+	//           .crit(lambda: "value" > 20)
+	//           .recoverAfter(5m)
+	//           .recoverAfterCount(3)
+	//
+	// A CRITICAL alert recovers once the value has been at most 20 for 5m and for at least 3 points.
+	// Default: 0, alerts recover immediately
+	RecoverAfter time.Duration `json:"recoverAfter"`
+
+	// Recover an alert only once the OK level has held for this many consecutive points or batches.
+	// When both recoverAfter and recoverAfterCount are set, both must hold before the alert recovers.
+	// Default: 0, alerts recover immediately
+	RecoverAfterCount int64 `json:"recoverAfterCount"`
+
+	// Send the events of the alert in a digest.
+	// tick:ignore
+	DigestFlag bool `tick:"Digest" json:"digest"`
+
+	// The field drawn as a sparkline of its recent values.
+	// tick:ignore
+	SparklineField string `tick:"Sparkline" json:"sparkline"`
+
+	// The number of recent values in the sparkline.
+	// tick:ignore
+	SparklinePoints int64 `json:"sparklinePoints"`
+
 	// Inhibitors
 	// tick:ignore
 	Inhibitors []Inhibitor `tick:"Inhibit" json:"inhibitors"`
@@ -473,6 +582,33 @@ func (n *AlertNodeData) ChainMethods() map[string]reflect.Value {
 }
 
 func (n *AlertNodeData) validate() error {
+	if n.Jitter < 0 {
+		return errors.New("alert jitter must not be negative")
+	}
+	if n.IncidentHistory < 0 || n.IncidentHistory > maxIncidentHistory {
+		return fmt.Errorf("alert incident history must be between 0 and %d", maxIncidentHistory)
+	}
+	if n.EscalateReminders < 0 {
+		return errors.New("alert escalateReminders must not be negative")
+	}
+	if n.EscalateAfter < 0 {
+		return errors.New("alert escalateAfter must not be negative")
+	}
+	if n.RecoverAfter < 0 {
+		return errors.New("alert recoverAfter must not be negative")
+	}
+	if n.RecoverAfterCount < 0 {
+		return errors.New("alert recoverAfterCount must not be negative")
+	}
+	if n.SparklineField != "" && (n.SparklinePoints < 2 || n.SparklinePoints > maxSparklinePoints) {
+		return fmt.Errorf("alert sparkline points must be between 2 and %d", maxSparklinePoints)
+	}
+	if n.Priority != nil {
+		if typ := constantType(n.Priority.Expression); typ != ast.InvalidType && typ != ast.TInt {
+			return fmt.Errorf("alert priority must evaluate to an int, got %v", typ)
+		}
+	}
+
 	for _, snmp := range n.SNMPTrapHandlers {
 		if err := snmp.validate(); err != nil {
 			return errors.Wrapf(err, "invalid SNMP trap %q", snmp.TrapOid)
@@ -487,6 +623,61 @@ func (n *AlertNodeData) validate() error {
 	return nil
 }
 
+// constantType returns the type of an expression when it does not depend on the data,
+// and ast.InvalidType when it is only known once the expression is evaluated.
+func constantType(n ast.Node) ast.ValueType {
+	switch node := n.(type) {
+	case *ast.NumberNode:
+		if node.IsInt {
+			return ast.TInt
+		}
+		return ast.TFloat
+	case *ast.StringNode:
+		return ast.TString
+	case *ast.BoolNode:
+		return ast.TBool
+	case *ast.DurationNode:
+		return ast.TDuration
+	case *ast.RegexNode:
+		return ast.TRegex
+	case *ast.UnaryNode:
+		if node.Operator == ast.TokenNot {
+			return ast.TBool
+		}
+		return constantType(node.Node)
+	case *ast.BinaryNode:
+		if ast.IsCompOperator(node.Operator) || ast.IsLogicalOperator(node.Operator) {
+			return ast.TBool
+		}
+		// Both operands of a math operator have the same type.
+		if l := constantType(node.Left); l != ast.InvalidType {
+			return l
+		}
+		return constantType(node.Right)
+	case *ast.FunctionNode:
+		switch node.Func {
+		case "int":
+			return ast.TInt
+		case "float":
+			return ast.TFloat
+		case "string":
+			return ast.TString
+		case "bool":
+			return ast.TBool
+		case "if":
+			if len(node.Args) == 3 {
+				if t := constantType(node.Args[1]); t != ast.InvalidType {
+					return t
+				}
+				return constantType(node.Args[2])
+			}
+		}
+	case *ast.LambdaNode:
+		return constantType(node.Expression)
+	}
+	return ast.InvalidType
+}
+
 // Indicates an alert should trigger only if all points in a batch match the criteria.
 // Does not apply to stream alerts.
 // tick:property
@@ -502,6 +693,63 @@ func (n *AlertNodeData) NoRecoveries() *AlertNodeData {
 	return n
 }
 
+// Send the events of the alert in a digest instead of individually, for non urgent alerts.
+// The events are accumulated and a single digest event listing them is sent
+// to the digest topic at the end of each digest interval, as configured in the [alert] section of the configuration.
+// CRITICAL events bypass the digest and are sent immediately.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('disk')
+//	    |alert()
+//	        .info(lambda: "used_percent" > 70)
+//	        .warn(lambda: "used_percent" > 80)
+//	        .crit(lambda: "used_percent" > 95)
+//	        .digest()
+//	        .slack()
+//
+// The INFO and WARNING events, and their recoveries, are part of the digest sent to the handlers of the digest topic,
+// while the CRITICAL events are sent to Slack as they happen.
+// Without a digest interval configured the events are sent individually.
+// tick:property
+func (n *AlertNodeData) Digest() *AlertNodeData {
+	n.DigestFlag = true
+	return n
+}
+
+// Draw a small chart of the recent values of a field in the events of the alert, so that the events are easier to read at a glance.
+// The sparkline is a line of text made of the Unicode block characters `▁▂▃▄▅▆▇█`, one per value,
+// scaled from the lowest to the highest value, e.g. `▁▂▄▃▆█`.
+// The values are those of the last points of the group, up to the given number of points and at most 100,
+// including the points of the batch for batch data. Points without a numeric value for the field are skipped.
+//
+// The sparkline is added to the alert data as `sparkline`, and is available as .Sparkline in the message and details templates
+// of the alert and in the templates of the handlers.
+// Handlers sending the alert data, such as post, tcp, log, exec and kafka, include it in their payload,
+// while chat and paging handlers, such as slack, telegram, teams, discord or pagerduty, render it when it is part of the message.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |alert()
+//	        .crit(lambda: "usage" > 90)
+//	        .message('{{ index .Tags "host" }} cpu is {{ .Level }} {{ .Sparkline }}')
+//	        .sparkline('usage', 20)
+//	        .slack()
+//
+// The sparkline is computed only when the alert has a sparkline, from a bounded number of values kept per group.
+// tick:property
+func (n *AlertNodeData) Sparkline(field string, points int64) *AlertNodeData {
+	n.SparklineField = field
+	n.SparklinePoints = points
+	return n
+}
+
 // Only sends events where the state changed.
 // Each different alert level OK, INFO, WARNING, and CRITICAL
 // are considered different states.
@@ -545,6 +793,10 @@ func (n *AlertNodeData) NoRecoveries() *AlertNodeData {
 //
 // The above usage will only trigger alerts to slack on state changes or at least every 10 minutes.
 //
+// The periodic alerts are not sent while the event is acknowledged,
+// see the `/kapacitor/v1/alerts/topics/<topic>/events/<event>` API.
+// An acknowledgement lasts until the event recovers or its level increases.
+//
 // tick:property
 func (n *AlertNodeData) StateChangesOnly(maxInterval ...time.Duration) *AlertNodeData {
 	n.IsStateChangesOnly = true
@@ -618,6 +870,32 @@ type Inhibitor struct {
 	EqualTags []string `json:"equalTags"`
 }
 
+// AlertHandlerLevel filters the events sent to a handler by their level,
+// so that one alert can send every event to one handler and only the most severe to another.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |alert()
+//	        .warn(lambda: "usage_idle" < 20)
+//	        .crit(lambda: "usage_idle" < 10)
+//	        .slack()
+//	        .pagerDuty()
+//	            .minLevel('CRITICAL')
+//
+// Slack is sent every event while PagerDuty is only sent the CRITICAL events,
+// and the recovery of the alert once it was sent a CRITICAL event.
+// tick:ignore
+type AlertHandlerLevel struct {
+	// The minimum level of the events sent to the handler, one of OK, INFO, WARNING or CRITICAL.
+	// Events of a lower level are not sent to the handler,
+	// except the OK event of the recovery of an alert the handler was sent.
+	// Default: OK, every event is sent
+	MinLevel string `json:"minLevel,omitempty"`
+}
+
 // HTTP POST JSON alert data to a specified URL.
 //
 // Example:
@@ -651,6 +929,7 @@ func (n *AlertNodeData) Post(urls ...string) *AlertHTTPPostHandler {
 // tick:embedded:AlertNode.Post
 type AlertHTTPPostHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The POST URL.
 	// tick:ignore
@@ -740,6 +1019,7 @@ func (n *AlertNodeData) Tcp(address string) *TcpHandler {
 // tick:embedded:AlertNode.Tcp
 type TcpHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The endpoint address.
 	Address string `json:"address"`
@@ -809,6 +1089,7 @@ func (n *AlertNodeData) Email(to ...string) *EmailHandler {
 // tick:embedded:AlertNode.Email
 type EmailHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// List of email recipients.
 	// tick:ignore
@@ -894,6 +1175,7 @@ func (n *AlertNodeData) Exec(executable string, args ...string) *ExecHandler {
 // tick:embedded:AlertNode.Exec
 type ExecHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The command to execute
 	// tick:ignore
@@ -929,6 +1211,7 @@ func (n *AlertNodeData) Log(filepath string) *LogHandler {
 // tick:embedded:AlertNode.Log
 type LogHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Absolute path the the log file.
 	// It will be created if it does not exist.
@@ -1001,6 +1284,7 @@ func (n *AlertNodeData) VictorOps() *VictorOpsHandler {
 // tick:embedded:AlertNode.VictorOps
 type VictorOpsHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The routing key to use for the alert.
 	// Defaults to the value in the configuration if empty.
@@ -1062,6 +1346,7 @@ func (n *AlertNodeData) PagerDuty() *PagerDutyHandler {
 // tick:embedded:AlertNode.PagerDuty
 type PagerDutyHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
@@ -1124,6 +1409,7 @@ func (n *AlertNodeData) PagerDuty2() *PagerDuty2Handler {
 // tick:embedded:AlertNode.PagerDuty
 type PagerDuty2Handler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The routing key to use for the alert.
 	// Defaults to the value in the configuration if empty.
@@ -1240,6 +1526,7 @@ func (n *AlertNodeData) HipChat() *HipChatHandler {
 // tick:embedded:AlertNode.HipChat
 type HipChatHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// HipChat room in which to post messages.
 	// If empty uses the channel from the configuration.
@@ -1248,6 +1535,11 @@ type HipChatHandler struct {
 	// HipChat authentication token.
 	// If empty uses the token from the configuration.
 	Token string `json:"token"`
+
+	// Name of a secret holding the HipChat authentication token.
+	// The secret is resolved when the task starts, taking precedence over Token,
+	// so the token itself is never part of the task definition.
+	TokenRef string `json:"tokenRef"`
 }
 
 // Send the alert to Alerta.
@@ -1301,11 +1593,17 @@ func (n *AlertNodeData) Alerta() *AlertaHandler {
 // tick:embedded:AlertNode.Alerta
 type AlertaHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Alerta authentication token.
 	// If empty uses the token from the configuration.
 	Token string `json:"token"`
 
+	// Name of a secret holding the Alerta authentication token.
+	// The secret is resolved when the task starts, taking precedence over Token,
+	// so the token itself is never part of the task definition.
+	TokenRef string `json:"tokenRef"`
+
 	// Alerta resource.
 	// Can be a template and has access to the same data as the AlertNode.Details property.
 	// Default: {{ .Name }}
@@ -1385,6 +1683,7 @@ func (n *AlertNodeData) Mqtt(topic string) *MQTTHandler {
 // tick:embedded:AlertNode.Mqtt
 type MQTTHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// BrokerName is the name of the configured MQTT broker to use when publishing the alert.
 	// If empty defaults to the configured default broker.
@@ -1447,6 +1746,7 @@ func (n *AlertNodeData) Sensu() *SensuHandler {
 // tick:embedded:AlertNode.Sensu
 type SensuHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Sensu source in which to post messages.
 	// If empty uses the Source from the configuration.
@@ -1528,6 +1828,7 @@ func (n *AlertNodeData) Pushover() *PushoverHandler {
 // tick:embedded:AlertNode.Pushover
 type PushoverHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// User/Group key of your user (or you), viewable when logged
 	// into the Pushover dashboard. Often referred to as USER_KEY
@@ -1607,6 +1908,17 @@ type PushoverHandler struct {
 //
 // send alerts to the opencommunity workspace on the channel '#support'
 //
+// Example:
+//
+//	stream
+//	     |alert()
+//	         .slack()
+//	         .tokenRef('SLACK_TOKEN')
+//
+// Send alerts using the token stored in the secret 'SLACK_TOKEN'.
+// Secrets are looked up in the file configured in the 'secrets' section,
+// falling back to the environment variables whose name starts with the configured 'env-prefix'.
+//
 // If the 'slack' section in the configuration has the option: global = true
 // then all alerts are sent to Slack without the need to explicitly state it
 // in the TICKscript.
@@ -1640,6 +1952,7 @@ func (n *AlertNodeData) Slack() *SlackHandler {
 // tick:embedded:AlertNode.Slack
 type SlackHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// The workspace to publish the alert to.  If empty defaults to the configured
 	// default broker.
@@ -1656,6 +1969,12 @@ type SlackHandler struct {
 	// IconEmoji is an emoji name surrounded in ':' characters.
 	// The emoji image will replace the normal user icon for the slack bot.
 	IconEmoji string `json:"iconEmoji"`
+
+	// Name of a secret holding the Slack token.
+	// The secret is resolved when the task starts, from the secrets file or environment,
+	// so the token itself is never part of the task definition.
+	// If empty uses the token from the configuration.
+	TokenRef string `json:"tokenRef"`
 }
 
 // Send the alert to Discord.
@@ -1723,6 +2042,7 @@ func (n *AlertNodeData) Discord() *DiscordHandler {
 // tick:embedded:AlertNode.Discord
 type DiscordHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Discord workspace ID to use when posting to webhook
 	// If empty uses the default config
@@ -1801,6 +2121,7 @@ func (n *AlertNodeData) BigPanda() *BigPandaHandler {
 // tick:embedded:AlertNode.BigPanda
 type BigPandaHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 	// Application key
 	// If empty uses the default config
 	AppKey string `json:"app-key"`
@@ -1895,6 +2216,7 @@ func (n *AlertNodeData) Telegram() *TelegramHandler {
 // tick:embedded:AlertNode.Telegram
 type TelegramHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Telegram user/group ID to post messages to.
 	// If empty uses the chati-d from the configuration.
@@ -1988,6 +2310,7 @@ func (n *AlertNodeData) OpsGenie() *OpsGenieHandler {
 // tick:embedded:AlertNode.OpsGenie
 type OpsGenieHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// OpsGenie Teams.
 	// tick:ignore
@@ -2075,6 +2398,7 @@ func (n *AlertNodeData) OpsGenie2() *OpsGenie2Handler {
 // tick:embedded:AlertNode.OpsGenie2
 type OpsGenie2Handler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// OpsGenie2 Teams.
 	// tick:ignore
@@ -2159,6 +2483,7 @@ func (n *AlertNodeData) Talk() *TalkHandler {
 // tick:embedded:AlertNode.Talk
 type TalkHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 }
 
 // Send the alert using SNMP traps.
@@ -2194,6 +2519,7 @@ func (n *AlertNodeData) SnmpTrap(trapOid string) *SNMPTrapHandler {
 // tick:embedded:AlertNode.SnmpTrap
 type SNMPTrapHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// TrapOid
 	// tick:ignore
@@ -2298,6 +2624,7 @@ func (n *AlertNodeData) Kafka() *KafkaHandler {
 // tick:embedded:AlertNode.Kafka
 type KafkaHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Cluster is the id of the configure kafka cluster
 	Cluster string `json:"cluster"`
@@ -2396,6 +2723,7 @@ func (n *AlertNodeData) Teams() *TeamsHandler {
 // tick:embedded:AlertNode.Teams
 type TeamsHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Teams channel webhook URL to post messages.
 	// If empty uses the URL from the configuration.
@@ -2450,6 +2778,7 @@ func (n *AlertNodeData) ServiceNow() *ServiceNowHandler {
 // tick:embedded:AlertNode.ServiceNow
 type ServiceNowHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// ServiceNow API URL to post alerts.
 	// If empty uses the URL from the configuration.
@@ -2553,6 +2882,7 @@ func (n *AlertNodeData) Zenoss() *ZenossHandler {
 // tick:embedded:AlertNode.Zenoss
 type ZenossHandler struct {
 	*AlertNodeData `json:"-"`
+	AlertHandlerLevel
 
 	// Zenoss API URL to post alerts.
 	// If empty uses the URL from the configuration.
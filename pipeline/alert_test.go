@@ -2,6 +2,8 @@ package pipeline
 
 import (
 	"testing"
+
+	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
 func TestAlertNode_MarshalJSON(t *testing.T) {
@@ -37,6 +39,7 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "priority": null,
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -51,6 +54,15 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "jitter": 0,
+    "incidentHistory": 0,
+    "escalateReminders": 0,
+    "escalateAfter": 0,
+    "recoverAfter": 0,
+    "recoverAfterCount": 0,
+    "digest": false,
+    "sparkline": "",
+    "sparklinePoints": 0,
     "inhibitors": null,
     "post": [
         {
@@ -116,6 +128,7 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "priority": null,
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -130,6 +143,15 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "jitter": 0,
+    "incidentHistory": 0,
+    "escalateReminders": 0,
+    "escalateAfter": 0,
+    "recoverAfter": 0,
+    "recoverAfterCount": 0,
+    "digest": false,
+    "sparkline": "",
+    "sparklinePoints": 0,
     "inhibitors": null,
     "post": null,
     "tcp": null,
@@ -192,6 +214,7 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "infoReset": null,
     "warnReset": null,
     "critReset": null,
+    "priority": null,
     "useFlapping": false,
     "flapLow": 0,
     "flapHigh": 0,
@@ -206,6 +229,15 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "jitter": 0,
+    "incidentHistory": 0,
+    "escalateReminders": 0,
+    "escalateAfter": 0,
+    "recoverAfter": 0,
+    "recoverAfterCount": 0,
+    "digest": false,
+    "sparkline": "",
+    "sparklinePoints": 0,
     "inhibitors": null,
     "post": null,
     "tcp": null,
@@ -247,3 +279,32 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertNode_ValidatePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority string
+		wantErr  bool
+	}{
+		{name: "int", priority: `if("level" == 'CRITICAL', 10, 5) + 2`},
+		{name: "field", priority: `"priority"`},
+		{name: "int function", priority: `int("priority")`},
+		{name: "float", priority: `1.5`, wantErr: true},
+		{name: "float if", priority: `if("level" == 'CRITICAL', 10.0, 5.0)`, wantErr: true},
+		{name: "comparison", priority: `"value" > 10`, wantErr: true},
+		{name: "string", priority: `string("value")`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := `stream
+	|from()
+	|alert()
+		.priority(lambda: ` + tt.priority + `)
+`
+			_, err := CreatePipeline(script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if got := err != nil; got != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
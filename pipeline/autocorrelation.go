@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the autocorrelation coefficient of a field at a given lag.
+// The coefficient is computed over the most recent points of each group,
+// and measures how similar the series is to itself shifted by lag points.
+// A series with a regular cadence of lag points has a coefficient close to 1,
+// and the coefficient degrades as the periodicity breaks down.
+//
+// The sample autocorrelation over the last size points is used:
+//
+//	r = sum((x[t] - mean) * (x[t+lag] - mean)) / sum((x[t] - mean)^2)
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('jobs')
+//	    |groupBy('job')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |count('runs')
+//	    |autocorrelation('count')
+//	        .lag(60)
+//	        .size(240)
+//	    |alert()
+//	        // The hourly job is no longer running on its schedule.
+//	        .warn(lambda: isPresent("autocorrelation") AND "autocorrelation" < 0.5)
+//
+// The coefficient is added to each point as the field `autocorrelation`.
+// Until size points have been seen (warm-up), or when all points in the window are equal (zero variance),
+// the coefficient is undefined and the field is not set on the point.
+// Points without a numeric value are dropped and are not added to the window.
+// State is kept per group, and is reset at the start of each batch.
+type AutocorrelationNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when computing the autocorrelation.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The lag, in number of points.
+	// Default: 1
+	Lag int64 `json:"lag"`
+
+	// The number of points over which the autocorrelation is computed.
+	// Must be greater than the lag plus one.
+	// Default: 10
+	Size int64 `json:"size"`
+
+	// The name of the autocorrelation field.
+	// Default: autocorrelation
+	As string `json:"as"`
+}
+
+func newAutocorrelationNode(wants EdgeType, field string) *AutocorrelationNode {
+	return &AutocorrelationNode{
+		chainnode: newBasicChainNode("autocorrelation", wants, wants),
+		Field:     field,
+		Lag:       1,
+		Size:      10,
+		As:        "autocorrelation",
+	}
+}
+
+// MarshalJSON converts AutocorrelationNode to JSON
+// tick:ignore
+func (n *AutocorrelationNode) MarshalJSON() ([]byte, error) {
+	type Alias AutocorrelationNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "autocorrelation",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an AutocorrelationNode
+// tick:ignore
+func (n *AutocorrelationNode) UnmarshalJSON(data []byte) error {
+	type Alias AutocorrelationNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "autocorrelation" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as AutocorrelationNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *AutocorrelationNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for autocorrelation")
+	}
+	if n.Lag < 1 {
+		return errors.New("autocorrelation lag must be at least 1")
+	}
+	if n.Size <= n.Lag+1 {
+		return errors.New("autocorrelation size must be greater than the lag plus one")
+	}
+	if n.As == "" {
+		return errors.New("autocorrelation as must not be empty")
+	}
+	return nil
+}
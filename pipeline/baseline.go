@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Pass the points of groups without a baseline without comparing them.
+	BaselineMissingPass = "pass"
+	// Drop the points of groups without a baseline.
+	BaselineMissingDrop = "drop"
+)
+
+// Compare the fields of each point against a stored baseline, such as a known good result before a deploy.
+// The baseline is a recording, captured with the recording API, e.g. `kapacitor record batch` or `kapacitor record query`,
+// and is loaded once when the first point is received.
+//
+// The baseline of a group is the mean of each field over the points of the recording with the same tag values as the group:
+// the values of the `on` tags, or of the group by tags of the point if no tags are specified.
+// For each compared field three fields are added to the points: the baseline value, `<field>_baseline`,
+// the difference with the baseline, `<field>_diff`, and the ratio to the baseline, `<field>_ratio`.
+// The ratio is not set when the baseline value is zero.
+//
+// Example:
+//
+//	batch
+//	    |query('SELECT mean(latency) AS latency, mean(errors) AS errors FROM "app"."autogen"."requests"')
+//	        .period(5m)
+//	        .every(5m)
+//	        .groupBy('endpoint')
+//	    |baseline('known-good')
+//	        .fields('latency', 'errors')
+//	    |alert()
+//	        .warn(lambda: "latency_ratio" > 1.2)
+//	        .crit(lambda: "errors_diff" > 10.0)
+//
+// The above example alerts when the latency of an endpoint is 20 percent higher than in the `known-good` recording,
+// or when it has 10 more errors.
+//
+// Groups present in only one of the baseline and the current data are handled as follows:
+//
+//   - Points of groups without a baseline are passed without comparison fields, or dropped with the missing property set to `drop`.
+//   - Fields without a baseline value for the group, or without a numeric value on the point, are not compared.
+//   - Groups of the baseline without current data are not reported, as no point is received for them.
+//     Use a deadman or a stateful alert on the current data to detect them.
+//
+// Only numeric fields of the recording are part of the baseline.
+// Without a list of fields, every numeric field of a point with a baseline value is compared.
+type BaselineNode struct {
+	chainnode `json:"-"`
+
+	// The ID of the recording of the baseline.
+	// tick:ignore
+	Recording string `json:"recording"`
+
+	// The fields to compare.
+	// tick:ignore
+	FieldsList []string `tick:"Fields" json:"fields"`
+
+	// The tags matching the groups of the baseline.
+	// tick:ignore
+	OnTags []string `tick:"On" json:"on"`
+
+	// The policy for the points of groups without a baseline, either `pass` or `drop`.
+	// Default: pass
+	Missing string `json:"missing"`
+}
+
+func newBaselineNode(wants EdgeType, recording string) *BaselineNode {
+	return &BaselineNode{
+		chainnode: newBasicChainNode("baseline", wants, wants),
+		Recording: recording,
+		Missing:   BaselineMissingPass,
+	}
+}
+
+// MarshalJSON converts BaselineNode to JSON
+// tick:ignore
+func (n *BaselineNode) MarshalJSON() ([]byte, error) {
+	type Alias BaselineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "baseline",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an BaselineNode
+// tick:ignore
+func (n *BaselineNode) UnmarshalJSON(data []byte) error {
+	type Alias BaselineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "baseline" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as BaselineNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// The fields to compare against the baseline.
+// tick:property
+func (n *BaselineNode) Fields(fields ...string) *BaselineNode {
+	n.FieldsList = fields
+	return n
+}
+
+// The tags matching the groups of the baseline, instead of the group by tags of the points.
+// tick:property
+func (n *BaselineNode) On(tags ...string) *BaselineNode {
+	n.OnTags = tags
+	return n
+}
+
+func (n *BaselineNode) validate() error {
+	if n.Recording == "" {
+		return errors.New("must specify a recording for baseline")
+	}
+	switch n.Missing {
+	case BaselineMissingPass, BaselineMissingDrop:
+	default:
+		return fmt.Errorf("invalid baseline missing policy %q, must be one of 'pass' or 'drop'", n.Missing)
+	}
+	for _, f := range n.FieldsList {
+		if f == "" {
+			return errors.New("baseline fields must not be empty")
+		}
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ import (
 //    * query_errors -- number of errors when querying
 //    * batches_queried -- number of batches returned from queries
 //    * points_queried -- total number of points in batches
+//    * query_duration_ns -- duration of the most recent query
+//    * query_points -- number of points returned by the most recent query
 //
 
 type BatchNode struct {
@@ -128,6 +130,25 @@ func (b *BatchNode) dot(buf *bytes.Buffer) {
 //
 // In the above example InfluxDB is queried every 20 seconds; the window of time returned
 // spans 1 minute and is grouped into 10 second buckets.
+//
+// The duration of each query and the number of points it returned are available
+// as the query_duration_ns and query_points statistics of the node,
+// and can also be set as fields on the queried points with the queryDurationAs and queryPointsAs properties.
+//
+// Example:
+//
+//	batch
+//	    |query('SELECT count("value") FROM "telegraf"."default".requests')
+//	        .period(5m)
+//	        .every(5m)
+//	        .groupBy('host')
+//	        .queryDurationAs('query_duration')
+//	    |alert()
+//	        .warn(lambda: "query_duration" > 10.0)
+//
+// The above example warns when the query takes longer than 10 seconds.
+// Queries are not retried, a failed query is counted in the errors statistic of the node
+// and its batches are skipped.
 type QueryNode struct {
 	chainnode `json:"-"`
 
@@ -190,6 +211,14 @@ type QueryNode struct {
 	// The name of a configured InfluxDB cluster.
 	// If empty the default cluster will be used.
 	Cluster string `json:"cluster"`
+
+	// The name of a field set on each point with the duration of the query that returned it, in seconds.
+	// If empty the field is not set.
+	QueryDurationAs string `json:"queryDurationAs"`
+
+	// The name of a field set on each point with the number of points returned by the query that returned it.
+	// If empty the field is not set.
+	QueryPointsAs string `json:"queryPointsAs"`
 }
 
 func newQueryNode() *QueryNode {
@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+const (
+	minCardinalityPrecision = 4
+	maxCardinalityPrecision = 18
+)
+
+// Estimate the number of distinct values of a field or tag over a sliding window, per group.
+// The estimate is computed with HyperLogLog++, so the memory used per group is bounded
+// regardless of the number of distinct values, at the cost of an approximate count.
+// Each point is emitted with the estimate over the window ending at its time.
+//
+// The value is read from the field with the given name, or else from the tag with that name.
+// Points with neither are dropped.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |cardinality('client_ip')
+//	        .period(5m)
+//	    |alert()
+//	        .crit(lambda: "cardinality" > 10000)
+//
+// The above example alerts when more than 10000 distinct clients sent requests to a service in the last 5 minutes.
+//
+// Accuracy: the standard error of the estimate is about 1.04 / sqrt(2^precision),
+// i.e. 0.8% with the default precision of 14, and 0.4% with a precision of 16.
+// Small cardinalities are counted almost exactly.
+//
+// Memory: the window is split into slices, each with its own sketch, plus one for the whole window.
+// A sketch starts small and grows with the number of distinct values up to 2^precision bytes,
+// so a group uses at most (slices + 1) * 2^precision bytes, 176KiB with the defaults.
+//
+// The oldest slice is dropped as a whole, so the window covers between period - period/slices and period.
+// The window is based on the time of the points, and slices are aligned to multiples of period/slices.
+// In batch mode the estimate is reset at the start of each batch.
+type CardinalityNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field or tag whose distinct values are counted.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The duration of the sliding window.
+	Period time.Duration `json:"period"`
+
+	// The number of slices of the window.
+	// More slices make the window slide more smoothly, but use more memory.
+	// Default: 10
+	Slices int64 `json:"slices"`
+
+	// The precision of the sketches, between 4 and 18.
+	// Each additional bit of precision halves the variance of the estimate and doubles the memory.
+	// Default: 14
+	Precision int64 `json:"precision"`
+
+	// The name of the field holding the estimate.
+	// Default: cardinality
+	As string `json:"as"`
+}
+
+func newCardinalityNode(wants EdgeType, field string) *CardinalityNode {
+	return &CardinalityNode{
+		chainnode: newBasicChainNode("cardinality", wants, wants),
+		Field:     field,
+		Slices:    10,
+		Precision: 14,
+		As:        "cardinality",
+	}
+}
+
+// MarshalJSON converts CardinalityNode to JSON
+// tick:ignore
+func (n *CardinalityNode) MarshalJSON() ([]byte, error) {
+	type Alias CardinalityNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "cardinality",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CardinalityNode
+// tick:ignore
+func (n *CardinalityNode) UnmarshalJSON(data []byte) error {
+	type Alias CardinalityNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "cardinality" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CardinalityNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CardinalityNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for cardinality")
+	}
+	if n.Period <= 0 {
+		return fmt.Errorf("cardinality period must be positive, got %v", n.Period)
+	}
+	if n.Slices < 1 {
+		return fmt.Errorf("cardinality slices must be at least 1, got %d", n.Slices)
+	}
+	if n.Period%time.Duration(n.Slices) != 0 {
+		return fmt.Errorf("cardinality period %v must be a multiple of the number of slices %d", n.Period, n.Slices)
+	}
+	if n.Precision < minCardinalityPrecision || n.Precision > maxCardinalityPrecision {
+		return fmt.Errorf("cardinality precision must be between %d and %d, got %d", minCardinalityPrecision, maxCardinalityPrecision, n.Precision)
+	}
+	if n.As == "" {
+		return errors.New("must specify a field name for cardinality")
+	}
+	return nil
+}
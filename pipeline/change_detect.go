@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
@@ -60,12 +61,39 @@ import (
 // packets in=1,out=0 0000000001
 // packets in=1,out=1 0000000002
 // packets in=2,out=1 0000000004
+//
+// Numeric fields can be compared with a tolerance, so that small fluctuations of stable metrics are not emitted,
+// and the emitted points can be limited to the fields that changed.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('disk')
+//	    |changeDetect('used', 'free')
+//	        .tolerance(1024.0)
+//	        .changedOnly()
+//	    ...
+//
+// The above example emits a point only when used or free changed by more than 1024,
+// with only the fields that changed.
+// Each field is compared to its last emitted value in the group, not to its value in the previous point,
+// so that a slow drift is emitted once it exceeds the tolerance, even while other fields change.
 type ChangeDetectNode struct {
 	chainnode `json:"-"`
 
 	// The field to use when calculating the changeDetect
 	// tick:ignore
 	Fields []string `json:"fields"`
+
+	// The amount a numeric field must change by to be considered changed.
+	// Non-numeric fields are changed if their values are different.
+	// Default: 0.0, any change
+	Tolerance float64 `json:"tolerance"`
+
+	// Emit only the watched fields that changed, instead of all fields of the point.
+	// tick:ignore
+	ChangedOnlyFlag bool `tick:"ChangedOnly" json:"changedOnly"`
 }
 
 func newChangeDetectNode(wants EdgeType, fields []string) *ChangeDetectNode {
@@ -113,3 +141,18 @@ func (n *ChangeDetectNode) UnmarshalJSON(data []byte) error {
 	n.setID(raw.ID)
 	return nil
 }
+
+// Emit only the watched fields that changed.
+// The first point of a group has all of the watched fields.
+// tick:property
+func (n *ChangeDetectNode) ChangedOnly() *ChangeDetectNode {
+	n.ChangedOnlyFlag = true
+	return n
+}
+
+func (n *ChangeDetectNode) validate() error {
+	if n.Tolerance < 0 {
+		return errors.New("changeDetect tolerance must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Keep only the points of a group for which two conditions hold at the same time.
+// Each condition is a lambda expression, typically over the fields of a different side of a join,
+// which makes it possible to detect correlated failures of the series of a group.
+//
+// Unlike a single lambda combining both conditions, each condition is evaluated on its own,
+// so that the sides of the join do not need to be available for the same points.
+// When a point is missing a field or tag referenced by a condition, for example when one side
+// of an outer join is missing or is filled with null, the condition is not evaluated for the point.
+// The last result of the condition in the group is used instead if it was evaluated within
+// the tolerance of the time of the point, otherwise the condition does not hold.
+// With the default tolerance of 0, both conditions must hold for the same point.
+//
+// Example:
+//
+//	var errors = stream
+//	    |from()
+//	        .measurement('errors')
+//	        .groupBy('service')
+//	var latency = stream
+//	    |from()
+//	        .measurement('latency')
+//	        .groupBy('service')
+//
+//	errors
+//	    |join(latency)
+//	        .as('errors', 'latency')
+//	        .fill('null')
+//	    |coincidence(lambda: "errors.rate" > 10.0, lambda: "latency.p99" > 500.0)
+//	        .tolerance(1m)
+//	    |alert()
+//	        .crit(lambda: TRUE)
+//
+// The points are passed through unchanged.
+// State is kept per group, and is reset at the start of each batch.
+type CoincidenceNode struct {
+	chainnode `json:"-"`
+
+	// The first condition.
+	// tick:ignore
+	Left *ast.LambdaNode `json:"left"`
+
+	// The second condition.
+	// tick:ignore
+	Right *ast.LambdaNode `json:"right"`
+
+	// How long the last result of a condition is used for points missing its fields.
+	// Default: 0, the conditions must hold for the same point
+	Tolerance time.Duration `json:"tolerance"`
+}
+
+func newCoincidenceNode(wants EdgeType, left, right *ast.LambdaNode) *CoincidenceNode {
+	return &CoincidenceNode{
+		chainnode: newBasicChainNode("coincidence", wants, wants),
+		Left:      left,
+		Right:     right,
+	}
+}
+
+// MarshalJSON converts CoincidenceNode to JSON
+// tick:ignore
+func (n *CoincidenceNode) MarshalJSON() ([]byte, error) {
+	type Alias CoincidenceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "coincidence",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CoincidenceNode
+// tick:ignore
+func (n *CoincidenceNode) UnmarshalJSON(data []byte) error {
+	type Alias CoincidenceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "coincidence" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CoincidenceNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CoincidenceNode) validate() error {
+	if n.Left == nil || n.Right == nil {
+		return errors.New("must specify two conditions for coincidence")
+	}
+	if n.Tolerance < 0 {
+		return errors.New("coincidence tolerance must not be negative")
+	}
+	return nil
+}
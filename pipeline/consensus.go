@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+const (
+	// The median of the values of the replicas.
+	ConsensusMedian = "median"
+	// The most common value of the replicas, i.e. a majority vote.
+	ConsensusMode = "mode"
+)
+
+// Compute a consensus value of a field across the replicas of an entity, such as redundant sensors measuring the same quantity,
+// and how much the replicas disagree, instead of trusting any single one of them.
+//
+// The points of the replicas of an entity must be in the same group, e.g. grouped by the tags of the entity and not by the replica tag.
+// The latest value of each replica within the period before each point is a vote,
+// and a point is emitted per point of the group with the consensus of the votes, `consensus`,
+// their disagreement, `disagreement`, the number of replicas voting, `replicas`,
+// and the replica the farthest from the consensus, `outlier`, which is empty when the replicas agree.
+// The emitted points have the tags of the group, without the replica tag.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('temperature')
+//	        .groupBy('room')
+//	    |consensus('value')
+//	        .replica('sensor')
+//	        .period(5m)
+//	    |alert()
+//	        .warn(lambda: "disagreement" > 2.0)
+//	        .crit(lambda: "replicas" < 2)
+//	        .message('{{ index .Tags "room" }} sensors disagree, {{ index .Fields "outlier" }} may be faulty')
+//
+// The above example warns when a temperature sensor of a room is more than 2 degrees from the median of the sensors of the room,
+// and is critical when fewer than two sensors of the room reported in the last 5 minutes.
+//
+// The consensus methods are:
+//
+//   - median -- the median of the votes; the disagreement is the largest absolute difference between a vote and the median.
+//   - mode -- the most common vote, the smallest of the most common on a tie;
+//     the disagreement is the fraction of the votes that differ from the mode.
+//
+// A replica going silent stops voting once its latest point is older than the period,
+// which shows as a drop of the number of replicas.
+// Points without the replica tag or a numeric value of the field are ignored.
+// For batch data a single point is emitted per group and batch, with the time of the batch.
+// State is kept per group across batches.
+type ConsensusNode struct {
+	chainnode `json:"-"`
+
+	// The field of the value of the replicas.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The tag identifying the replicas.
+	Replica string `json:"replica"`
+
+	// The period within which the latest value of a replica is a vote.
+	// Default: 1m
+	Period time.Duration `json:"period"`
+
+	// The consensus method, either median or mode.
+	// Default: median
+	Method string `json:"method"`
+
+	// The name of the consensus field.
+	// Default: consensus
+	As string `json:"as"`
+
+	// The name of the disagreement field.
+	// Default: disagreement
+	DisagreementAs string `json:"disagreementAs"`
+
+	// The name of the number of replicas field.
+	// Default: replicas
+	ReplicasAs string `json:"replicasAs"`
+
+	// The name of the outlier field.
+	// Default: outlier
+	OutlierAs string `json:"outlierAs"`
+}
+
+func newConsensusNode(wants EdgeType, field string) *ConsensusNode {
+	return &ConsensusNode{
+		chainnode:      newBasicChainNode("consensus", wants, StreamEdge),
+		Field:          field,
+		Period:         time.Minute,
+		Method:         ConsensusMedian,
+		As:             "consensus",
+		DisagreementAs: "disagreement",
+		ReplicasAs:     "replicas",
+		OutlierAs:      "outlier",
+	}
+}
+
+// MarshalJSON converts ConsensusNode to JSON
+// tick:ignore
+func (n *ConsensusNode) MarshalJSON() ([]byte, error) {
+	type Alias ConsensusNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "consensus",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ConsensusNode
+// tick:ignore
+func (n *ConsensusNode) UnmarshalJSON(data []byte) error {
+	type Alias ConsensusNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "consensus" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ConsensusNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *ConsensusNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for consensus")
+	}
+	if n.Replica == "" {
+		return errors.New("must specify a replica tag for consensus")
+	}
+	if n.Period <= 0 {
+		return errors.New("consensus period must be greater than zero")
+	}
+	switch n.Method {
+	case ConsensusMedian, ConsensusMode:
+	default:
+		return fmt.Errorf("unknown consensus method %q, must be one of median or mode", n.Method)
+	}
+	names := map[string]bool{}
+	for _, name := range []string{n.As, n.DisagreementAs, n.ReplicasAs, n.OutlierAs} {
+		if name == "" {
+			return errors.New("consensus field names must not be empty")
+		}
+		if names[name] {
+			return fmt.Errorf("consensus field name %q is used more than once", name)
+		}
+		names[name] = true
+	}
+	return nil
+}
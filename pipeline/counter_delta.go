@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the increase of a monotonic counter within each batch, handling counter resets.
+// The positive increments between consecutive points are summed.
+// A decrease is treated as a reset of the counter, for example after a restart,
+// and the counter is assumed to have restarted from zero,
+// so the value after the reset is counted as its increment.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('http')
+//	        .groupBy('host')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	        .align()
+//	    |counterDelta('requests')
+//	        .as('requests_per_minute')
+//
+// For the counter values 10, 15, 20, 3, 8 of a window, the increase is 5 + 5 + 3 + 5 = 18,
+// where the sum or the difference of the values would be incorrect across the reset.
+//
+// A single point is emitted per group and batch, with the time of the batch.
+// Only the increase observed within a batch is counted, not the increase from the last point of the previous batch.
+// The increase is an integer if all values are integers, and a float otherwise.
+// Points without a numeric value for the field are ignored, and empty batches do not emit a point.
+type CounterDeltaNode struct {
+	chainnode `json:"-"`
+
+	// The field of the counter.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the increase field.
+	// Default: delta
+	As string `json:"as"`
+}
+
+func newCounterDeltaNode(field string) *CounterDeltaNode {
+	return &CounterDeltaNode{
+		chainnode: newBasicChainNode("counterDelta", BatchEdge, StreamEdge),
+		Field:     field,
+		As:        "delta",
+	}
+}
+
+// MarshalJSON converts CounterDeltaNode to JSON
+// tick:ignore
+func (n *CounterDeltaNode) MarshalJSON() ([]byte, error) {
+	type Alias CounterDeltaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "counterDelta",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CounterDeltaNode
+// tick:ignore
+func (n *CounterDeltaNode) UnmarshalJSON(data []byte) error {
+	type Alias CounterDeltaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "counterDelta" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CounterDeltaNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CounterDeltaNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for counterDelta")
+	}
+	if n.As == "" {
+		return errors.New("counterDelta as must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the lag at which two fields are the most correlated, to detect lead and lag relationships between two series,
+// e.g. whether the depth of a queue predicts the latency of requests two minutes later.
+// The two fields are usually the fields of two joined series.
+//
+// The cross-correlation is computed over the most recent points of each group, for each lag from minLag to maxLag,
+// as the Pearson correlation coefficient of the leading field and the lagging field shifted by lag points:
+//
+//	r(lag) = corr(x[t], y[t+lag])
+//
+// A positive lag means the leading field leads the lagging field, and a negative lag means it lags behind it.
+// The lag with the highest correlation is added to each point as the field `lag`, in number of points,
+// and its correlation as the field `correlation`.
+// Ties are broken in favor of the lag closest to zero.
+//
+// Example:
+//
+//	var queue = stream
+//	    |from()
+//	        .measurement('queue')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |mean('depth')
+//	        .as('depth')
+//
+//	var latency = stream
+//	    |from()
+//	        .measurement('requests')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |mean('latency')
+//	        .as('latency')
+//
+//	queue
+//	    |join(latency)
+//	        .as('queue', 'latency')
+//	    |crossCorrelation('queue.depth', 'latency.latency')
+//	        .maxLag(10)
+//	        .size(120)
+//	    |alert()
+//	        // The latency follows the depth of the queue by two minutes or more.
+//	        .warn(lambda: isPresent("lag") AND "lag" >= 2 AND "correlation" > 0.8)
+//
+// Since the points are one minute apart, a lag of 2 is two minutes.
+//
+// Until size points have been seen (warm-up) the lag is undefined and the fields are not set on the point.
+// Lags for which either field has zero variance over the shifted points are skipped,
+// and the fields are not set when no lag is defined.
+// Points without a numeric value for both fields are dropped and are not added to the window.
+// State is kept per group, and is reset at the start of each batch.
+//
+// Computing the correlation of every lag takes time proportional to size times the number of lags for each point,
+// e.g. 120 points and 21 lags from -10 to 10 is about 2500 operations per point.
+// Prefer an aggregated series, such as one point per minute, over the raw points for large windows or ranges of lags.
+type CrossCorrelationNode struct {
+	chainnode `json:"-"`
+
+	// The leading field.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The lagging field.
+	// tick:ignore
+	Lagging string `json:"lagging"`
+
+	// The smallest lag, in number of points.
+	// Default: 0
+	MinLag int64 `json:"minLag"`
+
+	// The largest lag, in number of points.
+	// Default: 10
+	MaxLag int64 `json:"maxLag"`
+
+	// The number of points over which the cross-correlation is computed.
+	// Must be greater than the absolute value of the lags plus one.
+	// Default: 60
+	Size int64 `json:"size"`
+
+	// The name of the lag field.
+	// Default: lag
+	LagAs string `json:"lagAs"`
+
+	// The name of the correlation field.
+	// Default: correlation
+	CorrelationAs string `json:"correlationAs"`
+}
+
+func newCrossCorrelationNode(wants EdgeType, field, lagging string) *CrossCorrelationNode {
+	return &CrossCorrelationNode{
+		chainnode:     newBasicChainNode("crossCorrelation", wants, wants),
+		Field:         field,
+		Lagging:       lagging,
+		MaxLag:        10,
+		Size:          60,
+		LagAs:         "lag",
+		CorrelationAs: "correlation",
+	}
+}
+
+// MarshalJSON converts CrossCorrelationNode to JSON
+// tick:ignore
+func (n *CrossCorrelationNode) MarshalJSON() ([]byte, error) {
+	type Alias CrossCorrelationNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "crossCorrelation",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CrossCorrelationNode
+// tick:ignore
+func (n *CrossCorrelationNode) UnmarshalJSON(data []byte) error {
+	type Alias CrossCorrelationNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "crossCorrelation" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CrossCorrelationNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CrossCorrelationNode) validate() error {
+	if n.Field == "" || n.Lagging == "" {
+		return errors.New("must specify a leading and a lagging field for crossCorrelation")
+	}
+	if n.Field == n.Lagging {
+		return errors.New("crossCorrelation leading and lagging fields must be different")
+	}
+	if n.MinLag > n.MaxLag {
+		return errors.New("crossCorrelation minLag must not be greater than maxLag")
+	}
+	if n.Size <= absInt64(n.MinLag)+1 || n.Size <= absInt64(n.MaxLag)+1 {
+		return errors.New("crossCorrelation size must be greater than the absolute value of the lags plus one")
+	}
+	if n.LagAs == "" || n.CorrelationAs == "" {
+		return errors.New("crossCorrelation field names must not be empty")
+	}
+	if n.LagAs == n.CorrelationAs {
+		return errors.New("crossCorrelation lagAs and correlationAs must be different")
+	}
+	return nil
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the cumulative sum (CUSUM) change-point statistics of a field.
+// CUSUM detects sustained shifts of a series' mean away from a known reference,
+// and is more robust to isolated spikes than a simple threshold.
+//
+// For each point, with x the field value, the positive and negative statistics are updated via:
+//
+//	pos = max(0, pos + x - (target + slack))
+//	neg = max(0, neg + (target - slack) - x)
+//
+// When either statistic exceeds the threshold a change is signaled,
+// 1 for an upward shift, -1 for a downward shift and 0 otherwise.
+// After a signal both statistics are reset to zero,
+// so that each sustained shift is signaled once and detection restarts from the new point.
+//
+// Tuning:
+//
+//   - target is the reference mean of the series when it is in control.
+//   - slack (often called drift or k) is the amount of deviation tolerated without accumulating.
+//     A common choice is half of the shift size you want to detect.
+//   - threshold (often called h) is the decision threshold. Larger values reduce false positives
+//     at the cost of slower detection. A common choice is 4 or 5 times the standard deviation of the series.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('latency')
+//	    |groupBy('host')
+//	    |cusum('value')
+//	        .target(100.0)
+//	        .slack(5.0)
+//	        .threshold(50.0)
+//	    |alert()
+//	        .crit(lambda: "cusum_signal" != 0)
+//
+// The statistics are added to each point as the fields `cusum_pos`, `cusum_neg` and `cusum_signal`.
+// State is kept per group, and is reset at the start of each batch.
+type CusumNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when calculating the statistics.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The reference mean of the field.
+	Target float64 `json:"target"`
+
+	// The deviation from the target tolerated without accumulating.
+	// Default: 0.0
+	Slack float64 `json:"slack"`
+
+	// The decision threshold for the statistics.
+	Threshold float64 `json:"threshold"`
+
+	// The name of the positive statistic field.
+	// Default: cusum_pos
+	PositiveAs string `json:"positiveAs"`
+
+	// The name of the negative statistic field.
+	// Default: cusum_neg
+	NegativeAs string `json:"negativeAs"`
+
+	// The name of the signal field.
+	// Default: cusum_signal
+	SignalAs string `json:"signalAs"`
+}
+
+func newCusumNode(wants EdgeType, field string) *CusumNode {
+	return &CusumNode{
+		chainnode:  newBasicChainNode("cusum", wants, wants),
+		Field:      field,
+		PositiveAs: "cusum_pos",
+		NegativeAs: "cusum_neg",
+		SignalAs:   "cusum_signal",
+	}
+}
+
+// MarshalJSON converts CusumNode to JSON
+// tick:ignore
+func (n *CusumNode) MarshalJSON() ([]byte, error) {
+	type Alias CusumNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "cusum",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CusumNode
+// tick:ignore
+func (n *CusumNode) UnmarshalJSON(data []byte) error {
+	type Alias CusumNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "cusum" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CusumNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CusumNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for cusum")
+	}
+	if n.Slack < 0 {
+		return errors.New("cusum slack must not be negative")
+	}
+	if n.Threshold <= 0 {
+		return errors.New("cusum threshold must be greater than zero")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Decompose a field into trend, seasonal and residual components, and compute the residual.
+// The residual is what remains of a value once the trend and the seasonality are removed,
+// which makes anomalies of cyclical metrics stand out from their regular variations.
+//
+// The season is the number of points in a cycle, for example 24 for an hourly daily cycle.
+// For each value x, the components are estimated from the previous values of the group via:
+//
+//	trend    = mean of the previous season values
+//	seasonal = mean of (x - trend) at the same position of all previous seasons
+//	residual = x - trend - seasonal
+//
+// The points of a group must arrive at a regular interval for the positions in the season to line up.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |groupBy('service')
+//	    |window()
+//	        .period(1h)
+//	        .every(1h)
+//	        .align()
+//	    |sum('value')
+//	        .as('value')
+//	    |decompose('value')
+//	        .season(24)
+//	    |alert()
+//	        .warn(lambda: isPresent("residual") AND abs("residual") > 1000.0)
+//
+// The components are added to each point as the fields `residual`, `trend` and `seasonal`.
+// Until a full season of values has been seen (warm-up), the fields are not set on the point.
+// Points without a numeric value are dropped and are not added to the history.
+// State is kept per group, and is reset at the start of each batch.
+type DecomposeNode struct {
+	chainnode `json:"-"`
+
+	// The field to decompose.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The number of points in a season.
+	// Must be at least 2.
+	Season int64 `json:"season"`
+
+	// The name of the residual field.
+	// Default: residual
+	As string `json:"as"`
+
+	// The name of the trend field.
+	// Default: trend
+	TrendAs string `json:"trendAs"`
+
+	// The name of the seasonal field.
+	// Default: seasonal
+	SeasonalAs string `json:"seasonalAs"`
+}
+
+func newDecomposeNode(wants EdgeType, field string) *DecomposeNode {
+	return &DecomposeNode{
+		chainnode:  newBasicChainNode("decompose", wants, wants),
+		Field:      field,
+		As:         "residual",
+		TrendAs:    "trend",
+		SeasonalAs: "seasonal",
+	}
+}
+
+// MarshalJSON converts DecomposeNode to JSON
+// tick:ignore
+func (n *DecomposeNode) MarshalJSON() ([]byte, error) {
+	type Alias DecomposeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "decompose",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DecomposeNode
+// tick:ignore
+func (n *DecomposeNode) UnmarshalJSON(data []byte) error {
+	type Alias DecomposeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "decompose" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DecomposeNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DecomposeNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for decompose")
+	}
+	if n.Season < 2 {
+		return errors.New("decompose season must be at least 2")
+	}
+	if n.As == "" || n.TrendAs == "" || n.SeasonalAs == "" {
+		return errors.New("decompose field names must not be empty")
+	}
+	if n.As == n.TrendAs || n.As == n.SeasonalAs || n.TrendAs == n.SeasonalAs {
+		return errors.New("decompose field names must be unique")
+	}
+	return nil
+}
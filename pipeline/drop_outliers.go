@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Drop the statistical outliers of a field from each batch using Tukey's fences.
+// The first and third quartiles, Q1 and Q3, of the field are computed per group and batch,
+// and points outside of the fences are dropped:
+//
+//	[Q1 - k * (Q3 - Q1), Q3 + k * (Q3 - Q1)]
+//
+// The quartiles are linearly interpolated between the sorted values of the batch.
+// The remaining points are passed on unchanged, which makes the following reducers robust to spikes.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |groupBy('service')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |dropOutliers('latency')
+//	        .k(3.0)
+//	    |mean('latency')
+//
+// The above example computes the mean latency of each service, ignoring points
+// further than three interquartile ranges from the quartiles of the window.
+//
+// The quartiles of small batches are not meaningful,
+// batches with fewer than 4 points are passed on unchanged.
+// Points without a numeric value are dropped and do not contribute to the quartiles.
+// The number of dropped outliers is counted by the `outliers_dropped` stat.
+type DropOutliersNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when detecting outliers.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The multiple of the interquartile range which defines the fences.
+	// Common choices are 1.5 for outliers and 3.0 for extreme outliers.
+	// Default: 1.5
+	K float64 `json:"k"`
+}
+
+func newDropOutliersNode(field string) *DropOutliersNode {
+	return &DropOutliersNode{
+		chainnode: newBasicChainNode("dropOutliers", BatchEdge, BatchEdge),
+		Field:     field,
+		K:         1.5,
+	}
+}
+
+// MarshalJSON converts DropOutliersNode to JSON
+// tick:ignore
+func (n *DropOutliersNode) MarshalJSON() ([]byte, error) {
+	type Alias DropOutliersNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "dropOutliers",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DropOutliersNode
+// tick:ignore
+func (n *DropOutliersNode) UnmarshalJSON(data []byte) error {
+	type Alias DropOutliersNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "dropOutliers" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DropOutliersNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DropOutliersNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for dropOutliers")
+	}
+	if n.K < 0 {
+		return errors.New("dropOutliers k must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Enrich the points of a stream with the latest fields received from another node, per group.
+// The other node, usually computing thresholds periodically from a window of the same stream, is the source of the fields.
+// For each group the fields of the last point from the source are cached,
+// and are set on each point of the same group of the stream.
+// When a new batch arrives from the source the fields of its group are replaced at once,
+// so that points never see a mix of old and new fields.
+//
+// Example:
+//
+//	var requests = stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//
+//	var thresholds = requests
+//	    |window()
+//	        .period(1d)
+//	        .every(1h)
+//	    |percentile('latency', 99.0)
+//	        .as('p99')
+//
+//	requests
+//	    |enrich(thresholds)
+//	        .prefix('threshold_')
+//	        .defaultField('p99', 500.0)
+//	    |alert()
+//	        .crit(lambda: "latency" > "threshold_p99")
+//
+// The above example alerts when the latency of a service exceeds its p99 latency of the last day,
+// recomputed every hour, and uses a threshold of 500 until the first threshold is computed.
+// The source may also be another measurement of the stream, e.g. thresholds written by another task.
+//
+// The stream and the source are processed concurrently,
+// so a point arriving at the same time as new fields from the source may still be enriched with the previous fields.
+//
+// Both the stream and the source must be grouped by the same dimensions, as fields are matched by group.
+// The fields from the source overwrite fields with the same name on the stream points.
+// Until fields have been received for a group, the default fields are used in their place.
+type EnrichNode struct {
+	chainnode `json:"-"`
+
+	// Prefix added to the names of the fields from the source.
+	Prefix string `json:"prefix"`
+
+	// Fields used in place of the fields from the source, for groups without fields from the source.
+	// The names are prefixed like the fields from the source.
+	// tick:ignore
+	DefaultFields map[string]interface{} `tick:"DefaultField" json:"defaultFields"`
+}
+
+func newEnrichNode(stream, source Node) *EnrichNode {
+	e := &EnrichNode{
+		chainnode:     newBasicChainNode("enrich", StreamEdge, StreamEdge),
+		DefaultFields: make(map[string]interface{}),
+	}
+	stream.linkChild(e)
+	source.linkChild(e)
+	return e
+}
+
+// MarshalJSON converts EnrichNode to JSON
+// tick:ignore
+func (n *EnrichNode) MarshalJSON() ([]byte, error) {
+	type Alias EnrichNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "enrich",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an EnrichNode
+// tick:ignore
+func (n *EnrichNode) UnmarshalJSON(data []byte) error {
+	type Alias EnrichNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "enrich" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as EnrichNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Define a field used in place of the fields from the source until fields have been received for a group.
+// tick:property
+func (n *EnrichNode) DefaultField(name string, value interface{}) *EnrichNode {
+	n.DefaultFields[name] = value
+	return n
+}
+
+func (n *EnrichNode) validate() error {
+	if len(n.Parents()) != 2 {
+		return errors.New("enrich requires exactly one source node")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Compute the Shannon entropy of the distribution of a category over a sliding time window.
+// The category of a point is the value of a tag, or of a field if the point has no such tag.
+// For each group the number of points of each category within the period before each point is counted,
+// and the entropy of the counts, in bits, is added to the point:
+//
+//	-sum(p * log2(p)), where p is the fraction of the points of a category
+//
+// The entropy is 0 when all points have the same category, and log2(n) when the points are evenly distributed over n categories.
+// Use the normalize property to divide the entropy by log2(n), so that it ranges from 0 to 1 whatever the number of categories.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |entropy('endpoint')
+//	        .period(5m)
+//	        .normalize()
+//	    |alert()
+//	        // Traffic concentrates on a few endpoints.
+//	        .warn(lambda: "categories" > 10 AND "entropy" < 0.3)
+//
+// Two fields are added to each point, the entropy, `entropy`, and the number of distinct categories within the period, `categories`.
+// A drop of the entropy shows a concentration of the points on fewer categories, a rise shows a fragmentation.
+//
+// The number of distinct categories of each group is bounded by the max categories property:
+// points of new categories beyond the bound are dropped and counted with the `points_dropped` stat.
+// The number of categories of all groups is the `categories` stat.
+// Points without the category tag or field are dropped.
+// State is kept per group, and is reset at the start of each batch.
+type EntropyNode struct {
+	chainnode `json:"-"`
+
+	// The tag or field of the category.
+	// tick:ignore
+	Category string `json:"category"`
+
+	// The period of the sliding window.
+	// Default: 1m
+	Period time.Duration `json:"period"`
+
+	// The maximum number of distinct categories within the period of a group.
+	// Default: 1000
+	MaxCategories int64 `json:"maxCategories"`
+
+	// Whether to divide the entropy by its maximum for the number of categories.
+	// tick:ignore
+	NormalizeFlag bool `tick:"Normalize" json:"normalize"`
+
+	// The name of the entropy field.
+	// Default: entropy
+	As string `json:"as"`
+
+	// The name of the number of categories field.
+	// Default: categories
+	CategoriesAs string `json:"categoriesAs"`
+}
+
+func newEntropyNode(wants EdgeType, category string) *EntropyNode {
+	return &EntropyNode{
+		chainnode:     newBasicChainNode("entropy", wants, wants),
+		Category:      category,
+		Period:        time.Minute,
+		MaxCategories: 1000,
+		As:            "entropy",
+		CategoriesAs:  "categories",
+	}
+}
+
+// MarshalJSON converts EntropyNode to JSON
+// tick:ignore
+func (n *EntropyNode) MarshalJSON() ([]byte, error) {
+	type Alias EntropyNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "entropy",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an EntropyNode
+// tick:ignore
+func (n *EntropyNode) UnmarshalJSON(data []byte) error {
+	type Alias EntropyNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "entropy" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as EntropyNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Divide the entropy by log2 of the number of categories, so that it ranges from 0 to 1.
+// The normalized entropy of a single category is 0.
+// tick:property
+func (n *EntropyNode) Normalize() *EntropyNode {
+	n.NormalizeFlag = true
+	return n
+}
+
+func (n *EntropyNode) validate() error {
+	if n.Category == "" {
+		return errors.New("must specify a category for entropy")
+	}
+	if n.Period <= 0 {
+		return errors.New("entropy period must be greater than zero")
+	}
+	if n.MaxCategories <= 0 {
+		return errors.New("entropy maxCategories must be greater than zero")
+	}
+	if n.As == "" || n.CategoriesAs == "" {
+		return errors.New("entropy field names must not be empty")
+	}
+	if n.As == n.CategoriesAs {
+		return errors.New("entropy as and categoriesAs must be different")
+	}
+	return nil
+}
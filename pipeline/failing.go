@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Maintain the set of groups that are currently failing a condition, and emit that set on each interval.
+// The expression is evaluated for each point of each group, and a group is failing
+// while the latest evaluation of the expression is true.
+// A group that returns to an evaluation of false, has recovered and is removed from the set.
+//
+// Each interval a single batch is emitted with a point per failing group.
+// The points have the tags of their group and the duration the group has been failing,
+// and the batch is empty when no group is failing.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |failing(lambda: "usage_idle" < 10.0)
+//	        .every(1m)
+//	    |httpOut('failing')
+//
+// The above example provides the hosts that are currently overloaded, for a dashboard.
+//
+// The intervals are aligned to the every duration and, as time only advances with the data,
+// the set is emitted once data for a later interval arrives, at the start of that interval.
+// In batch mode the latest evaluation of a group is that of the last point of its batch.
+// Points for which the expression cannot be evaluated are ignored.
+type FailingNode struct {
+	chainnode `json:"-"`
+
+	// Expression to determine whether a group is failing.
+	// tick:ignore
+	Lambda *ast.LambdaNode `json:"lambda"`
+
+	// How often to emit the set of failing groups.
+	Every time.Duration `json:"every"`
+
+	// The measurement of the emitted batches.
+	// Default: failing
+	Measurement string `json:"measurement"`
+
+	// The name of the field with the duration the group has been failing.
+	// Default: failing_duration
+	As string `json:"as"`
+
+	// The time unit of the failing duration.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+}
+
+func newFailingNode(wants EdgeType, predicate *ast.LambdaNode) *FailingNode {
+	return &FailingNode{
+		chainnode:   newBasicChainNode("failing", wants, BatchEdge),
+		Lambda:      predicate,
+		Measurement: "failing",
+		As:          "failing_duration",
+		Unit:        time.Second,
+	}
+}
+
+// MarshalJSON converts FailingNode to JSON
+// tick:ignore
+func (n *FailingNode) MarshalJSON() ([]byte, error) {
+	type Alias FailingNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Every string `json:"every"`
+		Unit  string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "failing",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Every: influxql.FormatDuration(n.Every),
+		Unit:  influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an FailingNode
+// tick:ignore
+func (n *FailingNode) UnmarshalJSON(data []byte) error {
+	type Alias FailingNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Every string `json:"every"`
+		Unit  string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "failing" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as FailingNode", raw.ID, raw.Type)
+	}
+	n.Every, err = influxql.ParseDuration(raw.Every)
+	if err != nil {
+		return err
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *FailingNode) validate() error {
+	if n.Lambda == nil {
+		return errors.New("must provide an expression for failing")
+	}
+	if n.Every <= 0 {
+		return errors.New("every must be greater than zero for failing")
+	}
+	if n.Measurement == "" {
+		return errors.New("must specify a measurement for failing")
+	}
+	if n.As == "" {
+		return errors.New("must specify a field name for failing")
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than zero for failing")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// A FanOutNode splits the data into one branch per configured value of a tag.
+// Each branch becomes its own group, the tag is added to the existing dimensions of the data,
+// so that all nodes after the fanOut node form a shared tail which is applied independently to each branch.
+// State in the tail, e.g. windows, reducers or alert states, is kept per branch
+// and the outputs of all branches are merged in the same edge, as if each branch was followed by a union.
+// Points with a value of the tag that is not configured, or without the tag, are dropped.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |fanOut('region', 'us', 'eu', 'ap')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |mean('latency')
+//	    |alert()
+//	        .crit(lambda: "mean" > 500.0)
+//
+// The above example computes the mean latency and alerts independently for
+// each service in each of the three regions, without repeating the tail for each region.
+//
+// Since the branches are groups, a groupBy in the tail replaces the branches
+// unless the tag is one of its dimensions.
+type FanOutNode struct {
+	chainnode `json:"-"`
+
+	// The tag whose values define the branches.
+	// tick:ignore
+	Tag string `json:"tag"`
+
+	// The values of the tag, one per branch.
+	// tick:ignore
+	Values []string `json:"values"`
+}
+
+func newFanOutNode(wants EdgeType, tag string, values []string) *FanOutNode {
+	return &FanOutNode{
+		chainnode: newBasicChainNode("fanOut", wants, wants),
+		Tag:       tag,
+		Values:    values,
+	}
+}
+
+// MarshalJSON converts FanOutNode to JSON
+// tick:ignore
+func (n *FanOutNode) MarshalJSON() ([]byte, error) {
+	type Alias FanOutNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "fanOut",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an FanOutNode
+// tick:ignore
+func (n *FanOutNode) UnmarshalJSON(data []byte) error {
+	type Alias FanOutNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "fanOut" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as FanOutNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *FanOutNode) validate() error {
+	if n.Tag == "" {
+		return errors.New("must specify a tag for fanOut")
+	}
+	if len(n.Values) == 0 {
+		return errors.New("must specify at least one value for fanOut")
+	}
+	seen := make(map[string]bool, len(n.Values))
+	for _, v := range n.Values {
+		if seen[v] {
+			return fmt.Errorf("duplicate fanOut value %q", v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Compare the points of a node with the latest forecast from another node, per group,
+// to score how anomalous the observed values are in real time.
+// The forecast is usually computed periodically from a window of the data with the holtWinters function,
+// or by a query of a batch task.
+//
+// For each point the forecast value is the value of the latest forecast point at or before the point,
+// within the tolerance. Three fields are added to the point:
+// the forecast value, `forecast`,
+// the residual, `residual`, the observed value minus the forecast value,
+// and the anomaly score, `score`, the z-score of the residual against the previous residuals of the group:
+//
+//	score = (residual - mean(residuals)) / stddev(residuals)
+//
+// Example:
+//
+//	var usage = stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//
+//	var forecast = usage
+//	    |window()
+//	        .period(1d)
+//	        .every(1h)
+//	        .align()
+//	    |holtWinters('usage', 360, 0, 10s)
+//
+//	usage
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	        .align()
+//	    |forecastResidual(forecast)
+//	        .field('usage')
+//	        .tolerance(10s)
+//	    |alert()
+//	        .warn(lambda: isPresent("score") AND abs("score") > 3.0)
+//
+// The above example forecasts the CPU usage of each host for the next hour, every hour, from its usage over the last day,
+// with a point every 10s, and alerts when the usage of a host is more than three standard deviations away from its forecast.
+// The data is windowed as the forecast of holtWinters is a batch, and the data and the forecast must be of the same edge type.
+// The data and the forecast are processed concurrently, so the points of a window that ends when a new forecast is computed
+// are compared with the previous forecast or, if the new forecast replaced it first, pass through unchanged.
+//
+// Both the data and the forecast must be grouped by the same dimensions, as forecasts are matched by group.
+// A batch from the forecast replaces the forecast of its group, while points from a stream are added to it.
+// Forecast points before the one matched by a point are discarded, so the data of a group must be in time order.
+//
+// Points without a forecast value, before the first forecast of their group or past the tolerance of the forecast,
+// pass through unchanged, without the three fields.
+// Until size residuals have been seen (warm-up), or when the previous residuals are all equal (zero variance),
+// the score is undefined and is not set on the point.
+// Points without a numeric value for the field pass through unchanged.
+type ForecastResidualNode struct {
+	chainnode `json:"-"`
+
+	// The field of the observed value.
+	Field string `json:"field"`
+
+	// The field of the forecast value on the points of the forecast.
+	// Default: holtWinters
+	ForecastField string `json:"forecastField"`
+
+	// The maximum time between a point and the forecast point it is compared with.
+	// Default: 0, the latest forecast point at or before the point is used however old
+	Tolerance time.Duration `json:"tolerance"`
+
+	// The number of previous residuals the score is computed against.
+	// Default: 30
+	Size int64 `json:"size"`
+
+	// The name of the forecast field.
+	// Default: forecast
+	ForecastAs string `json:"forecastAs"`
+
+	// The name of the residual field.
+	// Default: residual
+	ResidualAs string `json:"residualAs"`
+
+	// The name of the score field.
+	// Default: score
+	ScoreAs string `json:"scoreAs"`
+}
+
+func newForecastResidualNode(data, forecast Node) *ForecastResidualNode {
+	r := &ForecastResidualNode{
+		chainnode:     newBasicChainNode("forecastResidual", data.Provides(), data.Provides()),
+		ForecastField: "holtWinters",
+		Size:          30,
+		ForecastAs:    "forecast",
+		ResidualAs:    "residual",
+		ScoreAs:       "score",
+	}
+	data.linkChild(r)
+	forecast.linkChild(r)
+	return r
+}
+
+// MarshalJSON converts ForecastResidualNode to JSON
+// tick:ignore
+func (n *ForecastResidualNode) MarshalJSON() ([]byte, error) {
+	type Alias ForecastResidualNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Tolerance string `json:"tolerance"`
+	}{
+		TypeOf: TypeOf{
+			Type: "forecastResidual",
+			ID:   n.ID(),
+		},
+		Alias:     (*Alias)(n),
+		Tolerance: influxql.FormatDuration(n.Tolerance),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ForecastResidualNode
+// tick:ignore
+func (n *ForecastResidualNode) UnmarshalJSON(data []byte) error {
+	type Alias ForecastResidualNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Tolerance string `json:"tolerance"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "forecastResidual" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ForecastResidualNode", raw.ID, raw.Type)
+	}
+	n.Tolerance, err = influxql.ParseDuration(raw.Tolerance)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *ForecastResidualNode) validate() error {
+	if len(n.Parents()) != 2 {
+		return errors.New("forecastResidual requires exactly one forecast node")
+	}
+	if n.Field == "" {
+		return errors.New("must specify a field for forecastResidual")
+	}
+	if n.ForecastField == "" {
+		return errors.New("forecastResidual forecastField must not be empty")
+	}
+	if n.Tolerance < 0 {
+		return errors.New("forecastResidual tolerance must not be negative")
+	}
+	if n.Size < 2 {
+		return errors.New("forecastResidual size must be at least 2")
+	}
+	if n.ForecastAs == "" || n.ResidualAs == "" || n.ScoreAs == "" {
+		return errors.New("forecastResidual field names must not be empty")
+	}
+	if n.ForecastAs == n.ResidualAs || n.ForecastAs == n.ScoreAs || n.ResidualAs == n.ScoreAs {
+		return errors.New("forecastResidual forecastAs, residualAs and scoreAs must be different")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// A GroupByExprNode groups the incoming data by the result of an expression.
+// The expression must evaluate to a string, which is set as a tag on each point
+// and becomes the only dimension of the grouping.
+// This makes it possible to group by a derived category without
+// first having to add a tag in a separate node.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |groupByExpr(lambda: regexReplace(/^([a-z]+)-.*$/, "host", '$1'))
+//	        .as('region')
+//	        .maxGroups(50)
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |mean('usage_user')
+//
+// The above example groups hosts named like `us-web01` by the region prefix of the hostname.
+//
+// As groups are created dynamically, the number of groups is bounded by maxGroups.
+// Points which would create a new group beyond the bound are dropped,
+// and counted by the `group_limit_drops` stat.
+// A group no longer counts towards the bound once all the incoming groups with points in it have been deleted,
+// e.g. by a barrier with delete.
+// Points for which the expression does not evaluate to a string are dropped.
+type GroupByExprNode struct {
+	chainnode `json:"-"`
+
+	// Expression to compute the group of a point.
+	// tick:ignore
+	Lambda *ast.LambdaNode `json:"lambda"`
+
+	// The name of the tag holding the group.
+	// Default: group
+	As string `json:"as"`
+
+	// The maximum number of active groups.
+	// Default: 1000
+	MaxGroups int64 `json:"maxGroups"`
+
+	// Whether to include the measurement in the group ID.
+	// tick:ignore
+	ByMeasurementFlag bool `tick:"ByMeasurement" json:"byMeasurement"`
+}
+
+func newGroupByExprNode(wants EdgeType, expr *ast.LambdaNode) *GroupByExprNode {
+	return &GroupByExprNode{
+		chainnode: newBasicChainNode("groupby_expr", wants, wants),
+		Lambda:    expr,
+		As:        "group",
+		MaxGroups: 1000,
+	}
+}
+
+// MarshalJSON converts GroupByExprNode to JSON
+// tick:ignore
+func (n *GroupByExprNode) MarshalJSON() ([]byte, error) {
+	type Alias GroupByExprNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "groupByExpr",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an GroupByExprNode
+// tick:ignore
+func (n *GroupByExprNode) UnmarshalJSON(data []byte) error {
+	type Alias GroupByExprNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "groupByExpr" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as GroupByExprNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// If set will include the measurement name in the group ID.
+// Along with any other group by dimensions.
+// tick:property
+func (n *GroupByExprNode) ByMeasurement() *GroupByExprNode {
+	n.ByMeasurementFlag = true
+	return n
+}
+
+func (n *GroupByExprNode) validate() error {
+	if n.Lambda == nil {
+		return errors.New("must specify an expression for groupByExpr")
+	}
+	if n.As == "" {
+		return errors.New("groupByExpr as must not be empty")
+	}
+	if n.MaxGroups <= 0 {
+		return errors.New("groupByExpr maxGroups must be greater than zero")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Emit an event point when a group is first seen and when it is deleted.
+// Groups are deleted by a barrier node with delete enabled, once they have been idle,
+// so combined with a barrier this node tracks the lifecycle of series,
+// for example hosts appearing and going away.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	        .groupBy('host')
+//	    |barrier()
+//	        .idle(5m)
+//	        .delete(TRUE)
+//	    |groupEvents()
+//	    |alert()
+//	        .info(lambda: "event" == 'created')
+//	        .warn(lambda: "event" == 'deleted')
+//	        .message('host {{ index .Tags "host" }} was {{ index .Fields "event" }}')
+//
+// The above example notifies when a host starts reporting, and warns when it has not reported for 5m.
+//
+// Only the events are emitted, the data itself is not forwarded.
+// Each event has the name and the tags of its group, and the field `event`
+// with the value `created` or `deleted`.
+// A created event has the time of the first point of the group,
+// and a deleted event the time the group was last seen, including the barrier that deleted it.
+// The events of a group are emitted again if the group reappears after it was deleted.
+type GroupEventsNode struct {
+	chainnode `json:"-"`
+
+	// The name of the event field.
+	// Default: event
+	As string `json:"as"`
+}
+
+func newGroupEventsNode(wants EdgeType) *GroupEventsNode {
+	return &GroupEventsNode{
+		chainnode: newBasicChainNode("groupEvents", wants, StreamEdge),
+		As:        "event",
+	}
+}
+
+// MarshalJSON converts GroupEventsNode to JSON
+// tick:ignore
+func (n *GroupEventsNode) MarshalJSON() ([]byte, error) {
+	type Alias GroupEventsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "groupEvents",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an GroupEventsNode
+// tick:ignore
+func (n *GroupEventsNode) UnmarshalJSON(data []byte) error {
+	type Alias GroupEventsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "groupEvents" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as GroupEventsNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *GroupEventsNode) validate() error {
+	if n.As == "" {
+		return errors.New("groupEvents as must not be empty")
+	}
+	return nil
+}
@@ -46,4 +46,6 @@ type ReduceCreater struct {
 	IsSimpleSelector       bool
 	IsStreamTransformation bool
 	IsEmptyOK              bool
+	// The reducers skip some values, and report how many with a Skipped() int64 method.
+	IsSkipping bool
 }
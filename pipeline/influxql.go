@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/influxdata/influxdb/query"
@@ -48,6 +49,12 @@ type InfluxQLNode struct {
 	// tick:ignore
 	PointTimes bool `tick:"UsePointTimes" json:"usePointTimes"`
 
+	// The name of a field holding the number of points that were aggregated,
+	// so that results computed from too few points can be told apart.
+	// Only applies to aggregation and selector functions.
+	// Default: no count field
+	CountAs string `json:"countAs"`
+
 	//tick:ignore
 	Reducer Node
 
@@ -108,7 +115,7 @@ func (n *InfluxQLNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	switch raw.Type {
-	case "count", "distinct", "mean", "median", "mode", "spread", "sum", "first":
+	case "count", "distinct", "mean", "geometricMean", "median", "mode", "spread", "sum", "first":
 	case "last", "min", "max", "stddev", "difference", "cumulativeSum":
 	case "top", "bottom", "movingAverage":
 		for i, arg := range raw.Args {
@@ -167,6 +174,19 @@ func (n *InfluxQLNode) UsePointTimes() *InfluxQLNode {
 	return n
 }
 
+func (n *InfluxQLNode) validate() error {
+	if n.CountAs == "" {
+		return nil
+	}
+	if n.ReduceCreater.IsStreamTransformation {
+		return fmt.Errorf("countAs does not apply to %s, which transforms each point", n.Method)
+	}
+	if n.CountAs == n.As {
+		return fmt.Errorf("countAs must be different from as, both are %q", n.As)
+	}
+	return nil
+}
+
 //------------------------------------
 // Aggregation Functions
 //
@@ -236,6 +256,80 @@ func (n *chainnode) Mean(field string) *InfluxQLNode {
 	return i
 }
 
+// Compute the geometric mean of the data, the nth root of the product of the n values.
+// The geometric mean is the correct average of multiplicative quantities such as ratios and growth rates.
+// It is computed from the mean of the logarithms of the values, so the product never overflows.
+// Values that are zero or negative are skipped, as their logarithm is undefined,
+// and no point is emitted if there are no positive values.
+// The number of skipped values is reported by the points_skipped stat of the node.
+func (n *chainnode) GeometricMean(field string) *InfluxQLNode {
+	i := newInfluxQLNode("geometricMean", field, n.Provides(), StreamEdge, ReduceCreater{
+		CreateFloatReducer: func() (query.FloatPointAggregator, query.FloatPointEmitter) {
+			fn := &geometricMeanReducer{}
+			return fn, fn
+		},
+		CreateIntegerFloatReducer: func() (query.IntegerPointAggregator, query.FloatPointEmitter) {
+			fn := &geometricMeanReducer{}
+			return fn, fn
+		},
+		IsSkipping: true,
+	})
+	n.linkChild(i)
+	return i
+}
+
+// geometricMeanReducer calculates the geometric mean of the aggregated positive points.
+type geometricMeanReducer struct {
+	logSum  float64
+	count   uint32
+	skipped int64
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *geometricMeanReducer) AggregateFloat(p *query.FloatPoint) {
+	r.aggregate(p.Value, p.Aggregated)
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *geometricMeanReducer) AggregateInteger(p *query.IntegerPoint) {
+	r.aggregate(float64(p.Value), p.Aggregated)
+}
+
+func (r *geometricMeanReducer) aggregate(v float64, aggregated uint32) {
+	if v <= 0 {
+		if aggregated >= 2 {
+			r.skipped += int64(aggregated)
+		} else {
+			r.skipped++
+		}
+		return
+	}
+	if aggregated >= 2 {
+		r.logSum += math.Log(v) * float64(aggregated)
+		r.count += aggregated
+	} else {
+		r.logSum += math.Log(v)
+		r.count++
+	}
+}
+
+// Skipped returns the number of values which were skipped as they are not positive.
+func (r *geometricMeanReducer) Skipped() int64 {
+	return r.skipped
+}
+
+// Emit emits the geometric mean of the aggregated points as a single point.
+func (r *geometricMeanReducer) Emit() []query.FloatPoint {
+	if r.count == 0 {
+		return nil
+	}
+	return []query.FloatPoint{{
+		Time:       query.ZeroTime,
+		Value:      math.Exp(r.logSum / float64(r.count)),
+		Aggregated: r.count,
+	}}
+}
+
 // Compute the median of the data. Note, this method is not a selector,
 // if you want the median point use `.percentile(field, 50.0)`.
 func (n *chainnode) Median(field string) *InfluxQLNode {
@@ -410,6 +504,26 @@ func (n *chainnode) Percentile(field string, percentile float64) *InfluxQLNode {
 	return i
 }
 
+// Select the points at each of the given percentiles, sorting the values only once.
+// The percentiles are emitted as the fields of a single point, see PercentilesNode.
+func (n *chainnode) Percentiles(field string, percentiles ...float64) *PercentilesNode {
+	p := newPercentilesNode(n.Provides(), field, percentiles)
+	n.linkChild(p)
+	return p
+}
+
+// Compute the increase of a monotonic counter within each batch, handling counter resets.
+// The increase is emitted as a single point, see CounterDeltaNode.
+func (n *chainnode) CounterDelta(field string) *CounterDeltaNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot compute counter delta of stream edge")
+	}
+
+	c := newCounterDeltaNode(field)
+	n.linkChild(c)
+	return c
+}
+
 //tick:ignore
 type TopBottomCallInfo struct {
 	FieldsAndTags []string
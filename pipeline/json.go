@@ -180,39 +180,79 @@ func init() {
 
 	// Add default construction of chain nodes
 	chainFunctions = map[string]func(parent chainnodeAlias) Node{
-		"window":            func(parent chainnodeAlias) Node { return parent.Window() },
-		"swarmAutoscale":    func(parent chainnodeAlias) Node { return parent.SwarmAutoscale() },
-		"stats":             func(parent chainnodeAlias) Node { return parent.Stats(0) },
-		"stateDuration":     func(parent chainnodeAlias) Node { return parent.StateDuration(nil) },
-		"stateCount":        func(parent chainnodeAlias) Node { return parent.StateCount(nil) },
-		"shift":             func(parent chainnodeAlias) Node { return parent.Shift(0) },
-		"sideload":          func(parent chainnodeAlias) Node { return parent.Sideload() },
-		"sample":            func(parent chainnodeAlias) Node { return parent.Sample(0) },
-		"log":               func(parent chainnodeAlias) Node { return parent.Log() },
-		"kapacitorLoopback": func(parent chainnodeAlias) Node { return parent.KapacitorLoopback() },
-		"k8sAutoscale":      func(parent chainnodeAlias) Node { return parent.K8sAutoscale() },
-		"influxdbOut":       func(parent chainnodeAlias) Node { return parent.InfluxDBOut() },
-		"httpPost":          func(parent chainnodeAlias) Node { return parent.HttpPost() },
-		"httpOut":           func(parent chainnodeAlias) Node { return parent.HttpOut("") },
-		"flatten":           func(parent chainnodeAlias) Node { return parent.Flatten() },
-		"eval":              func(parent chainnodeAlias) Node { return parent.Eval() },
-		"derivative":        func(parent chainnodeAlias) Node { return parent.Derivative("") },
-		"changeDetect":      func(parent chainnodeAlias) Node { return parent.ChangeDetect("") },
-		"delete":            func(parent chainnodeAlias) Node { return parent.Delete() },
-		"default":           func(parent chainnodeAlias) Node { return parent.Default() },
-		"combine":           func(parent chainnodeAlias) Node { return parent.Combine(nil) },
-		"alert":             func(parent chainnodeAlias) Node { return parent.Alert() },
+		"window":              func(parent chainnodeAlias) Node { return parent.Window() },
+		"swarmAutoscale":      func(parent chainnodeAlias) Node { return parent.SwarmAutoscale() },
+		"stats":               func(parent chainnodeAlias) Node { return parent.Stats(0) },
+		"summary":             func(parent chainnodeAlias) Node { return parent.Summary("") },
+		"stateDuration":       func(parent chainnodeAlias) Node { return parent.StateDuration(nil) },
+		"stateCount":          func(parent chainnodeAlias) Node { return parent.StateCount(nil) },
+		"shift":               func(parent chainnodeAlias) Node { return parent.Shift(0) },
+		"residual":            func(parent chainnodeAlias) Node { return parent.Residual("", nil) },
+		"sideload":            func(parent chainnodeAlias) Node { return parent.Sideload() },
+		"sample":              func(parent chainnodeAlias) Node { return parent.Sample(0) },
+		"log":                 func(parent chainnodeAlias) Node { return parent.Log() },
+		"monotonic":           func(parent chainnodeAlias) Node { return parent.Monotonic("") },
+		"sequence":            func(parent chainnodeAlias) Node { return parent.Sequence("") },
+		"accumulate":          func(parent chainnodeAlias) Node { return parent.Accumulate("") },
+		"entropy":             func(parent chainnodeAlias) Node { return parent.Entropy("") },
+		"tokenBucket":         func(parent chainnodeAlias) Node { return parent.TokenBucket("") },
+		"baseline":            func(parent chainnodeAlias) Node { return parent.Baseline("") },
+		"consensus":           func(parent chainnodeAlias) Node { return parent.Consensus("") },
+		"percentileThreshold": func(parent chainnodeAlias) Node { return parent.PercentileThreshold("") },
+		"crossCorrelation":    func(parent chainnodeAlias) Node { return parent.CrossCorrelation("", "") },
+		"leakyBucket":         func(parent chainnodeAlias) Node { return parent.LeakyBucket("") },
+		"kapacitorLoopback":   func(parent chainnodeAlias) Node { return parent.KapacitorLoopback() },
+		"k8sAutoscale":        func(parent chainnodeAlias) Node { return parent.K8sAutoscale() },
+		"influxdbOut":         func(parent chainnodeAlias) Node { return parent.InfluxDBOut() },
+		"httpPost":            func(parent chainnodeAlias) Node { return parent.HttpPost() },
+		"httpOut":             func(parent chainnodeAlias) Node { return parent.HttpOut("") },
+		"groupByExpr":         func(parent chainnodeAlias) Node { return parent.GroupByExpr(nil) },
+		"flatten":             func(parent chainnodeAlias) Node { return parent.Flatten() },
+		"eval":                func(parent chainnodeAlias) Node { return parent.Eval() },
+		"derivative":          func(parent chainnodeAlias) Node { return parent.Derivative("") },
+		"changeDetect":        func(parent chainnodeAlias) Node { return parent.ChangeDetect("") },
+		"delete":              func(parent chainnodeAlias) Node { return parent.Delete() },
+		"default":             func(parent chainnodeAlias) Node { return parent.Default() },
+		"combine":             func(parent chainnodeAlias) Node { return parent.Combine(nil) },
+		"cusum":               func(parent chainnodeAlias) Node { return parent.Cusum("") },
+		"alert":               func(parent chainnodeAlias) Node { return parent.Alert() },
+		"autocorrelation":     func(parent chainnodeAlias) Node { return parent.Autocorrelation("") },
+		"percentChange":       func(parent chainnodeAlias) Node { return parent.PercentChange("") },
+		"percentileRank":      func(parent chainnodeAlias) Node { return parent.PercentileRank("") },
+		"fanOut":              func(parent chainnodeAlias) Node { return parent.FanOut("") },
+		"rollup":              func(parent chainnodeAlias) Node { return parent.Rollup("") },
+		"schema":              func(parent chainnodeAlias) Node { return parent.Schema() },
+		"decompose":           func(parent chainnodeAlias) Node { return parent.Decompose("") },
+		"coincidence":         func(parent chainnodeAlias) Node { return parent.Coincidence(nil, nil) },
+		"stamp":               func(parent chainnodeAlias) Node { return parent.Stamp() },
+		"lag":                 func(parent chainnodeAlias) Node { return parent.Lag() },
+		"mirror":              func(parent chainnodeAlias) Node { return parent.Mirror("", "") },
+		"cardinality":         func(parent chainnodeAlias) Node { return parent.Cardinality("") },
+		"failing":             func(parent chainnodeAlias) Node { return parent.Failing(nil) },
+		"seasonalZScore":      func(parent chainnodeAlias) Node { return parent.SeasonalZScore("") },
+		"percentiles":         func(parent chainnodeAlias) Node { return parent.Percentiles("") },
+		"dropOutliers":        func(parent chainnodeAlias) Node { return parent.DropOutliers("") },
+		"uptime":              func(parent chainnodeAlias) Node { return parent.Uptime(nil) },
+		"mannKendall":         func(parent chainnodeAlias) Node { return parent.MannKendall("") },
+		"pivot":               func(parent chainnodeAlias) Node { return parent.Pivot() },
+		"ratio":               func(parent chainnodeAlias) Node { return parent.Ratio() },
+		"groupEvents":         func(parent chainnodeAlias) Node { return parent.GroupEvents() },
+		"counterDelta":        func(parent chainnodeAlias) Node { return parent.CounterDelta("") },
 	}
 
 	multiParents = map[string]func(chainnodeAlias, []Node) Node{
-		"union": func(parent chainnodeAlias, nodes []Node) Node { return parent.Union(nodes...) },
-		"join":  func(parent chainnodeAlias, nodes []Node) Node { return parent.Join(nodes...) },
+		"union":            func(parent chainnodeAlias, nodes []Node) Node { return parent.Union(nodes...) },
+		"join":             func(parent chainnodeAlias, nodes []Node) Node { return parent.Join(nodes...) },
+		"enrich":           func(parent chainnodeAlias, nodes []Node) Node { return parent.Enrich(nodes[0]) },
+		"lastMarker":       func(parent chainnodeAlias, nodes []Node) Node { return parent.LastMarker(nodes[0]) },
+		"forecastResidual": func(parent chainnodeAlias, nodes []Node) Node { return parent.ForecastResidual(nodes[0]) },
 	}
 
 	influxFunctions = map[string]func(chainnodeAlias, string) *InfluxQLNode{
 		"count":         func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Count(field) },
 		"distinct":      func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Distinct(field) },
 		"mean":          func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Mean(field) },
+		"geometricMean": func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.GeometricMean(field) },
 		"median":        func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Median(field) },
 		"mode":          func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Mode(field) },
 		"spread":        func(parent chainnodeAlias, field string) *InfluxQLNode { return parent.Spread(field) },
@@ -234,11 +274,12 @@ func init() {
 	}
 
 	uniqFunctions = map[string]func([]byte, []Node, TypeOf) (Node, error){
-		"top":     unmarshalTopBottom,
-		"bottom":  unmarshalTopBottom,
-		"where":   unmarshalWhere,
-		"groupBy": unmarshalGroupby,
-		"udf":     unmarshalUDF,
+		"top":        unmarshalTopBottom,
+		"bottom":     unmarshalTopBottom,
+		"where":      unmarshalWhere,
+		"groupBy":    unmarshalGroupby,
+		"udf":        unmarshalUDF,
+		"quarantine": unmarshalQuarantine,
 	}
 }
 
@@ -448,6 +489,20 @@ func unmarshalGroupby(data []byte, parents []Node, typ TypeOf) (Node, error) {
 	return child, err
 }
 
+func unmarshalQuarantine(data []byte, parents []Node, typ TypeOf) (Node, error) {
+	if len(parents) != 1 {
+		return nil, fmt.Errorf("expected one parent for node %d but found %d", typ.ID, len(parents))
+	}
+	parent := parents[0]
+	schema, ok := parent.(*SchemaNode)
+	if !ok {
+		return nil, fmt.Errorf("parent node of quarantine must be a schema node but is %T", parent)
+	}
+	child := schema.Quarantine()
+	err := json.Unmarshal(data, child)
+	return child, err
+}
+
 func unmarshalStats(data []byte, parents []Node, typ TypeOf) (Node, error) {
 	if len(parents) != 1 {
 		return nil, fmt.Errorf("expected one parent for node %d but found %d", typ.ID, len(parents))
@@ -526,12 +581,19 @@ func isChainNode(node Node) (chainnodeAlias, bool) {
 
 // chainnodeAlias is used to check for the presence of a chain node
 type chainnodeAlias interface {
+	Accumulate(string) *AccumulateNode
 	Alert() *AlertNode
+	Autocorrelation(string) *AutocorrelationNode
+	Baseline(string) *BaselineNode
 	Bottom(int64, string, ...string) *InfluxQLNode
 	Children() []Node
 	Combine(...*ast.LambdaNode) *CombineNode
+	Consensus(string) *ConsensusNode
 	Count(string) *InfluxQLNode
+	CounterDelta(string) *CounterDeltaNode
+	CrossCorrelation(string, string) *CrossCorrelationNode
 	CumulativeSum(string) *InfluxQLNode
+	Cusum(string) *CusumNode
 	Deadman(float64, time.Duration, ...*ast.LambdaNode) *AlertNode
 	Default() *DefaultNode
 	Delete() *DeleteNode
@@ -541,9 +603,17 @@ type chainnodeAlias interface {
 	Difference(string) *InfluxQLNode
 	Distinct(string) *InfluxQLNode
 	Elapsed(string, time.Duration) *InfluxQLNode
+	DropOutliers(string) *DropOutliersNode
+	Enrich(Node) *EnrichNode
+	Entropy(string) *EntropyNode
 	Eval(...*ast.LambdaNode) *EvalNode
+	FanOut(string, ...string) *FanOutNode
 	First(string) *InfluxQLNode
 	Flatten() *FlattenNode
+	ForecastResidual(Node) *ForecastResidualNode
+	GeometricMean(string) *InfluxQLNode
+	GroupByExpr(*ast.LambdaNode) *GroupByExprNode
+	GroupEvents() *GroupEventsNode
 	HoltWinters(string, int64, int64, time.Duration) *InfluxQLNode
 	HoltWintersWithFit(string, int64, int64, time.Duration) *InfluxQLNode
 	HttpOut(string) *HTTPOutNode
@@ -554,18 +624,40 @@ type chainnodeAlias interface {
 	K8sAutoscale() *K8sAutoscaleNode
 	KapacitorLoopback() *KapacitorLoopbackNode
 	Last(string) *InfluxQLNode
+	LastMarker(Node) *LastMarkerNode
+	LeakyBucket(string) *LeakyBucketNode
 	Log() *LogNode
+	MannKendall(string) *MannKendallNode
 	Max(string) *InfluxQLNode
 	Mean(string) *InfluxQLNode
 	Median(string) *InfluxQLNode
 	Min(string) *InfluxQLNode
 	Mode(string) *InfluxQLNode
+	Monotonic(string) *MonotonicNode
 	MovingAverage(string, int64) *InfluxQLNode
 	Name() string
 	Parents() []Node
+	PercentChange(string) *PercentChangeNode
 	Percentile(string, float64) *InfluxQLNode
+	PercentileRank(string) *PercentileRankNode
+	PercentileThreshold(string) *PercentileThresholdNode
+	Percentiles(string, ...float64) *PercentilesNode
+	Pivot() *PivotNode
 	Provides() EdgeType
+	Ratio() *RatioNode
+	Residual(string, *ast.LambdaNode) *ResidualNode
+	Rollup(string) *RollupNode
+	Schema() *SchemaNode
+	Decompose(string) *DecomposeNode
+	Coincidence(*ast.LambdaNode, *ast.LambdaNode) *CoincidenceNode
+	Stamp() *StampNode
+	Lag() *LagNode
+	Mirror(string, string) *MirrorNode
+	Cardinality(string) *CardinalityNode
+	Failing(*ast.LambdaNode) *FailingNode
+	SeasonalZScore(string) *SeasonalZScoreNode
 	Sample(interface{}) *SampleNode
+	Sequence(string) *SequenceNode
 	SetName(string)
 	Shift(time.Duration) *ShiftNode
 	Sideload() *SideloadNode
@@ -575,9 +667,12 @@ type chainnodeAlias interface {
 	Stats(time.Duration) *StatsNode
 	Stddev(string) *InfluxQLNode
 	Sum(string) *InfluxQLNode
+	Summary(string) *SummaryNode
 	SwarmAutoscale() *SwarmAutoscaleNode
+	TokenBucket(string) *TokenBucketNode
 	Top(int64, string, ...string) *InfluxQLNode
 	Union(...Node) *UnionNode
+	Uptime(*ast.LambdaNode) *UptimeNode
 	Wants() EdgeType
 	Window() *WindowNode
 	addParent(Node)
@@ -216,6 +216,7 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "infoReset": null,
             "warnReset": null,
             "critReset": null,
+            "priority": null,
             "useFlapping": false,
             "flapLow": 0,
             "flapHigh": 0,
@@ -230,6 +231,15 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "noRecoveries": false,
             "stateChangesOnly": true,
             "stateChangesOnlyDuration": 0,
+            "jitter": 0,
+            "incidentHistory": 0,
+            "escalateReminders": 0,
+            "escalateAfter": 0,
+            "recoverAfter": 0,
+            "recoverAfterCount": 0,
+            "digest": false,
+            "sparkline": "",
+            "sparklinePoints": 0,
             "inhibitors": null,
             "post": [
                 {
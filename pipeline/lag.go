@@ -0,0 +1,197 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Record the time at which points are ingested by the task.
+// The time is added as a field to each point, so that a descendant lag node
+// can compute how long the point took to reach it. See the lag node.
+//
+// The recorded value is read from a monotonic clock local to the Kapacitor process,
+// in nanoseconds, and is only meaningful to lag nodes in the same process.
+// The time of the points is left unchanged, so windows and other event-time
+// processing are not affected.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |stamp()
+//	    |where(lambda: "usage_idle" < 10.0)
+//	    |lag()
+//	        .unit(1ms)
+//	    |log()
+type StampNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field holding the ingestion time.
+	// Default: 'ingested'
+	As string `json:"as"`
+}
+
+func newStampNode(wants EdgeType) *StampNode {
+	return &StampNode{
+		chainnode: newBasicChainNode("stamp", wants, wants),
+		As:        "ingested",
+	}
+}
+
+// MarshalJSON converts StampNode to JSON
+// tick:ignore
+func (n *StampNode) MarshalJSON() ([]byte, error) {
+	type Alias StampNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "stamp",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an StampNode
+// tick:ignore
+func (n *StampNode) UnmarshalJSON(data []byte) error {
+	type Alias StampNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "stamp" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as StampNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *StampNode) validate() error {
+	if n.As == "" {
+		return errors.New("must specify a field name for stamp")
+	}
+	return nil
+}
+
+// Compute the processing lag of points, the time elapsed since they
+// were ingested by an ancestor stamp node, and add it as a field.
+// The lag is measured on a monotonic clock, so it is not affected by changes
+// to the wall clock, and is independent of the time of the points.
+//
+// Points without the ingestion time field, for example because an aggregation dropped it,
+// are passed through without a lag.
+// The ingestion time field is kept, so that several lag nodes can measure the same points;
+// use a delete node to remove it before writing the points.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('cpu')
+//	    |stamp()
+//	    |eval(lambda: 100.0 - "usage_idle")
+//	        .as('usage')
+//	        .keep()
+//	    |lag()
+//	        .unit(1ms)
+//	    |delete()
+//	        .field('ingested')
+//	    |influxDBOut()
+//	        .database('debug')
+type LagNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field holding the ingestion time, as set by the stamp node.
+	// Default: 'ingested'
+	Stamp string `json:"stamp"`
+
+	// The name of the field holding the lag.
+	// Default: 'lag'
+	As string `json:"as"`
+
+	// The time unit of the lag.
+	// Default: 1ms
+	Unit time.Duration `json:"unit"`
+}
+
+func newLagNode(wants EdgeType) *LagNode {
+	return &LagNode{
+		chainnode: newBasicChainNode("lag", wants, wants),
+		Stamp:     "ingested",
+		As:        "lag",
+		Unit:      time.Millisecond,
+	}
+}
+
+// MarshalJSON converts LagNode to JSON
+// tick:ignore
+func (n *LagNode) MarshalJSON() ([]byte, error) {
+	type Alias LagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "lag",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Unit:  influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an LagNode
+// tick:ignore
+func (n *LagNode) UnmarshalJSON(data []byte) error {
+	type Alias LagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "lag" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as LagNode", raw.ID, raw.Type)
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *LagNode) validate() error {
+	if n.Stamp == "" {
+		return errors.New("must specify the stamp field name for lag")
+	}
+	if n.As == "" {
+		return errors.New("must specify a field name for lag")
+	}
+	if n.Unit <= 0 {
+		return fmt.Errorf("lag unit must be positive, got %v", n.Unit)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Annotate data with the most recent event marker from another node, such as deploy events.
+// For each point the most recent marker, no later than the point and within the lookback window,
+// is attached to the point: the time since the marker, and the fields and tags of the marker,
+// with their names prefixed.
+// Points without a recent marker pass through unchanged, without the annotation.
+//
+// Example:
+//
+//	var deploys = stream
+//	    |from()
+//	        .measurement('deploys')
+//
+//	stream
+//	    |from()
+//	        .measurement('errors')
+//	        .groupBy('service')
+//	    |lastMarker(deploys)
+//	        .lookback(1h)
+//	        .on('service')
+//	        .unit(1m)
+//	    |alert()
+//	        .crit(lambda: "count" > 100)
+//	        .message('{{ .ID }} {{ if index .Fields "marker_since" }}last deploy of {{ index .Fields "marker_version" }} was {{ index .Fields "marker_since" }} minutes ago{{ end }}')
+//
+// The above example mentions the last deploy of a service in its alerts, if it was in the last hour.
+//
+// Markers apply to all points, unless matched to the points with the tags of on,
+// in which case only markers with the same values of those tags apply.
+// The markers are matched by their time, so a marker must be received before the points it annotates.
+type LastMarkerNode struct {
+	chainnode `json:"-"`
+
+	// How far back a marker annotates points.
+	// Default: 1h
+	Lookback time.Duration `json:"lookback"`
+
+	// The tags matching markers to points.
+	// tick:ignore
+	OnTags []string `tick:"On" json:"on"`
+
+	// Prefix added to the names of the fields and tags of the marker.
+	// Default: marker_
+	Prefix string `json:"prefix"`
+
+	// The name of the field with the time since the marker.
+	// Default: marker_since
+	SinceAs string `json:"sinceAs"`
+
+	// The time unit of the time since the marker.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+}
+
+func newLastMarkerNode(data, markers Node) *LastMarkerNode {
+	m := &LastMarkerNode{
+		chainnode: newBasicChainNode("lastMarker", data.Provides(), data.Provides()),
+		Lookback:  time.Hour,
+		Prefix:    "marker_",
+		SinceAs:   "marker_since",
+		Unit:      time.Second,
+	}
+	data.linkChild(m)
+	markers.linkChild(m)
+	return m
+}
+
+// MarshalJSON converts LastMarkerNode to JSON
+// tick:ignore
+func (n *LastMarkerNode) MarshalJSON() ([]byte, error) {
+	type Alias LastMarkerNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lookback string `json:"lookback"`
+		Unit     string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "lastMarker",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Lookback: influxql.FormatDuration(n.Lookback),
+		Unit:     influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an LastMarkerNode
+// tick:ignore
+func (n *LastMarkerNode) UnmarshalJSON(data []byte) error {
+	type Alias LastMarkerNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lookback string `json:"lookback"`
+		Unit     string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "lastMarker" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as LastMarkerNode", raw.ID, raw.Type)
+	}
+	n.Lookback, err = influxql.ParseDuration(raw.Lookback)
+	if err != nil {
+		return err
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Match markers to points with the same values of the tags.
+// tick:property
+func (n *LastMarkerNode) On(tags ...string) *LastMarkerNode {
+	n.OnTags = append(n.OnTags, tags...)
+	return n
+}
+
+func (n *LastMarkerNode) validate() error {
+	if len(n.Parents()) != 2 {
+		return errors.New("lastMarker requires exactly one marker node")
+	}
+	if n.Lookback <= 0 {
+		return errors.New("lookback must be greater than zero for lastMarker")
+	}
+	if n.SinceAs == "" {
+		return errors.New("must specify a field name for the time since the marker for lastMarker")
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than zero for lastMarker")
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Smooth a bursty counter, such as the number of errors of each interval, with a leaky bucket over the data,
+// to tolerate short bursts while catching sustained high rates.
+//
+// Each group has a bucket, which leaks continuously at the leak rate per interval.
+// The value of each point is added to the bucket, up to its capacity.
+// The level of the bucket after the point is added to the point as the field `level`,
+// and the amount that did not fit in the bucket as the field `overflow`, 0 when the bucket did not overflow.
+// A burst is absorbed as long as it fits in the bucket, while a rate sustained above the leak rate overflows it.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('errors')
+//	        .groupBy('service')
+//	    |leakyBucket('count')
+//	        .capacity(100.0)
+//	        .leakRate(10.0)
+//	        .interval(1m)
+//	    |alert()
+//	        .warn(lambda: "level" > 80.0)
+//	        .crit(lambda: "overflow" > 0.0)
+//
+// The above example alerts when a service has more than 10 errors per minute for long enough
+// to fill a bucket of 100 errors, while a one-off burst of 50 errors is tolerated.
+//
+// The bucket of a group starts empty.
+// The bucket leaks according to the times of the points, so that replays are smoothed the same way as live data.
+// Points without a numeric value are dropped and are not added to the bucket.
+// State is kept per group across batches.
+type LeakyBucketNode struct {
+	chainnode `json:"-"`
+
+	// The field with the counted value.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The capacity of the bucket.
+	Capacity float64 `json:"capacity"`
+
+	// The amount the bucket leaks per interval.
+	LeakRate float64 `json:"leakRate"`
+
+	// The interval of the leak rate.
+	// Default: 1m
+	Interval time.Duration `json:"interval"`
+
+	// The name of the level field.
+	// Default: level
+	LevelAs string `json:"levelAs"`
+
+	// The name of the overflow field.
+	// Default: overflow
+	OverflowAs string `json:"overflowAs"`
+}
+
+func newLeakyBucketNode(wants EdgeType, field string) *LeakyBucketNode {
+	return &LeakyBucketNode{
+		chainnode:  newBasicChainNode("leakyBucket", wants, wants),
+		Field:      field,
+		Interval:   time.Minute,
+		LevelAs:    "level",
+		OverflowAs: "overflow",
+	}
+}
+
+// MarshalJSON converts LeakyBucketNode to JSON
+// tick:ignore
+func (n *LeakyBucketNode) MarshalJSON() ([]byte, error) {
+	type Alias LeakyBucketNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "leakyBucket",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an LeakyBucketNode
+// tick:ignore
+func (n *LeakyBucketNode) UnmarshalJSON(data []byte) error {
+	type Alias LeakyBucketNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "leakyBucket" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as LeakyBucketNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *LeakyBucketNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for leakyBucket")
+	}
+	if n.Capacity <= 0 {
+		return errors.New("leakyBucket capacity must be greater than zero")
+	}
+	if n.LeakRate <= 0 {
+		return errors.New("leakyBucket leakRate must be greater than zero")
+	}
+	if n.Interval <= 0 {
+		return errors.New("leakyBucket interval must be greater than zero")
+	}
+	if n.LevelAs == "" || n.OverflowAs == "" {
+		return errors.New("leakyBucket field names must not be empty")
+	}
+	if n.LevelAs == n.OverflowAs {
+		return errors.New("leakyBucket levelAs and overflowAs must be different")
+	}
+	return nil
+}
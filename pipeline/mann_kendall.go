@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the Mann-Kendall trend test of a field within each batch.
+// The Mann-Kendall test is a non-parametric test for a monotonic trend.
+// It only considers the order of the values, so unlike a linear slope it is not fooled by outliers.
+//
+// For the n values x of a batch, in time order, the S statistic is the number of increasing pairs
+// minus the number of decreasing pairs:
+//
+//	S = sum over i < j of sign(x[j] - x[i])
+//
+// Its variance, corrected for groups of tied values where t is the size of each group, is:
+//
+//	var(S) = (n(n-1)(2n+5) - sum of t(t-1)(2t+5)) / 18
+//
+// The normal approximation of S, with a continuity correction, gives the Z score:
+//
+//	Z = (S - 1) / sqrt(var(S))   if S > 0
+//	Z = 0                        if S = 0
+//	Z = (S + 1) / sqrt(var(S))   if S < 0
+//
+// The p-value is two-sided. For samples of at most 10 values without ties,
+// the normal approximation is poor and the p-value is computed from the exact distribution of S instead.
+// The trend is 1 for a significant upward trend, -1 for a significant downward trend and 0 otherwise,
+// where a trend is significant if the p-value is less than alpha.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('disk')
+//	    |groupBy('host')
+//	    |window()
+//	        .period(1h)
+//	        .every(10m)
+//	    |mannKendall('used_percent')
+//	        .alpha(0.01)
+//	    |alert()
+//	        .warn(lambda: "mk_trend" == 1)
+//
+// The above example warns when the disk usage of a host has a significant upward trend over the last hour.
+//
+// A single point is emitted per group and batch with the fields `mk_s`, `mk_z`, `mk_p` and `mk_trend`.
+// Points without a numeric value for the field are ignored.
+// Batches with fewer than 3 values do not emit a point, as no trend can be significant.
+type MannKendallNode struct {
+	chainnode `json:"-"`
+
+	// The field to test for a trend.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The significance level of the test.
+	// Default: 0.05
+	Alpha float64 `json:"alpha"`
+
+	// The name of the S statistic field.
+	// Default: mk_s
+	SAs string `json:"sAs"`
+
+	// The name of the Z score field.
+	// Default: mk_z
+	ZAs string `json:"zAs"`
+
+	// The name of the p-value field.
+	// Default: mk_p
+	PAs string `json:"pAs"`
+
+	// The name of the trend field.
+	// Default: mk_trend
+	TrendAs string `json:"trendAs"`
+}
+
+func newMannKendallNode(field string) *MannKendallNode {
+	return &MannKendallNode{
+		chainnode: newBasicChainNode("mannKendall", BatchEdge, StreamEdge),
+		Field:     field,
+		Alpha:     0.05,
+		SAs:       "mk_s",
+		ZAs:       "mk_z",
+		PAs:       "mk_p",
+		TrendAs:   "mk_trend",
+	}
+}
+
+// MarshalJSON converts MannKendallNode to JSON
+// tick:ignore
+func (n *MannKendallNode) MarshalJSON() ([]byte, error) {
+	type Alias MannKendallNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "mannKendall",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MannKendallNode
+// tick:ignore
+func (n *MannKendallNode) UnmarshalJSON(data []byte) error {
+	type Alias MannKendallNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "mannKendall" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MannKendallNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *MannKendallNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for mannKendall")
+	}
+	if n.Alpha <= 0 || n.Alpha >= 1 {
+		return errors.New("mannKendall alpha must be between 0 and 1")
+	}
+	if n.SAs == "" || n.ZAs == "" || n.PAs == "" || n.TrendAs == "" {
+		return errors.New("mannKendall field names must not be empty")
+	}
+	return nil
+}
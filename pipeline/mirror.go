@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// A MirrorNode computes the difference of fields between two mirrored data centers.
+// Points that share all tags except the data center tag are paired,
+// and for each field the absolute and relative differences between the two data centers are emitted.
+//
+// The absolute difference of a field is |a - b|, and is named `<field>_abs_diff`.
+// The relative difference is |a - b| / max(|a|, |b|), between 0 and 1, and is named `<field>_rel_diff`.
+// The relative difference is 0 when both values are 0.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('dc', 'service')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |sum('value')
+//	        .as('value')
+//	    |mirror('us-east', 'us-west')
+//	        .field('value')
+//	    |alert()
+//	        .crit(lambda: "value_rel_diff" > 0.2)
+//
+// The above example alerts when the number of requests to a service differs by more than 20% between the data centers.
+//
+// Points are paired by time, and data is emitted once data for a later time arrives.
+// The emitted points only have the difference fields, and the tags other than the data center tag.
+// Points of other data centers are ignored.
+//
+// When only one of the data centers has a point for a time, the time is dropped by default.
+// With carryForward the last value of the missing data center is used instead, if there is one.
+// A field missing from either point, or that is not numeric, is not compared.
+type MirrorNode struct {
+	chainnode `json:"-"`
+
+	// The values of the data center tag of the two mirrored data centers.
+	// tick:ignore
+	DCs []string `json:"dcs"`
+
+	// The name of the data center tag.
+	// Default: dc
+	Tag string `json:"tag"`
+
+	// The fields to compare.
+	// tick:ignore
+	Fields []string `tick:"Field" json:"fields"`
+
+	// Whether to use the last value of a data center missing a point.
+	// tick:ignore
+	CarryForwardFlag bool `tick:"CarryForward" json:"carryForward"`
+}
+
+func newMirrorNode(wants EdgeType, a, b string) *MirrorNode {
+	return &MirrorNode{
+		chainnode: newBasicChainNode("mirror", wants, wants),
+		DCs:       []string{a, b},
+		Tag:       "dc",
+	}
+}
+
+// MarshalJSON converts MirrorNode to JSON
+// tick:ignore
+func (n *MirrorNode) MarshalJSON() ([]byte, error) {
+	type Alias MirrorNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "mirror",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MirrorNode
+// tick:ignore
+func (n *MirrorNode) UnmarshalJSON(data []byte) error {
+	type Alias MirrorNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "mirror" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MirrorNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Compare the fields between the data centers.
+// tick:property
+func (n *MirrorNode) Field(fields ...string) *MirrorNode {
+	n.Fields = append(n.Fields, fields...)
+	return n
+}
+
+// Use the last value of a data center when it has no point for a time.
+// tick:property
+func (n *MirrorNode) CarryForward() *MirrorNode {
+	n.CarryForwardFlag = true
+	return n
+}
+
+func (n *MirrorNode) validate() error {
+	if len(n.DCs) != 2 {
+		return errors.New("must specify two data centers for mirror")
+	}
+	if n.DCs[0] == "" || n.DCs[1] == "" {
+		return errors.New("mirror data centers must not be empty")
+	}
+	if n.DCs[0] == n.DCs[1] {
+		return fmt.Errorf("mirror data centers must be different, got %q twice", n.DCs[0])
+	}
+	if n.Tag == "" {
+		return errors.New("must specify the data center tag for mirror")
+	}
+	if len(n.Fields) == 0 {
+		return errors.New("must specify at least one field for mirror")
+	}
+	return nil
+}
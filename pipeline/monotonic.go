@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the length of the current monotonic run of a field.
+// For each point the value is compared with the previous value of the group.
+// While the values keep moving in the same direction the run length is incremented,
+// when the direction changes a new run of length 1 is started in the new direction.
+//
+// Two fields are added to each point, the run length, `run_length`, and the
+// direction of the run, `run_direction`, which is 1 for an increasing run,
+// -1 for a decreasing run and 0 when there is no run.
+//
+// Equal consecutive values (plateaus) continue the current run by default,
+// i.e. runs are non-decreasing or non-increasing.
+// Use the strict property to end the run at a plateau instead,
+// in which case the run length and the direction are reset to 0.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('mem')
+//	    |groupBy('host')
+//	    |monotonic('used')
+//	    |alert()
+//	        // Warn when memory usage has been climbing for 30 consecutive points.
+//	        .warn(lambda: "run_direction" == 1 AND "run_length" >= 30)
+//
+// The first point of a group has a run length of 0.
+// Points without a numeric value are dropped and do not affect the run.
+// State is kept per group, and is reset at the start of each batch.
+type MonotonicNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when computing the run.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the run length field.
+	// Default: run_length
+	As string `json:"as"`
+
+	// The name of the run direction field.
+	// Default: run_direction
+	DirectionAs string `json:"directionAs"`
+
+	// Whether equal consecutive values end the run.
+	// tick:ignore
+	StrictFlag bool `tick:"Strict" json:"strict"`
+}
+
+func newMonotonicNode(wants EdgeType, field string) *MonotonicNode {
+	return &MonotonicNode{
+		chainnode:   newBasicChainNode("monotonic", wants, wants),
+		Field:       field,
+		As:          "run_length",
+		DirectionAs: "run_direction",
+	}
+}
+
+// MarshalJSON converts MonotonicNode to JSON
+// tick:ignore
+func (n *MonotonicNode) MarshalJSON() ([]byte, error) {
+	type Alias MonotonicNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "monotonic",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MonotonicNode
+// tick:ignore
+func (n *MonotonicNode) UnmarshalJSON(data []byte) error {
+	type Alias MonotonicNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "monotonic" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MonotonicNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// If set, equal consecutive values end the current run,
+// so that only strictly increasing or decreasing values are counted.
+// tick:property
+func (n *MonotonicNode) Strict() *MonotonicNode {
+	n.StrictFlag = true
+	return n
+}
+
+func (n *MonotonicNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for monotonic")
+	}
+	if n.As == "" || n.DirectionAs == "" {
+		return errors.New("monotonic field names must not be empty")
+	}
+	if n.As == n.DirectionAs {
+		return errors.New("monotonic as and directionAs must be different")
+	}
+	return nil
+}
@@ -390,6 +390,24 @@ func (n *chainnode) Join(others ...Node) *JoinNode {
 	return j
 }
 
+// Enrich the points of this stream with the latest fields from source, per group.
+func (n *chainnode) Enrich(source Node) *EnrichNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot enrich batch edge")
+	}
+	return newEnrichNode(n, source)
+}
+
+// Annotate the data of this node with the most recent event marker from markers.
+func (n *chainnode) LastMarker(markers Node) *LastMarkerNode {
+	return newLastMarkerNode(n, markers)
+}
+
+// Compare the data of this node with the latest forecast from forecast, per group.
+func (n *chainnode) ForecastResidual(forecast Node) *ForecastResidualNode {
+	return newForecastResidualNode(n, forecast)
+}
+
 // Combine this node with itself. The data are combined on timestamp.
 func (n *chainnode) Combine(expressions ...*ast.LambdaNode) *CombineNode {
 	c := newCombineNode(n.provides, expressions)
@@ -425,6 +443,13 @@ func (n *chainnode) GroupBy(tag ...interface{}) *GroupByNode {
 	return g
 }
 
+// Group the data by the result of an expression.
+func (n *chainnode) GroupByExpr(expression *ast.LambdaNode) *GroupByExprNode {
+	g := newGroupByExprNode(n.provides, expression)
+	n.linkChild(g)
+	return g
+}
+
 // Create a new node that windows the stream by time.
 //
 // NOTE: Window can only be applied to stream edges.
@@ -469,6 +494,111 @@ func (n *chainnode) ChangeDetect(fields ...string) *ChangeDetectNode {
 	return s
 }
 
+// Create a new node that computes the autocorrelation coefficient of a field at a given lag.
+func (n *chainnode) Autocorrelation(field string) *AutocorrelationNode {
+	a := newAutocorrelationNode(n.Provides(), field)
+	n.linkChild(a)
+	return a
+}
+
+// Create a new node that splits the data into one branch per value of a tag.
+func (n *chainnode) FanOut(tag string, values ...string) *FanOutNode {
+	f := newFanOutNode(n.Provides(), tag, values)
+	n.linkChild(f)
+	return f
+}
+
+// Create a new node that aggregates the data across some of the tags of its groups.
+func (n *chainnode) Rollup(field string) *RollupNode {
+	r := newRollupNode(n.Provides(), field)
+	n.linkChild(r)
+	return r
+}
+
+// Create a new node that validates points against a schema of required fields.
+func (n *chainnode) Schema() *SchemaNode {
+	s := newSchemaNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a new node that decomposes a field into trend, seasonal and residual components.
+func (n *chainnode) Decompose(field string) *DecomposeNode {
+	d := newDecomposeNode(n.Provides(), field)
+	n.linkChild(d)
+	return d
+}
+
+// Create a new node that keeps the points for which two conditions hold at the same time.
+func (n *chainnode) Coincidence(left, right *ast.LambdaNode) *CoincidenceNode {
+	c := newCoincidenceNode(n.Provides(), left, right)
+	n.linkChild(c)
+	return c
+}
+
+// Create a new node that records the ingestion time of points.
+func (n *chainnode) Stamp() *StampNode {
+	s := newStampNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a new node that computes the time elapsed since points were ingested by a stamp node.
+func (n *chainnode) Lag() *LagNode {
+	l := newLagNode(n.Provides())
+	n.linkChild(l)
+	return l
+}
+
+// Create a new node that computes the difference of fields between two mirrored data centers.
+func (n *chainnode) Mirror(a, b string) *MirrorNode {
+	m := newMirrorNode(n.Provides(), a, b)
+	n.linkChild(m)
+	return m
+}
+
+// Create a new node that estimates the number of distinct values of a field or tag over a sliding window.
+func (n *chainnode) Cardinality(field string) *CardinalityNode {
+	c := newCardinalityNode(n.Provides(), field)
+	n.linkChild(c)
+	return c
+}
+
+// Create a new node that emits the set of groups that are currently failing a condition.
+func (n *chainnode) Failing(expression *ast.LambdaNode) *FailingNode {
+	f := newFailingNode(n.Provides(), expression)
+	n.linkChild(f)
+	return f
+}
+
+// Create a new node that computes the z-score of a field against a seasonal baseline.
+func (n *chainnode) SeasonalZScore(field string) *SeasonalZScoreNode {
+	s := newSeasonalZScoreNode(n.Provides(), field)
+	n.linkChild(s)
+	return s
+}
+
+// Create a new node that computes the percentage change of a field over a sliding time window.
+func (n *chainnode) PercentChange(field string) *PercentChangeNode {
+	p := newPercentChangeNode(n.Provides(), field)
+	n.linkChild(p)
+	return p
+}
+
+// Create a new node that computes the percentile rank of each value within the recent values of its group.
+func (n *chainnode) PercentileRank(field string) *PercentileRankNode {
+	p := newPercentileRankNode(n.Provides(), field)
+	n.linkChild(p)
+	return p
+}
+
+// Create a new node that computes the CUSUM change-point statistics of a field.
+func (n *chainnode) Cusum(field string) *CusumNode {
+	c := newCusumNode(n.Provides(), field)
+	n.linkChild(c)
+	return c
+}
+
 // Create a new node that shifts the incoming points or batches in time.
 func (n *chainnode) Shift(shift time.Duration) *ShiftNode {
 	s := newShiftNode(n.Provides(), shift)
@@ -532,6 +662,83 @@ func (n *chainnode) StateCount(expression *ast.LambdaNode) *StateCountNode {
 	return sc
 }
 
+// Create a node that computes the length of the current monotonic run of a field.
+func (n *chainnode) Monotonic(field string) *MonotonicNode {
+	m := newMonotonicNode(n.provides, field)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that accumulates the sum of a field since its last scheduled reset.
+func (n *chainnode) Accumulate(field string) *AccumulateNode {
+	a := newAccumulateNode(n.provides, field)
+	n.linkChild(a)
+	return a
+}
+
+// Create a node that emits an event when a sequence number field has a gap or a regression.
+func (n *chainnode) Sequence(field string) *SequenceNode {
+	s := newSequenceNode(n.provides, field)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that computes the entropy of the distribution of a category over a sliding window.
+func (n *chainnode) Entropy(category string) *EntropyNode {
+	e := newEntropyNode(n.provides, category)
+	n.linkChild(e)
+	return e
+}
+
+// Create a node that emits an event when a metered value exceeds the per group limit of a token bucket.
+func (n *chainnode) TokenBucket(field string) *TokenBucketNode {
+	b := newTokenBucketNode(n.provides, field)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that compares the fields of the points against the baseline of a stored recording.
+func (n *chainnode) Baseline(recording string) *BaselineNode {
+	b := newBaselineNode(n.provides, recording)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that computes the consensus of a field across the replicas of each group.
+func (n *chainnode) Consensus(field string) *ConsensusNode {
+	c := newConsensusNode(n.provides, field)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that learns a threshold of a field as a percentile of its values over a long period.
+func (n *chainnode) PercentileThreshold(field string) *PercentileThresholdNode {
+	p := newPercentileThresholdNode(n.provides, field)
+	n.linkChild(p)
+	return p
+}
+
+// Create a node that computes the lag at which two fields are the most correlated.
+func (n *chainnode) CrossCorrelation(field, lagging string) *CrossCorrelationNode {
+	c := newCrossCorrelationNode(n.provides, field, lagging)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that smooths a counter with a leaky bucket per group.
+func (n *chainnode) LeakyBucket(field string) *LeakyBucketNode {
+	b := newLeakyBucketNode(n.provides, field)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that computes the residual of a field against an expected value.
+func (n *chainnode) Residual(field string, expected *ast.LambdaNode) *ResidualNode {
+	r := newResidualNode(n.provides, field, expected)
+	n.linkChild(r)
+	return r
+}
+
 // Create a node that can load data from external sources
 func (n *chainnode) Sideload() *SideloadNode {
 	s := newSideloadNode(n.provides)
@@ -539,6 +746,39 @@ func (n *chainnode) Sideload() *SideloadNode {
 	return s
 }
 
+// Create a node that collapses all groups of a window into a single point.
+func (n *chainnode) Summary(field string) *SummaryNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot summarize stream edge")
+	}
+
+	s := newSummaryNode(field)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that drops the outliers of a field from batches.
+func (n *chainnode) DropOutliers(field string) *DropOutliersNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot drop outliers from stream edge")
+	}
+
+	d := newDropOutliersNode(field)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that computes the fraction of time a condition held within each batch.
+func (n *chainnode) Uptime(expression *ast.LambdaNode) *UptimeNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot compute uptime of stream edge")
+	}
+
+	u := newUptimeNode(expression)
+	n.linkChild(u)
+	return u
+}
+
 // Create a node that converts batches (such as windowed data) into non-batches.
 func (n *chainnode) Trickle() *TrickleNode {
 	if n.Provides() != BatchEdge {
@@ -549,3 +789,43 @@ func (n *chainnode) Trickle() *TrickleNode {
 	n.linkChild(s)
 	return s
 }
+
+// Create a node that computes the Mann-Kendall trend test of a field within each batch.
+func (n *chainnode) MannKendall(field string) *MannKendallNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot compute mann-kendall trend of stream edge")
+	}
+
+	m := newMannKendallNode(field)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that pivots each batch into a single denormalized point.
+func (n *chainnode) Pivot() *PivotNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot pivot stream edge")
+	}
+
+	p := newPivotNode()
+	n.linkChild(p)
+	return p
+}
+
+// Create a node that computes the ratio of two reducers over each batch.
+func (n *chainnode) Ratio() *RatioNode {
+	if n.Provides() != BatchEdge {
+		panic("cannot compute ratio of stream edge")
+	}
+
+	r := newRatioNode()
+	n.linkChild(r)
+	return r
+}
+
+// Create a node that emits an event when a group is first seen and when it is deleted.
+func (n *chainnode) GroupEvents() *GroupEventsNode {
+	g := newGroupEventsNode(n.Provides())
+	n.linkChild(g)
+	return g
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Compute the percentage change of a field over a sliding time window.
+// For each point the field is compared with its value one period earlier in the same group:
+//
+//	(current - value_period_ago) / value_period_ago * 100
+//
+// The value one period earlier is the most recent value at or before the point's time minus the period.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |groupBy('service')
+//	    |percentChange('rate')
+//	        .period(10m)
+//	    |alert()
+//	        // Traffic grew by more than 200% over the last 10 minutes.
+//	        .warn(lambda: isPresent("percent_change") AND "percent_change" > 200.0)
+//
+// The percentage change is added to each point as the field `percent_change`.
+// Until a full period of values has been seen (warm-up), or when the value one period earlier is zero,
+// the percentage change is undefined and the field is not set on the point.
+// Points without a numeric value are dropped and are not added to the window.
+// State is kept per group, and is reset at the start of each batch.
+type PercentChangeNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when computing the percentage change.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The period over which the change is computed.
+	// Default: 1m
+	Period time.Duration `json:"period"`
+
+	// The name of the percentage change field.
+	// Default: percent_change
+	As string `json:"as"`
+}
+
+func newPercentChangeNode(wants EdgeType, field string) *PercentChangeNode {
+	return &PercentChangeNode{
+		chainnode: newBasicChainNode("percentChange", wants, wants),
+		Field:     field,
+		Period:    time.Minute,
+		As:        "percent_change",
+	}
+}
+
+// MarshalJSON converts PercentChangeNode to JSON
+// tick:ignore
+func (n *PercentChangeNode) MarshalJSON() ([]byte, error) {
+	type Alias PercentChangeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "percentChange",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PercentChangeNode
+// tick:ignore
+func (n *PercentChangeNode) UnmarshalJSON(data []byte) error {
+	type Alias PercentChangeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "percentChange" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PercentChangeNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *PercentChangeNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for percentChange")
+	}
+	if n.Period <= 0 {
+		return errors.New("percentChange period must be greater than zero")
+	}
+	if n.As == "" {
+		return errors.New("percentChange as must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the percentile rank of each value within the recent values of its group.
+// The percentile rank is the percentage of the previous values of the window that are below the value,
+// counting values equal to it as half below:
+//
+//	(below + equal / 2) / size * 100
+//
+// It ranges from 0, lower than all recent values, to 100, higher than all recent values.
+// The window contains the previous size values of the group, not including the current value.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('latency')
+//	    |groupBy('service')
+//	    |percentileRank('p99')
+//	        .size(100)
+//	        .minPoints(20)
+//	    |alert()
+//	        // The latency is higher than 99% of its recent history.
+//	        .warn(lambda: isPresent("percentile_rank") AND "percentile_rank" > 99.0)
+//
+// The percentile rank is added to each point as the field `percentile_rank`.
+// Until the window has minPoints values (warm-up), the field is not set on the point.
+// Points without a numeric value are dropped and are not added to the window.
+// State is kept per group, and is reset at the start of each batch.
+type PercentileRankNode struct {
+	chainnode `json:"-"`
+
+	// The field to rank.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The maximum number of previous values in the window.
+	// Default: 100
+	Size int64 `json:"size"`
+
+	// The minimum number of previous values required to compute the rank.
+	// Default: 10
+	MinPoints int64 `json:"minPoints"`
+
+	// The name of the percentile rank field.
+	// Default: percentile_rank
+	As string `json:"as"`
+}
+
+func newPercentileRankNode(wants EdgeType, field string) *PercentileRankNode {
+	return &PercentileRankNode{
+		chainnode: newBasicChainNode("percentileRank", wants, wants),
+		Field:     field,
+		Size:      100,
+		MinPoints: 10,
+		As:        "percentile_rank",
+	}
+}
+
+// MarshalJSON converts PercentileRankNode to JSON
+// tick:ignore
+func (n *PercentileRankNode) MarshalJSON() ([]byte, error) {
+	type Alias PercentileRankNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "percentileRank",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PercentileRankNode
+// tick:ignore
+func (n *PercentileRankNode) UnmarshalJSON(data []byte) error {
+	type Alias PercentileRankNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "percentileRank" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PercentileRankNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *PercentileRankNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for percentileRank")
+	}
+	if n.Size <= 0 {
+		return errors.New("percentileRank size must be greater than zero")
+	}
+	if n.MinPoints <= 0 || n.MinPoints > n.Size {
+		return errors.New("percentileRank minPoints must be greater than zero and at most size")
+	}
+	if n.As == "" {
+		return errors.New("percentileRank as must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Learn a dynamic threshold of a field per group, as a percentile of its values over a long period,
+// instead of configuring and maintaining a fixed threshold.
+// The percentile is estimated with a t-digest, so the memory used per group is bounded whatever the number of points.
+// Each point is emitted with the threshold learned from the points before it, `threshold`,
+// so that it can be compared against the current value.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |percentileThreshold('latency')
+//	        .percentile(99.0)
+//	        .period(7d)
+//	    |alert()
+//	        .warn(lambda: isPresent("threshold") AND "latency" > "threshold")
+//
+// The above example warns when the latency of a service is higher than 99 percent of its latencies of the last 7 days.
+//
+// Warm-up: the threshold is only set once at least the min points have been learned,
+// before that the points are emitted without the threshold field, hence the isPresent check in the example above.
+//
+// Persistence: the learned values are part of the snapshots of the task,
+// saved every snapshot interval, and are restored when the task is restarted.
+//
+// The period is split into slices, each with its own digest, plus one for the whole period.
+// The oldest slice is dropped as a whole, so the learned values cover between period - period/slices and period.
+// The period is based on the time of the points, and slices are aligned to multiples of period/slices.
+// Points without a numeric value are dropped.
+// State is kept per group across batches.
+type PercentileThresholdNode struct {
+	chainnode `json:"-"`
+
+	// The field of the values.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The percentile of the threshold, between 0 and 100.
+	// Default: 99
+	Percentile float64 `json:"percentile"`
+
+	// The period of the learned values.
+	// Default: 7d
+	Period time.Duration `json:"period"`
+
+	// The number of slices of the period.
+	// More slices make the period slide more smoothly, but use more memory.
+	// Default: 7
+	Slices int64 `json:"slices"`
+
+	// The compression of the digests.
+	// A higher compression is more accurate, but uses more memory, about 50 bytes per unit of compression per digest.
+	// Default: 100
+	Compression float64 `json:"compression"`
+
+	// The minimum number of learned points before the threshold is set.
+	// Default: 100
+	MinPoints int64 `json:"minPoints"`
+
+	// The name of the threshold field.
+	// Default: threshold
+	As string `json:"as"`
+}
+
+func newPercentileThresholdNode(wants EdgeType, field string) *PercentileThresholdNode {
+	return &PercentileThresholdNode{
+		chainnode:   newBasicChainNode("percentileThreshold", wants, wants),
+		Field:       field,
+		Percentile:  99,
+		Period:      7 * 24 * time.Hour,
+		Slices:      7,
+		Compression: 100,
+		MinPoints:   100,
+		As:          "threshold",
+	}
+}
+
+// MarshalJSON converts PercentileThresholdNode to JSON
+// tick:ignore
+func (n *PercentileThresholdNode) MarshalJSON() ([]byte, error) {
+	type Alias PercentileThresholdNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "percentileThreshold",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PercentileThresholdNode
+// tick:ignore
+func (n *PercentileThresholdNode) UnmarshalJSON(data []byte) error {
+	type Alias PercentileThresholdNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "percentileThreshold" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PercentileThresholdNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *PercentileThresholdNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for percentileThreshold")
+	}
+	if n.Percentile <= 0 || n.Percentile >= 100 {
+		return fmt.Errorf("percentileThreshold percentile must be between 0 and 100, got %v", n.Percentile)
+	}
+	if n.Period <= 0 {
+		return fmt.Errorf("percentileThreshold period must be positive, got %v", n.Period)
+	}
+	if n.Slices < 1 {
+		return fmt.Errorf("percentileThreshold slices must be at least 1, got %d", n.Slices)
+	}
+	if n.Period%time.Duration(n.Slices) != 0 {
+		return fmt.Errorf("percentileThreshold period %v must be a multiple of the number of slices %d", n.Period, n.Slices)
+	}
+	if n.Compression <= 0 {
+		return fmt.Errorf("percentileThreshold compression must be positive, got %v", n.Compression)
+	}
+	if n.MinPoints < 1 {
+		return fmt.Errorf("percentileThreshold minPoints must be at least 1, got %d", n.MinPoints)
+	}
+	if n.As == "" {
+		return errors.New("must specify a field name for percentileThreshold")
+	}
+	return nil
+}
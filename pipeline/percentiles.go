@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Compute several percentiles of a field in a single pass.
+// The values of each batch, or of all stream points with the same time, are sorted once
+// and a single point is emitted per group with one field per requested percentile.
+// The percentiles are selected like the InfluxQL `percentile` function,
+// no interpolation between points is performed.
+//
+// The fields are named by the percentile prefixed with `p`,
+// i.e. the 50th percentile is the field `p50` and the 99.9th percentile is the field `p99.9`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |groupBy('service')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |percentiles('latency', 50.0, 90.0, 99.0)
+//	    |influxDBOut()
+//	        .database('latency_bands')
+//
+// The above example writes a point per service and window with the fields `p50`, `p90` and `p99`.
+//
+// The percentiles of more fields are computed with the fields property, sorting the values of each field independently.
+// When there is more than one field, the percentile fields are prefixed with the name of their field,
+// i.e. the 99th percentile of the field `latency` is the field `latency_p99`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('endpoints')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |percentiles('login', 50.0, 99.0)
+//	        .fields('search', 'checkout')
+//
+// The above example emits the fields `login_p50`, `login_p99`, `search_p50`, `search_p99`, `checkout_p50` and `checkout_p99`.
+// A point missing some of the fields only contributes the values of the fields it has,
+// and the percentiles of a field missing from all points are not set.
+//
+// Integer fields produce integer percentiles, all other numeric fields produce float percentiles.
+// A percentile which selects no point, e.g. the 1st percentile of fewer than 50 values, is not set on the point.
+type PercentilesNode struct {
+	chainnode `json:"-"`
+
+	// The field to use when computing the percentiles.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The percentiles to compute.
+	// tick:ignore
+	Percentiles []float64 `json:"percentiles"`
+
+	// More fields to compute the percentiles of.
+	// tick:ignore
+	MoreFields []string `tick:"Fields" json:"fields"`
+}
+
+func newPercentilesNode(wants EdgeType, field string, percentiles []float64) *PercentilesNode {
+	return &PercentilesNode{
+		chainnode:   newBasicChainNode("percentiles", wants, StreamEdge),
+		Field:       field,
+		Percentiles: percentiles,
+	}
+}
+
+// MarshalJSON converts PercentilesNode to JSON
+// tick:ignore
+func (n *PercentilesNode) MarshalJSON() ([]byte, error) {
+	type Alias PercentilesNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "percentiles",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PercentilesNode
+// tick:ignore
+func (n *PercentilesNode) UnmarshalJSON(data []byte) error {
+	type Alias PercentilesNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "percentiles" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PercentilesNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Compute the percentiles of more fields.
+// tick:property
+func (n *PercentilesNode) Fields(fields ...string) *PercentilesNode {
+	n.MoreFields = append(n.MoreFields, fields...)
+	return n
+}
+
+// AllFields returns all of the fields to compute the percentiles of.
+// tick:ignore
+func (n *PercentilesNode) AllFields() []string {
+	return append([]string{n.Field}, n.MoreFields...)
+}
+
+// FieldName returns the name of the field holding the given percentile of the field.
+// tick:ignore
+func (n *PercentilesNode) FieldName(field string, percentile float64) string {
+	name := "p" + strconv.FormatFloat(percentile, 'f', -1, 64)
+	if len(n.MoreFields) == 0 {
+		return name
+	}
+	return field + "_" + name
+}
+
+func (n *PercentilesNode) validate() error {
+	fields := make(map[string]bool, len(n.MoreFields)+1)
+	for _, f := range n.AllFields() {
+		if f == "" {
+			return errors.New("must specify a field for percentiles")
+		}
+		if fields[f] {
+			return fmt.Errorf("duplicate percentiles field %q", f)
+		}
+		fields[f] = true
+	}
+	if len(n.Percentiles) == 0 {
+		return errors.New("must specify at least one percentile")
+	}
+	seen := make(map[float64]bool, len(n.Percentiles))
+	for _, p := range n.Percentiles {
+		if p <= 0 || p > 100 {
+			return fmt.Errorf("percentile %v must be in the range (0, 100]", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("duplicate percentile %v", p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	defaultPivotDelimiter = "_"
+)
+
+// Pivot each batch into a single denormalized point, with one field per field and aggregate of the batch.
+// This is the inverse of flatten and suits export targets expecting one wide row per entity,
+// such as CSV files or relational tables.
+//
+// The aggregate of each point is identified by the values of the on tags.
+// For example given a batch of the points:
+//
+// m,host=A,aggregate=mean cpu=12,mem=40
+// m,host=A,aggregate=max cpu=80,mem=65
+//
+// Pivoting the batch on `aggregate` would result in a single point:
+//
+// m,host=A cpu_mean=12,cpu_max=80,mem_mean=40,mem_max=65
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('stats')
+//	        .groupBy('host')
+//	    |window()
+//	        .period(1h)
+//	        .every(1h)
+//	    |pivot()
+//	        .on('aggregate')
+//	        .columns('cpu_mean', 'cpu_max', 'mem_mean', 'mem_max')
+//	        .fill(0.0)
+//	    |httpOut('stats')
+//
+// The name of each field of the point is the name of the original field followed by the values of the on tags,
+// joined by the delimiter. Without on tags the fields keep their names,
+// so that the fields of all points of a batch are merged into a single point.
+// When several points of a batch have the same field, the field of the last point is used.
+// Points missing any of the on tags are ignored.
+//
+// The point has the time of the batch and the tags of its group.
+// Groups do not always have all of the aggregates, so that the points of different groups may have different fields.
+// To get the same fields for every group specify the columns: only those fields are kept,
+// and the columns missing from a batch are set to the fill value, or left out if there is no fill value.
+type PivotNode struct {
+	chainnode `json:"-"`
+
+	// The tags identifying the aggregate of each point.
+	// tick:ignore
+	Dimensions []string `tick:"On" json:"on"`
+
+	// The delimiter between the field name and the values of the on tags.
+	// Default: _
+	Delimiter string `json:"delimiter"`
+
+	// The fields of the point.
+	// If empty all fields are kept.
+	// tick:ignore
+	ColumnsList []string `tick:"Columns" json:"columns"`
+
+	// The value of the columns missing from a batch.
+	// If nil the missing columns are left out.
+	Fill interface{} `json:"fill"`
+}
+
+func newPivotNode() *PivotNode {
+	return &PivotNode{
+		chainnode: newBasicChainNode("pivot", BatchEdge, BatchEdge),
+		Delimiter: defaultPivotDelimiter,
+	}
+}
+
+// MarshalJSON converts PivotNode to JSON
+// tick:ignore
+func (n *PivotNode) MarshalJSON() ([]byte, error) {
+	type Alias PivotNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "pivot",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PivotNode
+// tick:ignore
+func (n *PivotNode) UnmarshalJSON(data []byte) error {
+	type Alias PivotNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "pivot" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PivotNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Specify the tags identifying the aggregate of each point.
+// tick:property
+func (n *PivotNode) On(dims ...string) *PivotNode {
+	n.Dimensions = dims
+	return n
+}
+
+// Specify the fields of the point.
+// tick:property
+func (n *PivotNode) Columns(columns ...string) *PivotNode {
+	n.ColumnsList = columns
+	return n
+}
+
+func (n *PivotNode) validate() error {
+	if len(n.Dimensions) > 0 && n.Delimiter == "" {
+		return errors.New("must specify a delimiter for pivot")
+	}
+	columns := make(map[string]bool, len(n.ColumnsList))
+	for _, c := range n.ColumnsList {
+		if columns[c] {
+			return fmt.Errorf("duplicate column %q for pivot", c)
+		}
+		columns[c] = true
+	}
+	switch n.Fill.(type) {
+	case nil, int64, float64, string, bool:
+	default:
+		return fmt.Errorf("unexpected fill value of type %T for pivot", n.Fill)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The reducers of a RatioNode.
+var ratioReducers = map[string]bool{
+	"count": true,
+	"sum":   true,
+	"mean":  true,
+	"min":   true,
+	"max":   true,
+}
+
+// Compute the ratio of two reducers over the same batch, such as the number of errors per request of a window,
+// in a single pass and without having to join two aggregations.
+//
+// The reducers are `count`, `sum`, `mean`, `min` and `max`, each of a field.
+// A single point is emitted per group and batch, with the time of the batch,
+// the values of the numerator `numerator`, the denominator `denominator` and their ratio `ratio`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |ratio()
+//	        .numerator('sum', 'errors')
+//	        .denominator('count', 'duration')
+//	        .as('error_rate')
+//	    |alert()
+//	        .crit(lambda: "error_rate" > 0.05)
+//
+// The above example alerts when more than 5 percent of the requests of a service had an error in the last minute.
+//
+// Points missing the field of a reducer, or with a non numeric value, are ignored by that reducer.
+// When the denominator is zero, or a reducer has no values, the ratio is null: the field is not set on the point.
+// Likewise the mean, min and max reducers without values are not set.
+// Empty batches do not emit a point.
+type RatioNode struct {
+	chainnode `json:"-"`
+
+	// The reducer of the numerator.
+	// tick:ignore
+	NumeratorReducer string `tick:"Numerator" json:"numeratorReducer"`
+
+	// The field of the numerator.
+	// tick:ignore
+	NumeratorField string `tick:"Numerator" json:"numeratorField"`
+
+	// The reducer of the denominator.
+	// tick:ignore
+	DenominatorReducer string `tick:"Denominator" json:"denominatorReducer"`
+
+	// The field of the denominator.
+	// tick:ignore
+	DenominatorField string `tick:"Denominator" json:"denominatorField"`
+
+	// The name of the ratio field.
+	// Default: ratio
+	As string `json:"as"`
+
+	// The name of the numerator field.
+	// Default: numerator
+	NumeratorAs string `json:"numeratorAs"`
+
+	// The name of the denominator field.
+	// Default: denominator
+	DenominatorAs string `json:"denominatorAs"`
+}
+
+func newRatioNode() *RatioNode {
+	return &RatioNode{
+		chainnode:     newBasicChainNode("ratio", BatchEdge, StreamEdge),
+		As:            "ratio",
+		NumeratorAs:   "numerator",
+		DenominatorAs: "denominator",
+	}
+}
+
+// MarshalJSON converts RatioNode to JSON
+// tick:ignore
+func (n *RatioNode) MarshalJSON() ([]byte, error) {
+	type Alias RatioNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "ratio",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an RatioNode
+// tick:ignore
+func (n *RatioNode) UnmarshalJSON(data []byte) error {
+	type Alias RatioNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "ratio" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as RatioNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// The reducer and field of the numerator.
+// tick:property
+func (n *RatioNode) Numerator(reducer, field string) *RatioNode {
+	n.NumeratorReducer = reducer
+	n.NumeratorField = field
+	return n
+}
+
+// The reducer and field of the denominator.
+// tick:property
+func (n *RatioNode) Denominator(reducer, field string) *RatioNode {
+	n.DenominatorReducer = reducer
+	n.DenominatorField = field
+	return n
+}
+
+func (n *RatioNode) validate() error {
+	if n.NumeratorReducer == "" || n.NumeratorField == "" {
+		return errors.New("must specify a numerator for ratio")
+	}
+	if n.DenominatorReducer == "" || n.DenominatorField == "" {
+		return errors.New("must specify a denominator for ratio")
+	}
+	for _, r := range []string{n.NumeratorReducer, n.DenominatorReducer} {
+		if !ratioReducers[r] {
+			return fmt.Errorf("unknown reducer %q for ratio, must be one of count, sum, mean, min or max", r)
+		}
+	}
+	if n.As == "" || n.NumeratorAs == "" || n.DenominatorAs == "" {
+		return errors.New("ratio field names must not be empty")
+	}
+	if n.As == n.NumeratorAs || n.As == n.DenominatorAs || n.NumeratorAs == n.DenominatorAs {
+		return errors.New("ratio as, numeratorAs and denominatorAs must be different")
+	}
+	return nil
+}
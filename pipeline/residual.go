@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Compute the residual of a field, the difference between its value and an expected value.
+// The expected value is defined via a lambda expression, which can reference the tags and
+// fields of the point as well as its time, making it possible to describe deterministic
+// but schedule dependent expectations.
+//
+// The residual is computed as:
+//
+//	residual = value - expected
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |residual('rate', lambda: if(hour("time") >= 9 AND hour("time") < 17, 1000.0, 200.0))
+//	    |alert()
+//	        .crit(lambda: abs("residual") > 300.0)
+//
+// The expected value is 1000 requests during business hours and 200 otherwise.
+// Time based functions such as hour, weekday and minute can be used to build up a schedule.
+//
+// The residual is added to each point as the field `residual`.
+// If the field is missing, is not numeric or the expression does not evaluate to a number,
+// the point is dropped.
+type ResidualNode struct {
+	chainnode `json:"-"`
+
+	// The field containing the actual value.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// Expression for the expected value of the field.
+	// tick:ignore
+	Expected *ast.LambdaNode `json:"expected"`
+
+	// The name of the residual field.
+	// Default: residual
+	As string `json:"as"`
+}
+
+func newResidualNode(wants EdgeType, field string, expected *ast.LambdaNode) *ResidualNode {
+	return &ResidualNode{
+		chainnode: newBasicChainNode("residual", wants, wants),
+		Field:     field,
+		Expected:  expected,
+		As:        "residual",
+	}
+}
+
+// MarshalJSON converts ResidualNode to JSON
+// tick:ignore
+func (n *ResidualNode) MarshalJSON() ([]byte, error) {
+	type Alias ResidualNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "residual",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ResidualNode
+// tick:ignore
+func (n *ResidualNode) UnmarshalJSON(data []byte) error {
+	type Alias ResidualNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "residual" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ResidualNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *ResidualNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for residual")
+	}
+	if n.Expected == nil {
+		return errors.New("must specify an expected value expression for residual")
+	}
+	if n.As == "" {
+		return errors.New("residual as must not be empty")
+	}
+	return nil
+}
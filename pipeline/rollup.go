@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// A RollupNode aggregates data across some of the tags of its groups, a partial groupBy.
+// The dropped tags are removed from the dimensions of the data,
+// and the values of the field of all groups that become the same group are combined
+// with the chosen aggregation, separately for each point time.
+//
+// Available aggregations are the same as summary, sum, mean, min, max and count.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('region', 'host')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |sum('value')
+//	        .as('value')
+//	    |rollup('value')
+//	        .drop('host')
+//	        .as('requests')
+//	    |alert()
+//	        .crit(lambda: "requests" > 10000.0)
+//
+// The above example counts the requests of each host per minute, and alerts on the total of each region.
+//
+// Points of the rolled up groups with the same time are aggregated into a single point, and so are batches:
+// the batches of the same time become one batch with a point per point time.
+// The aggregated points only have the tags that remain in the dimensions.
+// The data of a time is emitted once it has been seen for all groups that are rolled up together,
+// at the end of their batches or on their barriers, or else once data for a later time arrives.
+// The first time of a rolled up group always waits for later data, as its groups are not known until then.
+// The data of a group that joins the rolled up group later may arrive after its time was emitted, and is then emitted separately.
+// Any data still pending is emitted when the task stops.
+type RollupNode struct {
+	chainnode `json:"-"`
+
+	// The field to aggregate.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The tags to drop from the dimensions.
+	// tick:ignore
+	Tags []string `tick:"Drop" json:"drop"`
+
+	// The aggregation to apply, one of sum, mean, min, max or count.
+	// Default: sum
+	Aggregate string `json:"aggregate"`
+
+	// The name of the aggregated field.
+	// Default: the name of the aggregation
+	As string `json:"as"`
+}
+
+func newRollupNode(wants EdgeType, field string) *RollupNode {
+	return &RollupNode{
+		chainnode: newBasicChainNode("rollup", wants, wants),
+		Field:     field,
+		Aggregate: SummarySum,
+	}
+}
+
+// MarshalJSON converts RollupNode to JSON
+// tick:ignore
+func (n *RollupNode) MarshalJSON() ([]byte, error) {
+	type Alias RollupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "rollup",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an RollupNode
+// tick:ignore
+func (n *RollupNode) UnmarshalJSON(data []byte) error {
+	type Alias RollupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "rollup" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as RollupNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Drop the tags from the dimensions, aggregating the groups that become the same.
+// tick:property
+func (n *RollupNode) Drop(tags ...string) *RollupNode {
+	n.Tags = append(n.Tags, tags...)
+	return n
+}
+
+func (n *RollupNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for rollup")
+	}
+	if len(n.Tags) == 0 {
+		return errors.New("must specify the tags to drop for rollup")
+	}
+	switch n.Aggregate {
+	case SummarySum, SummaryMean, SummaryMin, SummaryMax, SummaryCount:
+	default:
+		return fmt.Errorf("invalid rollup aggregate %q, must be one of sum, mean, min, max or count", n.Aggregate)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Supported types of the fields of a schema.
+const (
+	SchemaFloat   = "float"
+	SchemaInteger = "integer"
+	SchemaString  = "string"
+	SchemaBoolean = "boolean"
+	// Either a float or an integer.
+	SchemaNumber = "number"
+)
+
+// Validate points against a schema of required fields and their types.
+// Points that have all the required fields, with the expected types, pass through the node unchanged.
+// Points that violate the schema are dropped and counted in the `violations` stat,
+// unless the node has quarantine children, which receive the violating points instead.
+// A quarantined point has a field added describing its first violation.
+//
+// The supported types are `float`, `integer`, `string`, `boolean`
+// and `number`, which accepts both floats and integers.
+//
+// Example:
+//
+//	var validated = stream
+//	    |from()
+//	        .measurement('requests')
+//	    |schema()
+//	        .require('value', 'float')
+//	        .require('status', 'integer')
+//
+//	validated
+//	    |quarantine()
+//	    |influxDBOut()
+//	        .database('quarantine')
+//	        .measurement('requests')
+//
+//	validated
+//	    |alert()
+//	        .crit(lambda: "status" >= 500)
+//
+// In batch mode the violating points of a batch are quarantined as a batch of their own,
+// and the batch of conforming points is always emitted, even when empty.
+type SchemaNode struct {
+	chainnode `json:"-"`
+
+	// The required fields and their types.
+	// tick:ignore
+	Fields []SchemaField `tick:"Require" json:"fields"`
+
+	// The name of the field added to quarantined points, describing the violation.
+	// Default: schema_violation
+	ViolationAs string `json:"violationAs"`
+}
+
+// A required field of a schema.
+// tick:ignore
+type SchemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func newSchemaNode(wants EdgeType) *SchemaNode {
+	return &SchemaNode{
+		chainnode:   newBasicChainNode("schema", wants, wants),
+		ViolationAs: "schema_violation",
+	}
+}
+
+// MarshalJSON converts SchemaNode to JSON
+// tick:ignore
+func (n *SchemaNode) MarshalJSON() ([]byte, error) {
+	type Alias SchemaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "schema",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SchemaNode
+// tick:ignore
+func (n *SchemaNode) UnmarshalJSON(data []byte) error {
+	type Alias SchemaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "schema" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SchemaNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Require a field of the given type.
+// The type is one of `float`, `integer`, `string`, `boolean` or `number`.
+// tick:property
+func (n *SchemaNode) Require(field, typ string) *SchemaNode {
+	n.Fields = append(n.Fields, SchemaField{
+		Name: field,
+		Type: typ,
+	})
+	return n
+}
+
+// Create a node that receives the points violating the schema.
+// The points are passed to the children of the quarantine node,
+// for example to be written to a separate database.
+func (n *SchemaNode) Quarantine() *QuarantineNode {
+	q := newQuarantineNode(n.Provides())
+	n.linkChild(q)
+	return q
+}
+
+func (n *SchemaNode) validate() error {
+	if len(n.Fields) == 0 {
+		return errors.New("must require at least one field for schema")
+	}
+	seen := make(map[string]bool, len(n.Fields))
+	for _, f := range n.Fields {
+		if f.Name == "" {
+			return errors.New("schema field must not be empty")
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("schema field %q is required more than once", f.Name)
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case SchemaFloat, SchemaInteger, SchemaString, SchemaBoolean, SchemaNumber:
+		default:
+			return fmt.Errorf("invalid type %q for schema field %q", f.Type, f.Name)
+		}
+	}
+	if n.ViolationAs == "" {
+		return errors.New("must specify a violation field name for schema")
+	}
+	return nil
+}
+
+// A node that receives the points violating the schema of its parent SchemaNode.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	    |schema()
+//	        .require('value', 'float')
+//	    |quarantine()
+//	    |log()
+type QuarantineNode struct {
+	chainnode `json:"-"`
+}
+
+func newQuarantineNode(wants EdgeType) *QuarantineNode {
+	return &QuarantineNode{
+		chainnode: newBasicChainNode("quarantine", wants, wants),
+	}
+}
+
+// MarshalJSON converts QuarantineNode to JSON
+// tick:ignore
+func (n *QuarantineNode) MarshalJSON() ([]byte, error) {
+	type Alias QuarantineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "quarantine",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an QuarantineNode
+// tick:ignore
+func (n *QuarantineNode) UnmarshalJSON(data []byte) error {
+	type Alias QuarantineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "quarantine" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as QuarantineNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
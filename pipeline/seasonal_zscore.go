@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Compute the z-score of a field against a seasonal baseline, such as the same time of day.
+// The season is divided into buckets, e.g. the hours of a day, and for each group and bucket
+// the mean and standard deviation of the values of the bucket in previous seasons are maintained.
+// Each point is set the number of standard deviations its value is from the mean of its bucket.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('service')
+//	    |seasonalZScore('rate')
+//	        .season(24h)
+//	        .bucket(1h)
+//	        .minSeasons(7)
+//	    |alert()
+//	        .crit(lambda: abs("seasonal_zscore") > 4.0)
+//
+// The above example alerts when the request rate of a service is unusual for the hour of the day,
+// compared to the same hour of at least the previous 7 days.
+//
+// The values of the current season only become part of the baseline of a bucket once the next season starts,
+// so that a point is never compared to itself.
+// Until the baseline of a bucket has values from at least the minimum number of seasons,
+// or when its values do not vary, the z-score field is not set on the points.
+// Buckets are aligned to the Unix epoch and points without a numeric value are dropped.
+type SeasonalZScoreNode struct {
+	chainnode `json:"-"`
+
+	// The field to compute the z-score of.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The duration of a season.
+	// Default: 24h
+	Season time.Duration `json:"season"`
+
+	// The duration of a bucket of the season, the season must be a multiple of it.
+	// Default: 1h
+	Bucket time.Duration `json:"bucket"`
+
+	// The minimum number of previous seasons in the baseline of a bucket before z-scores are computed.
+	// Default: 3
+	MinSeasons int64 `json:"minSeasons"`
+
+	// The name of the z-score field.
+	// Default: seasonal_zscore
+	As string `json:"as"`
+}
+
+func newSeasonalZScoreNode(wants EdgeType, field string) *SeasonalZScoreNode {
+	return &SeasonalZScoreNode{
+		chainnode:  newBasicChainNode("seasonalZScore", wants, wants),
+		Field:      field,
+		Season:     24 * time.Hour,
+		Bucket:     time.Hour,
+		MinSeasons: 3,
+		As:         "seasonal_zscore",
+	}
+}
+
+// MarshalJSON converts SeasonalZScoreNode to JSON
+// tick:ignore
+func (n *SeasonalZScoreNode) MarshalJSON() ([]byte, error) {
+	type Alias SeasonalZScoreNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Season string `json:"season"`
+		Bucket string `json:"bucket"`
+	}{
+		TypeOf: TypeOf{
+			Type: "seasonalZScore",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Season: influxql.FormatDuration(n.Season),
+		Bucket: influxql.FormatDuration(n.Bucket),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SeasonalZScoreNode
+// tick:ignore
+func (n *SeasonalZScoreNode) UnmarshalJSON(data []byte) error {
+	type Alias SeasonalZScoreNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Season string `json:"season"`
+		Bucket string `json:"bucket"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "seasonalZScore" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SeasonalZScoreNode", raw.ID, raw.Type)
+	}
+	n.Season, err = influxql.ParseDuration(raw.Season)
+	if err != nil {
+		return err
+	}
+	n.Bucket, err = influxql.ParseDuration(raw.Bucket)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *SeasonalZScoreNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for seasonalZScore")
+	}
+	if n.Bucket <= 0 {
+		return errors.New("bucket must be greater than zero for seasonalZScore")
+	}
+	if n.Season < n.Bucket || n.Season%n.Bucket != 0 {
+		return fmt.Errorf("season %v must be a multiple of the bucket %v for seasonalZScore", n.Season, n.Bucket)
+	}
+	if n.MinSeasons < 1 {
+		return errors.New("minSeasons must be at least 1 for seasonalZScore")
+	}
+	if n.As == "" {
+		return errors.New("must specify a field name for seasonalZScore")
+	}
+	return nil
+}
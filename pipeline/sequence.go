@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Check the integrity of a sequence number field, such as the sequence numbers of a protocol.
+// For each group the sequence number of each point is compared with the sequence number of the previous point,
+// and an event is emitted when a point is not the next number of the sequence:
+//
+//   - a gap, when sequence numbers are missing between the previous point and the point.
+//   - a regression, when the sequence number is not after the previous one, i.e. it is out of order or a duplicate.
+//
+// Points following their previous point, and the first point of each group, do not emit an event.
+// The events are the points with the unexpected sequence number, with three added fields:
+// the expected sequence number `expected`, the number of missing sequence numbers of a gap `missing`,
+// and the kind of error, `sequence_error`, which is either `gap` or `regression`.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('packets')
+//	        .groupBy('source')
+//	    |sequence('seq')
+//	        .max(65535)
+//	    |alert()
+//	        .warn(lambda: "sequence_error" == 'gap')
+//	        .crit(lambda: "sequence_error" == 'regression')
+//	        .message('{{ .ID }} expected {{ index .Fields "expected" }} got {{ index .Fields "seq" }}')
+//
+// The above example alerts on the dropped and reordered packets of each source,
+// where the sequence numbers wrap around to 0 after 65535.
+//
+// Without a max the sequence numbers do not wrap around.
+// With a max, sequence numbers are compared within the range of the wraparound:
+// a sequence number less than half of the range ahead of the expected number is a gap, otherwise it is a regression.
+//
+// The previous point is always the last point received, so reordered points are reported
+// as a regression followed by a gap, and a restarted sequence as a single regression.
+// Sequence numbers must be integers, points without an integer sequence number are ignored.
+// State is kept per group across batches.
+type SequenceNode struct {
+	chainnode `json:"-"`
+
+	// The field with the sequence number.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The largest sequence number, after which the sequence wraps around to 0.
+	// If zero the sequence does not wrap around.
+	Max int64 `json:"max"`
+
+	// The name of the expected sequence number field.
+	// Default: expected
+	ExpectedAs string `json:"expectedAs"`
+
+	// The name of the number of missing sequence numbers field.
+	// Default: missing
+	MissingAs string `json:"missingAs"`
+
+	// The name of the kind of error field.
+	// Default: sequence_error
+	ErrorAs string `json:"errorAs"`
+}
+
+func newSequenceNode(wants EdgeType, field string) *SequenceNode {
+	return &SequenceNode{
+		chainnode:  newBasicChainNode("sequence", wants, wants),
+		Field:      field,
+		ExpectedAs: "expected",
+		MissingAs:  "missing",
+		ErrorAs:    "sequence_error",
+	}
+}
+
+// MarshalJSON converts SequenceNode to JSON
+// tick:ignore
+func (n *SequenceNode) MarshalJSON() ([]byte, error) {
+	type Alias SequenceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "sequence",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SequenceNode
+// tick:ignore
+func (n *SequenceNode) UnmarshalJSON(data []byte) error {
+	type Alias SequenceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "sequence" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SequenceNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *SequenceNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for sequence")
+	}
+	if n.Max < 0 {
+		return errors.New("sequence max must not be negative")
+	}
+	if n.ExpectedAs == "" || n.MissingAs == "" || n.ErrorAs == "" {
+		return errors.New("sequence field names must not be empty")
+	}
+	if n.ExpectedAs == n.MissingAs || n.ExpectedAs == n.ErrorAs || n.MissingAs == n.ErrorAs {
+		return errors.New("sequence expectedAs, missingAs and errorAs must be different")
+	}
+	return nil
+}
@@ -41,6 +41,30 @@ import (
 //		}
 //	}
 //
+// A CSV file in the source directory is loaded as a lookup table, where the first line names the columns.
+// Each row can be referenced in the order statement by the path of the file followed by the value of its first column,
+// and the other columns of the row are its key/value pairs.
+// Empty cells are treated as missing so that the next path in the order is checked.
+// CSV Source example, thresholds.csv:
+//
+//	host,threshold_warn,threshold_crit
+//	host1,80,90
+//	host2,,95
+//
+// Example:
+//
+//	|sideload()
+//	     .source('file:///path/to/dir')
+//	     .order('thresholds.csv/{{.host}}')
+//	     .field('threshold_warn', 70.0)
+//	     .field('threshold_crit', 85.0)
+//	|alert()
+//	     .warn(lambda: "value" > "threshold_warn")
+//	     .crit(lambda: "value" > "threshold_crit")
+//
+// Hosts missing from the CSV file fall back to the default values of the fields,
+// allowing per host thresholds within a single task.
+//
 // The files paths are checked then checked in order for the specified keys and the first value that is found is used.
 // HTTP endpoints are checked in the same manner.
 type SideloadNode struct {
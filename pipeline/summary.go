@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Aggregations supported by the SummaryNode.
+const (
+	SummarySum   = "sum"
+	SummaryMean  = "mean"
+	SummaryMin   = "min"
+	SummaryMax   = "max"
+	SummaryCount = "count"
+)
+
+// A SummaryNode collapses all groups of a window into a single point.
+// The values of the field across all groups are combined with the chosen aggregation,
+// producing one fleet wide point per window regardless of how the data is grouped.
+//
+// Available aggregations are sum, mean, min, max and count.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('requests')
+//	        .groupBy('host')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |sum('value')
+//	        .as('value')
+//	    |window()
+//	        .period(1m)
+//	        .every(1m)
+//	    |summary('value')
+//	        .aggregate('max')
+//	        .as('busiest_host')
+//
+// The above example computes the request count of each host per minute,
+// and then emits the largest per host count as a single ungrouped point.
+//
+// The time of the output point is the time of the window, i.e. the time of the incoming batches.
+// The point is emitted once batches or a barrier for a later window arrive, as only then are all groups of the window known,
+// or when the task stops.
+// Barriers are forwarded as a single ungrouped barrier.
+// The output point has no tags and is not grouped.
+type SummaryNode struct {
+	chainnode `json:"-"`
+
+	// The field to aggregate.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The aggregation to apply, one of sum, mean, min, max or count.
+	// Default: sum
+	Aggregate string `json:"aggregate"`
+
+	// The name of the aggregated field.
+	// Default: the name of the aggregation
+	As string `json:"as"`
+}
+
+func newSummaryNode(field string) *SummaryNode {
+	return &SummaryNode{
+		chainnode: newBasicChainNode("summary", BatchEdge, StreamEdge),
+		Field:     field,
+		Aggregate: SummarySum,
+	}
+}
+
+// MarshalJSON converts SummaryNode to JSON
+// tick:ignore
+func (n *SummaryNode) MarshalJSON() ([]byte, error) {
+	type Alias SummaryNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "summary",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SummaryNode
+// tick:ignore
+func (n *SummaryNode) UnmarshalJSON(data []byte) error {
+	type Alias SummaryNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "summary" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SummaryNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *SummaryNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for summary")
+	}
+	switch n.Aggregate {
+	case SummarySum, SummaryMean, SummaryMin, SummaryMax, SummaryCount:
+	default:
+		return fmt.Errorf("invalid summary aggregate %q, must be one of sum, mean, min, max or count", n.Aggregate)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// AccumulateNode converts the Accumulate pipeline node into the TICKScript AST
+type AccumulateNode struct {
+	Function
+}
+
+// NewAccumulate creates an Accumulate function builder
+func NewAccumulate(parents []ast.Node) *AccumulateNode {
+	return &AccumulateNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Accumulate ast.Node
+func (n *AccumulateNode) Build(a *pipeline.AccumulateNode) (ast.Node, error) {
+	n.Pipe("accumulate", a.Field).
+		Dot("reset", a.Reset).
+		Dot("budget", a.Budget)
+	if len(a.FractionsList) > 0 {
+		fractions := make([]interface{}, len(a.FractionsList))
+		for i, f := range a.FractionsList {
+			fractions[i] = f
+		}
+		n.Dot("fractions", fractions...)
+	}
+	n.Dot("as", a.As).
+		Dot("fractionAs", a.FractionAs).
+		Dot("crossedAs", a.CrossedAs)
+	return n.prev, n.err
+}
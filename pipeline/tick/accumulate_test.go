@@ -0,0 +1,26 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestAccumulate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Accumulate("cost")
+	a.Reset = "0 0 1 * *"
+	a.Budget = 10000.0
+	a.Fractions(0.5, 0.8, 1.0)
+	a.As = "spent"
+
+	want := `stream
+    |from()
+    |accumulate('cost')
+        .reset('0 0 1 * *')
+        .budget(10000.0)
+        .fractions(0.5, 0.8, 1.0)
+        .as('spent')
+        .fractionAs('budget_fraction')
+        .crossedAs('budget_crossed')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
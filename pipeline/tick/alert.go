@@ -34,6 +34,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		Dot("infoReset", a.InfoReset).
 		Dot("warnReset", a.WarnReset).
 		Dot("critReset", a.CritReset).
+		Dot("priority", a.Priority).
 		Dot("history", a.History).
 		Dot("levelTag", a.LevelTag).
 		Dot("levelField", a.LevelField).
@@ -42,7 +43,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		Dot("idTag", a.IdTag).
 		Dot("idField", a.IdField).
 		DotIf("all", a.AllFlag).
-		DotIf("noRecoveries", a.NoRecoveriesFlag)
+		DotIf("noRecoveries", a.NoRecoveriesFlag).
+		DotIf("digest", a.DigestFlag)
+
+	if a.SparklineField != "" {
+		n.Dot("sparkline", a.SparklineField, a.SparklinePoints)
+	}
 
 	for _, in := range a.Inhibitors {
 		args := make([]interface{}, len(in.EqualTags)+1)
@@ -61,6 +67,13 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		}
 	}
 
+	n.Dot("jitter", a.Jitter)
+	n.Dot("incidentHistory", a.IncidentHistory)
+	n.Dot("escalateReminders", a.EscalateReminders)
+	n.Dot("escalateAfter", a.EscalateAfter)
+	n.Dot("recoverAfter", a.RecoverAfter)
+	n.Dot("recoverAfterCount", a.RecoverAfterCount)
+
 	if a.UseFlapping {
 		n.DotZeroValueOK("flapping", a.FlapLow, a.FlapHigh)
 	}
@@ -80,10 +93,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range headers {
 			n.Dot("header", k, h.Headers[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.TcpHandlers {
 		n.DotRemoveZeroValue("tcp", h.Address)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.EmailHandlers {
@@ -95,10 +110,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		if len(h.ToTemplatesList) != 0 {
 			n.Dot("toTemplates", h.ToTemplatesList)
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.ExecHandlers {
 		n.DotRemoveZeroValue("exec", args(h.Command)...)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.LogHandlers {
@@ -111,16 +128,19 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			}
 			n.Dot("mode", mode)
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.VictorOpsHandlers {
 		n.Dot("victorOps").
 			Dot("routingKey", h.RoutingKey)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.PagerDutyHandlers {
 		n.Dot("pagerDuty").
 			Dot("serviceKey", h.ServiceKey)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.PagerDuty2Handlers {
@@ -133,6 +153,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 				n.Dot("link", l.Href)
 			}
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.PushoverHandlers {
@@ -143,6 +164,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("uRL", h.URL).
 			Dot("uRLTitle", h.URLTitle).
 			Dot("sound", h.Sound)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.SensuHandlers {
@@ -159,6 +181,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range keys {
 			n.Dot("metadata", k, h.MetadataMap[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.ServiceNowHandlers {
@@ -179,6 +202,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range keys {
 			n.Dot("additionalInfo", k, h.AdditionalInfoMap[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.BigPandaHandlers {
@@ -197,6 +221,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range keys {
 			n.Dot("attribute", k, h.Attributes[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.SlackHandlers {
@@ -204,7 +229,9 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("workspace", h.Workspace).
 			Dot("channel", h.Channel).
 			Dot("username", h.Username).
-			Dot("iconEmoji", h.IconEmoji)
+			Dot("iconEmoji", h.IconEmoji).
+			Dot("tokenRef", h.TokenRef)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.TelegramHandlers {
@@ -213,12 +240,15 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("parseMode", h.ParseMode).
 			DotIf("disableWebPagePreview", h.IsDisableWebPagePreview).
 			DotIf("disableNotification", h.IsDisableNotification)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.HipChatHandlers {
 		n.Dot("hipChat").
 			Dot("room", h.Room).
-			Dot("token", h.Token)
+			Dot("token", h.Token).
+			Dot("tokenRef", h.TokenRef)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.KafkaHandlers {
@@ -228,11 +258,13 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			DotIf("disablePartitionById", h.IsDisablePartitionById).
 			Dot("partitionHashAlgorithm", h.PartitionHashAlgorithm).
 			Dot("template", h.Template)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.AlertaHandlers {
 		n.Dot("alerta").
 			Dot("token", h.Token).
+			Dot("tokenRef", h.TokenRef).
 			Dot("resource", h.Resource).
 			Dot("event", h.Event).
 			Dot("environment", h.Environment).
@@ -251,21 +283,25 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range attributes {
 			n.Dot("attribute", k, h.Attributes[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.OpsGenieHandlers {
 		n.Dot("opsGenie").
 			Dot("teams", args(h.TeamsList)...).
 			Dot("recipients", args(h.RecipientsList)...)
+		n.Dot("minLevel", h.MinLevel)
 	}
 	for _, h := range a.OpsGenie2Handlers {
 		n.Dot("opsGenie2").
 			Dot("teams", args(h.TeamsList)...).
 			Dot("recipients", args(h.RecipientsList)...)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
-	for range a.TalkHandlers {
+	for _, h := range a.TalkHandlers {
 		n.Dot("talk")
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.MQTTHandlers {
@@ -273,6 +309,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("brokerName", h.BrokerName).
 			Dot("qos", h.Qos).
 			Dot("retained", h.Retained)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.SNMPTrapHandlers {
@@ -280,6 +317,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, d := range h.DataList {
 			n.Dot("data", d.Oid, d.Type, d.Value)
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	for _, h := range a.ZenossHandlers {
@@ -306,10 +344,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range keys {
 			n.Dot("customField", k, h.CustomFieldsMap[k])
 		}
+		n.Dot("minLevel", h.MinLevel)
 	}
 	for _, h := range a.TeamsHandlers {
 		n.Dot("teams").
 			Dot("channelURL", h.ChannelURL)
+		n.Dot("minLevel", h.MinLevel)
 	}
 
 	return n.prev, n.err
@@ -46,7 +46,7 @@ func TestAlert(t *testing.T) {
 	alert.DurationField = "1000000"
 	alert.IdTag = "idTag"
 	alert.IdField = "idField"
-	alert.All().NoRecoveries().StateChangesOnly(time.Hour)
+	alert.All().NoRecoveries().Digest().StateChangesOnly(time.Hour)
 	alert.Inhibitors = []pipeline.Inhibitor{{Category: "other", EqualTags: []string{"t1", "t2"}}}
 
 	want := `stream
@@ -71,6 +71,7 @@ func TestAlert(t *testing.T) {
         .idField('idField')
         .all()
         .noRecoveries()
+        .digest()
         .inhibit('other', 't1', 't2')
         .stateChangesOnly(1h)
         .flapping(0.4, 0.7)
@@ -94,6 +95,116 @@ func TestAlertStateChanges(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertJitter(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Jitter = 30 * time.Second
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .jitter(30s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertSparkline(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Sparkline("usage", 20)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .sparkline('usage', 20)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertPriority(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Priority = &ast.LambdaNode{
+		Expression: &ast.ReferenceNode{
+			Reference: "priority",
+		},
+	}
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .priority(lambda: "priority")
+        .history(21)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertIncidentHistory(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.IncidentHistory = 5
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .incidentHistory(5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertEscalate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.EscalateReminders = 3
+	alert.EscalateAfter = time.Hour
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .escalateReminders(3)
+        .escalateAfter(1h)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertRecoverAfter(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.RecoverAfter = 5 * time.Minute
+	alert.RecoverAfterCount = 3
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .recoverAfter(5m)
+        .recoverAfterCount(3)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertHTTPPost(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().Post("http://coinop.com", "http://polybius.gov")
@@ -405,6 +516,27 @@ func TestAlertPagerDuty(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertMinLevel(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Alert()
+	a.Slack()
+	handler := a.PagerDuty()
+	handler.MinLevel = "CRITICAL"
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .pagerDuty()
+        .minLevel('CRITICAL')
+        .slack()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertPagerDuty2(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().PagerDuty2()
@@ -565,6 +697,26 @@ func TestAlertSlack(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertSlackTokenRef(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().Slack()
+	handler.Channel = "#application"
+	handler.TokenRef = "SLACK_TOKEN"
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .slack()
+        .channel('#application')
+        .tokenRef('SLACK_TOKEN')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertTelegram(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().Telegram()
@@ -82,16 +82,68 @@ func (a *AST) Link(node pipeline.Node, function ast.Node) {
 // Create converts a pipeline Node to a function
 func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 	switch node := n.(type) {
+	case *pipeline.EnrichNode:
+		return NewEnrich(parents).Build(node)
+	case *pipeline.LastMarkerNode:
+		return NewLastMarker(parents).Build(node)
 	case *pipeline.UnionNode:
 		return NewUnion(parents).Build(node)
 	case *pipeline.JoinNode:
 		return NewJoin(parents).Build(node)
 	case *pipeline.AlertNode:
 		return NewAlert(parents).Build(node)
+	case *pipeline.AutocorrelationNode:
+		return NewAutocorrelation(parents).Build(node)
+	case *pipeline.FanOutNode:
+		return NewFanOut(parents).Build(node)
+	case *pipeline.MannKendallNode:
+		return NewMannKendall(parents).Build(node)
+	case *pipeline.PivotNode:
+		return NewPivot(parents).Build(node)
+	case *pipeline.RatioNode:
+		return NewRatio(parents).Build(node)
+	case *pipeline.GroupEventsNode:
+		return NewGroupEvents(parents).Build(node)
+	case *pipeline.CounterDeltaNode:
+		return NewCounterDelta(parents).Build(node)
+	case *pipeline.UptimeNode:
+		return NewUptime(parents).Build(node)
+	case *pipeline.DropOutliersNode:
+		return NewDropOutliers(parents).Build(node)
+	case *pipeline.PercentilesNode:
+		return NewPercentiles(parents).Build(node)
+	case *pipeline.PercentChangeNode:
+		return NewPercentChange(parents).Build(node)
+	case *pipeline.RollupNode:
+		return NewRollup(parents).Build(node)
+	case *pipeline.SchemaNode:
+		return NewSchema(parents).Build(node)
+	case *pipeline.QuarantineNode:
+		return NewQuarantine(parents).Build(node)
+	case *pipeline.DecomposeNode:
+		return NewDecompose(parents).Build(node)
+	case *pipeline.CoincidenceNode:
+		return NewCoincidence(parents).Build(node)
+	case *pipeline.StampNode:
+		return NewStamp(parents).Build(node)
+	case *pipeline.LagNode:
+		return NewLag(parents).Build(node)
+	case *pipeline.MirrorNode:
+		return NewMirror(parents).Build(node)
+	case *pipeline.CardinalityNode:
+		return NewCardinality(parents).Build(node)
+	case *pipeline.FailingNode:
+		return NewFailing(parents).Build(node)
+	case *pipeline.SeasonalZScoreNode:
+		return NewSeasonalZScore(parents).Build(node)
+	case *pipeline.PercentileRankNode:
+		return NewPercentileRank(parents).Build(node)
 	case *pipeline.BarrierNode:
 		return NewBarrierNode(parents).Build(node)
 	case *pipeline.CombineNode:
 		return NewCombine(parents).Build(node)
+	case *pipeline.CusumNode:
+		return NewCusum(parents).Build(node)
 	case *pipeline.DefaultNode:
 		return NewDefault(parents).Build(node)
 	case *pipeline.DeleteNode:
@@ -110,6 +162,8 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewFrom(parents).Build(node)
 	case *pipeline.GroupByNode:
 		return NewGroupBy(parents).Build(node)
+	case *pipeline.GroupByExprNode:
+		return NewGroupByExpr(parents).Build(node)
 	case *pipeline.HTTPOutNode:
 		return NewHTTPOut(parents).Build(node)
 	case *pipeline.HTTPPostNode:
@@ -124,10 +178,34 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewKapacitorLoopbackNode(parents).Build(node)
 	case *pipeline.LogNode:
 		return NewLog(parents).Build(node)
+	case *pipeline.MonotonicNode:
+		return NewMonotonic(parents).Build(node)
+	case *pipeline.SequenceNode:
+		return NewSequence(parents).Build(node)
+	case *pipeline.AccumulateNode:
+		return NewAccumulate(parents).Build(node)
+	case *pipeline.EntropyNode:
+		return NewEntropy(parents).Build(node)
+	case *pipeline.TokenBucketNode:
+		return NewTokenBucket(parents).Build(node)
+	case *pipeline.BaselineNode:
+		return NewBaseline(parents).Build(node)
+	case *pipeline.ConsensusNode:
+		return NewConsensus(parents).Build(node)
+	case *pipeline.PercentileThresholdNode:
+		return NewPercentileThreshold(parents).Build(node)
+	case *pipeline.CrossCorrelationNode:
+		return NewCrossCorrelation(parents).Build(node)
+	case *pipeline.LeakyBucketNode:
+		return NewLeakyBucket(parents).Build(node)
+	case *pipeline.ForecastResidualNode:
+		return NewForecastResidual(parents).Build(node)
 	case *pipeline.QueryNode:
 		return NewQuery(parents).Build(node)
 	case *pipeline.QueryFluxNode:
 		return NewQueryFlux(parents).Build(node)
+	case *pipeline.ResidualNode:
+		return NewResidual(parents).Build(node)
 	case *pipeline.SampleNode:
 		return NewSample(parents).Build(node)
 	case *pipeline.ShiftNode:
@@ -138,6 +216,8 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewStateCount(parents).Build(node)
 	case *pipeline.StateDurationNode:
 		return NewStateDuration(parents).Build(node)
+	case *pipeline.SummaryNode:
+		return NewSummary(parents).Build(node)
 	case *pipeline.SwarmAutoscaleNode:
 		return NewSwarmAutoscale(parents).Build(node)
 	case *pipeline.UDFNode:
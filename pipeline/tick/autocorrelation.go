@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// AutocorrelationNode converts the Autocorrelation pipeline node into the TICKScript AST
+type AutocorrelationNode struct {
+	Function
+}
+
+// NewAutocorrelation creates an Autocorrelation function builder
+func NewAutocorrelation(parents []ast.Node) *AutocorrelationNode {
+	return &AutocorrelationNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Autocorrelation ast.Node
+func (n *AutocorrelationNode) Build(a *pipeline.AutocorrelationNode) (ast.Node, error) {
+	n.Pipe("autocorrelation", a.Field).
+		Dot("lag", a.Lag).
+		Dot("size", a.Size).
+		Dot("as", a.As)
+	return n.prev, n.err
+}
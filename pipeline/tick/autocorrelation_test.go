@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestAutocorrelation(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Autocorrelation("runs")
+	a.Lag = 24
+	a.Size = 96
+
+	want := `stream
+    |from()
+    |autocorrelation('runs')
+        .lag(24)
+        .size(96)
+        .as('autocorrelation')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// BaselineNode converts the Baseline pipeline node into the TICKScript AST
+type BaselineNode struct {
+	Function
+}
+
+// NewBaseline creates a Baseline function builder
+func NewBaseline(parents []ast.Node) *BaselineNode {
+	return &BaselineNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Baseline ast.Node
+func (n *BaselineNode) Build(b *pipeline.BaselineNode) (ast.Node, error) {
+	n.Pipe("baseline", b.Recording)
+	if len(b.FieldsList) > 0 {
+		n.Dot("fields", args(b.FieldsList)...)
+	}
+	if len(b.OnTags) > 0 {
+		n.Dot("on", args(b.OnTags)...)
+	}
+	n.Dot("missing", b.Missing)
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.Baseline("known-good")
+	b.Fields("latency", "errors")
+	b.On("endpoint")
+	b.Missing = "drop"
+
+	want := `stream
+    |from()
+    |baseline('known-good')
+        .fields('latency', 'errors')
+        .on('endpoint')
+        .missing('drop')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CardinalityNode converts the Cardinality pipeline node into the TICKScript AST
+type CardinalityNode struct {
+	Function
+}
+
+// NewCardinality creates a Cardinality function builder
+func NewCardinality(parents []ast.Node) *CardinalityNode {
+	return &CardinalityNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Cardinality ast.Node
+func (n *CardinalityNode) Build(c *pipeline.CardinalityNode) (ast.Node, error) {
+	n.Pipe("cardinality", c.Field).
+		Dot("period", c.Period).
+		Dot("slices", c.Slices).
+		Dot("precision", c.Precision).
+		Dot("as", c.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCardinality(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.Cardinality("client_ip")
+	c.Period = 5 * time.Minute
+	c.Slices = 5
+	c.Precision = 16
+	c.As = "clients"
+
+	want := `stream
+    |from()
+    |cardinality('client_ip')
+        .period(5m)
+        .slices(5)
+        .precision(16)
+        .as('clients')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
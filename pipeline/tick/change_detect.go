@@ -25,6 +25,8 @@ func (n *ChangeDetectNode) Build(d *pipeline.ChangeDetectNode) (ast.Node, error)
 	for i, f := range d.Fields {
 		fields[i] = f
 	}
-	n.Pipe("changeDetect", fields...)
+	n.Pipe("changeDetect", fields...).
+		Dot("tolerance", d.Tolerance).
+		DotIf("changedOnly", d.ChangedOnlyFlag)
 	return n.prev, n.err
 }
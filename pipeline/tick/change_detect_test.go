@@ -14,3 +14,16 @@ func TestChangeDetect(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestChangeDetectTolerance(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.ChangeDetect("used", "free").ChangedOnly().Tolerance = 1024.0
+
+	want := `stream
+    |from()
+    |changeDetect('used', 'free')
+        .tolerance(1024.0)
+        .changedOnly()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
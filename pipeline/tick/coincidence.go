@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CoincidenceNode converts the Coincidence pipeline node into the TICKScript AST
+type CoincidenceNode struct {
+	Function
+}
+
+// NewCoincidence creates a Coincidence function builder
+func NewCoincidence(parents []ast.Node) *CoincidenceNode {
+	return &CoincidenceNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Coincidence ast.Node
+func (n *CoincidenceNode) Build(c *pipeline.CoincidenceNode) (ast.Node, error) {
+	n.Pipe("coincidence", c.Left, c.Right).
+		Dot("tolerance", c.Tolerance)
+	return n.prev, n.err
+}
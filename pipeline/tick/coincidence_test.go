@@ -0,0 +1,35 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestCoincidence(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	left := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: ast.TokenGreater,
+			Left:     &ast.ReferenceNode{Reference: "errors.rate"},
+			Right:    &ast.NumberNode{IsFloat: true, Float64: 10},
+		},
+	}
+	right := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: ast.TokenGreater,
+			Left:     &ast.ReferenceNode{Reference: "latency.p99"},
+			Right:    &ast.NumberNode{IsFloat: true, Float64: 500},
+		},
+	}
+	c := from.Coincidence(left, right)
+	c.Tolerance = time.Minute
+
+	want := `stream
+    |from()
+    |coincidence(lambda: "errors.rate" > 10.0, lambda: "latency.p99" > 500.0)
+        .tolerance(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ConsensusNode converts the Consensus pipeline node into the TICKScript AST
+type ConsensusNode struct {
+	Function
+}
+
+// NewConsensus creates a Consensus function builder
+func NewConsensus(parents []ast.Node) *ConsensusNode {
+	return &ConsensusNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Consensus ast.Node
+func (n *ConsensusNode) Build(c *pipeline.ConsensusNode) (ast.Node, error) {
+	n.Pipe("consensus", c.Field).
+		Dot("replica", c.Replica).
+		Dot("period", c.Period).
+		Dot("method", c.Method).
+		Dot("as", c.As).
+		Dot("disagreementAs", c.DisagreementAs).
+		Dot("replicasAs", c.ReplicasAs).
+		Dot("outlierAs", c.OutlierAs)
+	return n.prev, n.err
+}
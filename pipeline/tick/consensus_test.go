@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsensus(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.Consensus("value")
+	c.Replica = "sensor"
+	c.Period = 5 * time.Minute
+	c.Method = "mode"
+
+	want := `stream
+    |from()
+    |consensus('value')
+        .replica('sensor')
+        .period(5m)
+        .method('mode')
+        .as('consensus')
+        .disagreementAs('disagreement')
+        .replicasAs('replicas')
+        .outlierAs('outlier')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CounterDeltaNode converts the CounterDelta pipeline node into the TICKScript AST
+type CounterDeltaNode struct {
+	Function
+}
+
+// NewCounterDelta creates a CounterDelta function builder
+func NewCounterDelta(parents []ast.Node) *CounterDeltaNode {
+	return &CounterDeltaNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a CounterDelta ast.Node
+func (n *CounterDeltaNode) Build(c *pipeline.CounterDeltaNode) (ast.Node, error) {
+	n.Pipe("counterDelta", c.Field).
+		Dot("as", c.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCounterDelta(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	c := w.CounterDelta("requests")
+	c.As = "requests_per_minute"
+
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+    |counterDelta('requests')
+        .as('requests_per_minute')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
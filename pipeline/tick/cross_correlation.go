@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CrossCorrelationNode converts the CrossCorrelation pipeline node into the TICKScript AST
+type CrossCorrelationNode struct {
+	Function
+}
+
+// NewCrossCorrelation creates a CrossCorrelation function builder
+func NewCrossCorrelation(parents []ast.Node) *CrossCorrelationNode {
+	return &CrossCorrelationNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a CrossCorrelation ast.Node
+func (n *CrossCorrelationNode) Build(c *pipeline.CrossCorrelationNode) (ast.Node, error) {
+	n.Pipe("crossCorrelation", c.Field, c.Lagging).
+		Dot("minLag", c.MinLag).
+		Dot("maxLag", c.MaxLag).
+		Dot("size", c.Size).
+		Dot("lagAs", c.LagAs).
+		Dot("correlationAs", c.CorrelationAs)
+	return n.prev, n.err
+}
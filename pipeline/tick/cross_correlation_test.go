@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestCrossCorrelation(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.CrossCorrelation("queue.depth", "latency.latency")
+	c.MinLag = -5
+	c.MaxLag = 5
+	c.Size = 120
+
+	want := `stream
+    |from()
+    |crossCorrelation('queue.depth', 'latency.latency')
+        .minLag(-5)
+        .maxLag(5)
+        .size(120)
+        .lagAs('lag')
+        .correlationAs('correlation')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
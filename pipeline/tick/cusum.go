@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CusumNode converts the Cusum pipeline node into the TICKScript AST
+type CusumNode struct {
+	Function
+}
+
+// NewCusum creates a Cusum function builder
+func NewCusum(parents []ast.Node) *CusumNode {
+	return &CusumNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Cusum ast.Node
+func (n *CusumNode) Build(d *pipeline.CusumNode) (ast.Node, error) {
+	n.Pipe("cusum", d.Field).
+		Dot("target", d.Target).
+		Dot("slack", d.Slack).
+		Dot("threshold", d.Threshold).
+		Dot("positiveAs", d.PositiveAs).
+		Dot("negativeAs", d.NegativeAs).
+		Dot("signalAs", d.SignalAs)
+	return n.prev, n.err
+}
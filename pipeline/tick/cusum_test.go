@@ -0,0 +1,26 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestCusum(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.Cusum("latency")
+	c.Target = 100
+	c.Slack = 5
+	c.Threshold = 50
+	c.SignalAs = "shifted"
+
+	want := `stream
+    |from()
+    |cusum('latency')
+        .target(100.0)
+        .slack(5.0)
+        .threshold(50.0)
+        .positiveAs('cusum_pos')
+        .negativeAs('cusum_neg')
+        .signalAs('shifted')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
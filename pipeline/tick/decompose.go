@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DecomposeNode converts the Decompose pipeline node into the TICKScript AST
+type DecomposeNode struct {
+	Function
+}
+
+// NewDecompose creates a Decompose function builder
+func NewDecompose(parents []ast.Node) *DecomposeNode {
+	return &DecomposeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Decompose ast.Node
+func (n *DecomposeNode) Build(d *pipeline.DecomposeNode) (ast.Node, error) {
+	n.Pipe("decompose", d.Field).
+		Dot("season", d.Season).
+		Dot("as", d.As).
+		Dot("trendAs", d.TrendAs).
+		Dot("seasonalAs", d.SeasonalAs)
+	return n.prev, n.err
+}
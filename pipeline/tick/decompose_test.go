@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestDecompose(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Decompose("value")
+	d.Season = 24
+	d.As = "r"
+	d.TrendAs = "t"
+	d.SeasonalAs = "s"
+
+	want := `stream
+    |from()
+    |decompose('value')
+        .season(24)
+        .as('r')
+        .trendAs('t')
+        .seasonalAs('s')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
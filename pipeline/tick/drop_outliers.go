@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DropOutliersNode converts the DropOutliers pipeline node into the TICKScript AST
+type DropOutliersNode struct {
+	Function
+}
+
+// NewDropOutliers creates a DropOutliers function builder
+func NewDropOutliers(parents []ast.Node) *DropOutliersNode {
+	return &DropOutliersNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DropOutliers ast.Node
+func (n *DropOutliersNode) Build(d *pipeline.DropOutliersNode) (ast.Node, error) {
+	n.Pipe("dropOutliers", d.Field).
+		Dot("k", d.K)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDropOutliers(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	d := w.DropOutliers("latency")
+	d.K = 3
+
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+    |dropOutliers('latency')
+        .k(3.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,42 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// EnrichNode converts the Enrich pipeline node into the TICKScript AST
+type EnrichNode struct {
+	Function
+}
+
+// NewEnrich creates an Enrich function builder
+func NewEnrich(parents []ast.Node) *EnrichNode {
+	return &EnrichNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Enrich ast.Node
+func (n *EnrichNode) Build(e *pipeline.EnrichNode) (ast.Node, error) {
+	sources := []interface{}{}
+	for _, p := range n.Parents[1:] {
+		sources = append(sources, p)
+	}
+	n.Pipe("enrich", sources...).
+		Dot("prefix", e.Prefix)
+
+	var fieldKeys []string
+	for k := range e.DefaultFields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+	for _, k := range fieldKeys {
+		n.Dot("defaultField", k, e.DefaultFields[k])
+	}
+	return n.prev, n.err
+}
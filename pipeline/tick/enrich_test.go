@@ -0,0 +1,42 @@
+package tick_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestEnrich(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "requests"
+	from1.GroupBy("service")
+
+	from2 := stream2.From()
+	from2.Measurement = "thresholds"
+	from2.GroupBy("service")
+
+	enrich := from1.Enrich(from2)
+	enrich.Prefix = "threshold_"
+	enrich.DefaultField("p99", 500.0)
+	enrich.DefaultField("p50", 100.0)
+
+	want := `var from3 = stream
+    |from()
+        .measurement('thresholds')
+        .groupBy('service')
+
+stream
+    |from()
+        .measurement('requests')
+        .groupBy('service')
+    |enrich(from3)
+        .prefix('threshold_')
+        .defaultField('p50', 100.0)
+        .defaultField('p99', 500.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
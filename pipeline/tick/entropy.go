@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// EntropyNode converts the Entropy pipeline node into the TICKScript AST
+type EntropyNode struct {
+	Function
+}
+
+// NewEntropy creates an Entropy function builder
+func NewEntropy(parents []ast.Node) *EntropyNode {
+	return &EntropyNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Entropy ast.Node
+func (n *EntropyNode) Build(e *pipeline.EntropyNode) (ast.Node, error) {
+	n.Pipe("entropy", e.Category).
+		Dot("period", e.Period).
+		Dot("maxCategories", e.MaxCategories).
+		DotIf("normalize", e.NormalizeFlag).
+		Dot("as", e.As).
+		Dot("categoriesAs", e.CategoriesAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntropy(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	e := from.Entropy("endpoint")
+	e.Period = 5 * time.Minute
+	e.MaxCategories = 100
+	e.Normalize()
+
+	want := `stream
+    |from()
+    |entropy('endpoint')
+        .period(5m)
+        .maxCategories(100)
+        .normalize()
+        .as('entropy')
+        .categoriesAs('categories')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// FailingNode converts the Failing pipeline node into the TICKScript AST
+type FailingNode struct {
+	Function
+}
+
+// NewFailing creates a Failing function builder
+func NewFailing(parents []ast.Node) *FailingNode {
+	return &FailingNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Failing ast.Node
+func (n *FailingNode) Build(f *pipeline.FailingNode) (ast.Node, error) {
+	n.Pipe("failing", f.Lambda).
+		Dot("every", f.Every).
+		Dot("measurement", f.Measurement).
+		Dot("as", f.As).
+		Dot("unit", f.Unit)
+	return n.prev, n.err
+}
@@ -0,0 +1,40 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestFailing(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	lambda := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "usage_idle",
+			},
+			Right: &ast.NumberNode{
+				IsFloat: true,
+				Float64: 10,
+			},
+			Operator: ast.TokenLess,
+		},
+	}
+
+	f := from.Failing(lambda)
+	f.Every = time.Minute
+	f.Measurement = "overloaded"
+	f.As = "overloaded_for"
+	f.Unit = time.Minute
+
+	want := `stream
+    |from()
+    |failing(lambda: "usage_idle" < 10.0)
+        .every(1m)
+        .measurement('overloaded')
+        .as('overloaded_for')
+        .unit(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// FanOutNode converts the FanOut pipeline node into the TICKScript AST
+type FanOutNode struct {
+	Function
+}
+
+// NewFanOut creates a FanOut function builder
+func NewFanOut(parents []ast.Node) *FanOutNode {
+	return &FanOutNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a FanOut ast.Node
+func (n *FanOutNode) Build(f *pipeline.FanOutNode) (ast.Node, error) {
+	args := make([]interface{}, 0, len(f.Values)+1)
+	args = append(args, f.Tag)
+	for _, v := range f.Values {
+		args = append(args, v)
+	}
+	n.Pipe("fanOut", args...)
+	return n.prev, n.err
+}
@@ -0,0 +1,16 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestFanOut(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.FanOut("region", "us", "eu")
+
+	want := `stream
+    |from()
+    |fanOut('region', 'us', 'eu')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
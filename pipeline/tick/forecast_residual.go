@@ -0,0 +1,37 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ForecastResidualNode converts the ForecastResidual pipeline node into the TICKScript AST
+type ForecastResidualNode struct {
+	Function
+}
+
+// NewForecastResidual creates a ForecastResidual function builder
+func NewForecastResidual(parents []ast.Node) *ForecastResidualNode {
+	return &ForecastResidualNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ForecastResidual ast.Node
+func (n *ForecastResidualNode) Build(r *pipeline.ForecastResidualNode) (ast.Node, error) {
+	forecasts := []interface{}{}
+	for _, p := range n.Parents[1:] {
+		forecasts = append(forecasts, p)
+	}
+	n.Pipe("forecastResidual", forecasts...).
+		Dot("field", r.Field).
+		Dot("forecastField", r.ForecastField).
+		Dot("tolerance", r.Tolerance).
+		Dot("size", r.Size).
+		Dot("forecastAs", r.ForecastAs).
+		Dot("residualAs", r.ResidualAs).
+		Dot("scoreAs", r.ScoreAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,48 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestForecastResidual(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "cpu"
+	from1.GroupBy("host")
+
+	from2 := stream2.From()
+	from2.Measurement = "cpu_forecast"
+	from2.GroupBy("host")
+
+	r := from1.ForecastResidual(from2)
+	r.Field = "usage"
+	r.ForecastField = "usage"
+	r.Tolerance = time.Hour
+	r.Size = 24
+
+	want := `var from3 = stream
+    |from()
+        .measurement('cpu_forecast')
+        .groupBy('host')
+
+stream
+    |from()
+        .measurement('cpu')
+        .groupBy('host')
+    |forecastResidual(from3)
+        .field('usage')
+        .forecastField('usage')
+        .tolerance(1h)
+        .size(24)
+        .forecastAs('forecast')
+        .residualAs('residual')
+        .scoreAs('score')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
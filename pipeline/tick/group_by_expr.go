@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// GroupByExprNode converts the GroupByExprNode pipeline node into the TICKScript AST
+type GroupByExprNode struct {
+	Function
+}
+
+// NewGroupByExpr creates a GroupByExprNode function builder
+func NewGroupByExpr(parents []ast.Node) *GroupByExprNode {
+	return &GroupByExprNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a GroupByExprNode ast.Node
+func (n *GroupByExprNode) Build(g *pipeline.GroupByExprNode) (ast.Node, error) {
+	n.Pipe("groupByExpr", g.Lambda).
+		Dot("as", g.As).
+		Dot("maxGroups", g.MaxGroups).
+		DotIf("byMeasurement", g.ByMeasurementFlag)
+
+	return n.prev, n.err
+}
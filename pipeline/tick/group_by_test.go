@@ -40,3 +40,22 @@ func TestGroupByStar(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestGroupByExpr(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	lambda := &ast.LambdaNode{
+		Expression: &ast.ReferenceNode{
+			Reference: "datacenter",
+		},
+	}
+	from.GroupByExpr(lambda).ByMeasurement().MaxGroups = 10
+
+	want := `stream
+    |from()
+    |groupByExpr(lambda: "datacenter")
+        .as('group')
+        .maxGroups(10)
+        .byMeasurement()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// GroupEventsNode converts the GroupEvents pipeline node into the TICKScript AST
+type GroupEventsNode struct {
+	Function
+}
+
+// NewGroupEvents creates a GroupEvents function builder
+func NewGroupEvents(parents []ast.Node) *GroupEventsNode {
+	return &GroupEventsNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a GroupEvents ast.Node
+func (n *GroupEventsNode) Build(g *pipeline.GroupEventsNode) (ast.Node, error) {
+	n.Pipe("groupEvents").
+		Dot("as", g.As)
+	return n.prev, n.err
+}
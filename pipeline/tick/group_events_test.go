@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupEvents(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.Barrier()
+	b.Idle = 5 * time.Minute
+	b.Delete = true
+	g := b.GroupEvents()
+	g.As = "lifecycle"
+
+	want := `stream
+    |from()
+    |barrier()
+        .idle(5m)
+        .delete(TRUE)
+    |groupEvents()
+        .as('lifecycle')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
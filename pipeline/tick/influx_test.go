@@ -21,6 +21,20 @@ func TestInfluxQLBottom(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestInfluxQLCountAs(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.Mean("usage")
+	influx.CountAs = "samples"
+
+	want := `stream
+    |from()
+    |mean('usage')
+        .as('mean')
+        .countAs('samples')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestInfluxQLTop(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	influx := from.Top(5, "shelf", "tags", "youre_it")
@@ -45,3 +59,15 @@ func TestInfluxQLCount(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestInfluxQLGeometricMean(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.GeometricMean("growth")
+
+	want := `stream
+    |from()
+    |geometricMean('growth')
+        .as('geometricMean')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,50 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// StampNode converts the Stamp pipeline node into the TICKScript AST
+type StampNode struct {
+	Function
+}
+
+// NewStamp creates a Stamp function builder
+func NewStamp(parents []ast.Node) *StampNode {
+	return &StampNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Stamp ast.Node
+func (n *StampNode) Build(s *pipeline.StampNode) (ast.Node, error) {
+	n.Pipe("stamp").
+		Dot("as", s.As)
+	return n.prev, n.err
+}
+
+// LagNode converts the Lag pipeline node into the TICKScript AST
+type LagNode struct {
+	Function
+}
+
+// NewLag creates a Lag function builder
+func NewLag(parents []ast.Node) *LagNode {
+	return &LagNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Lag ast.Node
+func (n *LagNode) Build(l *pipeline.LagNode) (ast.Node, error) {
+	n.Pipe("lag").
+		Dot("stamp", l.Stamp).
+		Dot("as", l.As).
+		Dot("unit", l.Unit)
+	return n.prev, n.err
+}
@@ -0,0 +1,38 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStamp(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Stamp()
+	s.As = "received"
+
+	want := `stream
+    |from()
+    |stamp()
+        .as('received')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestLag(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	l := from.Stamp().Lag()
+	l.Stamp = "received"
+	l.As = "processing_lag"
+	l.Unit = time.Microsecond
+
+	want := `stream
+    |from()
+    |stamp()
+        .as('ingested')
+    |lag()
+        .stamp('received')
+        .as('processing_lag')
+        .unit(1u)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
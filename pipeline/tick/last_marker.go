@@ -0,0 +1,37 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// LastMarkerNode converts the LastMarker pipeline node into the TICKScript AST
+type LastMarkerNode struct {
+	Function
+}
+
+// NewLastMarker creates a LastMarker function builder
+func NewLastMarker(parents []ast.Node) *LastMarkerNode {
+	return &LastMarkerNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a LastMarker ast.Node
+func (n *LastMarkerNode) Build(m *pipeline.LastMarkerNode) (ast.Node, error) {
+	markers := []interface{}{}
+	for _, p := range n.Parents[1:] {
+		markers = append(markers, p)
+	}
+	n.Pipe("lastMarker", markers...).
+		Dot("lookback", m.Lookback)
+	if len(m.OnTags) > 0 {
+		n.Dot("on", args(m.OnTags)...)
+	}
+	n.Dot("prefix", m.Prefix).
+		Dot("sinceAs", m.SinceAs).
+		Dot("unit", m.Unit)
+	return n.prev, n.err
+}
@@ -0,0 +1,45 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestLastMarker(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "errors"
+	from1.GroupBy("service")
+
+	from2 := stream2.From()
+	from2.Measurement = "deploys"
+
+	m := from1.LastMarker(from2)
+	m.Lookback = 30 * time.Minute
+	m.On("service")
+	m.Prefix = "deploy_"
+	m.SinceAs = "deployed"
+	m.Unit = time.Minute
+
+	want := `var from3 = stream
+    |from()
+        .measurement('deploys')
+
+stream
+    |from()
+        .measurement('errors')
+        .groupBy('service')
+    |lastMarker(from3)
+        .lookback(30m)
+        .on('service')
+        .prefix('deploy_')
+        .sinceAs('deployed')
+        .unit(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
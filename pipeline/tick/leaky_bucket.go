@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// LeakyBucketNode converts the LeakyBucket pipeline node into the TICKScript AST
+type LeakyBucketNode struct {
+	Function
+}
+
+// NewLeakyBucket creates a LeakyBucket function builder
+func NewLeakyBucket(parents []ast.Node) *LeakyBucketNode {
+	return &LeakyBucketNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a LeakyBucket ast.Node
+func (n *LeakyBucketNode) Build(b *pipeline.LeakyBucketNode) (ast.Node, error) {
+	n.Pipe("leakyBucket", b.Field).
+		Dot("capacity", b.Capacity).
+		Dot("leakRate", b.LeakRate).
+		Dot("interval", b.Interval).
+		Dot("levelAs", b.LevelAs).
+		Dot("overflowAs", b.OverflowAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeakyBucket(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.LeakyBucket("count")
+	b.Capacity = 100.0
+	b.LeakRate = 10.0
+	b.Interval = time.Hour
+
+	want := `stream
+    |from()
+    |leakyBucket('count')
+        .capacity(100.0)
+        .leakRate(10.0)
+        .interval(1h)
+        .levelAs('level')
+        .overflowAs('overflow')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MannKendallNode converts the MannKendall pipeline node into the TICKScript AST
+type MannKendallNode struct {
+	Function
+}
+
+// NewMannKendall creates a MannKendall function builder
+func NewMannKendall(parents []ast.Node) *MannKendallNode {
+	return &MannKendallNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a MannKendall ast.Node
+func (n *MannKendallNode) Build(m *pipeline.MannKendallNode) (ast.Node, error) {
+	n.Pipe("mannKendall", m.Field).
+		Dot("alpha", m.Alpha).
+		Dot("sAs", m.SAs).
+		Dot("zAs", m.ZAs).
+		Dot("pAs", m.PAs).
+		Dot("trendAs", m.TrendAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,30 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMannKendall(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Hour
+	w.Every = 10 * time.Minute
+	m := w.MannKendall("used_percent")
+	m.Alpha = 0.01
+	m.TrendAs = "trend"
+
+	want := `stream
+    |from()
+    |window()
+        .period(1h)
+        .every(10m)
+    |mannKendall('used_percent')
+        .alpha(0.01)
+        .sAs('mk_s')
+        .zAs('mk_z')
+        .pAs('mk_p')
+        .trendAs('trend')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
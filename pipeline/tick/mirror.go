@@ -0,0 +1,37 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MirrorNode converts the Mirror pipeline node into the TICKScript AST
+type MirrorNode struct {
+	Function
+}
+
+// NewMirror creates a Mirror function builder
+func NewMirror(parents []ast.Node) *MirrorNode {
+	return &MirrorNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Mirror ast.Node
+func (n *MirrorNode) Build(m *pipeline.MirrorNode) (ast.Node, error) {
+	dcs := make([]interface{}, len(m.DCs))
+	for i, dc := range m.DCs {
+		dcs[i] = dc
+	}
+	fields := make([]interface{}, len(m.Fields))
+	for i, f := range m.Fields {
+		fields[i] = f
+	}
+	n.Pipe("mirror", dcs...).
+		Dot("tag", m.Tag).
+		Dot("field", fields...).
+		DotIf("carryForward", m.CarryForwardFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.Mirror("us-east", "us-west")
+	m.Tag = "datacenter"
+	m.Field("requests", "errors")
+	m.CarryForward()
+
+	want := `stream
+    |from()
+    |mirror('us-east', 'us-west')
+        .tag('datacenter')
+        .field('requests', 'errors')
+        .carryForward()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
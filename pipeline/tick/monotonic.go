@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MonotonicNode converts the Monotonic pipeline node into the TICKScript AST
+type MonotonicNode struct {
+	Function
+}
+
+// NewMonotonic creates a Monotonic function builder
+func NewMonotonic(parents []ast.Node) *MonotonicNode {
+	return &MonotonicNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Monotonic ast.Node
+func (n *MonotonicNode) Build(m *pipeline.MonotonicNode) (ast.Node, error) {
+	n.Pipe("monotonic", m.Field).
+		Dot("as", m.As).
+		Dot("directionAs", m.DirectionAs).
+		DotIf("strict", m.StrictFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestMonotonic(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.Monotonic("used")
+	m.As = "climb"
+	m.Strict()
+
+	want := `stream
+    |from()
+    |monotonic('used')
+        .as('climb')
+        .directionAs('run_direction')
+        .strict()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PercentChangeNode converts the PercentChange pipeline node into the TICKScript AST
+type PercentChangeNode struct {
+	Function
+}
+
+// NewPercentChange creates a PercentChange function builder
+func NewPercentChange(parents []ast.Node) *PercentChangeNode {
+	return &PercentChangeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a PercentChange ast.Node
+func (n *PercentChangeNode) Build(p *pipeline.PercentChangeNode) (ast.Node, error) {
+	n.Pipe("percentChange", p.Field).
+		Dot("period", p.Period).
+		Dot("as", p.As)
+	return n.prev, n.err
+}
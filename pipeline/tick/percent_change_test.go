@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentChange(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	p := from.PercentChange("rate")
+	p.Period = 10 * time.Minute
+	p.As = "growth"
+
+	want := `stream
+    |from()
+    |percentChange('rate')
+        .period(10m)
+        .as('growth')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PercentileRankNode converts the PercentileRank pipeline node into the TICKScript AST
+type PercentileRankNode struct {
+	Function
+}
+
+// NewPercentileRank creates a PercentileRank function builder
+func NewPercentileRank(parents []ast.Node) *PercentileRankNode {
+	return &PercentileRankNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a PercentileRank ast.Node
+func (n *PercentileRankNode) Build(p *pipeline.PercentileRankNode) (ast.Node, error) {
+	n.Pipe("percentileRank", p.Field).
+		Dot("size", p.Size).
+		Dot("minPoints", p.MinPoints).
+		Dot("as", p.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestPercentileRank(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	p := from.PercentileRank("p99")
+	p.Size = 50
+	p.MinPoints = 5
+	p.As = "rank"
+
+	want := `stream
+    |from()
+    |percentileRank('p99')
+        .size(50)
+        .minPoints(5)
+        .as('rank')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PercentileThresholdNode converts the PercentileThreshold pipeline node into the TICKScript AST
+type PercentileThresholdNode struct {
+	Function
+}
+
+// NewPercentileThreshold creates a PercentileThreshold function builder
+func NewPercentileThreshold(parents []ast.Node) *PercentileThresholdNode {
+	return &PercentileThresholdNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a PercentileThreshold ast.Node
+func (n *PercentileThresholdNode) Build(p *pipeline.PercentileThresholdNode) (ast.Node, error) {
+	n.Pipe("percentileThreshold", p.Field).
+		Dot("percentile", p.Percentile).
+		Dot("period", p.Period).
+		Dot("slices", p.Slices).
+		Dot("compression", p.Compression).
+		Dot("minPoints", p.MinPoints).
+		Dot("as", p.As)
+	return n.prev, n.err
+}
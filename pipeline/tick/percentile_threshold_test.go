@@ -0,0 +1,28 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentileThreshold(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	p := from.PercentileThreshold("latency")
+	p.Percentile = 95.0
+	p.Period = 24 * time.Hour
+	p.Slices = 24
+	p.MinPoints = 1000
+	p.As = "learned_threshold"
+
+	want := `stream
+    |from()
+    |percentileThreshold('latency')
+        .percentile(95.0)
+        .period(1d)
+        .slices(24)
+        .compression(100.0)
+        .minPoints(1000)
+        .as('learned_threshold')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
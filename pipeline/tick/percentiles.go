@@ -0,0 +1,34 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PercentilesNode converts the Percentiles pipeline node into the TICKScript AST
+type PercentilesNode struct {
+	Function
+}
+
+// NewPercentiles creates a Percentiles function builder
+func NewPercentiles(parents []ast.Node) *PercentilesNode {
+	return &PercentilesNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Percentiles ast.Node
+func (n *PercentilesNode) Build(p *pipeline.PercentilesNode) (ast.Node, error) {
+	pipeArgs := make([]interface{}, 0, len(p.Percentiles)+1)
+	pipeArgs = append(pipeArgs, p.Field)
+	for _, v := range p.Percentiles {
+		pipeArgs = append(pipeArgs, v)
+	}
+	n.Pipe("percentiles", pipeArgs...)
+	if len(p.MoreFields) > 0 {
+		n.Dot("fields", args(p.MoreFields)...)
+	}
+	return n.prev, n.err
+}
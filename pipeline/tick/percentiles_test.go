@@ -0,0 +1,29 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestPercentiles(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Percentiles("latency", 50, 90, 99.9)
+
+	want := `stream
+    |from()
+    |percentiles('latency', 50.0, 90.0, 99.9)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestPercentilesFields(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Percentiles("login", 50, 99).
+		Fields("search", "checkout")
+
+	want := `stream
+    |from()
+    |percentiles('login', 50.0, 99.0)
+        .fields('search', 'checkout')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,36 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PivotNode converts the Pivot pipeline node into the TICKScript AST
+type PivotNode struct {
+	Function
+}
+
+// NewPivot creates a Pivot function builder
+func NewPivot(parents []ast.Node) *PivotNode {
+	return &PivotNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Pivot ast.Node
+func (n *PivotNode) Build(p *pipeline.PivotNode) (ast.Node, error) {
+	n.Pipe("pivot")
+	if len(p.Dimensions) > 0 {
+		n.Dot("on", args(p.Dimensions)...)
+	}
+	n.Dot("delimiter", p.Delimiter)
+	if len(p.ColumnsList) > 0 {
+		n.Dot("columns", args(p.ColumnsList)...)
+	}
+	if p.Fill != nil {
+		n.DotZeroValueOK("fill", p.Fill)
+	}
+	return n.prev, n.err
+}
@@ -0,0 +1,30 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPivot(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Hour
+	w.Every = time.Hour
+	p := w.Pivot()
+	p.On("aggregate")
+	p.Columns("cpu_mean", "cpu_max")
+	p.Fill = 0.0
+
+	want := `stream
+    |from()
+    |window()
+        .period(1h)
+        .every(1h)
+    |pivot()
+        .on('aggregate')
+        .delimiter('_')
+        .columns('cpu_mean', 'cpu_max')
+        .fill(0.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -31,7 +31,9 @@ func (n *QueryNode) Build(q *pipeline.QueryNode) (ast.Node, error) {
 		Dot("groupBy", q.Dimensions).
 		DotIf("groupByMeasurement", q.GroupByMeasurementFlag).
 		DotNotNil("fill", q.Fill).
-		Dot("cluster", q.Cluster)
+		Dot("cluster", q.Cluster).
+		Dot("queryDurationAs", q.QueryDurationAs).
+		Dot("queryPointsAs", q.QueryPointsAs)
 
 	return n.prev, n.err
 }
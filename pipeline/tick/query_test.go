@@ -17,6 +17,8 @@ func TestQuery(t *testing.T) {
 	query.GroupByMeasurementFlag = true
 	query.Fill = "linear"
 	query.Cluster = "mycluster"
+	query.QueryDurationAs = "query_duration"
+	query.QueryPointsAs = "query_points"
 
 	want := `batch
     |query('select cpu_usage from cpu')
@@ -29,6 +31,8 @@ func TestQuery(t *testing.T) {
         .groupByMeasurement()
         .fill('linear')
         .cluster('mycluster')
+        .queryDurationAs('query_duration')
+        .queryPointsAs('query_points')
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// RatioNode converts the Ratio pipeline node into the TICKScript AST
+type RatioNode struct {
+	Function
+}
+
+// NewRatio creates a Ratio function builder
+func NewRatio(parents []ast.Node) *RatioNode {
+	return &RatioNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Ratio ast.Node
+func (n *RatioNode) Build(r *pipeline.RatioNode) (ast.Node, error) {
+	n.Pipe("ratio").
+		Dot("numerator", r.NumeratorReducer, r.NumeratorField).
+		Dot("denominator", r.DenominatorReducer, r.DenominatorField).
+		Dot("as", r.As).
+		Dot("numeratorAs", r.NumeratorAs).
+		Dot("denominatorAs", r.DenominatorAs)
+	return n.prev, n.err
+}
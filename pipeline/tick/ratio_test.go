@@ -0,0 +1,31 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRatio(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	r := w.Ratio()
+	r.Numerator("sum", "errors")
+	r.Denominator("count", "duration")
+	r.As = "error_rate"
+
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+    |ratio()
+        .numerator('sum', 'errors')
+        .denominator('count', 'duration')
+        .as('error_rate')
+        .numeratorAs('numerator')
+        .denominatorAs('denominator')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ResidualNode converts the Residual pipeline node into the TICKScript AST
+type ResidualNode struct {
+	Function
+}
+
+// NewResidual creates a Residual function builder
+func NewResidual(parents []ast.Node) *ResidualNode {
+	return &ResidualNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Residual ast.Node
+func (n *ResidualNode) Build(r *pipeline.ResidualNode) (ast.Node, error) {
+	n.Pipe("residual", r.Field, r.Expected).
+		Dot("as", r.As)
+	return n.prev, n.err
+}
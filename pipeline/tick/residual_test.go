@@ -0,0 +1,42 @@
+package tick_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestResidual(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	lambda := &ast.LambdaNode{
+		Expression: &ast.FunctionNode{
+			Type: ast.GlobalFunc,
+			Func: "if",
+			Args: []ast.Node{
+				&ast.BinaryNode{
+					Operator: ast.TokenGreaterEqual,
+					Left: &ast.FunctionNode{
+						Type: ast.GlobalFunc,
+						Func: "hour",
+						Args: []ast.Node{
+							&ast.ReferenceNode{Reference: "time"},
+						},
+					},
+					Right: &ast.NumberNode{IsInt: true, Int64: 9, Base: 10},
+				},
+				&ast.NumberNode{IsFloat: true, Float64: 1000},
+				&ast.NumberNode{IsFloat: true, Float64: 200},
+			},
+		},
+	}
+
+	r := from.Residual("rate", lambda)
+	r.As = "deviation"
+
+	want := `stream
+    |from()
+    |residual('rate', lambda: if(hour("time") >= 9, 1000.0, 200.0))
+        .as('deviation')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
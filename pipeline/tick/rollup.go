@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// RollupNode converts the Rollup pipeline node into the TICKScript AST
+type RollupNode struct {
+	Function
+}
+
+// NewRollup creates a Rollup function builder
+func NewRollup(parents []ast.Node) *RollupNode {
+	return &RollupNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Rollup ast.Node
+func (n *RollupNode) Build(r *pipeline.RollupNode) (ast.Node, error) {
+	tags := make([]interface{}, len(r.Tags))
+	for i, t := range r.Tags {
+		tags[i] = t
+	}
+	n.Pipe("rollup", r.Field).
+		Dot("drop", tags...).
+		Dot("aggregate", r.Aggregate).
+		Dot("as", r.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestRollup(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.Rollup("value").Drop("host", "rack")
+	r.Aggregate = "mean"
+	r.As = "requests"
+
+	want := `stream
+    |from()
+    |rollup('value')
+        .drop('host', 'rack')
+        .aggregate('mean')
+        .as('requests')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,50 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SchemaNode converts the Schema pipeline node into the TICKScript AST
+type SchemaNode struct {
+	Function
+}
+
+// NewSchema creates a Schema function builder
+func NewSchema(parents []ast.Node) *SchemaNode {
+	return &SchemaNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Schema ast.Node
+func (n *SchemaNode) Build(s *pipeline.SchemaNode) (ast.Node, error) {
+	n.Pipe("schema")
+	for _, f := range s.Fields {
+		n.Dot("require", f.Name, f.Type)
+	}
+	n.Dot("violationAs", s.ViolationAs)
+	return n.prev, n.err
+}
+
+// QuarantineNode converts the Quarantine pipeline node into the TICKScript AST
+type QuarantineNode struct {
+	Function
+}
+
+// NewQuarantine creates a Quarantine function builder
+func NewQuarantine(parents []ast.Node) *QuarantineNode {
+	return &QuarantineNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Quarantine ast.Node
+func (n *QuarantineNode) Build(q *pipeline.QuarantineNode) (ast.Node, error) {
+	n.Pipe("quarantine")
+	return n.prev, n.err
+}
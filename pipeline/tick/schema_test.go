@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	schema := from.Schema().
+		Require("value", "float").
+		Require("status", "integer")
+	schema.ViolationAs = "reason"
+	schema.Quarantine()
+
+	want := `stream
+    |from()
+    |schema()
+        .require('value', 'float')
+        .require('status', 'integer')
+        .violationAs('reason')
+    |quarantine()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
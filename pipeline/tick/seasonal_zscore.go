@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SeasonalZScoreNode converts the SeasonalZScore pipeline node into the TICKScript AST
+type SeasonalZScoreNode struct {
+	Function
+}
+
+// NewSeasonalZScore creates a SeasonalZScore function builder
+func NewSeasonalZScore(parents []ast.Node) *SeasonalZScoreNode {
+	return &SeasonalZScoreNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a SeasonalZScore ast.Node
+func (n *SeasonalZScoreNode) Build(s *pipeline.SeasonalZScoreNode) (ast.Node, error) {
+	n.Pipe("seasonalZScore", s.Field).
+		Dot("season", s.Season).
+		Dot("bucket", s.Bucket).
+		Dot("minSeasons", s.MinSeasons).
+		Dot("as", s.As)
+	return n.prev, n.err
+}
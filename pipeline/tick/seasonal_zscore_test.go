@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeasonalZScore(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.SeasonalZScore("rate")
+	s.Season = 7 * 24 * time.Hour
+	s.Bucket = 30 * time.Minute
+	s.MinSeasons = 4
+	s.As = "zscore"
+
+	want := `stream
+    |from()
+    |seasonalZScore('rate')
+        .season(1w)
+        .bucket(30m)
+        .minSeasons(4)
+        .as('zscore')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SequenceNode converts the Sequence pipeline node into the TICKScript AST
+type SequenceNode struct {
+	Function
+}
+
+// NewSequence creates a Sequence function builder
+func NewSequence(parents []ast.Node) *SequenceNode {
+	return &SequenceNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Sequence ast.Node
+func (n *SequenceNode) Build(s *pipeline.SequenceNode) (ast.Node, error) {
+	n.Pipe("sequence", s.Field).
+		Dot("max", s.Max).
+		Dot("expectedAs", s.ExpectedAs).
+		Dot("missingAs", s.MissingAs).
+		Dot("errorAs", s.ErrorAs)
+	return n.prev, n.err
+}
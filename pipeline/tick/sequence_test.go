@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Sequence("seq")
+	s.Max = 65535
+	s.ErrorAs = "error"
+
+	want := `stream
+    |from()
+    |sequence('seq')
+        .max(65535)
+        .expectedAs('expected')
+        .missingAs('missing')
+        .errorAs('error')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
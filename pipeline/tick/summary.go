@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SummaryNode converts the Summary pipeline node into the TICKScript AST
+type SummaryNode struct {
+	Function
+}
+
+// NewSummary creates a Summary function builder
+func NewSummary(parents []ast.Node) *SummaryNode {
+	return &SummaryNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Summary ast.Node
+func (n *SummaryNode) Build(s *pipeline.SummaryNode) (ast.Node, error) {
+	n.Pipe("summary", s.Field).
+		Dot("aggregate", s.Aggregate).
+		Dot("as", s.As)
+	return n.prev, n.err
+}
@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	s := w.Summary("value")
+	s.Aggregate = "max"
+	s.As = "busiest"
+
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+    |summary('value')
+        .aggregate('max')
+        .as('busiest')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
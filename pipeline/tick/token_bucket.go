@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// TokenBucketNode converts the TokenBucket pipeline node into the TICKScript AST
+type TokenBucketNode struct {
+	Function
+}
+
+// NewTokenBucket creates a TokenBucket function builder
+func NewTokenBucket(parents []ast.Node) *TokenBucketNode {
+	return &TokenBucketNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a TokenBucket ast.Node
+func (n *TokenBucketNode) Build(b *pipeline.TokenBucketNode) (ast.Node, error) {
+	n.Pipe("tokenBucket", b.Field).
+		Dot("limit", b.Limit).
+		Dot("interval", b.Interval).
+		Dot("limitField", b.LimitField).
+		Dot("burst", b.Burst).
+		Dot("overageAs", b.OverageAs).
+		Dot("limitAs", b.LimitAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.TokenBucket("calls")
+	b.Limit = 1000.0
+	b.Interval = time.Hour
+	b.LimitField = "quota"
+	b.Burst = 2.0
+
+	want := `stream
+    |from()
+    |tokenBucket('calls')
+        .limit(1000.0)
+        .interval(1h)
+        .limitField('quota')
+        .burst(2.0)
+        .overageAs('overage')
+        .limitAs('limit')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// UptimeNode converts the Uptime pipeline node into the TICKScript AST
+type UptimeNode struct {
+	Function
+}
+
+// NewUptime creates an Uptime function builder
+func NewUptime(parents []ast.Node) *UptimeNode {
+	return &UptimeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Uptime ast.Node
+func (n *UptimeNode) Build(u *pipeline.UptimeNode) (ast.Node, error) {
+	n.Pipe("uptime", u.Lambda).
+		Dot("as", u.As)
+	return n.prev, n.err
+}
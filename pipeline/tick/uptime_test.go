@@ -0,0 +1,38 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestUptime(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Hour
+	w.Every = time.Hour
+	lambda := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "status",
+			},
+			Right: &ast.StringNode{
+				Literal: "up",
+			},
+			Operator: ast.TokenEqual,
+		},
+	}
+	u := w.Uptime(lambda)
+	u.As = "availability"
+
+	want := `stream
+    |from()
+    |window()
+        .period(1h)
+        .every(1h)
+    |uptime(lambda: "status" == 'up')
+        .as('availability')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// Detect when a metered value exceeds a per group rate limit, such as the API calls of each key,
+// using a token bucket over the data.
+//
+// Each group has a bucket of tokens, refilled continuously at the rate of the limit per interval,
+// up to the burst size of the bucket. Each point consumes its value from the bucket.
+// When the value of a point is more than the tokens left in the bucket, the point is over the limit:
+// an event is emitted with the amount over the limit, `overage`, and the limit of the group, `limit`,
+// and the bucket is emptied.
+// Points within the limit do not emit an event.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('api_calls')
+//	        .groupBy('key')
+//	    |sideload()
+//	        .source('file:///etc/kapacitor/quotas')
+//	        .order('quotas.csv/{{.key}}')
+//	        .field('quota', 0.0)
+//	    |tokenBucket('calls')
+//	        .limit(1000.0)
+//	        .interval(1m)
+//	        .limitField('quota')
+//	    |alert()
+//	        .warn(lambda: TRUE)
+//	        .message('{{ index .Tags "key" }} is {{ index .Fields "overage" }} calls over its quota of {{ index .Fields "limit" }} per minute')
+//
+// The above example alerts when a key makes more calls than its quota per minute,
+// looked up from a CSV file, or than the default limit of 1000 calls per minute for keys without a quota.
+//
+// The limit of a group is the value of the limit field of each point if it is set and positive, otherwise the default limit.
+// The bucket of a group starts full and its burst size defaults to the limit, i.e. a full interval of tokens.
+// The bucket is refilled according to the times of the points, so that replays are limited the same way as live data.
+// Points without a numeric value are dropped and do not consume tokens.
+// State is kept per group across batches.
+type TokenBucketNode struct {
+	chainnode `json:"-"`
+
+	// The field with the metered value.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The default limit of the value per interval.
+	Limit float64 `json:"limit"`
+
+	// The interval of the limit.
+	// Default: 1m
+	Interval time.Duration `json:"interval"`
+
+	// The field with the limit of the group, e.g. loaded with a sideload node.
+	// If empty every group has the default limit.
+	LimitField string `json:"limitField"`
+
+	// The burst size of the bucket, as a multiple of the limit.
+	// Default: 1, the limit
+	Burst float64 `json:"burst"`
+
+	// The name of the overage field.
+	// Default: overage
+	OverageAs string `json:"overageAs"`
+
+	// The name of the limit field.
+	// Default: limit
+	LimitAs string `json:"limitAs"`
+}
+
+func newTokenBucketNode(wants EdgeType, field string) *TokenBucketNode {
+	return &TokenBucketNode{
+		chainnode: newBasicChainNode("tokenBucket", wants, wants),
+		Field:     field,
+		Interval:  time.Minute,
+		Burst:     1,
+		OverageAs: "overage",
+		LimitAs:   "limit",
+	}
+}
+
+// MarshalJSON converts TokenBucketNode to JSON
+// tick:ignore
+func (n *TokenBucketNode) MarshalJSON() ([]byte, error) {
+	type Alias TokenBucketNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "tokenBucket",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TokenBucketNode
+// tick:ignore
+func (n *TokenBucketNode) UnmarshalJSON(data []byte) error {
+	type Alias TokenBucketNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "tokenBucket" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TokenBucketNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *TokenBucketNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must specify a field for tokenBucket")
+	}
+	if n.Limit <= 0 {
+		return errors.New("tokenBucket limit must be greater than zero")
+	}
+	if n.Interval <= 0 {
+		return errors.New("tokenBucket interval must be greater than zero")
+	}
+	if n.Burst < 1 {
+		return errors.New("tokenBucket burst must be at least 1")
+	}
+	if n.OverageAs == "" || n.LimitAs == "" {
+		return errors.New("tokenBucket field names must not be empty")
+	}
+	if n.OverageAs == n.LimitAs {
+		return errors.New("tokenBucket overageAs and limitAs must be different")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Compute the fraction of time a condition held within each batch.
+// The expression is evaluated for each point of a batch, and its result is assumed
+// to hold from the time of the point until the time of the next point.
+// The result of the last point holds until the end of the batch, the time of the batch.
+// A single point is emitted per group and batch with the ratio from 0 to 1.
+//
+// Example:
+//
+//	stream
+//	    |from()
+//	        .measurement('healthcheck')
+//	    |groupBy('service')
+//	    |window()
+//	        .period(1h)
+//	        .every(1h)
+//	        .align()
+//	    |uptime(lambda: "status" == 'up')
+//	    |eval(lambda: "uptime" * 100.0)
+//	        .as('availability')
+//
+// The above example computes the hourly availability percentage of each service.
+//
+// Boundary conditions:
+//
+//   - The state before the first point of a batch is unknown, so the ratio
+//     is computed over the time from the first point until the end of the batch.
+//   - If that time is zero, e.g. a batch with a single point at its end,
+//     the ratio is 1 or 0 according to whether the expression holds for the last point.
+//   - Points for which the expression cannot be evaluated are ignored,
+//     and the previous result holds until the next point.
+//   - Empty batches do not emit a point.
+type UptimeNode struct {
+	chainnode `json:"-"`
+
+	// Expression to determine whether the condition holds.
+	// tick:ignore
+	Lambda *ast.LambdaNode `json:"lambda"`
+
+	// The name of the ratio field.
+	// Default: uptime
+	As string `json:"as"`
+}
+
+func newUptimeNode(predicate *ast.LambdaNode) *UptimeNode {
+	return &UptimeNode{
+		chainnode: newBasicChainNode("uptime", BatchEdge, StreamEdge),
+		Lambda:    predicate,
+		As:        "uptime",
+	}
+}
+
+// MarshalJSON converts UptimeNode to JSON
+// tick:ignore
+func (n *UptimeNode) MarshalJSON() ([]byte, error) {
+	type Alias UptimeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "uptime",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an UptimeNode
+// tick:ignore
+func (n *UptimeNode) UnmarshalJSON(data []byte) error {
+	type Alias UptimeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "uptime" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as UptimeNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *UptimeNode) validate() error {
+	if n.Lambda == nil {
+		return errors.New("must specify an expression for uptime")
+	}
+	if n.As == "" {
+		return errors.New("uptime as must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package kapacitor
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type PivotNode struct {
+	node
+	p *pipeline.PivotNode
+
+	// The columns to keep, if any.
+	columns map[string]bool
+}
+
+// Create a new pivot node.
+func newPivotNode(et *ExecutingTask, n *pipeline.PivotNode, d NodeDiagnostic) (*PivotNode, error) {
+	pn := &PivotNode{
+		node: node{Node: n, et: et, diag: d},
+		p:    n,
+	}
+	if len(n.ColumnsList) > 0 {
+		pn.columns = make(map[string]bool, len(n.ColumnsList))
+		for _, c := range n.ColumnsList {
+			pn.columns[c] = true
+		}
+	}
+	pn.node.runF = pn.runPivot
+	return pn, nil
+}
+
+func (n *PivotNode) runPivot([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PivotNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *PivotNode) newGroup() *pivotGroup {
+	return &pivotGroup{
+		n: n,
+	}
+}
+
+type pivotGroup struct {
+	n *PivotNode
+
+	begin  edge.BeginBatchMessage
+	fields models.Fields
+}
+
+func (g *pivotGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin.ShallowCopy()
+	g.fields = make(models.Fields)
+	return nil, nil
+}
+
+func (g *pivotGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	suffix, ok := g.n.suffix(bp.Tags())
+	if !ok {
+		g.n.diag.Error("cannot pivot point",
+			errors.New("point is missing an on tag"),
+			keyvalue.KV("on", strings.Join(g.n.p.Dimensions, ",")),
+		)
+		return nil, nil
+	}
+	for field, value := range bp.Fields() {
+		column := field + suffix
+		if g.n.columns != nil && !g.n.columns[column] {
+			continue
+		}
+		g.fields[column] = value
+	}
+	return nil, nil
+}
+
+// suffix returns the suffix of the columns of a point with the tags,
+// and whether the tags have all of the on tags.
+func (n *PivotNode) suffix(tags models.Tags) (string, bool) {
+	if len(n.p.Dimensions) == 0 {
+		return "", true
+	}
+	var b strings.Builder
+	for _, d := range n.p.Dimensions {
+		v, ok := tags[d]
+		if !ok {
+			return "", false
+		}
+		b.WriteString(n.p.Delimiter)
+		b.WriteString(v)
+	}
+	return b.String(), true
+}
+
+func (g *pivotGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if g.n.p.Fill != nil {
+		for _, c := range g.n.p.ColumnsList {
+			if _, ok := g.fields[c]; !ok {
+				g.fields[c] = g.n.p.Fill
+			}
+		}
+	}
+
+	var points []edge.BatchPointMessage
+	if len(g.fields) > 0 {
+		points = []edge.BatchPointMessage{
+			edge.NewBatchPointMessage(g.fields, g.begin.Tags(), g.begin.Time()),
+		}
+	}
+	g.begin.SetSizeHint(len(points))
+	return edge.NewBufferedBatchMessage(g.begin, points, end), nil
+}
+
+func (g *pivotGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *pivotGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *pivotGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *pivotGroup) Done() {}
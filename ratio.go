@@ -0,0 +1,162 @@
+package kapacitor
+
+import (
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type RatioNode struct {
+	node
+	r *pipeline.RatioNode
+}
+
+// Create a new ratio node.
+func newRatioNode(et *ExecutingTask, n *pipeline.RatioNode, d NodeDiagnostic) (*RatioNode, error) {
+	rn := &RatioNode{
+		node: node{Node: n, et: et, diag: d},
+		r:    n,
+	}
+	rn.node.runF = rn.runRatio
+	return rn, nil
+}
+
+func (n *RatioNode) runRatio([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *RatioNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *RatioNode) newGroup() *ratioGroup {
+	return &ratioGroup{
+		n:           n,
+		numerator:   ratioReducer{reducer: n.r.NumeratorReducer, field: n.r.NumeratorField},
+		denominator: ratioReducer{reducer: n.r.DenominatorReducer, field: n.r.DenominatorField},
+	}
+}
+
+type ratioGroup struct {
+	n *RatioNode
+
+	begin       edge.BeginBatchMessage
+	points      int
+	numerator   ratioReducer
+	denominator ratioReducer
+}
+
+// ratioReducer reduces the values of a field of a batch.
+type ratioReducer struct {
+	reducer string
+	field   string
+
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func (r *ratioReducer) reset() {
+	r.count = 0
+	r.sum = 0
+	r.min = math.Inf(1)
+	r.max = math.Inf(-1)
+}
+
+func (r *ratioReducer) add(fields models.Fields) {
+	value, ok := numToFloat(fields[r.field])
+	if !ok {
+		return
+	}
+	r.count++
+	r.sum += value
+	r.min = math.Min(r.min, value)
+	r.max = math.Max(r.max, value)
+}
+
+// value returns the reduced value, and whether there is one.
+func (r *ratioReducer) value() (float64, bool) {
+	switch r.reducer {
+	case "count":
+		return float64(r.count), true
+	case "sum":
+		return r.sum, true
+	}
+	if r.count == 0 {
+		return 0, false
+	}
+	switch r.reducer {
+	case "mean":
+		return r.sum / float64(r.count), true
+	case "min":
+		return r.min, true
+	default:
+		return r.max, true
+	}
+}
+
+func (g *ratioGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.points = 0
+	g.numerator.reset()
+	g.denominator.reset()
+	return nil, nil
+}
+
+func (g *ratioGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.points++
+	g.numerator.add(bp.Fields())
+	g.denominator.add(bp.Fields())
+	return nil, nil
+}
+
+func (g *ratioGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if g.points == 0 {
+		return nil, nil
+	}
+	r := g.n.r
+	fields := make(models.Fields, 3)
+	numerator, nok := g.numerator.value()
+	if nok {
+		fields[r.NumeratorAs] = numerator
+	}
+	denominator, dok := g.denominator.value()
+	if dok {
+		fields[r.DenominatorAs] = denominator
+	}
+	// The ratio is null, i.e. not set, if it is undefined.
+	if nok && dok && denominator != 0 {
+		fields[r.As] = numerator / denominator
+	}
+
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		fields,
+		g.begin.GroupInfo().Tags,
+		g.begin.Time(),
+	), nil
+}
+
+func (g *ratioGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *ratioGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *ratioGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *ratioGroup) Done() {}
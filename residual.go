@@ -0,0 +1,141 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+type ResidualNode struct {
+	node
+	r *pipeline.ResidualNode
+
+	expression stateful.Expression
+	scopePool  stateful.ScopePool
+}
+
+// Create a new residual node.
+func newResidualNode(et *ExecutingTask, n *pipeline.ResidualNode, d NodeDiagnostic) (*ResidualNode, error) {
+	if n.Expected == nil {
+		return nil, errors.New("nil expression passed to ResidualNode")
+	}
+	expr, err := stateful.NewExpression(n.Expected.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile expected expression: %v", err)
+	}
+	rn := &ResidualNode{
+		node:       node{Node: n, et: et, diag: d},
+		r:          n,
+		expression: expr,
+		scopePool:  stateful.NewScopePool(ast.FindReferenceVariables(n.Expected.Expression)),
+	}
+	rn.node.runF = rn.runResidual
+	return rn, nil
+}
+
+func (n *ResidualNode) runResidual([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *ResidualNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *ResidualNode) newGroup() *residualGroup {
+	return &residualGroup{
+		n:          n,
+		expression: n.expression.CopyReset(),
+	}
+}
+
+type residualGroup struct {
+	n          *ResidualNode
+	expression stateful.Expression
+}
+
+func (g *residualGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *residualGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doResidual(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *residualGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *residualGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doResidual(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doResidual sets the residual of the field value against the expected value on p.
+// Points for which either value is not numeric are dropped.
+func (g *residualGroup) doResidual(p edge.FieldsTagsTimeSetter) bool {
+	r := g.n.r
+	value, ok := numToFloat(p.Fields()[r.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute residual",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", r.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[r.Field])),
+		)
+		return false
+	}
+	expected, err := g.evalExpected(p)
+	if err != nil {
+		g.n.diag.Error("error evaluating expected expression", err)
+		return false
+	}
+
+	fields := p.Fields().Copy()
+	fields[r.As] = value - expected
+	p.SetFields(fields)
+	return true
+}
+
+func (g *residualGroup) evalExpected(p edge.FieldsTagsTimeGetter) (float64, error) {
+	vars := g.n.scopePool.Get()
+	defer g.n.scopePool.Put(vars)
+	if err := fillScope(vars, g.n.scopePool.ReferenceVariables(), p); err != nil {
+		return 0, err
+	}
+	v, err := g.expression.Eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	expected, ok := numToFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("expected expression must evaluate to a number, got %T", v)
+	}
+	return expected, nil
+}
+
+func (g *residualGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *residualGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *residualGroup) Done() {}
@@ -0,0 +1,413 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type RollupNode struct {
+	node
+	r  *pipeline.RollupNode
+	as string
+
+	drop map[string]bool
+
+	begin edge.BeginBatchMessage
+	// The rolled up groups, by their ID.
+	groups map[models.GroupID]*rollupGroup
+	// The order the groups were first seen, so they are emitted deterministically.
+	order []models.GroupID
+	// The rolled up group of each incoming group.
+	rolled map[models.GroupID]models.GroupID
+}
+
+type rollupGroup struct {
+	name string
+	info edge.GroupInfo
+	// The incoming groups of the group, with how far their data has been seen.
+	members map[models.GroupID]rollupProgress
+	// Whether a time of the group has been emitted.
+	// Only then are all the incoming groups of the group known.
+	known bool
+	// The data of each time, the point times of a stream or the batch times.
+	buckets map[time.Time]*rollupBucket
+}
+
+type rollupBucket struct {
+	begin edge.BeginBatchMessage
+	// The aggregates of each point time.
+	aggregates map[time.Time]*rollupAggregate
+}
+
+// rollupProgress is the time up to which all data of an incoming group has been seen.
+type rollupProgress struct {
+	time time.Time
+	// Whether the data of the time itself has been seen.
+	inclusive bool
+}
+
+// seen reports whether all data of the time t has been seen.
+func (p rollupProgress) seen(t time.Time) bool {
+	return p.time.After(t) || (p.inclusive && p.time.Equal(t))
+}
+
+type rollupAggregate struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// Create a new RollupNode which aggregates the data across some of the tags of its groups.
+func newRollupNode(et *ExecutingTask, n *pipeline.RollupNode, d NodeDiagnostic) (*RollupNode, error) {
+	drop := make(map[string]bool, len(n.Tags))
+	for _, t := range n.Tags {
+		drop[t] = true
+	}
+	rn := &RollupNode{
+		node:   node{Node: n, et: et, diag: d},
+		r:      n,
+		as:     n.As,
+		drop:   drop,
+		groups: make(map[models.GroupID]*rollupGroup),
+		rolled: make(map[models.GroupID]models.GroupID),
+	}
+	if rn.as == "" {
+		rn.as = n.Aggregate
+	}
+	rn.node.runF = rn.runRollup
+	return rn, nil
+}
+
+func (n *RollupNode) runRollup([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// groupInfo returns the group of the rolled up data, without the dropped tags.
+func (n *RollupNode) groupInfo(name string, tags models.Tags, dims models.Dimensions) edge.GroupInfo {
+	tagNames := make([]string, 0, len(dims.TagNames))
+	newTags := make(models.Tags, len(dims.TagNames))
+	for _, t := range dims.TagNames {
+		if n.drop[t] {
+			continue
+		}
+		tagNames = append(tagNames, t)
+		if v, ok := tags[t]; ok {
+			newTags[t] = v
+		}
+	}
+	newDims := models.Dimensions{
+		ByName:   dims.ByName,
+		TagNames: tagNames,
+	}
+	return edge.GroupInfo{
+		ID:         models.ToGroupID(name, newTags, newDims),
+		Tags:       newTags,
+		Dimensions: newDims,
+	}
+}
+
+// group returns the rolled up group of the incoming group id, adding the incoming group to its members.
+func (n *RollupNode) group(name string, info edge.GroupInfo, id models.GroupID) *rollupGroup {
+	group, ok := n.groups[info.ID]
+	if !ok {
+		group = &rollupGroup{
+			name:    name,
+			info:    info,
+			members: make(map[models.GroupID]rollupProgress),
+			buckets: make(map[time.Time]*rollupBucket),
+		}
+		n.groups[info.ID] = group
+		n.order = append(n.order, info.ID)
+	}
+	if _, ok := group.members[id]; !ok {
+		group.members[id] = rollupProgress{}
+		n.rolled[id] = info.ID
+	}
+	return group
+}
+
+// add aggregates the value of the field at time t into the bucket of the rolled up group.
+func (n *RollupNode) add(group *rollupGroup, begin edge.BeginBatchMessage, bucketTime, t time.Time, fields models.Fields) {
+	value, ok := numToFloat(fields[n.r.Field])
+	if !ok {
+		n.diag.Error("cannot rollup point",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", n.r.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", fields[n.r.Field])),
+		)
+		return
+	}
+	bucket, ok := group.buckets[bucketTime]
+	if !ok {
+		bucket = &rollupBucket{
+			begin:      begin,
+			aggregates: make(map[time.Time]*rollupAggregate),
+		}
+		group.buckets[bucketTime] = bucket
+	}
+	a, ok := bucket.aggregates[t]
+	if !ok {
+		a = &rollupAggregate{
+			min: math.Inf(1),
+			max: math.Inf(-1),
+		}
+		bucket.aggregates[t] = a
+	}
+	a.count++
+	a.sum += value
+	a.min = math.Min(a.min, value)
+	a.max = math.Max(a.max, value)
+}
+
+// advance records the progress of the incoming group id,
+// and emits the times of its rolled up group that have been seen for all incoming groups.
+// The node timer must be started when calling this method.
+func (n *RollupNode) advance(id models.GroupID, p rollupProgress) error {
+	group, ok := n.groups[n.rolled[id]]
+	if !ok {
+		return nil
+	}
+	if _, ok := group.members[id]; !ok {
+		return nil
+	}
+	group.members[id] = p
+	return n.emitSeen(group)
+}
+
+func (n *RollupNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emitBefore(p.Time()); err != nil {
+		return err
+	}
+	info := n.groupInfo(p.Name(), p.Tags(), p.Dimensions())
+	group := n.group(p.Name(), info, p.GroupID())
+	n.add(group, nil, p.Time(), p.Time(), p.Fields())
+	// Other points of the group may still have the same time.
+	return n.advance(p.GroupID(), rollupProgress{time: p.Time()})
+}
+
+func (n *RollupNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emitBefore(begin.Time()); err != nil {
+		return err
+	}
+	n.begin = begin
+	info := n.groupInfo(begin.Name(), begin.Tags(), begin.Dimensions())
+	n.group(begin.Name(), info, begin.GroupID())
+	return nil
+}
+
+func (n *RollupNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	begin := n.begin
+	group := n.groups[n.rolled[begin.GroupID()]]
+	n.add(group, begin, begin.Time(), bp.Time(), bp.Fields())
+	return nil
+}
+
+func (n *RollupNode) EndBatch(end edge.EndBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	return n.advance(n.begin.GroupID(), rollupProgress{time: n.begin.Time(), inclusive: true})
+}
+
+func (n *RollupNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emitBefore(b.Time())
+	if err == nil {
+		err = n.advance(b.GroupID(), rollupProgress{time: b.Time()})
+	}
+	info := n.groupInfo(b.Name(), b.Tags(), b.Dimensions())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return edge.Forward(n.outs, edge.NewBarrierMessage(info, b.Time()))
+}
+
+func (n *RollupNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	id := d.GroupID()
+	group, ok := n.groups[n.rolled[id]]
+	if !ok {
+		return nil
+	}
+	delete(group.members, id)
+	delete(n.rolled, id)
+	if len(group.members) > 0 {
+		// The deleted group may have been the last one a time was waiting for.
+		return n.emitSeen(group)
+	}
+	// The rolled up group has no incoming groups left, so it is deleted once its data is sent.
+	if err := n.emitGroup(group, nil); err != nil {
+		return err
+	}
+	delete(n.groups, group.info.ID)
+	for i, o := range n.order {
+		if o == group.info.ID {
+			n.order = append(n.order[:i], n.order[i+1:]...)
+			break
+		}
+	}
+	n.timer.Pause()
+	defer n.timer.Resume()
+	return edge.Forward(n.outs, edge.NewDeleteGroupMessage(group.info))
+}
+
+func (n *RollupNode) Done() {
+	n.timer.Start()
+	defer n.timer.Stop()
+	// The data still pending is complete, as no more data will arrive.
+	for _, id := range n.order {
+		if err := n.emitGroup(n.groups[id], nil); err != nil {
+			n.diag.Error("failed to send the last rolled up data", err)
+			return
+		}
+	}
+}
+
+// value returns the aggregated value.
+func (n *RollupNode) value(a *rollupAggregate) (interface{}, error) {
+	switch n.r.Aggregate {
+	case pipeline.SummarySum:
+		return a.sum, nil
+	case pipeline.SummaryMean:
+		return a.sum / float64(a.count), nil
+	case pipeline.SummaryMin:
+		return a.min, nil
+	case pipeline.SummaryMax:
+		return a.max, nil
+	case pipeline.SummaryCount:
+		return a.count, nil
+	default:
+		return nil, fmt.Errorf("unknown rollup aggregate %q", n.r.Aggregate)
+	}
+}
+
+// emitBefore sends the data of all rolled up groups before time t to children nodes,
+// as data arrives in time order and no more data before t will arrive.
+// The node timer must be started when calling this method.
+func (n *RollupNode) emitBefore(t time.Time) error {
+	for _, id := range n.order {
+		if err := n.emitGroup(n.groups[id], func(bt time.Time) bool { return bt.Before(t) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitSeen sends the times of the group that have been seen for all its incoming groups.
+// Until a time of the group has been emitted, not all its incoming groups may have arrived yet,
+// so its times are only sent once later data arrives.
+// The node timer must be started when calling this method.
+func (n *RollupNode) emitSeen(group *rollupGroup) error {
+	if !group.known {
+		return nil
+	}
+	return n.emitGroup(group, func(bt time.Time) bool {
+		for _, p := range group.members {
+			if !p.seen(bt) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// emitGroup sends the times of the group in order, while ready reports them as ready.
+// All times are sent if ready is nil.
+// The node timer must be started when calling this method.
+func (n *RollupNode) emitGroup(group *rollupGroup, ready func(time.Time) bool) error {
+	times := make([]time.Time, 0, len(group.buckets))
+	for bt := range group.buckets {
+		times = append(times, bt)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for _, bt := range times {
+		if ready != nil && !ready(bt) {
+			return nil
+		}
+		bucket := group.buckets[bt]
+		delete(group.buckets, bt)
+		group.known = true
+		if err := n.emitBucket(group, bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitBucket sends the aggregated data of a time of the group to children nodes.
+// The node timer must be started when calling this method.
+func (n *RollupNode) emitBucket(group *rollupGroup, bucket *rollupBucket) error {
+	times := make([]time.Time, 0, len(bucket.aggregates))
+	for pt := range bucket.aggregates {
+		times = append(times, pt)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	var msgs []edge.Message
+	if bucket.begin == nil {
+		for _, pt := range times {
+			value, err := n.value(bucket.aggregates[pt])
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, edge.NewPointMessage(
+				group.name, "", "",
+				group.info.Dimensions,
+				models.Fields{n.as: value},
+				group.info.Tags,
+				pt,
+			))
+		}
+	} else {
+		begin := bucket.begin.ShallowCopy()
+		begin.SetTagsAndDimensions(group.info.Tags, group.info.Dimensions)
+		begin.SetSizeHint(len(times))
+		points := make([]edge.BatchPointMessage, len(times))
+		for i, pt := range times {
+			value, err := n.value(bucket.aggregates[pt])
+			if err != nil {
+				return err
+			}
+			points[i] = edge.NewBatchPointMessage(
+				models.Fields{n.as: value},
+				group.info.Tags,
+				pt,
+			)
+		}
+		msgs = append(msgs, edge.NewBufferedBatchMessage(begin, points, edge.NewEndBatchMessage()))
+	}
+
+	n.timer.Pause()
+	defer n.timer.Resume()
+	for _, m := range msgs {
+		if err := edge.Forward(n.outs, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
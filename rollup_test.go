@@ -0,0 +1,92 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+func TestRollupEmitsSeenTimes(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	r := stream.From().Window().Rollup("value").Drop("host")
+	n, err := newRollupNode(nil, r, testNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := edge.NewStatsEdge(edge.NewChannelEdge(pipeline.BatchEdge, 10))
+	n.outs = []edge.StatsEdge{out}
+	n.timer = timer.NewNoOp()
+
+	zero := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := func(region, host string, tm time.Time, value float64) {
+		t.Helper()
+		tags := models.Tags{"region": region, "host": host}
+		if err := n.BeginBatch(edge.NewBeginBatchMessage("requests", tags, false, tm, 1)); err != nil {
+			t.Fatal(err)
+		}
+		if err := n.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": value}, tags, tm)); err != nil {
+			t.Fatal(err)
+		}
+		if err := n.EndBatch(edge.NewEndBatchMessage()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(batches int64) {
+		t.Helper()
+		if got := out.Collected() - out.Emitted(); got != batches {
+			t.Fatalf("unexpected number of batches: got %d exp %d", got, batches)
+		}
+	}
+	next := func(tm time.Time, value float64) {
+		t.Helper()
+		m, _ := out.Emit()
+		b, ok := m.(edge.BufferedBatchMessage)
+		if !ok {
+			t.Fatalf("unexpected message of type %T", m)
+		}
+		if got := b.Begin().Time(); !got.Equal(tm) {
+			t.Errorf("unexpected time: got %v exp %v", got, tm)
+		}
+		if got := b.Points()[0].Fields()["sum"]; got != value {
+			t.Errorf("unexpected value: got %v exp %v", got, value)
+		}
+	}
+
+	// The groups of the first time are not known, so it waits for later data.
+	batch("east", "a", zero, 1)
+	batch("east", "b", zero, 2)
+	expect(0)
+	batch("east", "a", zero.Add(time.Second), 3)
+	expect(1)
+	next(zero, 3)
+
+	// The later times are emitted once the batches of all groups have ended.
+	batch("east", "b", zero.Add(time.Second), 4)
+	expect(1)
+	next(zero.Add(time.Second), 7)
+
+	// The time is emitted once the group it was waiting for is deleted.
+	batch("east", "a", zero.Add(2*time.Second), 5)
+	expect(0)
+	b := edge.NewBeginBatchMessage("requests", models.Tags{"region": "east", "host": "b"}, false, zero, 0)
+	if err := n.DeleteGroup(edge.NewDeleteGroupMessage(b.GroupInfo())); err != nil {
+		t.Fatal(err)
+	}
+	expect(1)
+	next(zero.Add(2*time.Second), 5)
+	batch("east", "a", zero.Add(3*time.Second), 6)
+	expect(1)
+	next(zero.Add(3*time.Second), 6)
+
+	// The pending data is emitted when the node is done.
+	batch("west", "c", zero.Add(3*time.Second), 7)
+	expect(0)
+	n.Done()
+	expect(1)
+	next(zero.Add(3*time.Second), 7)
+}
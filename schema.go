@@ -0,0 +1,203 @@
+package kapacitor
+
+import (
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statViolations = "violations"
+)
+
+type SchemaNode struct {
+	node
+	s *pipeline.SchemaNode
+
+	// The edges to the children that receive the conforming points,
+	// and to the quarantine children that receive the violating points.
+	valid       []edge.StatsEdge
+	quarantined []edge.StatsEdge
+
+	buffer     edge.BatchBuffer
+	violations *expvar.Int
+}
+
+// Create a new SchemaNode which validates points against a schema.
+func newSchemaNode(et *ExecutingTask, n *pipeline.SchemaNode, d NodeDiagnostic) (*SchemaNode, error) {
+	sn := &SchemaNode{
+		node:       node{Node: n, et: et, diag: d},
+		s:          n,
+		violations: new(expvar.Int),
+	}
+	sn.node.runF = sn.runSchema
+	return sn, nil
+}
+
+func (n *SchemaNode) runSchema([]byte) error {
+	n.statMap.Set(statViolations, n.violations)
+	for i, c := range n.children {
+		if _, ok := c.(*QuarantineNode); ok {
+			n.quarantined = append(n.quarantined, n.outs[i])
+		} else {
+			n.valid = append(n.valid, n.outs[i])
+		}
+	}
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+// violation returns a description of the first violation of the schema by the fields,
+// or the empty string if the fields conform to the schema.
+func (n *SchemaNode) violation(fields models.Fields) string {
+	for _, f := range n.s.Fields {
+		value, ok := fields[f.Name]
+		if !ok {
+			return fmt.Sprintf("missing field %s", f.Name)
+		}
+		var valid bool
+		switch value.(type) {
+		case float64:
+			valid = f.Type == pipeline.SchemaFloat || f.Type == pipeline.SchemaNumber
+		case int64:
+			valid = f.Type == pipeline.SchemaInteger || f.Type == pipeline.SchemaNumber
+		case string:
+			valid = f.Type == pipeline.SchemaString
+		case bool:
+			valid = f.Type == pipeline.SchemaBoolean
+		}
+		if !valid {
+			return fmt.Sprintf("field %s is %s, expected %s", f.Name, schemaTypeOf(value), f.Type)
+		}
+	}
+	return ""
+}
+
+// quarantine returns a copy of the fields with the violation added.
+func (n *SchemaNode) quarantine(fields models.Fields, violation string) models.Fields {
+	fields = fields.Copy()
+	fields[n.s.ViolationAs] = violation
+	return fields
+}
+
+func (n *SchemaNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	violation := n.violation(p.Fields())
+	if violation == "" {
+		n.timer.Stop()
+		return edge.Forward(n.valid, p)
+	}
+	n.violations.Add(1)
+	if len(n.quarantined) == 0 {
+		n.timer.Stop()
+		return nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(n.quarantine(p.Fields(), violation))
+	n.timer.Stop()
+	return edge.Forward(n.quarantined, p)
+}
+
+func (n *SchemaNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	return n.buffer.BeginBatch(begin)
+}
+
+func (n *SchemaNode) BatchPoint(bp edge.BatchPointMessage) error {
+	return n.buffer.BatchPoint(bp)
+}
+
+func (n *SchemaNode) EndBatch(end edge.EndBatchMessage) error {
+	return n.BufferedBatch(n.buffer.BufferedBatchMessage(end))
+}
+
+func (n *SchemaNode) BufferedBatch(b edge.BufferedBatchMessage) error {
+	n.timer.Start()
+	valid := make([]edge.BatchPointMessage, 0, len(b.Points()))
+	var quarantined []edge.BatchPointMessage
+	for _, bp := range b.Points() {
+		violation := n.violation(bp.Fields())
+		if violation == "" {
+			valid = append(valid, bp)
+			continue
+		}
+		n.violations.Add(1)
+		if len(n.quarantined) > 0 {
+			bp = bp.ShallowCopy()
+			bp.SetFields(n.quarantine(bp.Fields(), violation))
+			quarantined = append(quarantined, bp)
+		}
+	}
+	n.timer.Stop()
+
+	if err := edge.Forward(n.valid, n.batch(b, valid)); err != nil {
+		return err
+	}
+	if len(quarantined) > 0 {
+		return edge.Forward(n.quarantined, n.batch(b, quarantined))
+	}
+	return nil
+}
+
+// batch returns a copy of the batch b with the points.
+func (n *SchemaNode) batch(b edge.BufferedBatchMessage, points []edge.BatchPointMessage) edge.BufferedBatchMessage {
+	b = b.ShallowCopy()
+	begin := b.Begin().ShallowCopy()
+	begin.SetSizeHint(len(points))
+	b.SetBegin(begin)
+	b.SetPoints(points)
+	return b
+}
+
+func (n *SchemaNode) Barrier(b edge.BarrierMessage) error {
+	return edge.Forward(n.outs, b)
+}
+
+func (n *SchemaNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(n.outs, d)
+}
+
+func (n *SchemaNode) Done() {}
+
+// schemaTypeOf returns the name of the schema type of the value.
+func schemaTypeOf(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return pipeline.SchemaFloat
+	case int64:
+		return pipeline.SchemaInteger
+	case string:
+		return pipeline.SchemaString
+	case bool:
+		return pipeline.SchemaBoolean
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+type QuarantineNode struct {
+	node
+}
+
+// Create a new QuarantineNode which passes the points violating the schema of its parent to its children.
+func newQuarantineNode(et *ExecutingTask, n *pipeline.QuarantineNode, d NodeDiagnostic) (*QuarantineNode, error) {
+	qn := &QuarantineNode{
+		node: node{Node: n, et: et, diag: d},
+	}
+	qn.node.runF = qn.runQuarantine
+	return qn, nil
+}
+
+func (n *QuarantineNode) runQuarantine([]byte) error {
+	for m, ok := n.ins[0].Emit(); ok; m, ok = n.ins[0].Emit() {
+		if err := edge.Forward(n.outs, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type SeasonalZScoreNode struct {
+	node
+	s *pipeline.SeasonalZScoreNode
+}
+
+// Create a new seasonal z-score node.
+func newSeasonalZScoreNode(et *ExecutingTask, n *pipeline.SeasonalZScoreNode, d NodeDiagnostic) (*SeasonalZScoreNode, error) {
+	sn := &SeasonalZScoreNode{
+		node: node{Node: n, et: et, diag: d},
+		s:    n,
+	}
+	sn.node.runF = sn.runSeasonalZScore
+	return sn, nil
+}
+
+func (n *SeasonalZScoreNode) runSeasonalZScore([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *SeasonalZScoreNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *SeasonalZScoreNode) newGroup() *seasonalZScoreGroup {
+	return &seasonalZScoreGroup{
+		n:       n,
+		buckets: make([]seasonalBucket, n.s.Season/n.s.Bucket),
+	}
+}
+
+type seasonalZScoreGroup struct {
+	n *SeasonalZScoreNode
+
+	buckets []seasonalBucket
+}
+
+// seasonalBucket is the baseline of a bucket of the season.
+type seasonalBucket struct {
+	// The values of the previous seasons, and the number of seasons.
+	baseline runningStats
+	seasons  int64
+
+	// The values of the current season, not yet part of the baseline.
+	current runningStats
+	season  int64
+}
+
+// runningStats computes the mean and variance of values in a single pass, using Welford's algorithm.
+type runningStats struct {
+	count int64
+	mean  float64
+	m2    float64
+}
+
+func (s *runningStats) add(value float64) {
+	s.count++
+	delta := value - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (value - s.mean)
+}
+
+// merge adds the values of o to s.
+func (s *runningStats) merge(o runningStats) {
+	if o.count == 0 {
+		return
+	}
+	count := s.count + o.count
+	delta := o.mean - s.mean
+	s.mean += delta * float64(o.count) / float64(count)
+	s.m2 += o.m2 + delta*delta*float64(s.count)*float64(o.count)/float64(count)
+	s.count = count
+}
+
+// stddev returns the sample standard deviation of the values.
+func (s *runningStats) stddev() float64 {
+	if s.count < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.count-1))
+}
+
+func (g *seasonalZScoreGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *seasonalZScoreGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doZScore(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *seasonalZScoreGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *seasonalZScoreGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doZScore(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doZScore sets the z-score of the value of p against the baseline of its bucket as a field on p,
+// and then adds the value to the current season of the bucket.
+// Points without a numeric value are dropped.
+func (g *seasonalZScoreGroup) doZScore(p edge.FieldsTagsTimeSetter) bool {
+	s := g.n.s
+	value, ok := numToFloat(p.Fields()[s.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute seasonal z-score",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", s.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[s.Field])),
+		)
+		return false
+	}
+
+	t := p.Time().UnixNano()
+	season := floorDiv(t, int64(s.Season))
+	b := &g.buckets[(t-season*int64(s.Season))/int64(s.Bucket)]
+	if b.current.count > 0 && season > b.season {
+		// A new season started, the values of the previous season become part of the baseline.
+		b.baseline.merge(b.current)
+		b.seasons++
+		b.current = runningStats{}
+	}
+	if b.current.count == 0 {
+		b.season = season
+	}
+
+	if stddev := b.baseline.stddev(); b.seasons >= s.MinSeasons && stddev > 0 {
+		fields := p.Fields().Copy()
+		fields[s.As] = (value - b.baseline.mean) / stddev
+		p.SetFields(fields)
+	}
+	b.current.add(value)
+	return true
+}
+
+// floorDiv returns the quotient of a and b rounded towards negative infinity.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func (g *seasonalZScoreGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *seasonalZScoreGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *seasonalZScoreGroup) Done() {}
@@ -0,0 +1,168 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	sequenceGap        = "gap"
+	sequenceRegression = "regression"
+)
+
+type SequenceNode struct {
+	node
+	s *pipeline.SequenceNode
+}
+
+// Create a new sequence node.
+func newSequenceNode(et *ExecutingTask, n *pipeline.SequenceNode, d NodeDiagnostic) (*SequenceNode, error) {
+	sn := &SequenceNode{
+		node: node{Node: n, et: et, diag: d},
+		s:    n,
+	}
+	sn.node.runF = sn.runSequence
+	return sn, nil
+}
+
+func (n *SequenceNode) runSequence([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *SequenceNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *SequenceNode) newGroup() *sequenceGroup {
+	return &sequenceGroup{
+		n: n,
+	}
+}
+
+type sequenceGroup struct {
+	n *SequenceNode
+
+	hasPrev bool
+	prev    int64
+}
+
+func (g *sequenceGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *sequenceGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doSequence(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *sequenceGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *sequenceGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doSequence(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doSequence compares the sequence number of p with the previous sequence number,
+// and returns whether p is an event, in which case the fields of the event are set on p.
+func (g *sequenceGroup) doSequence(p edge.FieldsTagsTimeSetter) bool {
+	s := g.n.s
+	value, ok := sequenceNumber(p.Fields()[s.Field])
+	if !ok || value < 0 || (s.Max > 0 && value > s.Max) {
+		g.n.diag.Error("cannot check sequence",
+			errors.New("field is missing, the wrong type or out of range"),
+			keyvalue.KV("field", s.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[s.Field])),
+		)
+		return false
+	}
+
+	if !g.hasPrev {
+		g.hasPrev = true
+		g.prev = value
+		return false
+	}
+	expected, missing, kind := g.n.check(g.prev, value)
+	g.prev = value
+	if kind == "" {
+		return false
+	}
+
+	fields := p.Fields().Copy()
+	fields[s.ExpectedAs] = expected
+	fields[s.MissingAs] = missing
+	fields[s.ErrorAs] = kind
+	p.SetFields(fields)
+	return true
+}
+
+// check returns the sequence number expected after prev, the number of missing sequence numbers before value
+// and the kind of error of value, which is empty if value is the expected sequence number.
+func (n *SequenceNode) check(prev, value int64) (expected, missing int64, kind string) {
+	if n.s.Max == 0 {
+		expected = prev + 1
+		switch {
+		case value > expected:
+			return expected, value - expected, sequenceGap
+		case value < expected:
+			return expected, 0, sequenceRegression
+		}
+		return expected, 0, ""
+	}
+
+	// The sequence numbers wrap around within the range [0, max].
+	size := uint64(n.s.Max) + 1
+	expected = int64((uint64(prev) + 1) % size)
+	ahead := (uint64(value) + size - uint64(expected)) % size
+	switch {
+	case ahead == 0:
+		return expected, 0, ""
+	case ahead < size/2:
+		return expected, int64(ahead), sequenceGap
+	}
+	return expected, 0, sequenceRegression
+}
+
+// sequenceNumber returns the integer value of a sequence number field.
+func sequenceNumber(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func (g *sequenceGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *sequenceGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *sequenceGroup) Done() {}
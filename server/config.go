@@ -47,6 +47,7 @@ import (
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
 	"github.com/influxdata/kapacitor/services/scraper"
+	"github.com/influxdata/kapacitor/services/secrets"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/serverset"
 	"github.com/influxdata/kapacitor/services/servicenow"
@@ -138,6 +139,7 @@ type Config struct {
 	Stats     stats.Config     `toml:"stats"`
 	UDF       udf.Config       `toml:"udf"`
 	Deadman   deadman.Config   `toml:"deadman"`
+	Secrets   secrets.Config   `toml:"secrets"`
 
 	Hostname               string `toml:"hostname"`
 	DataDir                string `toml:"data_dir"`
@@ -196,6 +198,7 @@ func NewConfig() *Config {
 	c.Stats = stats.NewConfig()
 	c.UDF = udf.NewConfig()
 	c.Deadman = deadman.NewConfig()
+	c.Secrets = secrets.NewConfig()
 	c.Load = load.NewConfig()
 
 	return c
@@ -248,12 +251,18 @@ func (c *Config) Validate() error {
 	if err := c.Task.Validate(); err != nil {
 		return errors.Wrap(err, "task")
 	}
+	if err := c.Alert.Validate(); err != nil {
+		return errors.Wrap(err, "alert")
+	}
 	if err := c.FluxTask.Validate(); err != nil {
 		return errors.Wrap(err, "fluxtask")
 	}
 	if err := c.TLS.Validate(); err != nil {
 		return errors.Wrap(err, "tls")
 	}
+	if err := c.Secrets.Validate(); err != nil {
+		return errors.Wrap(err, "secrets")
+	}
 	if err := c.Load.Validate(); err != nil {
 		return err
 	}
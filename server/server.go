@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/influxql"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/auth"
+	"github.com/influxdata/kapacitor/clock"
 	"github.com/influxdata/kapacitor/command"
 	iclient "github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/keyvalue"
@@ -60,6 +61,7 @@ import (
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
 	"github.com/influxdata/kapacitor/services/scraper"
+	"github.com/influxdata/kapacitor/services/secrets"
 	"github.com/influxdata/kapacitor/services/sensu"
 	"github.com/influxdata/kapacitor/services/serverset"
 	"github.com/influxdata/kapacitor/services/servicenow"
@@ -231,6 +233,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 	kd := diagService.NewKapacitorHandler()
 	s.TaskMaster = kapacitor.NewTaskMaster(kapacitor.MainTaskMaster, vars.Info, kd)
 	s.TaskMaster.DefaultRetentionPolicy = c.DefaultRetentionPolicy
+	s.TaskMaster.BatchParallelism = c.Task.BatchParallelism
 	s.TaskMaster.Commander = s.Commander
 	s.TaskMasterLookup.Set(s.TaskMaster)
 	if err := s.TaskMaster.Open(); err != nil {
@@ -252,6 +255,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service, disabl
 	s.appendConfigOverrideService()
 	s.appendTesterService()
 	s.appendSideloadService()
+	s.appendSecretsService()
 
 	// Init alert service
 	s.initAlertService()
@@ -415,6 +419,47 @@ func (s *Server) initAlertService() {
 	srv.PersistTopics = s.config.Alert.PersistTopics
 	s.AlertService = srv
 	s.TaskMaster.AlertService = srv
+
+	if s.config.Alert.RateLimit > 0 {
+		rl, err := kapacitor.NewAlertRateLimiter(kapacitor.AlertRateLimiterConfig{
+			Limit:          s.config.Alert.RateLimit,
+			Interval:       time.Duration(s.config.Alert.RateLimitInterval),
+			Overflow:       s.config.Alert.RateLimitOverflow,
+			MaxDeferred:    s.config.Alert.RateLimitMaxDeferred,
+			ExemptCritical: s.config.Alert.RateLimitExemptCritical,
+			Topic:          s.config.Alert.RateLimitTopic,
+		}, clock.Wall(), srv)
+		if err != nil {
+			s.Diag.Error("failed to create alert rate limiter", err)
+			return
+		}
+		s.TaskMaster.AlertRateLimiter = rl
+	}
+
+	if s.config.Alert.DedupWindow > 0 {
+		d, err := kapacitor.NewAlertDeduplicator(kapacitor.AlertDeduplicatorConfig{
+			Window: time.Duration(s.config.Alert.DedupWindow),
+			Tag:    s.config.Alert.DedupTag,
+		})
+		if err != nil {
+			s.Diag.Error("failed to create alert deduplicator", err)
+			return
+		}
+		s.TaskMaster.AlertDeduplicator = d
+	}
+
+	if s.config.Alert.DigestInterval > 0 {
+		d, err := kapacitor.NewAlertDigester(kapacitor.AlertDigesterConfig{
+			Interval: time.Duration(s.config.Alert.DigestInterval),
+			Topic:    s.config.Alert.DigestTopic,
+			PerTask:  s.config.Alert.DigestPerTask,
+		}, clock.Wall(), srv)
+		if err != nil {
+			s.Diag.Error("failed to create alert digester", err)
+			return
+		}
+		s.TaskMaster.AlertDigester = d
+	}
 }
 
 func (s *Server) appendAlertService() {
@@ -440,6 +485,13 @@ func (s *Server) appendTesterService() {
 	s.AppendService("tests", srv)
 }
 
+func (s *Server) appendSecretsService() {
+	srv := secrets.NewService(s.config.Secrets)
+
+	s.TaskMaster.SecretsService = srv
+	s.AppendService("secrets", srv)
+}
+
 func (s *Server) appendSideloadService() {
 	d := s.DiagService.NewSideloadHandler()
 	srv := sideload.NewService(d)
@@ -454,6 +506,7 @@ func (s *Server) appendSMTPService() {
 	c := s.config.SMTP
 	d := s.DiagService.NewSMTPHandler()
 	srv := smtp.NewService(c, d)
+	srv.SecretsService = s.TaskMaster.SecretsService
 
 	s.TaskMaster.SMTPService = srv
 	s.AlertService.SMTPService = srv
@@ -568,6 +621,7 @@ func (s *Server) appendReplayService() {
 	srv.TaskMaster = s.TaskMaster
 	srv.TaskMasterLookup = s.TaskMasterLookup
 
+	s.TaskMaster.RecordingService = srv
 	s.ReplayService = srv
 	s.AppendService("replay", srv)
 }
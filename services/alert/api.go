@@ -195,6 +195,11 @@ func (s *apiServer) handleRouteTopicGet(w http.ResponseWriter, r *http.Request)
 func (s *apiServer) handleRouteTopicPost(w http.ResponseWriter, r *http.Request) {
 	p := strings.TrimPrefix(r.URL.Path, topicsBasePathAnchored)
 	topic := s.topicIDFromPath(p)
+	if pathMatch(eventPattern, p) {
+		event := s.eventIDFromPath(p)
+		s.handleAcknowledgeEvent(topic, event, w, r)
+		return
+	}
 	s.handleCreateHandler(topic, w, r)
 }
 
@@ -268,11 +273,12 @@ func (s *apiServer) handleGetTopic(id string, w http.ResponseWriter, r *http.Req
 
 func (s *apiServer) convertEventStateToClient(state alert.EventState) client.EventState {
 	return client.EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: client.Duration(state.Duration),
-		Level:    state.Level.String(),
+		Message:      state.Message,
+		Details:      state.Details,
+		Time:         state.Time,
+		Duration:     client.Duration(state.Duration),
+		Level:        state.Level.String(),
+		Acknowledged: state.Acknowledged,
 	}
 }
 
@@ -334,6 +340,30 @@ func (s *apiServer) handleGetEvent(topic, eventID string, w http.ResponseWriter,
 	w.Write(httpd.MarshalJSON(event, true))
 }
 
+func (s *apiServer) handleAcknowledgeEvent(topic, eventID string, w http.ResponseWriter, r *http.Request) {
+	opt := client.TopicEventAcknowledgeOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid acknowledgement json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	state, ok, err := s.Topics.AcknowledgeEvent(topic, eventID, opt.Acknowledged)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to acknowledge event: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown event %q in topic %q", eventID, topic), true, http.StatusNotFound)
+		return
+	}
+	event := client.TopicEvent{
+		Link:  s.topicEventLink(topic, eventID),
+		ID:    eventID,
+		State: s.convertEventStateToClient(state),
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(event, true))
+}
+
 func (s *apiServer) handleListHandlers(topic string, w http.ResponseWriter, r *http.Request) {
 	pattern := r.URL.Query().Get("pattern")
 	if err := validatePattern(pattern); err != nil {
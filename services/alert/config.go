@@ -1,29 +1,96 @@
 package alert
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
 	"github.com/influxdata/kapacitor/alert"
+	"github.com/pkg/errors"
 )
 
 const (
 	DefaultShutdownTimeout = toml.Duration(time.Second * 10)
+
+	DefaultRateLimitInterval    = toml.Duration(time.Minute)
+	DefaultRateLimitOverflow    = "drop"
+	DefaultRateLimitMaxDeferred = 1000
+	DefaultRateLimitTopic       = "kapacitor_alert_rate_limit"
+
+	DefaultDigestTopic = "kapacitor_alert_digest"
 )
 
 type Config struct {
 	// Whether we persist the alert topics to BoltDB or not
 	PersistTopics     bool `toml:"persist-topics"`
 	TopicBufferLength int  `toml:"topic-buffer-length"`
+
+	// RateLimit is the maximum number of alert events dispatched across all tasks
+	// within each RateLimitInterval. A value of zero disables the global rate limit.
+	RateLimit         int           `toml:"rate-limit"`
+	RateLimitInterval toml.Duration `toml:"rate-limit-interval"`
+	// RateLimitOverflow is what happens to events once the limit is exceeded.
+	// Either "drop" to discard them or "defer" to dispatch them in later intervals.
+	RateLimitOverflow string `toml:"rate-limit-overflow"`
+	// RateLimitMaxDeferred is the maximum number of deferred events, further events are dropped.
+	RateLimitMaxDeferred int `toml:"rate-limit-max-deferred"`
+	// RateLimitExemptCritical allows CRITICAL events to bypass the rate limit.
+	RateLimitExemptCritical bool `toml:"rate-limit-exempt-critical"`
+	// RateLimitTopic is the topic which receives a summary event for each interval in which events were rate limited,
+	// at the end of the interval.
+	RateLimitTopic string `toml:"rate-limit-topic"`
+
+	// DedupWindow is the minimum time between the alerts sent at the same level for an incident
+	// shared by the alerts of several tasks. A value of zero disables the deduplication of alerts across tasks.
+	DedupWindow toml.Duration `toml:"dedup-window"`
+	// DedupTag is the tag of the alerts holding their dedup key.
+	// If empty the ID of the alerts is their dedup key.
+	DedupTag string `toml:"dedup-tag"`
+
+	// DigestInterval is the time between the digests of the alerts with a digest.
+	// A value of zero disables the digests, the events of the alerts are sent individually.
+	DigestInterval toml.Duration `toml:"digest-interval"`
+	// DigestTopic is the topic which receives the digest events.
+	DigestTopic string `toml:"digest-topic"`
+	// DigestPerTask sends a digest per task instead of a single digest of all tasks.
+	DigestPerTask bool `toml:"digest-per-task"`
 }
 
 func NewConfig() Config {
 	return Config{
-		PersistTopics:     true,
-		TopicBufferLength: alert.DefaultEventBufferSize,
+		PersistTopics:        true,
+		TopicBufferLength:    alert.DefaultEventBufferSize,
+		RateLimitInterval:    DefaultRateLimitInterval,
+		RateLimitOverflow:    DefaultRateLimitOverflow,
+		RateLimitMaxDeferred: DefaultRateLimitMaxDeferred,
+		RateLimitTopic:       DefaultRateLimitTopic,
+		DigestTopic:          DefaultDigestTopic,
 	}
 }
 
 func (c Config) Validate() error {
+	if c.RateLimit < 0 {
+		return errors.New("rate-limit must not be negative")
+	}
+	if c.RateLimit > 0 && c.RateLimitInterval <= 0 {
+		return errors.New("rate-limit-interval must be positive when rate-limit is set")
+	}
+	switch c.RateLimitOverflow {
+	case "", "drop", "defer":
+	default:
+		return fmt.Errorf("invalid rate-limit-overflow %q, must be one of 'drop' or 'defer'", c.RateLimitOverflow)
+	}
+	if c.RateLimitMaxDeferred < 0 {
+		return errors.New("rate-limit-max-deferred must not be negative")
+	}
+	if c.DedupWindow < 0 {
+		return errors.New("dedup-window must not be negative")
+	}
+	if c.DigestInterval < 0 {
+		return errors.New("digest-interval must not be negative")
+	}
+	if c.DigestInterval > 0 && c.DigestTopic == "" {
+		return errors.New("digest-topic must not be empty when digest-interval is set")
+	}
 	return nil
 }
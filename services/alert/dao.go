@@ -235,11 +235,14 @@ type TopicState struct {
 
 //easyjson:json
 type EventState struct {
-	Message  string        `json:"message,omitempty"`
-	Details  string        `json:"details,omitempty"`
-	Time     time.Time     `json:"time,omitempty"`
-	Duration time.Duration `json:"duration,omitempty"`
-	Level    alert.Level   `json:"level"`
+	Message      string           `json:"message,omitempty"`
+	Details      string           `json:"details,omitempty"`
+	Time         time.Time        `json:"time,omitempty"`
+	Duration     time.Duration    `json:"duration,omitempty"`
+	Level        alert.Level      `json:"level"`
+	Acknowledged bool             `json:"acknowledged,omitempty"`
+	Incidents    []alert.Incident `json:"incidents,omitempty"`
+	FireCount    int64            `json:"fire-count,omitempty"`
 }
 
 func (e *EventState) Reset() {
@@ -248,16 +251,22 @@ func (e *EventState) Reset() {
 	e.Time = time.Time{}
 	e.Duration = 0
 	e.Level = 0
+	e.Acknowledged = false
+	e.Incidents = nil
+	e.FireCount = 0
 }
 
 func (e *EventState) AlertEventState(id string) *alert.EventState {
 	return &alert.EventState{
-		ID:       id,
-		Message:  e.Message,
-		Details:  e.Details,
-		Time:     e.Time,
-		Duration: e.Duration,
-		Level:    e.Level,
+		ID:           id,
+		Message:      e.Message,
+		Details:      e.Details,
+		Time:         e.Time,
+		Duration:     e.Duration,
+		Level:        e.Level,
+		Acknowledged: e.Acknowledged,
+		Incidents:    e.Incidents,
+		FireCount:    e.FireCount,
 	}
 }
 
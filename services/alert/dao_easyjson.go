@@ -4,6 +4,7 @@ package alert
 
 import (
 	json "encoding/json"
+	alert "github.com/influxdata/kapacitor/alert"
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
@@ -154,6 +155,33 @@ func easyjson7be57abeDecodeGithubComInfluxdataKapacitorServicesAlert1(in *jlexer
 			if data := in.UnsafeBytes(); in.Ok() {
 				in.AddError((out.Level).UnmarshalText(data))
 			}
+		case "acknowledged":
+			out.Acknowledged = bool(in.Bool())
+		case "incidents":
+			if in.IsNull() {
+				in.Skip()
+				out.Incidents = nil
+			} else {
+				in.Delim('[')
+				if out.Incidents == nil {
+					if !in.IsDelim(']') {
+						out.Incidents = make([]alert.Incident, 0, 1)
+					} else {
+						out.Incidents = []alert.Incident{}
+					}
+				} else {
+					out.Incidents = (out.Incidents)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v3 alert.Incident
+					easyjson7be57abeDecodeGithubComInfluxdataKapacitorAlert(in, &v3)
+					out.Incidents = append(out.Incidents, v3)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "fire-count":
+			out.FireCount = int64(in.Int64())
 		default:
 			in.SkipRecursive()
 		}
@@ -214,6 +242,30 @@ func easyjson7be57abeEncodeGithubComInfluxdataKapacitorServicesAlert1(out *jwrit
 		}
 		out.RawText((in.Level).MarshalText())
 	}
+	if in.Acknowledged {
+		const prefix string = ",\"acknowledged\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Acknowledged))
+	}
+	if len(in.Incidents) != 0 {
+		const prefix string = ",\"incidents\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v4, v5 := range in.Incidents {
+				if v4 > 0 {
+					out.RawByte(',')
+				}
+				easyjson7be57abeEncodeGithubComInfluxdataKapacitorAlert(out, v5)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.FireCount != 0 {
+		const prefix string = ",\"fire-count\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.FireCount))
+	}
 	out.RawByte('}')
 }
 
@@ -240,3 +292,63 @@ func (v *EventState) UnmarshalJSON(data []byte) error {
 func (v *EventState) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson7be57abeDecodeGithubComInfluxdataKapacitorServicesAlert1(l, v)
 }
+func easyjson7be57abeDecodeGithubComInfluxdataKapacitorAlert(in *jlexer.Lexer, out *alert.Incident) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "time":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Time).UnmarshalJSON(data))
+			}
+		case "duration":
+			out.Duration = time.Duration(in.Int64())
+		case "level":
+			if data := in.UnsafeBytes(); in.Ok() {
+				in.AddError((out.Level).UnmarshalText(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7be57abeEncodeGithubComInfluxdataKapacitorAlert(out *jwriter.Writer, in alert.Incident) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"time\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.Time).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"duration\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Duration))
+	}
+	{
+		const prefix string = ",\"level\":"
+		out.RawString(prefix)
+		out.RawText((in.Level).MarshalText())
+	}
+	out.RawByte('}')
+}
@@ -2,11 +2,39 @@ package alert_test
 
 import (
 	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	kapacitoralert "github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alert/alerttest"
-	"testing"
 )
 
+func TestEventState_MarshalJSON_Incidents(t *testing.T) {
+	exp := alert.EventState{
+		Message:  "message",
+		Time:     time.Date(2020, 1, 1, 0, 10, 0, 0, time.UTC),
+		Duration: time.Minute,
+		Level:    kapacitoralert.Critical,
+		Incidents: []kapacitoralert.Incident{
+			{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Duration: 5 * time.Minute, Level: kapacitoralert.Warning},
+		},
+		FireCount: 2,
+	}
+	data, err := exp.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got alert.EventState
+	if err := got.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected event state:\ngot %v\nexp %v", got, exp)
+	}
+}
+
 func BenchmarkTopicState_MarshalBinary(b *testing.B) {
 	benchmarks := []struct {
 		n int
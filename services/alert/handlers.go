@@ -82,6 +82,15 @@ func (h *logHandler) Handle(event alert.Event) {
 	}
 }
 
+// Render returns the line of JSON the handler appends to its log file for the event.
+func (h *logHandler) Render(event alert.Event) (alert.Payload, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(event.AlertData()); err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.Payload{Destination: h.logpath, Body: buf.Bytes()}, nil
+}
+
 type ExecHandlerConfig struct {
 	Prog      string            `mapstructure:"prog"`
 	Args      []string          `mapstructure:"args"`
@@ -136,6 +145,15 @@ func (h *execHandler) Handle(event alert.Event) {
 	}
 }
 
+// Render returns the JSON the handler writes to the stdin of its command for the event.
+func (h *execHandler) Render(event alert.Event) (alert.Payload, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(event.AlertData()); err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.Payload{Destination: strings.Join(append([]string{h.s.Prog}, h.s.Args...), " "), Body: buf.Bytes()}, nil
+}
+
 type TCPHandlerConfig struct {
 	Address string `mapstructure:"address"`
 }
@@ -176,6 +194,16 @@ func (h *tcpHandler) Handle(event alert.Event) {
 	conn.Write(buf.Bytes())
 }
 
+// Render returns the JSON the handler writes to its address for the event.
+func (h *tcpHandler) Render(event alert.Event) (alert.Payload, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(event.AlertData()); err != nil {
+		return alert.Payload{}, err
+	}
+	buf.WriteByte('\n')
+	return alert.Payload{Destination: h.addr, Body: buf.Bytes()}, nil
+}
+
 type AggregateHandlerConfig struct {
 	ID       string        `mapstructure:"id"`
 	Interval time.Duration `mapstructure:"interval"`
@@ -389,22 +389,28 @@ func (s *Service) loadSavedHandlerSpecs() error {
 
 func convertEventStateToAlert(id string, state *EventState) *alert.EventState {
 	return &alert.EventState{
-		ID:       id,
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+		ID:           id,
+		Message:      state.Message,
+		Details:      state.Details,
+		Time:         state.Time,
+		Duration:     state.Duration,
+		Level:        state.Level,
+		Acknowledged: state.Acknowledged,
+		Incidents:    state.Incidents,
+		FireCount:    state.FireCount,
 	}
 }
 
 func convertEventStateFromAlert(state alert.EventState) *EventState {
 	return &EventState{
-		Message:  state.Message,
-		Details:  state.Details,
-		Time:     state.Time,
-		Duration: state.Duration,
-		Level:    state.Level,
+		Message:      state.Message,
+		Details:      state.Details,
+		Time:         state.Time,
+		Duration:     state.Duration,
+		Level:        state.Level,
+		Acknowledged: state.Acknowledged,
+		Incidents:    state.Incidents,
+		FireCount:    state.FireCount,
 	}
 }
 
@@ -473,6 +479,20 @@ func (s *Service) setTopicHandler(topic, id string, h handler) {
 }
 
 func (s *Service) Collect(event alert.Event) error {
+	return s.collect(event, s.topics.Collect)
+}
+
+// RecordEvent updates and persists the state of the event in its topic,
+// without sending it to the handlers of the topic.
+func (s *Service) RecordEvent(event alert.Event) error {
+	return s.collect(event, func(event alert.Event) error {
+		s.topics.Record(event)
+		return nil
+	})
+}
+
+// collect collects the event with the collect function and persists its state.
+func (s *Service) collect(event alert.Event, collect func(alert.Event) error) error {
 	s.mu.RLock()
 	closed := s.closedTopics[event.Topic]
 	s.mu.RUnlock()
@@ -483,7 +503,7 @@ func (s *Service) Collect(event alert.Event) error {
 		}
 	}
 
-	err := s.topics.Collect(event)
+	err := collect(event)
 	if err != nil {
 		return err
 	}
@@ -495,6 +515,10 @@ func (s *Service) Collect(event alert.Event) error {
 			return nil
 		}
 	} else {
+		// Persist any acknowledgement carried over from the previous state.
+		if state, ok := s.topics.EventState(event.Topic, event.State.ID); ok {
+			event.State.Acknowledged = state.Acknowledged
+		}
 		return s.persistEventState(event)
 	}
 }
@@ -623,6 +647,22 @@ func (s *Service) UpdateEvent(topic string, event alert.EventState) error {
 	})
 }
 
+// AcknowledgeEvent sets whether an existing event is acknowledged.
+// An acknowledged event suppresses reminders of its alert until it recovers or its level increases.
+func (s *Service) AcknowledgeEvent(topic, event string, acknowledged bool) (alert.EventState, bool, error) {
+	state, ok := s.topics.AcknowledgeEvent(topic, event, acknowledged)
+	if !ok {
+		return alert.EventState{}, false, nil
+	}
+	if err := s.persistEventState(alert.Event{
+		Topic: topic,
+		State: state,
+	}); err != nil {
+		return alert.EventState{}, false, err
+	}
+	return state, true, nil
+}
+
 func (s *Service) RegisterAnonHandler(topic string, h alert.Handler) {
 	s.topics.RegisterHandler(topic, h)
 }
@@ -31,6 +31,8 @@ type Topics interface {
 	// EventStates returns the current state of events for the specified topic.
 	// Only events greater or equal to minLevel will be returned
 	EventStates(topic string, minLevel alert.Level) (map[string]alert.EventState, error)
+	// AcknowledgeEvent sets whether the event is acknowledged and returns its updated state.
+	AcknowledgeEvent(topic, event string, acknowledged bool) (alert.EventState, bool, error)
 }
 
 // AnonHandlerRegistrar is responsible for directly registering handlers for anonymous topics.
@@ -50,6 +52,8 @@ type EventCollector interface {
 // Events is responsible for accepting events for processing and reporting on the state of events.
 type Events interface {
 	EventCollector
+	// RecordEvent updates the state of an event in its topic without sending it to the handlers of the topic.
+	RecordEvent(event alert.Event) error
 	// UpdateEvent updates an existing event with a previously known state.
 	UpdateEvent(topic string, event alert.EventState) error
 	// EventState returns the current events state.
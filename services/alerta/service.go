@@ -141,15 +141,23 @@ func (s *Service) Update(newConfig []interface{}) error {
 }
 
 func (s *Service) Alert(token, tokenPrefix, resource, event, environment, severity, group, value, message, origin string, service []string, correlate []string, attributes map[string]interface{}, timeout time.Duration, tags map[string]string, data models.Result) error {
-	if resource == "" || event == "" {
-		return errors.New("Resource and Event are required to send an alert")
-	}
-
-	req, err := s.preparePost(token, tokenPrefix, resource, event, environment, severity, group, value, message, origin, service, correlate, attributes, timeout, tags, data)
+	req, err := s.prepareAlert(token, tokenPrefix, resource, event, environment, severity, group, value, message, origin, service, correlate, attributes, timeout, tags, data)
 	if err != nil {
 		return err
 	}
+	return s.send(req)
+}
+
+// prepareAlert validates an alert and prepares its request.
+func (s *Service) prepareAlert(token, tokenPrefix, resource, event, environment, severity, group, value, message, origin string, service []string, correlate []string, attributes map[string]interface{}, timeout time.Duration, tags map[string]string, data models.Result) (*http.Request, error) {
+	if resource == "" || event == "" {
+		return nil, errors.New("Resource and Event are required to send an alert")
+	}
+	return s.preparePost(token, tokenPrefix, resource, event, environment, severity, group, value, message, origin, service, correlate, attributes, timeout, tags, data)
+}
 
+// send sends the request of an alert.
+func (s *Service) send(req *http.Request) error {
 	client := s.clientValue.Load().(*http.Client)
 	resp, err := client.Do(req)
 	if err != nil {
@@ -414,13 +422,15 @@ type eventData struct {
 	Tags map[string]string
 }
 
-func (h *handler) Handle(event alert.Event) {
+// prepare renders the templates of the handler for the event and prepares the request of the alert.
+// Any error is reported before it is returned.
+func (h *handler) prepare(event alert.Event) (*http.Request, error) {
 	td := event.TemplateData()
 	var buf bytes.Buffer
 	err := h.resourceTmpl.Execute(&buf, td)
 	if err != nil {
 		h.diag.TemplateError(err, keyvalue.KV("resource", h.c.Resource))
-		return
+		return nil, err
 	}
 	resource := buf.String()
 	buf.Reset()
@@ -435,7 +445,7 @@ func (h *handler) Handle(event alert.Event) {
 	err = h.eventTmpl.Execute(&buf, data)
 	if err != nil {
 		h.diag.TemplateError(err, keyvalue.KV("event", h.c.Event))
-		return
+		return nil, err
 	}
 	eventStr := buf.String()
 	buf.Reset()
@@ -443,7 +453,7 @@ func (h *handler) Handle(event alert.Event) {
 	err = h.environmentTmpl.Execute(&buf, td)
 	if err != nil {
 		h.diag.TemplateError(err, keyvalue.KV("environment", h.c.Environment))
-		return
+		return nil, err
 	}
 	environment := buf.String()
 	buf.Reset()
@@ -451,7 +461,7 @@ func (h *handler) Handle(event alert.Event) {
 	err = h.groupTmpl.Execute(&buf, td)
 	if err != nil {
 		h.diag.TemplateError(err, keyvalue.KV("group", h.c.Group))
-		return
+		return nil, err
 	}
 	group := buf.String()
 	buf.Reset()
@@ -459,7 +469,7 @@ func (h *handler) Handle(event alert.Event) {
 	err = h.valueTmpl.Execute(&buf, td)
 	if err != nil {
 		h.diag.TemplateError(err, keyvalue.KV("value", h.c.Value))
-		return
+		return nil, err
 	}
 	value := buf.String()
 	buf.Reset()
@@ -472,7 +482,7 @@ func (h *handler) Handle(event alert.Event) {
 			err = tmpl.Execute(&buf, td)
 			if err != nil {
 				h.diag.TemplateError(err, keyvalue.KV("service", tmpl.Name()))
-				return
+				return nil, err
 			}
 			service = append(service, buf.String())
 			buf.Reset()
@@ -488,7 +498,7 @@ func (h *handler) Handle(event alert.Event) {
 			err = tmpl.Execute(&buf, td)
 			if err != nil {
 				h.diag.TemplateError(err, keyvalue.KV("correlate", tmpl.Name()))
-				return
+				return nil, err
 			}
 			correlate = append(correlate, buf.String())
 			buf.Reset()
@@ -503,7 +513,7 @@ func (h *handler) Handle(event alert.Event) {
 				err = value.Execute(&buf, td)
 				if err != nil {
 					h.diag.TemplateError(err, keyvalue.KV("attributes", value.Name()))
-					return
+					return nil, err
 				}
 				attributes[k] = buf.String()
 				buf.Reset()
@@ -528,7 +538,7 @@ func (h *handler) Handle(event alert.Event) {
 		severity = "indeterminate"
 	}
 
-	if err := h.s.Alert(
+	req, err := h.s.prepareAlert(
 		h.c.Token,
 		h.c.TokenPrefix,
 		resource,
@@ -545,7 +555,29 @@ func (h *handler) Handle(event alert.Event) {
 		h.c.Timeout,
 		event.Data.Tags,
 		event.Data.Result,
-	); err != nil {
+	)
+	if err != nil {
+		h.diag.Error("failed to send event to Alerta", err)
+		return nil, err
+	}
+	return req, nil
+}
+
+func (h *handler) Handle(event alert.Event) {
+	req, err := h.prepare(event)
+	if err != nil {
+		return
+	}
+	if err := h.s.send(req); err != nil {
 		h.diag.Error("failed to send event to Alerta", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	req, err := h.prepare(event)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(req.URL.String(), req.Body)
+}
@@ -359,6 +359,29 @@ func (h *handler) Handle(event alert.Event) {
 	}
 }
 
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	td := event.TemplateData()
+	attrs, err := h.renderAttributes(&td)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	req, err := h.s.preparePost(
+		event.State.ID,
+		event.State.Message,
+		event.State.Details,
+		event.State.Level,
+		event.State.Time,
+		event.Data,
+		&h.c,
+		attrs,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(req.URL.String(), req.Body)
+}
+
 func (h *handler) renderAttributes(td *alert.TemplateData) (map[string]string, error) {
 	var buf bytes.Buffer
 	render := func(name, template string) (string, error) {
@@ -369,3 +369,24 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to Discord", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	var buf bytes.Buffer
+	if err := h.embedTitleTmpl.Execute(&buf, event.TemplateData()); err != nil {
+		return alert.Payload{}, err
+	}
+	url, post, err := h.s.preparePost(
+		h.c.Workspace,
+		event.State.Message,
+		h.c.Username,
+		h.c.AvatarURL,
+		buf.String(),
+		event.State.Time,
+		event.State.Level,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
@@ -317,27 +317,43 @@ func (h *handler) NewHTTPRequest(body io.Reader, tmplCTX interface{}) (req *http
 	return
 }
 
-func (h *handler) Handle(event alert.Event) {
-	var err error
-
-	// Construct the body of the HTTP request
+// body constructs the body of the HTTP request of the alert data, and returns its content type if it is known.
+func (h *handler) body(ad alert.Data) (*bytes.Buffer, string, error) {
 	body := new(bytes.Buffer)
-	ad := event.AlertData()
-
-	var contentType string
 	if h.endpoint.AlertTemplate() != nil {
-		err := h.endpoint.AlertTemplate().Execute(body, ad)
-		if err != nil {
-			h.diag.Error("failed to execute alert template", err)
-			return
+		if err := h.endpoint.AlertTemplate().Execute(body, ad); err != nil {
+			return nil, "", errors.Wrap(err, "failed to execute alert template")
 		}
-	} else {
-		err = json.NewEncoder(body).Encode(ad)
-		if err != nil {
-			h.diag.Error("failed to marshal alert data json", err)
-			return
-		}
-		contentType = "application/json"
+		return body, "", nil
+	}
+	if err := json.NewEncoder(body).Encode(ad); err != nil {
+		return nil, "", errors.Wrap(err, "failed to marshal alert data json")
+	}
+	return body, "application/json", nil
+}
+
+// Render returns the URL and body of the HTTP request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	ad := event.AlertData()
+	body, _, err := h.body(ad)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	req, err := h.NewHTTPRequest(body, ad)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(req.URL.String(), body)
+}
+
+func (h *handler) Handle(event alert.Event) {
+	ad := event.AlertData()
+
+	// Construct the body of the HTTP request
+	body, contentType, err := h.body(ad)
+	if err != nil {
+		h.diag.Error("failed to construct the body of the request", err)
+		return
 	}
 
 	req, err := h.NewHTTPRequest(body, ad)
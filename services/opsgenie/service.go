@@ -211,17 +211,10 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 }
 
 func (h *handler) Handle(event alert.Event) {
-	var messageType string
-	switch event.State.Level {
-	case alert.OK:
-		messageType = "RECOVERY"
-	default:
-		messageType = event.State.Level.String()
-	}
 	if err := h.s.Alert(
 		h.c.TeamsList,
 		h.c.RecipientsList,
-		messageType,
+		messageType(event.State.Level),
 		event.State.Message,
 		event.State.ID,
 		event.State.Time,
@@ -230,3 +223,28 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to OpsGenie", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, post, err := h.s.preparePost(
+		h.c.TeamsList,
+		h.c.RecipientsList,
+		messageType(event.State.Level),
+		event.State.Message,
+		event.State.ID,
+		event.State.Time,
+		event.Data.Result,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
+
+// messageType returns the OpsGenie message type of the level of an event.
+func messageType(level alert.Level) string {
+	if level == alert.OK {
+		return "RECOVERY"
+	}
+	return level.String()
+}
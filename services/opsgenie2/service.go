@@ -305,3 +305,22 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to OpsGenie", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	req, err := h.s.preparePost(
+		h.c.TeamsList,
+		h.c.RecipientsList,
+		h.c.RecoveryAction,
+		event.State.Level,
+		event.State.Message,
+		event.State.ID,
+		event.State.Time,
+		event.State.Details,
+		event.Data.Result,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(req.URL.String(), req.Body)
+}
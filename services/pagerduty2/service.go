@@ -369,16 +369,15 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, er
 }
 
 // Handle is a bound method to the handler that processes a given alert
-func (h *handler) Handle(event alert.Event) {
-	// Execute templates
+// renderLinks executes the templates of the links of the handler for the event.
+func (h *handler) renderLinks(event alert.Event) error {
 	td := event.TemplateData()
 	var hrefBuf bytes.Buffer
 	var textBuf bytes.Buffer
 	for i, l := range h.c.Links {
 		err := l.hrefTmpl.Execute(&hrefBuf, td)
 		if err != nil {
-			h.diag.Error("failed to handle event", err)
-			return
+			return err
 		}
 		h.c.Links[i].Href = hrefBuf.String()
 		hrefBuf.Reset()
@@ -386,8 +385,7 @@ func (h *handler) Handle(event alert.Event) {
 		if l.textTmpl != nil {
 			err = l.textTmpl.Execute(&textBuf, td)
 			if err != nil {
-				h.diag.Error("failed to handle event", err)
-				return
+				return err
 			}
 			h.c.Links[i].Text = textBuf.String()
 			textBuf.Reset()
@@ -395,6 +393,15 @@ func (h *handler) Handle(event alert.Event) {
 			h.c.Links[i].Text = h.c.Links[i].Href
 		}
 	}
+	return nil
+}
+
+func (h *handler) Handle(event alert.Event) {
+	// Execute templates
+	if err := h.renderLinks(event); err != nil {
+		h.diag.Error("failed to handle event", err)
+		return
+	}
 
 	if err := h.s.Alert(
 		h.c.RoutingKey,
@@ -408,3 +415,23 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to PagerDuty", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	if err := h.renderLinks(event); err != nil {
+		return alert.Payload{}, err
+	}
+	url, post, err := h.s.preparePost(
+		h.c.RoutingKey,
+		h.c.Links,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+		event.State.Time,
+		event.Data,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
@@ -274,3 +274,21 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to Pushover", err)
 	}
 }
+
+// Render returns the URL and form of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, post, err := h.s.preparePost(
+		event.State.Message,
+		h.c.Device,
+		h.c.Title,
+		h.c.URL,
+		h.c.URLTitle,
+		h.c.Sound,
+		h.c.UserKey,
+		event.State.Level,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.Payload{Destination: url, Body: []byte(post.Encode())}, nil
+}
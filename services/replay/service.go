@@ -591,6 +591,40 @@ func (s *Service) handleDeleteRecording(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RecordingReaders returns the readers of the data of a finished recording and whether it is a stream recording.
+func (s *Service) RecordingReaders(id string) ([]io.ReadCloser, bool, error) {
+	if s.recordings == nil {
+		return nil, false, errors.New("recordings are not available")
+	}
+	recording, err := s.recordings.Get(id)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "error finding recording")
+	}
+	if recording.Status != Finished {
+		return nil, false, fmt.Errorf("recording %s is not finished", id)
+	}
+	dataSource, err := parseDataSourceURL(recording.DataURL)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "load data source")
+	}
+	switch recording.Type {
+	case StreamRecording:
+		f, err := dataSource.StreamReader()
+		if err != nil {
+			return nil, false, errors.Wrap(err, "data source open")
+		}
+		return []io.ReadCloser{f}, true, nil
+	case BatchRecording:
+		fs, err := dataSource.BatchReaders()
+		if err != nil {
+			return nil, false, errors.Wrap(err, "data source open")
+		}
+		return fs, false, nil
+	default:
+		return nil, false, fmt.Errorf("unknown recording type %v", recording.Type)
+	}
+}
+
 func (s *Service) dataURLFromID(id, ext string) url.URL {
 	return url.URL{
 		Scheme: "file",
@@ -0,0 +1,35 @@
+package secrets
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultEnvPrefix = "KAPACITOR_SECRET_"
+
+type Config struct {
+	// Path to a TOML file of secret names and values.
+	// Secrets not found in the file are resolved from environment variables.
+	File string `toml:"file"`
+	// Only secrets whose name starts with EnvPrefix are resolved from environment variables,
+	// so that tasks cannot read arbitrary variables of the Kapacitor process.
+	// If empty secrets are never resolved from environment variables.
+	EnvPrefix string `toml:"env-prefix"`
+}
+
+func NewConfig() Config {
+	return Config{
+		EnvPrefix: DefaultEnvPrefix,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.File == "" {
+		return nil
+	}
+	if _, err := os.Stat(c.File); err != nil {
+		return errors.Wrap(err, "invalid secrets file")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// Service resolves secrets referenced by name,
+// so that credentials need not be embedded in task definitions.
+type Service struct {
+	c Config
+
+	mu      sync.RWMutex
+	secrets map[string]string
+}
+
+func NewService(c Config) *Service {
+	return &Service{
+		c:       c,
+		secrets: make(map[string]string),
+	}
+}
+
+func (s *Service) Open() error {
+	if s.c.File == "" {
+		return nil
+	}
+	secrets := make(map[string]string)
+	if _, err := toml.DecodeFile(s.c.File, &secrets); err != nil {
+		return errors.Wrapf(err, "failed to load secrets file %q", s.c.File)
+	}
+	s.mu.Lock()
+	s.secrets = secrets
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// Secret returns the value of the named secret.
+// Secrets from the secrets file take precedence over environment variables,
+// which are only used for names starting with the configured prefix.
+func (s *Service) Secret(name string) (string, error) {
+	s.mu.RLock()
+	v, ok := s.secrets[name]
+	s.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+	if s.c.EnvPrefix != "" && strings.HasPrefix(name, s.c.EnvPrefix) {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown secret %q", name)
+}
@@ -0,0 +1,50 @@
+package secrets_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/services/secrets"
+)
+
+func TestService_Secret(t *testing.T) {
+	t.Setenv("SLACK_TOKEN", "env-token")
+	t.Setenv("ALERTA_TOKEN", "alerta-token")
+	t.Setenv("KAPACITOR_SECRET_ALERTA_TOKEN", "prefixed-alerta-token")
+
+	c := secrets.NewConfig()
+	c.File = "testdata/secrets.toml"
+	s := secrets.NewService(c)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	testCases := []struct {
+		name string
+		exp  string
+		err  bool
+	}{
+		{name: "SLACK_TOKEN", exp: "file-token"},
+		{name: "HIPCHAT_TOKEN", exp: "hipchat-token"},
+		{name: "KAPACITOR_SECRET_ALERTA_TOKEN", exp: "prefixed-alerta-token"},
+		// Environment variables without the prefix are not secrets.
+		{name: "ALERTA_TOKEN", err: true},
+		{name: "MISSING_TOKEN", err: true},
+	}
+	for _, tc := range testCases {
+		got, err := s.Secret(tc.name)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if got != tc.exp {
+			t.Errorf("%s: unexpected secret: got %q exp %q", tc.name, got, tc.exp)
+		}
+	}
+}
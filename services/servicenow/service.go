@@ -378,3 +378,19 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to ServiceNow", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	postUrl, post, err := h.s.preparePost(
+		h.c.URL,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+		&event.Data,
+		&h.c,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(postUrl, post)
+}
@@ -2,6 +2,7 @@ package sideload
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -213,10 +214,6 @@ func (s *fileSource) UpdateCache() error {
 		if info.IsDir() {
 			return nil
 		}
-		values, err := readValues(path)
-		if err != nil {
-			return err
-		}
 		rel, err := filepath.Rel(s.dir, path)
 		if err != nil {
 			return err
@@ -227,6 +224,20 @@ func (s *fileSource) UpdateCache() error {
 		if len(rel) == 0 || rel[0] == '.' {
 			return errors.New("invalid relative path")
 		}
+		if filepath.Ext(path) == ".csv" {
+			rows, err := readCSVValues(path)
+			if err != nil {
+				return err
+			}
+			for key, values := range rows {
+				s.cache[filepath.Join(rel, key)] = values
+			}
+			return nil
+		}
+		values, err := readValues(path)
+		if err != nil {
+			return err
+		}
 		s.cache[rel] = values
 		return nil
 	})
@@ -317,6 +328,36 @@ func readValues(p string) (map[string]interface{}, error) {
 	return values, nil
 }
 
+// readCSVValues reads a CSV file with a header row.
+// The first column of each row is the key of the row,
+// and the remaining columns are the values of the row keyed by the column names.
+func readCSVValues(p string) (map[string]map[string]interface{}, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open values file %q", p)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read csv values %q", p)
+	}
+	rows := make(map[string]map[string]interface{})
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		values := make(map[string]interface{}, len(header)-1)
+		for i := 1; i < len(header); i++ {
+			if record[i] != "" {
+				values[header[i]] = record[i]
+			}
+		}
+		rows[record[0]] = values
+	}
+	return rows, nil
+}
+
 func loadValues(resp io.ReadCloser) (map[string]map[string]interface{}, error) {
 	data, err := io.ReadAll(resp)
 	if err != nil {
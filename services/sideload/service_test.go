@@ -74,6 +74,38 @@ func TestService_Source_Lookup(t *testing.T) {
 			key:  "key1",
 			want: "foo",
 		},
+		{
+			order: []string{
+				"thresholds.csv/hostA",
+				"default.yml",
+			},
+			key:  "threshold_crit",
+			want: "90",
+		},
+		{
+			order: []string{
+				"thresholds.csv/hostA",
+				"default.yml",
+			},
+			key:  "key0",
+			want: 0.0,
+		},
+		{
+			order: []string{
+				"thresholds.csv/hostB",
+				"default.yml",
+			},
+			key:  "key0",
+			want: "7.5",
+		},
+		{
+			order: []string{
+				"thresholds.csv/hostC",
+				"default.yml",
+			},
+			key:  "threshold_crit",
+			want: nil,
+		},
 	}
 	for i, tc := range testCases {
 		tc := tc
@@ -225,10 +225,18 @@ func (s *Service) Test(options interface{}) error {
 }
 
 func (s *Service) Alert(workspace, channel, message, username, iconEmoji string, level alert.Level) error {
-	url, token, post, err := s.preparePost(workspace, channel, message, username, iconEmoji, level)
+	return s.alert(workspace, channel, message, username, iconEmoji, "", level)
+}
+
+// alert posts the message to Slack, using token in place of the configured token if it is not empty.
+func (s *Service) alert(workspace, channel, message, username, iconEmoji, token string, level alert.Level) error {
+	url, configToken, post, err := s.preparePost(workspace, channel, message, username, iconEmoji, level)
 	if err != nil {
 		return err
 	}
+	if token == "" {
+		token = configToken
+	}
 
 	client, err := s.client(workspace)
 	if err != nil {
@@ -333,6 +341,10 @@ type HandlerConfig struct {
 	// IconEmoji is an emoji name surrounded in ':' characters.
 	// The emoji image will replace the normal user icon for the slack bot.
 	IconEmoji string `mapstructure:"icon-emoji"`
+
+	// Token used to authenticate with Slack.
+	// If empty uses the token from the configuration.
+	Token string `mapstructure:"token"`
 }
 
 type handler struct {
@@ -351,14 +363,31 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 
 func (h *handler) Handle(event alert.Event) {
 
-	if err := h.s.Alert(
+	if err := h.s.alert(
 		h.c.Workspace,
 		h.c.Channel,
 		event.State.Message,
 		h.c.Username,
 		h.c.IconEmoji,
+		h.c.Token,
 		event.State.Level,
 	); err != nil {
 		h.diag.Error("failed to send event", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, _, post, err := h.s.preparePost(
+		h.c.Workspace,
+		h.c.Channel,
+		event.State.Message,
+		h.c.Username,
+		h.c.IconEmoji,
+		event.State.Level,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
@@ -15,6 +15,8 @@ type Config struct {
 	Port     int    `toml:"port" override:"port"`
 	Username string `toml:"username" override:"username"`
 	Password string `toml:"password" override:"password,redact"`
+	// Name of a secret holding the password, taking precedence over Password.
+	PasswordRef string `toml:"password-ref" override:"password-ref"`
 	// Whether to skip TLS verify.
 	NoVerify bool `toml:"no-verify" override:"no-verify"`
 	// Whether all alerts should trigger an email.
@@ -31,6 +31,10 @@ type Service struct {
 	diag        Diagnostic
 	wg          sync.WaitGroup
 	opened      bool
+
+	SecretsService interface {
+		Secret(name string) (string, error)
+	}
 }
 
 func NewService(c Config, d Diagnostic) *Service {
@@ -110,7 +114,17 @@ func (s *Service) StateChangesOnly() bool {
 
 func (s *Service) dialer() (d *gomail.Dialer, idleTimeout time.Duration) {
 	c := s.config()
-	d = &gomail.Dialer{Host: c.Host, Port: c.Port, Username: c.Username, Password: c.Password}
+	password := c.Password
+	if c.PasswordRef != "" {
+		// Resolved for each dialer so that updated secrets are used after a config update.
+		p, err := s.password(c.PasswordRef)
+		if err != nil {
+			s.diag.Error("failed to resolve SMTP password", err)
+		} else {
+			password = p
+		}
+	}
+	d = &gomail.Dialer{Host: c.Host, Port: c.Port, Username: c.Username, Password: password}
 	if c.NoVerify {
 		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 	}
@@ -118,6 +132,13 @@ func (s *Service) dialer() (d *gomail.Dialer, idleTimeout time.Duration) {
 	return
 }
 
+func (s *Service) password(ref string) (string, error) {
+	if s.SecretsService == nil {
+		return "", fmt.Errorf("cannot resolve secret %q, no secrets service configured", ref)
+	}
+	return s.SecretsService.Secret(ref)
+}
+
 func (s *Service) runMailer() {
 	var idleTimeout time.Duration
 	var d *gomail.Dialer
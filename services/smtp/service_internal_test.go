@@ -0,0 +1,53 @@
+package smtp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/kapacitor/keyvalue"
+)
+
+type testSecrets map[string]string
+
+func (s testSecrets) Secret(name string) (string, error) {
+	if v, ok := s[name]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown secret %q", name)
+}
+
+type testDiag struct {
+	errors []error
+}
+
+func (d *testDiag) WithContext(ctx ...keyvalue.T) Diagnostic { return d }
+func (d *testDiag) Error(msg string, err error)              { d.errors = append(d.errors, err) }
+
+func Test_dialerPasswordRef(t *testing.T) {
+	testCases := []struct {
+		name        string
+		password    string
+		passwordRef string
+		exp         string
+		expErr      bool
+	}{
+		{name: "password", password: "literal", exp: "literal"},
+		{name: "password ref", password: "literal", passwordRef: "SMTP_PASSWORD", exp: "secret"},
+		{name: "unknown ref", password: "literal", passwordRef: "MISSING", exp: "literal", expErr: true},
+	}
+	for _, tc := range testCases {
+		c := NewConfig()
+		c.Password = tc.password
+		c.PasswordRef = tc.passwordRef
+		d := new(testDiag)
+		s := NewService(c, d)
+		s.SecretsService = testSecrets{"SMTP_PASSWORD": "secret"}
+		dialer, _ := s.dialer()
+		if dialer.Password != tc.exp {
+			t.Errorf("%s: unexpected password: got %q exp %q", tc.name, dialer.Password, tc.exp)
+		}
+		if got := len(d.errors) > 0; got != tc.expErr {
+			t.Errorf("%s: unexpected errors: %v", tc.name, d.errors)
+		}
+	}
+}
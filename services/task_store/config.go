@@ -1,6 +1,7 @@
 package task_store
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
@@ -10,15 +11,21 @@ type Config struct {
 	// Deprecated, only needed to find old db and migrate
 	Dir              string        `toml:"dir"`
 	SnapshotInterval toml.Duration `toml:"snapshot-interval"`
+	// The number of groups of a batch that are processed concurrently, by the nodes that support it.
+	BatchParallelism int `toml:"batch-parallelism"`
 }
 
 func NewConfig() Config {
 	return Config{
 		Dir:              "./tasks",
 		SnapshotInterval: toml.Duration(time.Minute),
+		BatchParallelism: 1,
 	}
 }
 
 func (c Config) Validate() error {
+	if c.BatchParallelism < 1 {
+		return fmt.Errorf("batch-parallelism must be at least 1, got %d", c.BatchParallelism)
+	}
 	return nil
 }
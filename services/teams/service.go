@@ -221,3 +221,18 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to Teams", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, post, err := h.s.preparePost(
+		h.c.ChannelURL,
+		event.Topic,
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
@@ -226,3 +226,18 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to Telegram", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, post, err := h.s.preparePost(
+		h.c.ChatId,
+		h.c.ParseMode,
+		event.State.Message,
+		h.c.DisableWebPagePreview,
+		h.c.DisableNotification,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
@@ -191,16 +191,9 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 }
 
 func (h *handler) Handle(event alert.Event) {
-	var messageType string
-	switch event.State.Level {
-	case alert.OK:
-		messageType = "RECOVERY"
-	default:
-		messageType = event.State.Level.String()
-	}
 	if err := h.s.Alert(
 		h.c.RoutingKey,
-		messageType,
+		messageType(event.State.Level),
 		event.State.Message,
 		event.State.ID,
 		event.State.Time,
@@ -209,3 +202,27 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	url, post, err := h.s.preparePost(
+		h.c.RoutingKey,
+		messageType(event.State.Level),
+		event.State.Message,
+		event.State.ID,
+		event.State.Time,
+		event.Data.Result,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(url, post)
+}
+
+// messageType returns the VictorOps message type of the level of an event.
+func messageType(level alert.Level) string {
+	if level == alert.OK {
+		return "RECOVERY"
+	}
+	return level.String()
+}
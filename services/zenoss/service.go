@@ -454,3 +454,16 @@ func (h *handler) Handle(event alert.Event) {
 		h.diag.Error("failed to send event to Zenoss", err)
 	}
 }
+
+// Render returns the URL and body of the request the handler would post for the event.
+func (h *handler) Render(event alert.Event) (alert.Payload, error) {
+	postUrl, postBody, err := h.s.preparePost(
+		&event.State,
+		&event.Data,
+		&h.c,
+	)
+	if err != nil {
+		return alert.Payload{}, err
+	}
+	return alert.NewPayload(postUrl, postBody)
+}
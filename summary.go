@@ -0,0 +1,186 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type SummaryNode struct {
+	node
+	s  *pipeline.SummaryNode
+	as string
+
+	name    string
+	time    time.Time
+	pending bool
+
+	// The time of the last forwarded barrier, the barriers of all groups are collapsed with the groups.
+	barrier time.Time
+
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// Create a new SummaryNode which collapses all groups of a window into a single point.
+func newSummaryNode(et *ExecutingTask, n *pipeline.SummaryNode, d NodeDiagnostic) (*SummaryNode, error) {
+	sn := &SummaryNode{
+		node: node{Node: n, et: et, diag: d},
+		s:    n,
+		as:   n.As,
+	}
+	if sn.as == "" {
+		sn.as = n.Aggregate
+	}
+	sn.reset()
+	sn.node.runF = sn.runSummary
+	return sn, nil
+}
+
+func (n *SummaryNode) runSummary([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+func (n *SummaryNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	if err := n.emit(begin.Time()); err != nil {
+		return err
+	}
+	if !n.pending {
+		n.name = begin.Name()
+		n.time = begin.Time()
+		n.pending = true
+	}
+	return nil
+}
+
+func (n *SummaryNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+
+	value, ok := numToFloat(bp.Fields()[n.s.Field])
+	if !ok {
+		n.diag.Error("cannot summarize point",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", n.s.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", bp.Fields()[n.s.Field])),
+		)
+		return nil
+	}
+	n.count++
+	n.sum += value
+	n.min = math.Min(n.min, value)
+	n.max = math.Max(n.max, value)
+	return nil
+}
+
+func (n *SummaryNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *SummaryNode) Point(p edge.PointMessage) error {
+	return errors.New("summary does not support stream data")
+}
+
+func (n *SummaryNode) Barrier(b edge.BarrierMessage) error {
+	n.timer.Start()
+	err := n.emit(b.Time())
+	n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	if !b.Time().After(n.barrier) {
+		return nil
+	}
+	n.barrier = b.Time()
+	return edge.Forward(n.outs, edge.NewBarrierMessage(edge.GroupInfo{
+		ID:         models.NilGroup,
+		Tags:       models.Tags{},
+		Dimensions: models.Dimensions{},
+	}, b.Time()))
+}
+
+func (n *SummaryNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return nil
+}
+
+// Done sends the summary of the last window, as no later window will end it.
+func (n *SummaryNode) Done() {
+	if !n.pending {
+		return
+	}
+	n.timer.Start()
+	defer n.timer.Stop()
+	if err := n.send(); err != nil {
+		n.diag.Error("failed to send the summary of the last window", err)
+	}
+}
+
+// emit sends the summary of the pending window if t is after the window.
+// The node timer must be started when calling this method.
+func (n *SummaryNode) emit(t time.Time) error {
+	if !n.pending || !t.After(n.time) {
+		return nil
+	}
+	return n.send()
+}
+
+// send sends the summary of the pending window.
+// The node timer must be started when calling this method.
+func (n *SummaryNode) send() error {
+	defer n.reset()
+	if n.count == 0 {
+		return nil
+	}
+
+	var value interface{}
+	switch n.s.Aggregate {
+	case pipeline.SummarySum:
+		value = n.sum
+	case pipeline.SummaryMean:
+		value = n.sum / float64(n.count)
+	case pipeline.SummaryMin:
+		value = n.min
+	case pipeline.SummaryMax:
+		value = n.max
+	case pipeline.SummaryCount:
+		value = n.count
+	default:
+		return fmt.Errorf("unknown summary aggregate %q", n.s.Aggregate)
+	}
+
+	p := edge.NewPointMessage(
+		n.name,
+		"",
+		"",
+		models.Dimensions{},
+		models.Fields{n.as: value},
+		models.Tags{},
+		n.time,
+	)
+	n.timer.Pause()
+	defer n.timer.Resume()
+	return edge.Forward(n.outs, p)
+}
+
+func (n *SummaryNode) reset() {
+	n.pending = false
+	n.count = 0
+	n.sum = 0
+	n.min = math.Inf(1)
+	n.max = math.Inf(-1)
+}
@@ -335,6 +335,14 @@ func (et *ExecutingTask) registerOutput(name string, o Output) {
 	et.outputs[name] = o
 }
 
+// Resolve a secret by name using the task master's secrets service.
+func (et *ExecutingTask) resolveSecret(name string) (string, error) {
+	if et.tm.SecretsService == nil {
+		return "", fmt.Errorf("cannot resolve secret %q, no secrets service configured", name)
+	}
+	return et.tm.SecretsService.Secret(name)
+}
+
 type ExecutionStats struct {
 	TaskStats map[string]interface{}
 	NodeStats map[string]map[string]interface{}
@@ -410,6 +418,52 @@ func (et *ExecutingTask) EDot(labels bool) []byte {
 	return buf.Bytes()
 }
 
+// ExecutionGraph is the topology of an executing task annotated with the current stats of its nodes and edges,
+// such as to render a live diagram of the pipeline.
+type ExecutionGraph struct {
+	// The throughput of the task, in points or batches per second.
+	Throughput float64              `json:"throughput"`
+	Nodes      []ExecutionGraphNode `json:"nodes"`
+	Edges      []ExecutionGraphEdge `json:"edges"`
+}
+
+type ExecutionGraphNode struct {
+	Name string `json:"name"`
+	// The type of the node, e.g. window or alert.
+	Type string `json:"type"`
+	// The stats of the node, with its collected and emitted counts.
+	Stats map[string]interface{} `json:"stats"`
+}
+
+type ExecutionGraphEdge struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+	// The number of points, or batches, processed from the parent by the child.
+	Processed int64 `json:"processed"`
+}
+
+// Return the graph of the task with the current stats of its nodes and edges.
+// Like EDot the stats are read as the task runs, without stopping it,
+// so each call returns the current values.
+func (et *ExecutingTask) ExecutionGraph() ExecutionGraph {
+	graph := ExecutionGraph{
+		Throughput: et.getThroughput(),
+	}
+	_ = et.walk(func(n Node) error {
+		stats := n.stats()
+		stats["collected"] = n.collectedCount()
+		stats["emitted"] = n.emittedCount()
+		graph.Nodes = append(graph.Nodes, ExecutionGraphNode{
+			Name:  n.Name(),
+			Type:  n.Desc(),
+			Stats: stats,
+		})
+		graph.Edges = append(graph.Edges, n.graphEdges()...)
+		return nil
+	})
+	return graph
+}
+
 // Return the current throughput value.
 func (et *ExecutingTask) getThroughput() float64 {
 	et.tmu.RLock()
@@ -470,6 +524,8 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newAlertNode(et, t, d)
 	case *pipeline.GroupByNode:
 		n, err = newGroupByNode(et, t, d)
+	case *pipeline.GroupByExprNode:
+		n, err = newGroupByExprNode(et, t, d)
 	case *pipeline.UnionNode:
 		n, err = newUnionNode(et, t, d)
 	case *pipeline.JoinNode:
@@ -486,6 +542,84 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newDerivativeNode(et, t, d)
 	case *pipeline.ChangeDetectNode:
 		n, err = newChangeDetectNode(et, t, d)
+	case *pipeline.AutocorrelationNode:
+		n, err = newAutocorrelationNode(et, t, d)
+	case *pipeline.FanOutNode:
+		n, err = newFanOutNode(et, t, d)
+	case *pipeline.EnrichNode:
+		n, err = newEnrichNode(et, t, d)
+	case *pipeline.MannKendallNode:
+		n, err = newMannKendallNode(et, t, d)
+	case *pipeline.PivotNode:
+		n, err = newPivotNode(et, t, d)
+	case *pipeline.RatioNode:
+		n, err = newRatioNode(et, t, d)
+	case *pipeline.GroupEventsNode:
+		n, err = newGroupEventsNode(et, t, d)
+	case *pipeline.CounterDeltaNode:
+		n, err = newCounterDeltaNode(et, t, d)
+	case *pipeline.UptimeNode:
+		n, err = newUptimeNode(et, t, d)
+	case *pipeline.DropOutliersNode:
+		n, err = newDropOutliersNode(et, t, d)
+	case *pipeline.PercentilesNode:
+		n, err = newPercentilesNode(et, t, d)
+	case *pipeline.PercentChangeNode:
+		n, err = newPercentChangeNode(et, t, d)
+	case *pipeline.RollupNode:
+		n, err = newRollupNode(et, t, d)
+	case *pipeline.SchemaNode:
+		n, err = newSchemaNode(et, t, d)
+	case *pipeline.QuarantineNode:
+		n, err = newQuarantineNode(et, t, d)
+	case *pipeline.DecomposeNode:
+		n, err = newDecomposeNode(et, t, d)
+	case *pipeline.CoincidenceNode:
+		n, err = newCoincidenceNode(et, t, d)
+	case *pipeline.StampNode:
+		n, err = newStampNode(et, t, d)
+	case *pipeline.LagNode:
+		n, err = newLagNode(et, t, d)
+	case *pipeline.MirrorNode:
+		n, err = newMirrorNode(et, t, d)
+	case *pipeline.CardinalityNode:
+		n, err = newCardinalityNode(et, t, d)
+	case *pipeline.FailingNode:
+		n, err = newFailingNode(et, t, d)
+	case *pipeline.LastMarkerNode:
+		n, err = newLastMarkerNode(et, t, d)
+	case *pipeline.SeasonalZScoreNode:
+		n, err = newSeasonalZScoreNode(et, t, d)
+	case *pipeline.PercentileRankNode:
+		n, err = newPercentileRankNode(et, t, d)
+	case *pipeline.CusumNode:
+		n, err = newCusumNode(et, t, d)
+	case *pipeline.MonotonicNode:
+		n, err = newMonotonicNode(et, t, d)
+	case *pipeline.SequenceNode:
+		n, err = newSequenceNode(et, t, d)
+	case *pipeline.AccumulateNode:
+		n, err = newAccumulateNode(et, t, d)
+	case *pipeline.EntropyNode:
+		n, err = newEntropyNode(et, t, d)
+	case *pipeline.TokenBucketNode:
+		n, err = newTokenBucketNode(et, t, d)
+	case *pipeline.BaselineNode:
+		n, err = newBaselineNode(et, t, d)
+	case *pipeline.ConsensusNode:
+		n, err = newConsensusNode(et, t, d)
+	case *pipeline.PercentileThresholdNode:
+		n, err = newPercentileThresholdNode(et, t, d)
+	case *pipeline.CrossCorrelationNode:
+		n, err = newCrossCorrelationNode(et, t, d)
+	case *pipeline.LeakyBucketNode:
+		n, err = newLeakyBucketNode(et, t, d)
+	case *pipeline.ForecastResidualNode:
+		n, err = newForecastResidualNode(et, t, d)
+	case *pipeline.ResidualNode:
+		n, err = newResidualNode(et, t, d)
+	case *pipeline.SummaryNode:
+		n, err = newSummaryNode(et, t, d)
 	case *pipeline.UDFNode:
 		n, err = newUDFNode(et, t, d)
 	case *pipeline.StatsNode:
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -118,6 +119,29 @@ type TaskMaster struct {
 		alertservice.TopicPersister
 		alertservice.InhibitorLookup
 	}
+	// AlertRateLimiter, if set, caps the number of alert events dispatched across all tasks.
+	// It is not shared with the task masters returned by New, so that replays and tests do not spend the budget of live tasks.
+	AlertRateLimiter *AlertRateLimiter
+	// AlertDeduplicator, if set, collapses the alerts of all tasks sharing a dedup key into a single incident.
+	// It is not shared with the task masters returned by New, so that replays and tests do not join the incidents of live tasks.
+	AlertDeduplicator *AlertDeduplicator
+	// AlertDigester, if set, sends the events of the alerts with a digest in periodic digests.
+	// It is not shared with the task masters returned by New, as it is closed by the task master that owns it.
+	AlertDigester *AlertDigester
+	// AlertJitterSource, if set, creates the source of the random delays of each alert node with a jitter,
+	// so that tests can seed the delays. Otherwise the sources are seeded with the current time.
+	AlertJitterSource func() rand.Source
+	// RecordingService, if set, provides the data of the recordings, such as the baselines of baseline nodes.
+	RecordingService interface {
+		// RecordingReaders returns the readers of the data of a recording and whether it is a stream recording.
+		// A stream recording has a single reader of points in line protocol with nanosecond precision,
+		// a batch recording has a reader of the JSON encoded batches of each of its sources.
+		RecordingReaders(id string) ([]io.ReadCloser, bool, error)
+	}
+	// AlertCapture, if set, replaces the alert handlers and topics of all tasks with stubs capturing their events.
+	// It is meant for testing the alert logic of tasks.
+	AlertCapture *AlertCapture
+
 	InfluxDBService interface {
 		NewNamedClient(name string) (influxdb.Client, error)
 	}
@@ -215,6 +239,10 @@ type TaskMaster struct {
 		Source(*httppost.Endpoint) (sideload.Source, error)
 	}
 
+	SecretsService interface {
+		Secret(name string) (string, error)
+	}
+
 	TeamsService interface {
 		Global() bool
 		StateChangesOnly() bool
@@ -235,6 +263,10 @@ type TaskMaster struct {
 
 	DefaultRetentionPolicy string
 
+	// BatchParallelism is the number of groups of a batch processed concurrently by the nodes that support it.
+	// Values less than 2 process the groups serially.
+	BatchParallelism int
+
 	// Incoming streams
 	writePointsIn StreamCollector
 	writesClosed  bool
@@ -307,11 +339,15 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 func (tm *TaskMaster) New(id string) *TaskMaster {
 	n := NewTaskMaster(id, tm.ServerInfo, tm.diag)
 	n.DefaultRetentionPolicy = tm.DefaultRetentionPolicy
+	n.BatchParallelism = tm.BatchParallelism
 	n.HTTPDService = tm.HTTPDService
 	n.TaskStore = tm.TaskStore
 	n.DeadmanService = tm.DeadmanService
 	n.UDFService = tm.UDFService
 	n.AlertService = tm.AlertService
+	n.AlertJitterSource = tm.AlertJitterSource
+	n.RecordingService = tm.RecordingService
+	n.AlertCapture = tm.AlertCapture
 	n.InfluxDBService = tm.InfluxDBService
 	n.SMTPService = tm.SMTPService
 	n.MQTTService = tm.MQTTService
@@ -335,6 +371,7 @@ func (tm *TaskMaster) New(id string) *TaskMaster {
 	n.K8sService = tm.K8sService
 	n.Commander = tm.Commander
 	n.SideloadService = tm.SideloadService
+	n.SecretsService = tm.SecretsService
 	n.TeamsService = tm.TeamsService
 	n.ServiceNowService = tm.ServiceNowService
 	n.ZenossService = tm.ZenossService
@@ -382,6 +419,13 @@ func (tm *TaskMaster) Close() error {
 
 	tm.Drain()
 
+	if tm.AlertRateLimiter != nil {
+		tm.AlertRateLimiter.Close()
+	}
+	if tm.AlertDigester != nil {
+		tm.AlertDigester.Close()
+	}
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 	tm.closed = true
@@ -615,6 +659,12 @@ func (tm *TaskMaster) stopTask(id string) (err error) {
 			delete(tm.batches, id)
 		}
 		err = et.stop()
+		if tm.AlertRateLimiter != nil {
+			tm.AlertRateLimiter.StopTask(id)
+		}
+		if tm.AlertDeduplicator != nil {
+			tm.AlertDeduplicator.StopTask(id)
+		}
 		if err != nil {
 			tm.diag.StoppedTaskWithError(id, err)
 		} else {
@@ -657,6 +707,18 @@ func (tm *TaskMaster) ExecutionStats(id string) (ExecutionStats, error) {
 	return task.ExecutionStats()
 }
 
+// ExecutionGraph returns the graph of an executing task with the current stats of its nodes,
+// and whether the task is executing.
+func (tm *TaskMaster) ExecutionGraph(id string) (ExecutionGraph, bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	et, executing := tm.tasks[id]
+	if !executing {
+		return ExecutionGraph{}, false
+	}
+	return et.ExecutionGraph(), true
+}
+
 func (tm *TaskMaster) ExecutingDot(id string, labels bool) string {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
@@ -0,0 +1,139 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type TokenBucketNode struct {
+	node
+	b *pipeline.TokenBucketNode
+}
+
+// Create a new tokenBucket node.
+func newTokenBucketNode(et *ExecutingTask, n *pipeline.TokenBucketNode, d NodeDiagnostic) (*TokenBucketNode, error) {
+	bn := &TokenBucketNode{
+		node: node{Node: n, et: et, diag: d},
+		b:    n,
+	}
+	bn.node.runF = bn.runTokenBucket
+	return bn, nil
+}
+
+func (n *TokenBucketNode) runTokenBucket([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *TokenBucketNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *TokenBucketNode) newGroup() *tokenBucketGroup {
+	return &tokenBucketGroup{
+		n: n,
+	}
+}
+
+type tokenBucketGroup struct {
+	n *TokenBucketNode
+
+	// The tokens in the bucket at the time of the last point, zero before the first point.
+	tokens float64
+	last   time.Time
+}
+
+func (g *tokenBucketGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *tokenBucketGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !g.doTokenBucket(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *tokenBucketGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *tokenBucketGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !g.doTokenBucket(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// doTokenBucket consumes the value of p from the bucket, and returns whether p is over the limit,
+// in which case the overage and the limit are set as fields on p.
+func (g *tokenBucketGroup) doTokenBucket(p edge.FieldsTagsTimeSetter) bool {
+	b := g.n.b
+	value, ok := numToFloat(p.Fields()[b.Field])
+	if !ok {
+		g.n.diag.Error("cannot apply token bucket",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", b.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[b.Field])),
+		)
+		return false
+	}
+
+	limit := b.Limit
+	if b.LimitField != "" {
+		if l, ok := numToFloat(p.Fields()[b.LimitField]); ok && l > 0 {
+			limit = l
+		}
+	}
+	capacity := limit * b.Burst
+
+	// Refill the bucket at the rate of the limit since the last point.
+	t := p.Time()
+	if g.last.IsZero() {
+		g.tokens = capacity
+	} else if elapsed := t.Sub(g.last); elapsed > 0 {
+		g.tokens += limit * float64(elapsed) / float64(b.Interval)
+	}
+	g.tokens = math.Min(g.tokens, capacity)
+	if t.After(g.last) {
+		g.last = t
+	}
+
+	if value <= g.tokens {
+		g.tokens -= value
+		return false
+	}
+	overage := value - g.tokens
+	g.tokens = 0
+
+	fields := p.Fields().Copy()
+	fields[b.OverageAs] = overage
+	fields[b.LimitAs] = limit
+	p.SetFields(fields)
+	return true
+}
+
+func (g *tokenBucketGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *tokenBucketGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *tokenBucketGroup) Done() {}
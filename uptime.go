@@ -0,0 +1,149 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+type UptimeNode struct {
+	node
+	u *pipeline.UptimeNode
+
+	expr      stateful.Expression
+	scopePool stateful.ScopePool
+}
+
+// Create a new uptime node.
+func newUptimeNode(et *ExecutingTask, n *pipeline.UptimeNode, d NodeDiagnostic) (*UptimeNode, error) {
+	if n.Lambda == nil {
+		return nil, errors.New("nil expression passed to UptimeNode")
+	}
+	expr, err := stateful.NewExpression(n.Lambda.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile uptime expression: %v", err)
+	}
+	un := &UptimeNode{
+		node:      node{Node: n, et: et, diag: d},
+		u:         n,
+		expr:      expr,
+		scopePool: stateful.NewScopePool(ast.FindReferenceVariables(n.Lambda.Expression)),
+	}
+	un.node.runF = un.runUptime
+	return un, nil
+}
+
+func (n *UptimeNode) runUptime([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *UptimeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *UptimeNode) newGroup() *uptimeGroup {
+	return &uptimeGroup{
+		n:    n,
+		expr: n.expr.CopyReset(),
+	}
+}
+
+type uptimeGroup struct {
+	n    *UptimeNode
+	expr stateful.Expression
+
+	begin edge.BeginBatchMessage
+
+	// Whether the batch has any points, and the time of its first point.
+	seen  bool
+	first time.Time
+	// The time and result of the previous point.
+	last  time.Time
+	holds bool
+	// The accumulated time the condition held.
+	uptime time.Duration
+}
+
+func (g *uptimeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.seen = false
+	g.uptime = 0
+	return nil, nil
+}
+
+func (g *uptimeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	holds, err := EvalPredicate(g.expr, g.n.scopePool, bp)
+	if err != nil {
+		g.n.diag.Error("error while evaluating expression", err)
+		return nil, nil
+	}
+	t := bp.Time()
+	if !g.seen {
+		g.seen = true
+		g.first = t
+	} else {
+		g.advance(t)
+	}
+	g.last = t
+	g.holds = holds
+	return nil, nil
+}
+
+// advance accounts for the time from the previous point until t.
+func (g *uptimeGroup) advance(t time.Time) {
+	if g.holds && t.After(g.last) {
+		g.uptime += t.Sub(g.last)
+	}
+}
+
+func (g *uptimeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if !g.seen {
+		return nil, nil
+	}
+	stop := g.begin.Time()
+	if stop.Before(g.last) {
+		stop = g.last
+	}
+	g.advance(stop)
+
+	var ratio float64
+	if total := stop.Sub(g.first); total > 0 {
+		ratio = float64(g.uptime) / float64(total)
+	} else if g.holds {
+		ratio = 1
+	}
+
+	return edge.NewPointMessage(
+		g.begin.Name(), "", "",
+		g.begin.Dimensions(),
+		models.Fields{g.n.u.As: ratio},
+		g.begin.GroupInfo().Tags,
+		g.begin.Time(),
+	), nil
+}
+
+func (g *uptimeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *uptimeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *uptimeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *uptimeGroup) Done() {}